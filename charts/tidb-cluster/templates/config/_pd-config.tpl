@@ -82,7 +82,14 @@ max-replicas = {{ .Values.pd.maxReplicas }}
 # The placement priorities is implied by the order of label keys.
 # For example, ["zone", "rack"] means that we should place replicas to
 # different zones first, then to different racks if we don't have enough zones.
-location-labels = ["region", "zone", "rack", "host"]
+{{- $locationLabels := list "region" "zone" "rack" "host" }}
+{{- if .Values.haTopologyKey }}
+{{- $topologyLabel := .Values.haTopologyKey | splitList "/" | last }}
+{{- if and (ne .Values.haTopologyKey "kubernetes.io/hostname") (not (has $topologyLabel $locationLabels)) }}
+{{- $locationLabels = append $locationLabels $topologyLabel }}
+{{- end }}
+{{- end }}
+location-labels = [{{ range $i, $l := $locationLabels }}{{ if $i }}, {{ end }}{{ $l | quote }}{{ end }}]
 
 [label-property]
 # Do not assign region leaders to stores that have these tags.
@@ -26,9 +26,12 @@ then
     tail -f /dev/null
 fi
 
+# PD_ADDR is set when the PD is shared from another TidbCluster
+PD_ADDR=${PD_ADDR:-${CLUSTER_NAME}-pd:2379}
+
 ARGS="--store=tikv \
 --host=0.0.0.0 \
---path=${CLUSTER_NAME}-pd:2379 \
+--path=${PD_ADDR} \
 --config=/etc/tidb/tidb.toml
 "
 
@@ -28,7 +28,10 @@ then
 	tail -f /dev/null
 fi
 
-ARGS="--pd=${CLUSTER_NAME}-pd:2379 \
+# PD_ADDR is set when the PD is shared from another TidbCluster
+PD_ADDR=${PD_ADDR:-${CLUSTER_NAME}-pd:2379}
+
+ARGS="--pd=${PD_ADDR} \
 --advertise-addr=${HOSTNAME}.${HEADLESS_SERVICE_NAME}.${NAMESPACE}.svc:20160 \
 --addr=0.0.0.0:20160 \
 --data-dir=/var/lib/tikv \
@@ -56,6 +56,7 @@ def call(BUILD_BRANCH, CREDENTIALS_ID) {
 					make check
 					make test
 					make
+					make kube-scheduler
 					make e2e-build
 					"""
 				}
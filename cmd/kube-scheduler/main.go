@@ -0,0 +1,111 @@
+// Copyright 2019 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build kube_scheduler
+
+// This is a kube-scheduler with the predicates and priorities of tidb-scheduler built in,
+// it's an alternative to running tidb-scheduler as an extender of the upstream kube-scheduler.
+// It depends on k8s.io/kubernetes/cmd/kube-scheduler/app, build it with:
+//
+//	go build -tags kube_scheduler -o tidb-kube-scheduler ./cmd/kube-scheduler
+//
+// The plugin is enabled by the TiDBOperatorProvider algorithm provider, which is the DefaultProvider
+// with the plugin added, run it with --algorithm-provider=TiDBOperatorProvider. A scheduler policy
+// can enable the plugin instead by listing TiDBOperatorFitPredicate in its predicates and
+// TiDBOperatorPredicatePriority and TiDBOperatorPriority in its priorities.
+package main
+
+import (
+	goflag "flag"
+	"math/rand"
+	"os"
+	"time"
+
+	"github.com/golang/glog"
+	"github.com/pingcap/tidb-operator/pkg/client/clientset/versioned"
+	"github.com/pingcap/tidb-operator/pkg/features"
+	"github.com/pingcap/tidb-operator/pkg/scheduler/plugin"
+	"github.com/pingcap/tidb-operator/pkg/scheduler/predicates"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apiserver/pkg/util/logs"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	"k8s.io/kubernetes/cmd/kube-scheduler/app"
+	"k8s.io/kubernetes/pkg/scheduler/factory"
+)
+
+// algorithmProviderName is the name of the algorithm provider enabling the plugin
+const algorithmProviderName = "TiDBOperatorProvider"
+
+var (
+	predicatePriorityWeight int
+	priorityWeight          int
+)
+
+func init() {
+	goflag.IntVar(&predicatePriorityWeight, "tidb-predicate-priority-weight", 10, "The weight of the priority preferring the nodes kept by the tidb-scheduler predicates, e.g. the previous node under stable scheduling")
+	goflag.IntVar(&priorityWeight, "tidb-priority-weight", 1, "The weight of the tidb-scheduler priorities")
+	goflag.DurationVar(&predicates.SchedulingLockTTL, "scheduling-lock-ttl", predicates.SchedulingLockTTL, "The time a pod can hold the scheduling lock of its cluster component")
+	features.DefaultFeatureGate.AddFlag(goflag.CommandLine)
+}
+
+func main() {
+	rand.Seed(time.Now().UnixNano())
+
+	command := app.NewSchedulerCommand()
+	run := command.Run
+	// the plugin is registered after the flags are parsed, it must be registered before the scheduler is created
+	command.Run = func(cmd *cobra.Command, args []string) {
+		registerPlugin()
+		run(cmd, args)
+	}
+	pflag.CommandLine.AddGoFlagSet(goflag.CommandLine)
+
+	logs.InitLogs()
+	defer logs.FlushLogs()
+
+	if err := command.Execute(); err != nil {
+		os.Exit(1)
+	}
+}
+
+func registerPlugin() {
+	cfg, err := rest.InClusterConfig()
+	if err != nil {
+		glog.Fatalf("failed to get config: %v", err)
+	}
+	kubeCli, err := kubernetes.NewForConfig(cfg)
+	if err != nil {
+		glog.Fatalf("failed to get kubernetes Clientset: %v", err)
+	}
+	cli, err := versioned.NewForConfig(cfg)
+	if err != nil {
+		glog.Fatalf("failed to create Clientset: %v", err)
+	}
+
+	p := plugin.New(kubeCli, cli)
+	p.Register(plugin.Registry{
+		RegisterFitPredicate:     factory.RegisterFitPredicate,
+		RegisterPriorityFunction: factory.RegisterPriorityFunction,
+	}, predicatePriorityWeight, priorityWeight)
+	// the DefaultProvider is registered by the algorithmprovider package imported by the app
+	defaultProvider, err := factory.GetAlgorithmProvider(factory.DefaultProvider)
+	if err != nil {
+		glog.Fatalf("failed to get the default algorithm provider: %v", err)
+	}
+	predicateKeys := sets.NewString(defaultProvider.FitPredicateKeys.List()...).Insert(plugin.FitPredicateName)
+	priorityKeys := sets.NewString(defaultProvider.PriorityFunctionKeys.List()...).Insert(plugin.PredicatePriorityName, plugin.PriorityName)
+	factory.RegisterAlgorithmProvider(algorithmProviderName, predicateKeys, priorityKeys)
+}
@@ -23,8 +23,10 @@ import (
 	"github.com/golang/glog"
 	"github.com/pingcap/tidb-operator/pkg/client/clientset/versioned"
 	"github.com/pingcap/tidb-operator/pkg/features"
+	"github.com/pingcap/tidb-operator/pkg/scheduler/predicates"
 	"github.com/pingcap/tidb-operator/pkg/scheduler/server"
 	"github.com/pingcap/tidb-operator/version"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"k8s.io/apimachinery/pkg/util/wait"
 	"k8s.io/apiserver/pkg/util/logs"
 	"k8s.io/client-go/kubernetes"
@@ -40,6 +42,7 @@ func init() {
 	flag.BoolVar(&printVersion, "V", false, "Show version and quit")
 	flag.BoolVar(&printVersion, "version", false, "Show version and quit")
 	flag.IntVar(&port, "port", 10262, "The port that the tidb scheduler's http service runs on (default 10262)")
+	flag.DurationVar(&predicates.SchedulingLockTTL, "scheduling-lock-ttl", predicates.SchedulingLockTTL, "The time a pod can hold the scheduling lock of its cluster component (default 5m)")
 	features.DefaultFeatureGate.AddFlag(flag.CommandLine)
 	flag.Parse()
 }
@@ -67,6 +70,7 @@ func main() {
 		glog.Fatalf("failed to create Clientset: %v", err)
 	}
 
+	http.Handle("/metrics", promhttp.Handler())
 	go wait.Forever(func() {
 		server.StartServer(kubeCli, cli, port)
 	}, 5*time.Second)
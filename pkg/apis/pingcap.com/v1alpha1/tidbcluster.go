@@ -13,6 +13,11 @@
 
 package v1alpha1
 
+import "strings"
+
+// DefaultHATopologyKey is the default HA topology key, which spreads the PD and TiKV pods across nodes
+const DefaultHATopologyKey = "kubernetes.io/hostname"
+
 func (mt MemberType) String() string {
 	return string(mt)
 }
@@ -64,11 +69,40 @@ func (tc *TidbCluster) PDRealReplicas() int32 {
 }
 
 func (tc *TidbCluster) TiKVAllPodsStarted() bool {
-	return tc.TiKVRealReplicas() == tc.Status.TiKV.StatefulSet.Replicas
+	if tc.TiKVRealReplicas() != tc.Status.TiKV.StatefulSet.Replicas {
+		return false
+	}
+
+	for i := range tc.Spec.TiKV.Groups {
+		group := &tc.Spec.TiKV.Groups[i]
+		status, ok := tc.Status.TiKV.Groups[group.Name]
+		if !ok || status.StatefulSet == nil || status.StatefulSet.Replicas != tc.TiKVGroupRealReplicas(group) {
+			return false
+		}
+	}
+	return true
+}
+
+// TiKVGroupPodsStarted returns whether all the pods of the TiKV group are started, the default group is ""
+func (tc *TidbCluster) TiKVGroupPodsStarted(name string) bool {
+	if name == "" {
+		return tc.Status.TiKV.StatefulSet != nil && tc.TiKVRealReplicas() == tc.Status.TiKV.StatefulSet.Replicas
+	}
+
+	group := tc.TiKVGroup(name)
+	if group == nil {
+		return false
+	}
+	status, ok := tc.Status.TiKV.Groups[name]
+	return ok && status.StatefulSet != nil && status.StatefulSet.Replicas == tc.TiKVGroupRealReplicas(group)
 }
 
 func (tc *TidbCluster) TiKVAllStoresReady() bool {
-	if int(tc.TiKVRealReplicas()) != len(tc.Status.TiKV.Stores) {
+	replicas := tc.TiKVRealReplicas()
+	for i := range tc.Spec.TiKV.Groups {
+		replicas += tc.TiKVGroupRealReplicas(&tc.Spec.TiKV.Groups[i])
+	}
+	if int(replicas) != len(tc.Status.TiKV.Stores) {
 		return false
 	}
 
@@ -82,15 +116,54 @@ func (tc *TidbCluster) TiKVAllStoresReady() bool {
 }
 
 func (tc *TidbCluster) TiKVRealReplicas() int32 {
-	return tc.Spec.TiKV.Replicas + int32(len(tc.Status.TiKV.FailureStores))
+	return tc.Spec.TiKV.Replicas + tc.tikvFailureStoresCount("")
+}
+
+// TiKVGroupRealReplicas returns the replicas of the TiKV group including the replacements of its failure stores
+func (tc *TidbCluster) TiKVGroupRealReplicas(group *TiKVGroupSpec) int32 {
+	return group.Replicas + tc.tikvFailureStoresCount(group.Name)
+}
+
+// TiKVGroupsReplicas returns the total replicas of all the TiKV groups
+func (tc *TidbCluster) TiKVGroupsReplicas() int32 {
+	var replicas int32
+	for _, group := range tc.Spec.TiKV.Groups {
+		replicas += group.Replicas
+	}
+	return replicas
+}
+
+// TiKVGroup returns the TiKV group with the given name, nil if it is not found
+func (tc *TidbCluster) TiKVGroup(name string) *TiKVGroupSpec {
+	for i := range tc.Spec.TiKV.Groups {
+		if tc.Spec.TiKV.Groups[i].Name == name {
+			return &tc.Spec.TiKV.Groups[i]
+		}
+	}
+	return nil
 }
 
 func (tc *TidbCluster) TiDBAllPodsStarted() bool {
-	return tc.TiDBRealReplicas() == tc.Status.TiDB.StatefulSet.Replicas
+	if tc.TiDBRealReplicas() != tc.Status.TiDB.StatefulSet.Replicas {
+		return false
+	}
+
+	for i := range tc.Spec.TiDB.Groups {
+		group := &tc.Spec.TiDB.Groups[i]
+		status, ok := tc.Status.TiDB.Groups[group.Name]
+		if !ok || status.StatefulSet == nil || status.StatefulSet.Replicas != tc.TiDBGroupRealReplicas(group) {
+			return false
+		}
+	}
+	return true
 }
 
 func (tc *TidbCluster) TiDBAllMembersReady() bool {
-	if int(tc.TiDBRealReplicas()) != len(tc.Status.TiDB.Members) {
+	replicas := tc.TiDBRealReplicas()
+	for i := range tc.Spec.TiDB.Groups {
+		replicas += tc.TiDBGroupRealReplicas(&tc.Spec.TiDB.Groups[i])
+	}
+	if int(replicas) != len(tc.Status.TiDB.Members) {
 		return false
 	}
 
@@ -104,10 +177,93 @@ func (tc *TidbCluster) TiDBAllMembersReady() bool {
 }
 
 func (tc *TidbCluster) TiDBRealReplicas() int32 {
-	return tc.Spec.TiDB.Replicas + int32(len(tc.Status.TiDB.FailureMembers))
+	return tc.Spec.TiDB.Replicas + tc.tidbFailureMembersCount("")
+}
+
+// TiDBGroupRealReplicas returns the replicas of the TiDB group including the replacements of its failure members
+func (tc *TidbCluster) TiDBGroupRealReplicas(group *TiDBGroupSpec) int32 {
+	return group.Replicas + tc.tidbFailureMembersCount(group.Name)
+}
+
+// TiDBGroupsReplicas returns the total replicas of all the TiDB groups
+func (tc *TidbCluster) TiDBGroupsReplicas() int32 {
+	var replicas int32
+	for _, group := range tc.Spec.TiDB.Groups {
+		replicas += group.Replicas
+	}
+	return replicas
+}
+
+// TiDBGroup returns the TiDB group with the given name, nil if it is not found
+func (tc *TidbCluster) TiDBGroup(name string) *TiDBGroupSpec {
+	for i := range tc.Spec.TiDB.Groups {
+		if tc.Spec.TiDB.Groups[i].Name == name {
+			return &tc.Spec.TiDB.Groups[i]
+		}
+	}
+	return nil
+}
+
+func (tc *TidbCluster) tikvFailureStoresCount(group string) int32 {
+	var count int32
+	for _, failureStore := range tc.Status.TiKV.FailureStores {
+		if failureStore.Group == group {
+			count++
+		}
+	}
+	return count
+}
+
+func (tc *TidbCluster) tidbFailureMembersCount(group string) int32 {
+	var count int32
+	for _, failureMember := range tc.Status.TiDB.FailureMembers {
+		if failureMember.Group == group {
+			count++
+		}
+	}
+	return count
+}
+
+// HATopologyKey returns the node label key of the failure domain across which the PD and TiKV pods are spread
+func (tc *TidbCluster) HATopologyKey() string {
+	if tc.Spec.HATopologyKey == "" {
+		return DefaultHATopologyKey
+	}
+	return tc.Spec.HATopologyKey
+}
+
+// HATopologyStoreLabel returns the TiKV store label key of the HA topology domain, e.g. zone for
+// failure-domain.beta.kubernetes.io/zone, and host for the default kubernetes.io/hostname
+func (tc *TidbCluster) HATopologyStoreLabel() string {
+	key := tc.HATopologyKey()
+	if key == DefaultHATopologyKey {
+		return "host"
+	}
+	return key[strings.LastIndex(key, "/")+1:]
+}
+
+// HasClusterRef returns whether this TidbCluster shares the PD of another TidbCluster
+func (tc *TidbCluster) HasClusterRef() bool {
+	return tc.Spec.Cluster != nil && tc.Spec.Cluster.Name != ""
+}
+
+// PDClusterRef returns the namespace and name of the TidbCluster that runs the PD used by this TidbCluster
+func (tc *TidbCluster) PDClusterRef() (string, string) {
+	if !tc.HasClusterRef() {
+		return tc.GetNamespace(), tc.GetName()
+	}
+	ns := tc.Spec.Cluster.Namespace
+	if ns == "" {
+		ns = tc.GetNamespace()
+	}
+	return ns, tc.Spec.Cluster.Name
 }
 
 func (tc *TidbCluster) PDIsAvailable() bool {
+	if tc.HasClusterRef() {
+		return tc.sharedPDIsAvailable()
+	}
+
 	lowerLimit := tc.Spec.PD.Replicas/2 + 1
 	if int32(len(tc.Status.PD.Members)) < lowerLimit {
 		return false
@@ -131,6 +287,24 @@ func (tc *TidbCluster) PDIsAvailable() bool {
 	return true
 }
 
+// sharedPDIsAvailable checks the majority of the shared PD members are healthy,
+// the StatefulSet of the shared PD is owned by the referenced TidbCluster
+func (tc *TidbCluster) sharedPDIsAvailable() bool {
+	members := int32(len(tc.Status.PD.Members))
+	if members == 0 {
+		return false
+	}
+
+	var availableNum int32
+	for _, pdMember := range tc.Status.PD.Members {
+		if pdMember.Health {
+			availableNum++
+		}
+	}
+
+	return availableNum >= members/2+1
+}
+
 func (tc *TidbCluster) TiKVIsAvailable() bool {
 	var lowerLimit int32 = 1
 	if int32(len(tc.Status.TiKV.Stores)) < lowerLimit {
@@ -148,11 +322,18 @@ func (tc *TidbCluster) TiKVIsAvailable() bool {
 		return false
 	}
 
-	if tc.Status.TiKV.StatefulSet == nil || tc.Status.TiKV.StatefulSet.ReadyReplicas < lowerLimit {
-		return false
+	// the ready pods of all the TiKV groups count, a cluster may run its TiKV only in the groups
+	var readyReplicas int32
+	if tc.Status.TiKV.StatefulSet != nil {
+		readyReplicas += tc.Status.TiKV.StatefulSet.ReadyReplicas
+	}
+	for _, group := range tc.Status.TiKV.Groups {
+		if group.StatefulSet != nil {
+			readyReplicas += group.StatefulSet.ReadyReplicas
+		}
 	}
 
-	return true
+	return readyReplicas >= lowerLimit
 }
 
 func (tc *TidbCluster) GetClusterID() string {
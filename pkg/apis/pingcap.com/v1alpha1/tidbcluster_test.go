@@ -90,6 +90,34 @@ func TestPDIsAvailable(t *testing.T) {
 				g.Expect(b).To(BeTrue())
 			},
 		},
+		{
+			name: "shared pd members count is 3, but health count is 1",
+			update: func(tc *TidbCluster) {
+				tc.Spec.Cluster = &TidbClusterRef{Name: "shared"}
+				tc.Status.PD.Members = map[string]PDMember{
+					"pd-0": {Name: "pd-0", Health: true},
+					"pd-1": {Name: "pd-1", Health: false},
+					"pd-2": {Name: "pd-2", Health: false},
+				}
+			},
+			expectFn: func(g *GomegaWithT, b bool) {
+				g.Expect(b).To(BeFalse())
+			},
+		},
+		{
+			name: "shared pd is available",
+			update: func(tc *TidbCluster) {
+				tc.Spec.Cluster = &TidbClusterRef{Name: "shared"}
+				tc.Status.PD.Members = map[string]PDMember{
+					"pd-0": {Name: "pd-0", Health: true},
+					"pd-1": {Name: "pd-1", Health: true},
+					"pd-2": {Name: "pd-2", Health: false},
+				}
+			},
+			expectFn: func(g *GomegaWithT, b bool) {
+				g.Expect(b).To(BeTrue())
+			},
+		},
 	}
 
 	for i := range tests {
@@ -157,6 +185,21 @@ func TestTiKVIsAvailable(t *testing.T) {
 				g.Expect(b).To(BeTrue())
 			},
 		},
+		{
+			name: "tikv runs only in the groups",
+			update: func(tc *TidbCluster) {
+				tc.Status.TiKV.Stores = map[string]TiKVStore{
+					"tikv-ssd-0": {PodName: "tikv-ssd-0", State: TiKVStateUp},
+				}
+				tc.Status.TiKV.StatefulSet = &apps.StatefulSetStatus{ReadyReplicas: 0}
+				tc.Status.TiKV.Groups = map[string]TiKVGroupStatus{
+					"ssd": {StatefulSet: &apps.StatefulSetStatus{ReadyReplicas: 1}},
+				}
+			},
+			expectFn: func(g *GomegaWithT, b bool) {
+				g.Expect(b).To(BeTrue())
+			},
+		},
 	}
 
 	for i := range tests {
@@ -164,6 +207,87 @@ func TestTiKVIsAvailable(t *testing.T) {
 	}
 }
 
+func TestTiKVAllStoresReadyWithGroups(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	tc := newTidbCluster()
+	tc.Spec.TiKV.Replicas = 1
+	tc.Spec.TiKV.Groups = []TiKVGroupSpec{{Name: "ssd", Replicas: 2}}
+	tc.Status.TiKV.StatefulSet = &apps.StatefulSetStatus{Replicas: 1}
+	tc.Status.TiKV.Stores = map[string]TiKVStore{
+		"1": {ID: "1", State: TiKVStateUp},
+		"2": {ID: "2", State: TiKVStateUp},
+	}
+	g.Expect(tc.TiKVGroupsReplicas()).To(Equal(int32(2)))
+	g.Expect(tc.TiKVAllPodsStarted()).To(BeFalse())
+	g.Expect(tc.TiKVAllStoresReady()).To(BeFalse())
+
+	tc.Status.TiKV.Groups = map[string]TiKVGroupStatus{
+		"ssd": {StatefulSet: &apps.StatefulSetStatus{Replicas: 2}},
+	}
+	tc.Status.TiKV.Stores["3"] = TiKVStore{ID: "3", State: TiKVStateUp}
+	g.Expect(tc.TiKVAllPodsStarted()).To(BeTrue())
+	g.Expect(tc.TiKVAllStoresReady()).To(BeTrue())
+	g.Expect(tc.TiKVGroup("ssd")).NotTo(BeNil())
+	g.Expect(tc.TiKVGroup("hdd")).To(BeNil())
+
+	// the replacement of a failure store is counted against its own group
+	tc.Status.TiKV.FailureStores = map[string]TiKVFailureStore{
+		"3": {PodName: "test-tikv-ssd-1", StoreID: "3", Group: "ssd"},
+	}
+	g.Expect(tc.TiKVRealReplicas()).To(Equal(int32(1)))
+	g.Expect(tc.TiKVGroupRealReplicas(tc.TiKVGroup("ssd"))).To(Equal(int32(3)))
+	g.Expect(tc.TiKVGroupPodsStarted("")).To(BeTrue())
+	g.Expect(tc.TiKVGroupPodsStarted("ssd")).To(BeFalse())
+	g.Expect(tc.TiKVAllPodsStarted()).To(BeFalse())
+	g.Expect(tc.TiKVAllStoresReady()).To(BeFalse())
+}
+
+func TestTiDBReplicasWithGroups(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	tc := newTidbCluster()
+	tc.Spec.TiDB.Groups = []TiDBGroupSpec{{Name: "olap", Replicas: 2}}
+	tc.Status.TiDB.StatefulSet = &apps.StatefulSetStatus{Replicas: 1}
+	tc.Status.TiDB.FailureMembers = map[string]TiDBFailureMember{
+		"test-pd-tidb-olap-1": {PodName: "test-pd-tidb-olap-1", Group: "olap"},
+	}
+	g.Expect(tc.TiDBRealReplicas()).To(Equal(int32(1)))
+	g.Expect(tc.TiDBGroupRealReplicas(tc.TiDBGroup("olap"))).To(Equal(int32(3)))
+	g.Expect(tc.TiDBGroupsReplicas()).To(Equal(int32(2)))
+	g.Expect(tc.TiDBGroup("oltp")).To(BeNil())
+	g.Expect(tc.TiDBAllPodsStarted()).To(BeFalse())
+
+	tc.Status.TiDB.Groups = map[string]TiDBGroupStatus{
+		"olap": {StatefulSet: &apps.StatefulSetStatus{Replicas: 3}},
+	}
+	g.Expect(tc.TiDBAllPodsStarted()).To(BeTrue())
+
+	tc.Status.TiDB.Members = map[string]TiDBMember{
+		"test-pd-tidb-0":      {Name: "test-pd-tidb-0", Health: true},
+		"test-pd-tidb-olap-0": {Name: "test-pd-tidb-olap-0", Health: true, Group: "olap"},
+		"test-pd-tidb-olap-1": {Name: "test-pd-tidb-olap-1", Health: true, Group: "olap"},
+	}
+	g.Expect(tc.TiDBAllMembersReady()).To(BeFalse())
+	tc.Status.TiDB.Members["test-pd-tidb-olap-2"] = TiDBMember{Name: "test-pd-tidb-olap-2", Health: true, Group: "olap"}
+	g.Expect(tc.TiDBAllMembersReady()).To(BeTrue())
+}
+
+func TestHATopologyKey(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	tc := newTidbCluster()
+	g.Expect(tc.HATopologyKey()).To(Equal("kubernetes.io/hostname"))
+	g.Expect(tc.HATopologyStoreLabel()).To(Equal("host"))
+
+	tc.Spec.HATopologyKey = "failure-domain.beta.kubernetes.io/zone"
+	g.Expect(tc.HATopologyKey()).To(Equal("failure-domain.beta.kubernetes.io/zone"))
+	g.Expect(tc.HATopologyStoreLabel()).To(Equal("zone"))
+
+	tc.Spec.HATopologyKey = "rack"
+	g.Expect(tc.HATopologyStoreLabel()).To(Equal("rack"))
+}
+
 func newTidbCluster() *TidbCluster {
 	return &TidbCluster{
 		TypeMeta: metav1.TypeMeta{
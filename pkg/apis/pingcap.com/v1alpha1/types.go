@@ -93,6 +93,28 @@ type TidbClusterSpec struct {
 	Services        []Service                            `json:"services,omitempty"`
 	PVReclaimPolicy corev1.PersistentVolumeReclaimPolicy `json:"pvReclaimPolicy,omitempty"`
 	Timezone        string                               `json:"timezone,omitempty"`
+	// Cluster references another TidbCluster whose PD is shared with this cluster,
+	// the TiKV and TiDB members of this cluster join that PD instead of running a PD of their own
+	Cluster *TidbClusterRef `json:"cluster,omitempty"`
+	// HATopologyKey is the node label key of the failure domain across which the PD and TiKV pods are spread
+	// by the HA scheduler, e.g. zone or rack, it defaults to kubernetes.io/hostname.
+	// The node label is also set as the TiKV store label named by the last segment of the key,
+	// which should be listed in the location-labels of PD.
+	// When there are 3 or more replicas, each failure domain can hold at most (replicas+1)/2-1 pods of PD or TiKV,
+	// so the number of failure domains must be at least 3 for 3 or 5 replicas and at least 4 for 4 replicas,
+	// and the nodes without this label are never chosen
+	HATopologyKey string `json:"haTopologyKey,omitempty"`
+}
+
+// TidbClusterRef references a TidbCluster in the same Kubernetes cluster, the PD of the referenced TidbCluster
+// is reached by its in-cluster Service address <name>-pd.<namespace>:2379, and its status is read from the
+// same API server, so a TidbCluster in another Kubernetes cluster can't be referenced
+type TidbClusterRef struct {
+	// Namespace is the namespace of the referenced TidbCluster,
+	// defaults to the namespace of the referring TidbCluster
+	Namespace string `json:"namespace,omitempty"`
+	// Name is the name of the referenced TidbCluster
+	Name string `json:"name"`
 }
 
 // TidbClusterStatus represents the current status of a tidb cluster.
@@ -112,6 +134,31 @@ type PDSpec struct {
 	StorageClassName string              `json:"storageClassName,omitempty"`
 	Tolerations      []corev1.Toleration `json:"tolerations,omitempty"`
 	Annotations      map[string]string   `json:"annotations,omitempty"`
+	// Service customizes the PD client Service, it takes precedence over TidbClusterSpec.Services
+	Service     *ServiceSpec         `json:"service,omitempty"`
+	PodTemplate *PodTemplateOverride `json:"podTemplate,omitempty"`
+	// Schedulers are the PD schedulers managed by tidb-operator, they are added to PD if missing
+	// and removed from PD after being removed from the spec. Only the schedulers added by tidb-operator are removed,
+	// the ones already running in PD, e.g. the PD defaults, and the ones added by others are left untouched
+	Schedulers []PDSchedulerSpec `json:"schedulers,omitempty"`
+	// Replication is the desired replication config of PD, it is left untouched if nil
+	Replication *PDReplicationSpec `json:"replication,omitempty"`
+}
+
+// PDSchedulerSpec is a PD scheduler
+type PDSchedulerSpec struct {
+	// Name is the type of the scheduler, e.g. balance-hot-region-scheduler, label-scheduler or evict-leader-scheduler
+	Name string `json:"name"`
+	// StoreID is the store of the evict-leader-scheduler or the grant-leader-scheduler
+	StoreID string `json:"storeID,omitempty"`
+}
+
+// PDReplicationSpec is the replication config of PD, the unset fields are left untouched
+type PDReplicationSpec struct {
+	// MaxReplicas is the number of replicas for each region
+	MaxReplicas *int32 `json:"maxReplicas,omitempty"`
+	// LocationLabels are the store label keys specifying the location of a store, in order of placement priority
+	LocationLabels []string `json:"locationLabels,omitempty"`
 }
 
 // TiDBSpec contains details of PD member
@@ -127,6 +174,31 @@ type TiDBSpec struct {
 	MaxFailoverCount int32                 `json:"maxFailoverCount,omitempty"`
 	SeparateSlowLog  bool                  `json:"separateSlowLog,omitempty"`
 	SlowLogTailer    TiDBSlowLogTailerSpec `json:"slowLogTailer,omitempty"`
+	PodTemplate      *PodTemplateOverride  `json:"podTemplate,omitempty"`
+	// Service is the TiDB client Service managed by tidb-operator, it selects the TiDB servers of all the groups,
+	// no Service is created if it is nil. A Service of the same name not created by tidb-operator, e.g. the one
+	// rendered by the tidb-cluster chart, is never adopted, the TiDB sync fails until it's deleted or this is unset
+	Service *ServiceSpec `json:"service,omitempty"`
+	// Groups are TiDB server groups besides the default one,
+	// each group runs in a separate StatefulSet. Adding the first group rolls the default TiDB pods once
+	// to label them with the empty group, so that the default Service stops selecting the group pods
+	Groups []TiDBGroupSpec `json:"groups,omitempty"`
+}
+
+// TiDBGroupSpec contains details of a TiDB server group,
+// unset fields are inherited from the TiDBSpec
+type TiDBGroupSpec struct {
+	// Name is the unique name of the group in the TidbCluster, a DNS-1123 label other than "peer"
+	// which is reserved by the TiDB headless Service
+	Name     string               `json:"name"`
+	Replicas int32                `json:"replicas"`
+	Requests *ResourceRequirement `json:"requests,omitempty"`
+	Limits   *ResourceRequirement `json:"limits,omitempty"`
+	// Config is merged over the TiDB config file of the cluster for this group, the keys are the dotted paths
+	// of the config items, e.g. performance.max-procs, and the values are TOML values, e.g. 8 or "warn"
+	Config map[string]string `json:"config,omitempty"`
+	// Service is the Service exposing this group, no Service is created if it is nil
+	Service *ServiceSpec `json:"service,omitempty"`
 }
 
 // TiDBSlowLogTailerSpec represents an optional log tailer sidecar with TiDB
@@ -144,6 +216,26 @@ type TiKVSpec struct {
 	StorageClassName string              `json:"storageClassName,omitempty"`
 	Tolerations      []corev1.Toleration `json:"tolerations,omitempty"`
 	Annotations      map[string]string   `json:"annotations,omitempty"`
+	// PodTemplate is merged into the pod templates of the default and the group StatefulSets
+	PodTemplate *PodTemplateOverride `json:"podTemplate,omitempty"`
+	// Groups are heterogeneous TiKV groups besides the default one,
+	// each group runs in a separate StatefulSet
+	Groups []TiKVGroupSpec `json:"groups,omitempty"`
+}
+
+// TiKVGroupSpec contains details of a TiKV group,
+// unset fields are inherited from the TiKVSpec
+type TiKVGroupSpec struct {
+	// Name is the unique name of the group in the TidbCluster, a DNS-1123 label other than "peer"
+	// which is reserved by the TiKV headless Service
+	Name             string               `json:"name"`
+	Replicas         int32                `json:"replicas"`
+	Requests         *ResourceRequirement `json:"requests,omitempty"`
+	Limits           *ResourceRequirement `json:"limits,omitempty"`
+	StorageClassName string               `json:"storageClassName,omitempty"`
+	NodeSelector     map[string]string    `json:"nodeSelector,omitempty"`
+	// StoreLabels are set to the stores of this group besides the labels of nodes
+	StoreLabels map[string]string `json:"storeLabels,omitempty"`
 }
 
 // TiKVPromGatewaySpec runs as a sidecar with TiKVSpec
@@ -165,6 +257,39 @@ type Service struct {
 	Type string `json:"type,omitempty"`
 }
 
+// PodTemplateOverride contains the pod template fields merged into the StatefulSet of a component
+type PodTemplateOverride struct {
+	// Labels are added to the pods, the labels managed by tidb-operator can not be overridden
+	Labels map[string]string `json:"labels,omitempty"`
+	// Env is merged into the env of the component container, overriding the variables with the same name
+	Env               []corev1.EnvVar            `json:"env,omitempty"`
+	PriorityClassName string                     `json:"priorityClassName,omitempty"`
+	SecurityContext   *corev1.PodSecurityContext `json:"securityContext,omitempty"`
+	HostNetwork       bool                       `json:"hostNetwork,omitempty"`
+	InitContainers    []corev1.Container         `json:"initContainers,omitempty"`
+	// Sidecars are appended to the containers of the pod
+	Sidecars []corev1.Container `json:"sidecars,omitempty"`
+	// Volumes are appended to the volumes of the pod
+	Volumes []corev1.Volume `json:"volumes,omitempty"`
+	// VolumeMounts are appended to the volume mounts of the component container
+	VolumeMounts                  []corev1.VolumeMount `json:"volumeMounts,omitempty"`
+	TerminationGracePeriodSeconds *int64               `json:"terminationGracePeriodSeconds,omitempty"`
+}
+
+// ServiceSpec describes the Service exposing a component
+type ServiceSpec struct {
+	Type corev1.ServiceType `json:"type,omitempty"`
+	// Annotations are added to the Service, e.g. to configure the cloud load balancer
+	Annotations              map[string]string `json:"annotations,omitempty"`
+	LoadBalancerIP           string            `json:"loadBalancerIP,omitempty"`
+	LoadBalancerSourceRanges []string          `json:"loadBalancerSourceRanges,omitempty"`
+	// ExternalTrafficPolicy only takes effect on NodePort and LoadBalancer Services
+	ExternalTrafficPolicy corev1.ServiceExternalTrafficPolicyType `json:"externalTrafficPolicy,omitempty"`
+	// NodePorts maps the port names of the Service to node ports, the unset ones are allocated by Kubernetes
+	NodePorts       map[string]int32       `json:"nodePorts,omitempty"`
+	SessionAffinity corev1.ServiceAffinity `json:"sessionAffinity,omitempty"`
+}
+
 // ResourceRequirement is resource requirements for a pod
 type ResourceRequirement struct {
 	// CPU is how many cores a pod requires
@@ -183,6 +308,18 @@ type PDStatus struct {
 	Members        map[string]PDMember        `json:"members,omitempty"`
 	Leader         PDMember                   `json:"leader,omitempty"`
 	FailureMembers map[string]PDFailureMember `json:"failureMembers,omitempty"`
+	// Placement is the state of the PD schedulers and replication config managed through the spec
+	Placement PDPlacementStatus `json:"placement,omitempty"`
+}
+
+// PDPlacementStatus is the state of the PD schedulers and replication config managed through the spec
+type PDPlacementStatus struct {
+	// Synced is true if PD was reconciled to the spec in the last sync
+	Synced bool `json:"synced,omitempty"`
+	// Schedulers are the names of the schedulers added to PD by tidb-operator, e.g. evict-leader-scheduler-1
+	Schedulers []string `json:"schedulers,omitempty"`
+	// Drift describes how PD differed from the spec in the last sync
+	Drift []string `json:"drift,omitempty"`
 }
 
 // PDMember is PD member
@@ -195,6 +332,8 @@ type PDMember struct {
 	Health    bool   `json:"health"`
 	// Last time the health transitioned from one to another.
 	LastTransitionTime metav1.Time `json:"lastTransitionTime,omitempty"`
+	// Node hosting pod of this PD member.
+	NodeName string `json:"node,omitempty"`
 }
 
 // PDFailureMember is the pd failure member information
@@ -212,6 +351,13 @@ type TiDBStatus struct {
 	Members                  map[string]TiDBMember        `json:"members,omitempty"`
 	FailureMembers           map[string]TiDBFailureMember `json:"failureMembers,omitempty"`
 	ResignDDLOwnerRetryCount int32                        `json:"resignDDLOwnerRetryCount,omitempty"`
+	Groups                   map[string]TiDBGroupStatus   `json:"groups,omitempty"`
+}
+
+// TiDBGroupStatus is the status of a TiDB server group
+type TiDBGroupStatus struct {
+	Phase       MemberPhase             `json:"phase,omitempty"`
+	StatefulSet *apps.StatefulSetStatus `json:"statefulSet,omitempty"`
 }
 
 // TiDBMember is TiDB member
@@ -222,11 +368,14 @@ type TiDBMember struct {
 	LastTransitionTime metav1.Time `json:"lastTransitionTime,omitempty"`
 	// Node hosting pod of this TiDB member.
 	NodeName string `json:"node,omitempty"`
+	// Group of this TiDB member, empty for the default group
+	Group string `json:"group,omitempty"`
 }
 
 // TiDBFailureMember is the tidb failure member information
 type TiDBFailureMember struct {
 	PodName string `json:"podName,omitempty"`
+	Group   string `json:"group,omitempty"`
 }
 
 // TiKVStatus is TiKV status
@@ -237,6 +386,24 @@ type TiKVStatus struct {
 	Stores          map[string]TiKVStore        `json:"stores,omitempty"`
 	TombstoneStores map[string]TiKVStore        `json:"tombstoneStores,omitempty"`
 	FailureStores   map[string]TiKVFailureStore `json:"failureStores,omitempty"`
+	Groups          map[string]TiKVGroupStatus  `json:"groups,omitempty"`
+	LeavingStores   map[string]TiKVLeavingStore `json:"leavingStores,omitempty"`
+}
+
+// TiKVLeavingStore is the progress of a TiKV store being removed by scale-in
+type TiKVLeavingStore struct {
+	PodName string `json:"podName"`
+	// The number of regions on the store when the scale-in started.
+	InitialRegionCount int32 `json:"initialRegionCount"`
+	// The number of regions remained on the store, the store becomes tombstone after all of them are moved away.
+	RegionCount int32       `json:"regionCount"`
+	StartTime   metav1.Time `json:"startTime"`
+}
+
+// TiKVGroupStatus is the status of a TiKV group
+type TiKVGroupStatus struct {
+	Phase       MemberPhase             `json:"phase,omitempty"`
+	StatefulSet *apps.StatefulSetStatus `json:"statefulSet,omitempty"`
 }
 
 // TiKVStores is either Up/Down/Offline/Tombstone
@@ -246,14 +413,20 @@ type TiKVStore struct {
 	PodName           string      `json:"podName"`
 	IP                string      `json:"ip"`
 	LeaderCount       int32       `json:"leaderCount"`
+	RegionCount       int32       `json:"regionCount"`
 	State             string      `json:"state"`
 	LastHeartbeatTime metav1.Time `json:"lastHeartbeatTime"`
 	// Last time the health transitioned from one to another.
 	LastTransitionTime metav1.Time `json:"lastTransitionTime,omitempty"`
+	// Node hosting pod of this TiKV store.
+	NodeName string `json:"node,omitempty"`
+	// Group of this TiKV store, empty for the default group
+	Group string `json:"group,omitempty"`
 }
 
 // TiKVFailureStore is the tikv failure store information
 type TiKVFailureStore struct {
 	PodName string `json:"podName,omitempty"`
 	StoreID string `json:"storeID,omitempty"`
+	Group   string `json:"group,omitempty"`
 }
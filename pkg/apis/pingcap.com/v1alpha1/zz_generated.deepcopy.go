@@ -85,6 +85,74 @@ func (in *PDMember) DeepCopy() *PDMember {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PDPlacementStatus) DeepCopyInto(out *PDPlacementStatus) {
+	*out = *in
+	if in.Schedulers != nil {
+		in, out := &in.Schedulers, &out.Schedulers
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Drift != nil {
+		in, out := &in.Drift, &out.Drift
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PDPlacementStatus.
+func (in *PDPlacementStatus) DeepCopy() *PDPlacementStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(PDPlacementStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PDReplicationSpec) DeepCopyInto(out *PDReplicationSpec) {
+	*out = *in
+	if in.MaxReplicas != nil {
+		in, out := &in.MaxReplicas, &out.MaxReplicas
+		*out = new(int32)
+		**out = **in
+	}
+	if in.LocationLabels != nil {
+		in, out := &in.LocationLabels, &out.LocationLabels
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PDReplicationSpec.
+func (in *PDReplicationSpec) DeepCopy() *PDReplicationSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(PDReplicationSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PDSchedulerSpec) DeepCopyInto(out *PDSchedulerSpec) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PDSchedulerSpec.
+func (in *PDSchedulerSpec) DeepCopy() *PDSchedulerSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(PDSchedulerSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *PDSpec) DeepCopyInto(out *PDSpec) {
 	*out = *in
@@ -115,6 +183,26 @@ func (in *PDSpec) DeepCopyInto(out *PDSpec) {
 			(*out)[key] = val
 		}
 	}
+	if in.Service != nil {
+		in, out := &in.Service, &out.Service
+		*out = new(ServiceSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.PodTemplate != nil {
+		in, out := &in.PodTemplate, &out.PodTemplate
+		*out = new(PodTemplateOverride)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Schedulers != nil {
+		in, out := &in.Schedulers, &out.Schedulers
+		*out = make([]PDSchedulerSpec, len(*in))
+		copy(*out, *in)
+	}
+	if in.Replication != nil {
+		in, out := &in.Replication, &out.Replication
+		*out = new(PDReplicationSpec)
+		(*in).DeepCopyInto(*out)
+	}
 	return
 }
 
@@ -151,6 +239,7 @@ func (in *PDStatus) DeepCopyInto(out *PDStatus) {
 			(*out)[key] = val
 		}
 	}
+	in.Placement.DeepCopyInto(&out.Placement)
 	return
 }
 
@@ -164,6 +253,74 @@ func (in *PDStatus) DeepCopy() *PDStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PodTemplateOverride) DeepCopyInto(out *PodTemplateOverride) {
+	*out = *in
+	if in.Labels != nil {
+		in, out := &in.Labels, &out.Labels
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.Env != nil {
+		in, out := &in.Env, &out.Env
+		*out = make([]v1.EnvVar, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.SecurityContext != nil {
+		in, out := &in.SecurityContext, &out.SecurityContext
+		*out = new(v1.PodSecurityContext)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.InitContainers != nil {
+		in, out := &in.InitContainers, &out.InitContainers
+		*out = make([]v1.Container, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Sidecars != nil {
+		in, out := &in.Sidecars, &out.Sidecars
+		*out = make([]v1.Container, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Volumes != nil {
+		in, out := &in.Volumes, &out.Volumes
+		*out = make([]v1.Volume, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.VolumeMounts != nil {
+		in, out := &in.VolumeMounts, &out.VolumeMounts
+		*out = make([]v1.VolumeMount, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.TerminationGracePeriodSeconds != nil {
+		in, out := &in.TerminationGracePeriodSeconds, &out.TerminationGracePeriodSeconds
+		*out = new(int64)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PodTemplateOverride.
+func (in *PodTemplateOverride) DeepCopy() *PodTemplateOverride {
+	if in == nil {
+		return nil
+	}
+	out := new(PodTemplateOverride)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ResourceRequirement) DeepCopyInto(out *ResourceRequirement) {
 	*out = *in
@@ -196,6 +353,41 @@ func (in *Service) DeepCopy() *Service {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ServiceSpec) DeepCopyInto(out *ServiceSpec) {
+	*out = *in
+	if in.Annotations != nil {
+		in, out := &in.Annotations, &out.Annotations
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.LoadBalancerSourceRanges != nil {
+		in, out := &in.LoadBalancerSourceRanges, &out.LoadBalancerSourceRanges
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.NodePorts != nil {
+		in, out := &in.NodePorts, &out.NodePorts
+		*out = make(map[string]int32, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ServiceSpec.
+func (in *ServiceSpec) DeepCopy() *ServiceSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(ServiceSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *TiDBFailureMember) DeepCopyInto(out *TiDBFailureMember) {
 	*out = *in
@@ -212,6 +404,65 @@ func (in *TiDBFailureMember) DeepCopy() *TiDBFailureMember {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TiDBGroupSpec) DeepCopyInto(out *TiDBGroupSpec) {
+	*out = *in
+	if in.Requests != nil {
+		in, out := &in.Requests, &out.Requests
+		*out = new(ResourceRequirement)
+		**out = **in
+	}
+	if in.Limits != nil {
+		in, out := &in.Limits, &out.Limits
+		*out = new(ResourceRequirement)
+		**out = **in
+	}
+	if in.Config != nil {
+		in, out := &in.Config, &out.Config
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.Service != nil {
+		in, out := &in.Service, &out.Service
+		*out = new(ServiceSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TiDBGroupSpec.
+func (in *TiDBGroupSpec) DeepCopy() *TiDBGroupSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(TiDBGroupSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TiDBGroupStatus) DeepCopyInto(out *TiDBGroupStatus) {
+	*out = *in
+	if in.StatefulSet != nil {
+		in, out := &in.StatefulSet, &out.StatefulSet
+		*out = new(v1beta1.StatefulSetStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TiDBGroupStatus.
+func (in *TiDBGroupStatus) DeepCopy() *TiDBGroupStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(TiDBGroupStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *TiDBMember) DeepCopyInto(out *TiDBMember) {
 	*out = *in
@@ -277,6 +528,23 @@ func (in *TiDBSpec) DeepCopyInto(out *TiDBSpec) {
 		}
 	}
 	in.SlowLogTailer.DeepCopyInto(&out.SlowLogTailer)
+	if in.PodTemplate != nil {
+		in, out := &in.PodTemplate, &out.PodTemplate
+		*out = new(PodTemplateOverride)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Service != nil {
+		in, out := &in.Service, &out.Service
+		*out = new(ServiceSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Groups != nil {
+		in, out := &in.Groups, &out.Groups
+		*out = make([]TiDBGroupSpec, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 	return
 }
 
@@ -312,6 +580,13 @@ func (in *TiDBStatus) DeepCopyInto(out *TiDBStatus) {
 			(*out)[key] = val
 		}
 	}
+	if in.Groups != nil {
+		in, out := &in.Groups, &out.Groups
+		*out = make(map[string]TiDBGroupStatus, len(*in))
+		for key, val := range *in {
+			(*out)[key] = *val.DeepCopy()
+		}
+	}
 	return
 }
 
@@ -341,6 +616,84 @@ func (in *TiKVFailureStore) DeepCopy() *TiKVFailureStore {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TiKVGroupSpec) DeepCopyInto(out *TiKVGroupSpec) {
+	*out = *in
+	if in.Requests != nil {
+		in, out := &in.Requests, &out.Requests
+		*out = new(ResourceRequirement)
+		**out = **in
+	}
+	if in.Limits != nil {
+		in, out := &in.Limits, &out.Limits
+		*out = new(ResourceRequirement)
+		**out = **in
+	}
+	if in.NodeSelector != nil {
+		in, out := &in.NodeSelector, &out.NodeSelector
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.StoreLabels != nil {
+		in, out := &in.StoreLabels, &out.StoreLabels
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TiKVGroupSpec.
+func (in *TiKVGroupSpec) DeepCopy() *TiKVGroupSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(TiKVGroupSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TiKVGroupStatus) DeepCopyInto(out *TiKVGroupStatus) {
+	*out = *in
+	if in.StatefulSet != nil {
+		in, out := &in.StatefulSet, &out.StatefulSet
+		*out = new(v1beta1.StatefulSetStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TiKVGroupStatus.
+func (in *TiKVGroupStatus) DeepCopy() *TiKVGroupStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(TiKVGroupStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TiKVLeavingStore) DeepCopyInto(out *TiKVLeavingStore) {
+	*out = *in
+	in.StartTime.DeepCopyInto(&out.StartTime)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TiKVLeavingStore.
+func (in *TiKVLeavingStore) DeepCopy() *TiKVLeavingStore {
+	if in == nil {
+		return nil
+	}
+	out := new(TiKVLeavingStore)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *TiKVPromGatewaySpec) DeepCopyInto(out *TiKVPromGatewaySpec) {
 	*out = *in
@@ -388,6 +741,18 @@ func (in *TiKVSpec) DeepCopyInto(out *TiKVSpec) {
 			(*out)[key] = val
 		}
 	}
+	if in.PodTemplate != nil {
+		in, out := &in.PodTemplate, &out.PodTemplate
+		*out = new(PodTemplateOverride)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Groups != nil {
+		in, out := &in.Groups, &out.Groups
+		*out = make([]TiKVGroupSpec, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 	return
 }
 
@@ -430,6 +795,20 @@ func (in *TiKVStatus) DeepCopyInto(out *TiKVStatus) {
 			(*out)[key] = val
 		}
 	}
+	if in.Groups != nil {
+		in, out := &in.Groups, &out.Groups
+		*out = make(map[string]TiKVGroupStatus, len(*in))
+		for key, val := range *in {
+			(*out)[key] = *val.DeepCopy()
+		}
+	}
+	if in.LeavingStores != nil {
+		in, out := &in.LeavingStores, &out.LeavingStores
+		*out = make(map[string]TiKVLeavingStore, len(*in))
+		for key, val := range *in {
+			(*out)[key] = *val.DeepCopy()
+		}
+	}
 	return
 }
 
@@ -522,6 +901,22 @@ func (in *TidbClusterList) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TidbClusterRef) DeepCopyInto(out *TidbClusterRef) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TidbClusterRef.
+func (in *TidbClusterRef) DeepCopy() *TidbClusterRef {
+	if in == nil {
+		return nil
+	}
+	out := new(TidbClusterRef)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *TidbClusterSpec) DeepCopyInto(out *TidbClusterSpec) {
 	*out = *in
@@ -534,6 +929,11 @@ func (in *TidbClusterSpec) DeepCopyInto(out *TidbClusterSpec) {
 		*out = make([]Service, len(*in))
 		copy(*out, *in)
 	}
+	if in.Cluster != nil {
+		in, out := &in.Cluster, &out.Cluster
+		*out = new(TidbClusterRef)
+		**out = **in
+	}
 	return
 }
 
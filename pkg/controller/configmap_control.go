@@ -0,0 +1,162 @@
+// Copyright 2019. PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/golang/glog"
+	"github.com/pingcap/tidb-operator/pkg/apis/pingcap.com/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	coreinformers "k8s.io/client-go/informers/core/v1"
+	"k8s.io/client-go/kubernetes"
+	corelisters "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/client-go/util/retry"
+)
+
+// ConfigMapControlInterface manages the ConfigMaps generated for TidbCluster
+type ConfigMapControlInterface interface {
+	CreateConfigMap(*v1alpha1.TidbCluster, *corev1.ConfigMap) error
+	UpdateConfigMap(*v1alpha1.TidbCluster, *corev1.ConfigMap) (*corev1.ConfigMap, error)
+}
+
+type realConfigMapControl struct {
+	kubeCli  kubernetes.Interface
+	cmLister corelisters.ConfigMapLister
+	recorder record.EventRecorder
+}
+
+// NewRealConfigMapControl creates a new ConfigMapControlInterface
+func NewRealConfigMapControl(kubeCli kubernetes.Interface, cmLister corelisters.ConfigMapLister, recorder record.EventRecorder) ConfigMapControlInterface {
+	return &realConfigMapControl{
+		kubeCli,
+		cmLister,
+		recorder,
+	}
+}
+
+func (cc *realConfigMapControl) CreateConfigMap(tc *v1alpha1.TidbCluster, cm *corev1.ConfigMap) error {
+	_, err := cc.kubeCli.CoreV1().ConfigMaps(tc.Namespace).Create(cm)
+	if apierrors.IsAlreadyExists(err) {
+		return err
+	}
+	cc.recordConfigMapEvent("create", tc, cm, err)
+	return err
+}
+
+func (cc *realConfigMapControl) UpdateConfigMap(tc *v1alpha1.TidbCluster, cm *corev1.ConfigMap) (*corev1.ConfigMap, error) {
+	ns := tc.GetNamespace()
+	tcName := tc.GetName()
+	cmName := cm.GetName()
+	data := cm.Data
+
+	var updateCM *corev1.ConfigMap
+	err := retry.RetryOnConflict(retry.DefaultBackoff, func() error {
+		var updateErr error
+		updateCM, updateErr = cc.kubeCli.CoreV1().ConfigMaps(ns).Update(cm)
+		if updateErr == nil {
+			glog.Infof("update ConfigMap: [%s/%s] successfully, TidbCluster: %s", ns, cmName, tcName)
+			return nil
+		}
+
+		if updated, err := cc.cmLister.ConfigMaps(ns).Get(cmName); err == nil {
+			// make a copy so we don't mutate the shared cache
+			cm = updated.DeepCopy()
+			cm.Data = data
+		} else {
+			utilruntime.HandleError(fmt.Errorf("error getting updated ConfigMap %s/%s from lister: %v", ns, cmName, err))
+		}
+
+		return updateErr
+	})
+	cc.recordConfigMapEvent("update", tc, cm, err)
+	return updateCM, err
+}
+
+func (cc *realConfigMapControl) recordConfigMapEvent(verb string, tc *v1alpha1.TidbCluster, cm *corev1.ConfigMap, err error) {
+	tcName := tc.Name
+	cmName := cm.Name
+	if err == nil {
+		reason := fmt.Sprintf("Successful%s", strings.Title(verb))
+		msg := fmt.Sprintf("%s ConfigMap %s in TidbCluster %s successful",
+			strings.ToLower(verb), cmName, tcName)
+		cc.recorder.Event(tc, corev1.EventTypeNormal, reason, msg)
+	} else {
+		reason := fmt.Sprintf("Failed%s", strings.Title(verb))
+		msg := fmt.Sprintf("%s ConfigMap %s in TidbCluster %s failed error: %s",
+			strings.ToLower(verb), cmName, tcName, err)
+		cc.recorder.Event(tc, corev1.EventTypeWarning, reason, msg)
+	}
+}
+
+var _ ConfigMapControlInterface = &realConfigMapControl{}
+
+// FakeConfigMapControl is a fake ConfigMapControlInterface
+type FakeConfigMapControl struct {
+	CmLister               corelisters.ConfigMapLister
+	CmIndexer              cache.Indexer
+	createConfigMapTracker requestTracker
+	updateConfigMapTracker requestTracker
+}
+
+// NewFakeConfigMapControl returns a FakeConfigMapControl
+func NewFakeConfigMapControl(cmInformer coreinformers.ConfigMapInformer) *FakeConfigMapControl {
+	return &FakeConfigMapControl{
+		cmInformer.Lister(),
+		cmInformer.Informer().GetIndexer(),
+		requestTracker{0, nil, 0},
+		requestTracker{0, nil, 0},
+	}
+}
+
+// SetCreateConfigMapError sets the error attributes of createConfigMapTracker
+func (fcc *FakeConfigMapControl) SetCreateConfigMapError(err error, after int) {
+	fcc.createConfigMapTracker.err = err
+	fcc.createConfigMapTracker.after = after
+}
+
+// SetUpdateConfigMapError sets the error attributes of updateConfigMapTracker
+func (fcc *FakeConfigMapControl) SetUpdateConfigMapError(err error, after int) {
+	fcc.updateConfigMapTracker.err = err
+	fcc.updateConfigMapTracker.after = after
+}
+
+// CreateConfigMap adds the ConfigMap to CmIndexer
+func (fcc *FakeConfigMapControl) CreateConfigMap(_ *v1alpha1.TidbCluster, cm *corev1.ConfigMap) error {
+	defer fcc.createConfigMapTracker.inc()
+	if fcc.createConfigMapTracker.errorReady() {
+		defer fcc.createConfigMapTracker.reset()
+		return fcc.createConfigMapTracker.err
+	}
+
+	return fcc.CmIndexer.Add(cm)
+}
+
+// UpdateConfigMap updates the ConfigMap of CmIndexer
+func (fcc *FakeConfigMapControl) UpdateConfigMap(_ *v1alpha1.TidbCluster, cm *corev1.ConfigMap) (*corev1.ConfigMap, error) {
+	defer fcc.updateConfigMapTracker.inc()
+	if fcc.updateConfigMapTracker.errorReady() {
+		defer fcc.updateConfigMapTracker.reset()
+		return nil, fcc.updateConfigMapTracker.err
+	}
+
+	return cm, fcc.CmIndexer.Update(cm)
+}
+
+var _ ConfigMapControlInterface = &FakeConfigMapControl{}
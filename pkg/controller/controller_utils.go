@@ -126,11 +126,23 @@ func PDPeerMemberName(clusterName string) string {
 	return fmt.Sprintf("%s-pd-peer", clusterName)
 }
 
+// PDClientAddr returns the client address of the PD used by the TidbCluster,
+// which is the PD of the referenced TidbCluster if there is one
+func PDClientAddr(tc *v1alpha1.TidbCluster) string {
+	ns, tcName := tc.PDClusterRef()
+	return fmt.Sprintf("%s.%s:2379", PDMemberName(tcName), ns)
+}
+
 // TiKVMemberName returns tikv member name
 func TiKVMemberName(clusterName string) string {
 	return fmt.Sprintf("%s-tikv", clusterName)
 }
 
+// TiKVGroupMemberName returns tikv group member name
+func TiKVGroupMemberName(clusterName, group string) string {
+	return fmt.Sprintf("%s-tikv-%s", clusterName, group)
+}
+
 // TiKVPeerMemberName returns tikv peer service name
 func TiKVPeerMemberName(clusterName string) string {
 	return fmt.Sprintf("%s-tikv-peer", clusterName)
@@ -141,6 +153,11 @@ func TiDBMemberName(clusterName string) string {
 	return fmt.Sprintf("%s-tidb", clusterName)
 }
 
+// TiDBGroupMemberName returns tidb group member name
+func TiDBGroupMemberName(clusterName, group string) string {
+	return fmt.Sprintf("%s-tidb-%s", clusterName, group)
+}
+
 // TiDBPeerMemberName returns tidb peer service name
 func TiDBPeerMemberName(clusterName string) string {
 	return fmt.Sprintf("%s-tidb-peer", clusterName)
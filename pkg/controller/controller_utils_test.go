@@ -171,6 +171,11 @@ func TestTiDBMemberName(t *testing.T) {
 	g.Expect(TiDBMemberName("demo")).To(Equal("demo-tidb"))
 }
 
+func TestTiDBGroupMemberName(t *testing.T) {
+	g := NewGomegaWithT(t)
+	g.Expect(TiDBGroupMemberName("demo", "olap")).To(Equal("demo-tidb-olap"))
+}
+
 func TestTiDBPeerMemberName(t *testing.T) {
 	g := NewGomegaWithT(t)
 	g.Expect(TiDBPeerMemberName("demo")).To(Equal("demo-tidb-peer"))
@@ -15,11 +15,15 @@ package controller
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
 	"io/ioutil"
+	"net"
 	"net/http"
+	"net/url"
+	"sort"
 	"strings"
 	"sync"
 	"time"
@@ -28,17 +32,27 @@ import (
 	"github.com/pingcap/kvproto/pkg/pdpb"
 	"github.com/pingcap/pd/pkg/typeutil"
 	"github.com/pingcap/pd/server"
+	"github.com/pingcap/pd/server/core"
 	"github.com/pingcap/tidb-operator/pkg/apis/pingcap.com/v1alpha1"
 )
 
 const (
 	timeout = 5 * time.Second
+	// pdRequestRetries is the number of retries of the idempotent requests to PD
+	pdRequestRetries       = 2
+	pdRequestRetryInterval = 500 * time.Millisecond
 )
 
+// pdRequestDeadline caps the time of a request to PD including the fallbacks and retries,
+// so that an unavailable PD doesn't block the sync of a TidbCluster for long
+var pdRequestDeadline = 10 * time.Second
+
 // PDControlInterface is an interface that knows how to manage and get tidb cluster's PD client
 type PDControlInterface interface {
 	// GetPDClient provides PDClient of the tidb cluster.
 	GetPDClient(tc *v1alpha1.TidbCluster) PDClient
+	// RemovePDClient evicts the cached PDClient of the tidb cluster, it's called after the tidb cluster is deleted
+	RemovePDClient(namespace, tcName string)
 }
 
 // defaultPDControl is the default implementation of PDControlInterface.
@@ -53,18 +67,51 @@ func NewDefaultPDControl() PDControlInterface {
 }
 
 // GetPDClient provides a PDClient of real pd cluster,if the PDClient not existing, it will create new one.
+// The PD members in the TidbCluster status are used as the fallback endpoints of the PD Service
 func (pdc *defaultPDControl) GetPDClient(tc *v1alpha1.TidbCluster) PDClient {
 	pdc.mutex.Lock()
 	defer pdc.mutex.Unlock()
-	namespace := tc.GetNamespace()
-	tcName := tc.GetName()
+	// a TidbCluster referencing another TidbCluster talks to the PD of the referenced one
+	namespace, tcName := tc.PDClusterRef()
 	key := pdClientKey(namespace, tcName)
 	if _, ok := pdc.pdClients[key]; !ok {
 		pdc.pdClients[key] = NewPDClient(pdClientURL(namespace, tcName), timeout)
 	}
+	if client, ok := pdc.pdClients[key].(*pdClient); ok {
+		client.endpoints.set(tc.Status.PD.Leader.ClientURL, pdMemberURLs(tc))
+	}
 	return pdc.pdClients[key]
 }
 
+// RemovePDClient evicts the cached PDClient of the tidb cluster
+func (pdc *defaultPDControl) RemovePDClient(namespace, tcName string) {
+	pdc.mutex.Lock()
+	defer pdc.mutex.Unlock()
+	delete(pdc.pdClients, pdClientKey(namespace, tcName))
+}
+
+// pdMemberURLs returns the client URLs of the PD members, the healthy ones first
+func pdMemberURLs(tc *v1alpha1.TidbCluster) []string {
+	names := make([]string, 0, len(tc.Status.PD.Members))
+	for name, member := range tc.Status.PD.Members {
+		if member.ClientURL != "" {
+			names = append(names, name)
+		}
+	}
+	members := tc.Status.PD.Members
+	sort.Slice(names, func(i, j int) bool {
+		if members[names[i]].Health != members[names[j]].Health {
+			return members[names[i]].Health
+		}
+		return names[i] < names[j]
+	})
+	urls := make([]string, 0, len(names))
+	for _, name := range names {
+		urls = append(urls, members[name].ClientURL)
+	}
+	return urls
+}
+
 // pdClientKey returns the pd client key
 func pdClientKey(namespace, clusterName string) string {
 	return fmt.Sprintf("%s.%s", clusterName, namespace)
@@ -96,6 +143,8 @@ type PDClient interface {
 	SetStoreLabels(storeID uint64, labels map[string]string) (bool, error)
 	// DeleteStore deletes a TiKV store from cluster
 	DeleteStore(storeID uint64) error
+	// SetStoreState sets the state of a TiKV store, it's used to bring an offline store back up
+	SetStoreState(storeID uint64, state string) error
 	// DeleteMember deletes a PD member from cluster
 	DeleteMember(name string) error
 	// DeleteMemberByID deletes a PD member from cluster
@@ -107,10 +156,54 @@ type PDClient interface {
 	EndEvictLeader(storeID uint64) error
 	// GetEvictLeaderSchedulers gets schedulers of evict leader
 	GetEvictLeaderSchedulers() ([]string, error)
+	// GetSchedulers returns the names of all the schedulers in PD
+	GetSchedulers() ([]string, error)
+	// AddScheduler adds a scheduler to PD, storeID is only used by the schedulers for a store, e.g. evict-leader-scheduler
+	AddScheduler(name string, storeID uint64) error
+	// RemoveScheduler removes a scheduler from PD by its name returned by GetSchedulers
+	RemoveScheduler(name string) error
+	// SetReplicationConfig sets the replication config of PD
+	SetReplicationConfig(config *server.ReplicationConfig) error
+	// GetScheduleConfig returns the schedule config of PD
+	GetScheduleConfig() (*server.ScheduleConfig, error)
+	// SetScheduleConfig sets the schedule config of PD
+	SetScheduleConfig(config *server.ScheduleConfig) error
+	// GetRegions lists all the regions from cluster
+	GetRegions() (*RegionsInfo, error)
+	// GetRegion gets a region for a specific region id from cluster
+	GetRegion(regionID uint64) (*RegionInfo, error)
+	// GetStoreRegions lists the regions having a peer on a specific store
+	GetStoreRegions(storeID uint64) (*RegionsInfo, error)
+	// GetRegionStats returns the statistics and the distribution of all the regions
+	GetRegionStats() (*core.RegionStats, error)
+	// GetHotReadRegions returns the hot read regions grouped by store
+	GetHotReadRegions() (*core.StoreHotRegionInfos, error)
+	// GetHotWriteRegions returns the hot write regions grouped by store
+	GetHotWriteRegions() (*core.StoreHotRegionInfos, error)
+	// GetTopReadFlowRegions lists at most limit regions with the most read bytes
+	GetTopReadFlowRegions(limit int) (*RegionsInfo, error)
+	// GetTopWriteFlowRegions lists at most limit regions with the most written bytes
+	GetTopWriteFlowRegions(limit int) (*RegionsInfo, error)
+	// GetLabels lists all the store labels in cluster
+	GetLabels() ([]*metapb.StoreLabel, error)
+	// GetStoresByLabel lists the stores having a specific label
+	GetStoresByLabel(key, value string) (*StoresInfo, error)
+	// GetStoreLimits returns the rate limits of adding and removing peers of all the stores
+	GetStoreLimits() (map[uint64]float64, error)
+	// SetStoreLimit sets the rate limit of adding and removing peers of a store
+	SetStoreLimit(storeID uint64, rate float64) error
+	// GetOperators lists the running operators
+	GetOperators() ([]string, error)
+	// AddOperator adds an operator to a region
+	AddOperator(operator *Operator) error
+	// RemoveOperator removes the running operator of a region
+	RemoveOperator(regionID uint64) error
 	// GetPDLeader returns pd leader
 	GetPDLeader() (*pdpb.Member, error)
 	// TransferPDLeader transfers pd leader to specified member
 	TransferPDLeader(name string) error
+	// WithContext returns a PDClient sending the requests with the context
+	WithContext(ctx context.Context) PDClient
 }
 
 var (
@@ -119,16 +212,31 @@ var (
 	storesPrefix           = "pd/api/v1/stores"
 	storePrefix            = "pd/api/v1/store"
 	configPrefix           = "pd/api/v1/config"
+	replicationPrefix      = "pd/api/v1/config/replicate"
 	clusterIDPrefix        = "pd/api/v1/cluster"
 	schedulersPrefix       = "pd/api/v1/schedulers"
 	pdLeaderPrefix         = "pd/api/v1/leader"
 	pdLeaderTransferPrefix = "pd/api/v1/leader/transfer"
+	scheduleConfigPrefix   = "pd/api/v1/config/schedule"
+	regionsPrefix          = "pd/api/v1/regions"
+	regionPrefix           = "pd/api/v1/region"
+	regionStatsPrefix      = "pd/api/v1/stats/region"
+	hotReadRegionsPrefix   = "pd/api/v1/hotspot/regions/read"
+	hotWriteRegionsPrefix  = "pd/api/v1/hotspot/regions/write"
+	readFlowRegionsPrefix  = "pd/api/v1/regions/readflow"
+	writeFlowRegionsPrefix = "pd/api/v1/regions/writeflow"
+	labelsPrefix           = "pd/api/v1/labels"
+	storesLimitPrefix      = "pd/api/v1/stores/limit"
+	operatorsPrefix        = "pd/api/v1/operators"
 )
 
 // pdClient is default implementation of PDClient
 type pdClient struct {
 	url        string
 	httpClient *http.Client
+	ctx        context.Context
+	// endpoints are shared by the clients returned by WithContext
+	endpoints *pdEndpoints
 }
 
 // NewPDClient returns a new PDClient
@@ -136,7 +244,50 @@ func NewPDClient(url string, timeout time.Duration) PDClient {
 	return &pdClient{
 		url:        url,
 		httpClient: &http.Client{Timeout: timeout},
+		ctx:        context.Background(),
+		endpoints:  &pdEndpoints{},
+	}
+}
+
+func (pc *pdClient) WithContext(ctx context.Context) PDClient {
+	return &pdClient{
+		url:        pc.url,
+		httpClient: pc.httpClient,
+		ctx:        ctx,
+		endpoints:  pc.endpoints,
+	}
+}
+
+// pdEndpoints are the URLs of the individual PD members besides the URL of the PD Service
+type pdEndpoints struct {
+	mutex      sync.RWMutex
+	leaderURL  string
+	memberURLs []string
+}
+
+func (pe *pdEndpoints) set(leaderURL string, memberURLs []string) {
+	pe.mutex.Lock()
+	defer pe.mutex.Unlock()
+	pe.leaderURL = leaderURL
+	pe.memberURLs = memberURLs
+}
+
+// urls returns the URLs to try in order, the reads go to the PD Service first,
+// and the writes go to the PD leader first to save a forwarding
+func (pe *pdEndpoints) urls(serviceURL string, write bool) []string {
+	pe.mutex.RLock()
+	defer pe.mutex.RUnlock()
+	urls := []string{}
+	if write && pe.leaderURL != "" {
+		urls = append(urls, pe.leaderURL)
+	}
+	urls = append(urls, serviceURL)
+	for _, u := range pe.memberURLs {
+		if u != pe.leaderURL || !write {
+			urls = append(urls, u)
+		}
 	}
+	return urls
 }
 
 // following struct definitions are copied from github.com/pingcap/pd/server/api/store
@@ -198,20 +349,51 @@ type MembersInfo struct {
 	EtcdLeader *pdpb.Member         `json:"etcd_leader,omitempty"`
 }
 
+// RegionInfo is a region returned from PD RESTful interface
+type RegionInfo struct {
+	ID              uint64              `json:"id"`
+	StartKey        string              `json:"start_key"`
+	EndKey          string              `json:"end_key"`
+	RegionEpoch     *metapb.RegionEpoch `json:"epoch,omitempty"`
+	Peers           []*metapb.Peer      `json:"peers,omitempty"`
+	Leader          *metapb.Peer        `json:"leader,omitempty"`
+	DownPeers       []*pdpb.PeerStats   `json:"down_peers,omitempty"`
+	PendingPeers    []*metapb.Peer      `json:"pending_peers,omitempty"`
+	WrittenBytes    uint64              `json:"written_bytes,omitempty"`
+	ReadBytes       uint64              `json:"read_bytes,omitempty"`
+	ApproximateSize int64               `json:"approximate_size,omitempty"`
+	ApproximateKeys int64               `json:"approximate_keys,omitempty"`
+}
+
+// RegionsInfo is regions info returned from PD RESTful interface
+type RegionsInfo struct {
+	Count   int           `json:"count"`
+	Regions []*RegionInfo `json:"regions"`
+}
+
+// Operator is an operator added to a region through PD RESTful interface,
+// the fields used depend on the operator, e.g. transfer-leader uses RegionID and ToStoreID
+type Operator struct {
+	// Name is one of transfer-leader, transfer-region, transfer-peer, add-peer, remove-peer, merge-region and split-region
+	Name           string   `json:"name"`
+	RegionID       uint64   `json:"region_id,omitempty"`
+	StoreID        uint64   `json:"store_id,omitempty"`
+	FromStoreID    uint64   `json:"from_store_id,omitempty"`
+	ToStoreID      uint64   `json:"to_store_id,omitempty"`
+	ToStoreIDs     []uint64 `json:"to_store_ids,omitempty"`
+	SourceRegionID uint64   `json:"source_region_id,omitempty"`
+	TargetRegionID uint64   `json:"target_region_id,omitempty"`
+	Policy         string   `json:"policy,omitempty"`
+}
+
 type schedulerInfo struct {
 	Name    string `json:"name"`
 	StoreID uint64 `json:"store_id"`
 }
 
 func (pc *pdClient) GetHealth() (*HealthInfo, error) {
-	apiURL := fmt.Sprintf("%s/%s", pc.url, healthPrefix)
-	body, err := pc.getBodyOK(apiURL)
-	if err != nil {
-		return nil, err
-	}
 	healths := []MemberHealth{}
-	err = json.Unmarshal(body, &healths)
-	if err != nil {
+	if err := pc.get(healthPrefix, &healths); err != nil {
 		return nil, err
 	}
 	return &HealthInfo{
@@ -220,84 +402,48 @@ func (pc *pdClient) GetHealth() (*HealthInfo, error) {
 }
 
 func (pc *pdClient) GetConfig() (*server.Config, error) {
-	apiURL := fmt.Sprintf("%s/%s", pc.url, configPrefix)
-	body, err := pc.getBodyOK(apiURL)
-	if err != nil {
-		return nil, err
-	}
 	config := &server.Config{}
-	err = json.Unmarshal(body, config)
-	if err != nil {
+	if err := pc.get(configPrefix, config); err != nil {
 		return nil, err
 	}
 	return config, nil
 }
 
 func (pc *pdClient) GetCluster() (*metapb.Cluster, error) {
-	apiURL := fmt.Sprintf("%s/%s", pc.url, clusterIDPrefix)
-	body, err := pc.getBodyOK(apiURL)
-	if err != nil {
-		return nil, err
-	}
 	cluster := &metapb.Cluster{}
-	err = json.Unmarshal(body, cluster)
-	if err != nil {
+	if err := pc.get(clusterIDPrefix, cluster); err != nil {
 		return nil, err
 	}
 	return cluster, nil
 }
 
 func (pc *pdClient) GetMembers() (*MembersInfo, error) {
-	apiURL := fmt.Sprintf("%s/%s", pc.url, membersPrefix)
-	body, err := pc.getBodyOK(apiURL)
-	if err != nil {
-		return nil, err
-	}
 	members := &MembersInfo{}
-	err = json.Unmarshal(body, members)
-	if err != nil {
+	if err := pc.get(membersPrefix, members); err != nil {
 		return nil, err
 	}
 	return members, nil
 }
 
 func (pc *pdClient) GetStores() (*StoresInfo, error) {
-	apiURL := fmt.Sprintf("%s/%s", pc.url, storesPrefix)
-	body, err := pc.getBodyOK(apiURL)
-	if err != nil {
-		return nil, err
-	}
 	storesInfo := &StoresInfo{}
-	err = json.Unmarshal(body, storesInfo)
-	if err != nil {
+	if err := pc.get(storesPrefix, storesInfo); err != nil {
 		return nil, err
 	}
 	return storesInfo, nil
 }
 
 func (pc *pdClient) GetTombStoneStores() (*StoresInfo, error) {
-	apiURL := fmt.Sprintf("%s/%s?state=%d", pc.url, storesPrefix, metapb.StoreState_Tombstone)
-	body, err := pc.getBodyOK(apiURL)
-	if err != nil {
-		return nil, err
-	}
 	storesInfo := &StoresInfo{}
-	err = json.Unmarshal(body, storesInfo)
-	if err != nil {
+	if err := pc.get(fmt.Sprintf("%s?state=%d", storesPrefix, metapb.StoreState_Tombstone), storesInfo); err != nil {
 		return nil, err
 	}
 	return storesInfo, nil
 }
 
 func (pc *pdClient) GetStore(storeID uint64) (*StoreInfo, error) {
-	apiURL := fmt.Sprintf("%s/%s/%d", pc.url, storePrefix, storeID)
-	body, err := pc.getBodyOK(apiURL)
-	if err != nil {
-		return nil, err
-	}
 	storeInfo := &StoreInfo{}
-	err = json.Unmarshal(body, storeInfo)
-	if err != nil {
+	if err := pc.get(fmt.Sprintf("%s/%d", storePrefix, storeID), storeInfo); err != nil {
 		return nil, err
 	}
 	return storeInfo, nil
@@ -318,27 +464,20 @@ func (pc *pdClient) DeleteStore(storeID uint64) error {
 	if !exist {
 		return nil
 	}
-	apiURL := fmt.Sprintf("%s/%s/%d", pc.url, storePrefix, storeID)
-	req, err := http.NewRequest("DELETE", apiURL, nil)
-	if err != nil {
-		return err
-	}
-	res, err := pc.httpClient.Do(req)
-	if err != nil {
-		return err
-	}
-	defer DeferClose(res.Body, &err)
-
 	// Remove an offline store should returns http.StatusOK
-	if res.StatusCode == http.StatusOK || res.StatusCode == http.StatusNotFound {
-		return nil
+	err = pc.do("DELETE", fmt.Sprintf("%s/%d", storePrefix, storeID), nil, nil)
+	if err != nil && !IsPDNotFound(err) {
+		return fmt.Errorf("failed to delete store %d: %v", storeID, err)
 	}
-	body, err := ioutil.ReadAll(res.Body)
+	return nil
+}
+
+func (pc *pdClient) SetStoreState(storeID uint64, state string) error {
+	err := pc.do("POST", fmt.Sprintf("%s/%d/state?state=%s", storePrefix, storeID, state), nil, nil)
 	if err != nil {
-		return err
+		return fmt.Errorf("failed to set the state of store %d to %s: %v", storeID, state, err)
 	}
-
-	return fmt.Errorf("failed to delete store %d: %v", storeID, string(body))
+	return nil
 }
 
 func (pc *pdClient) DeleteMemberByID(memberID uint64) error {
@@ -356,21 +495,11 @@ func (pc *pdClient) DeleteMemberByID(memberID uint64) error {
 	if !exist {
 		return nil
 	}
-	apiURL := fmt.Sprintf("%s/%s/id/%d", pc.url, membersPrefix, memberID)
-	req, err := http.NewRequest("DELETE", apiURL, nil)
-	if err != nil {
-		return err
-	}
-	res, err := pc.httpClient.Do(req)
-	if err != nil {
-		return err
-	}
-	defer DeferClose(res.Body, &err)
-	if res.StatusCode == http.StatusOK || res.StatusCode == http.StatusNotFound {
-		return nil
+	err = pc.do("DELETE", fmt.Sprintf("%s/id/%d", membersPrefix, memberID), nil, nil)
+	if err != nil && !IsPDNotFound(err) {
+		return fmt.Errorf("failed to delete member %d: %v", memberID, err)
 	}
-	err2 := readErrorBody(res.Body)
-	return fmt.Errorf("failed %v to delete member %d: %v", res.StatusCode, memberID, err2)
+	return nil
 }
 
 func (pc *pdClient) DeleteMember(name string) error {
@@ -388,56 +517,26 @@ func (pc *pdClient) DeleteMember(name string) error {
 	if !exist {
 		return nil
 	}
-	apiURL := fmt.Sprintf("%s/%s/name/%s", pc.url, membersPrefix, name)
-	req, err := http.NewRequest("DELETE", apiURL, nil)
-	if err != nil {
-		return err
+	err = pc.do("DELETE", fmt.Sprintf("%s/name/%s", membersPrefix, name), nil, nil)
+	if err != nil && !IsPDNotFound(err) {
+		return fmt.Errorf("failed to delete member %s: %v", name, err)
 	}
-	res, err := pc.httpClient.Do(req)
-	if err != nil {
-		return err
-	}
-	defer DeferClose(res.Body, &err)
-	if res.StatusCode == http.StatusOK || res.StatusCode == http.StatusNotFound {
-		return nil
-	}
-	err2 := readErrorBody(res.Body)
-	return fmt.Errorf("failed %v to delete member %s: %v", res.StatusCode, name, err2)
+	return nil
 }
 
 func (pc *pdClient) SetStoreLabels(storeID uint64, labels map[string]string) (bool, error) {
-	apiURL := fmt.Sprintf("%s/%s/%d/label", pc.url, storePrefix, storeID)
-	data, err := json.Marshal(labels)
-	if err != nil {
-		return false, err
-	}
-	res, err := http.Post(apiURL, "application/json", bytes.NewBuffer(data))
+	err := pc.do("POST", fmt.Sprintf("%s/%d/label", storePrefix, storeID), labels, nil)
 	if err != nil {
-		return false, err
-	}
-	defer DeferClose(res.Body, &err)
-	if res.StatusCode == http.StatusOK {
-		return true, nil
+		return false, fmt.Errorf("failed to set store labels: %v", err)
 	}
-	err2 := readErrorBody(res.Body)
-	return false, fmt.Errorf("failed %v to set store labels: %v", res.StatusCode, err2)
+	return true, nil
 }
 
 func (pc *pdClient) BeginEvictLeader(storeID uint64) error {
-	leaderEvictInfo := getLeaderEvictSchedulerInfo(storeID)
-	apiURL := fmt.Sprintf("%s/%s", pc.url, schedulersPrefix)
-	data, err := json.Marshal(leaderEvictInfo)
-	if err != nil {
-		return err
-	}
-	res, err := http.Post(apiURL, "application/json", bytes.NewBuffer(data))
-	if err != nil {
+	err := pc.do("POST", schedulersPrefix, getLeaderEvictSchedulerInfo(storeID), nil)
+	if _, ok := err.(*PDHTTPError); !ok {
 		return err
 	}
-	defer DeferClose(res.Body, &err)
-	if res.StatusCode == http.StatusOK {
-		return nil
-	}
 
 	// pd will return an error with the body contains "scheduler existed" if the scheduler already exists
 	// this is not the standard response.
@@ -446,9 +545,9 @@ func (pc *pdClient) BeginEvictLeader(storeID uint64) error {
 	//   - return nil if the scheduler already exists
 	//
 	// when PD returns standard json response, we should get rid of this verbose code.
-	evictLeaderSchedulers, err := pc.GetEvictLeaderSchedulers()
-	if err != nil {
-		return err
+	evictLeaderSchedulers, err2 := pc.GetEvictLeaderSchedulers()
+	if err2 != nil {
+		return err2
 	}
 	for _, s := range evictLeaderSchedulers {
 		if s == getLeaderEvictSchedulerStr(storeID) {
@@ -456,25 +555,18 @@ func (pc *pdClient) BeginEvictLeader(storeID uint64) error {
 		}
 	}
 
-	err2 := readErrorBody(res.Body)
-	return fmt.Errorf("failed %v to begin evict leader of store:[%d],error: %v", res.StatusCode, storeID, err2)
+	return fmt.Errorf("failed to begin evict leader of store:[%d],error: %v", storeID, err)
 }
 
 func (pc *pdClient) EndEvictLeader(storeID uint64) error {
 	sName := getLeaderEvictSchedulerStr(storeID)
-	apiURL := fmt.Sprintf("%s/%s/%s", pc.url, schedulersPrefix, sName)
-	req, err := http.NewRequest("DELETE", apiURL, nil)
-	if err != nil {
-		return err
+	err := pc.do("DELETE", fmt.Sprintf("%s/%s", schedulersPrefix, sName), nil, nil)
+	if err == nil || IsPDNotFound(err) {
+		return nil
 	}
-	res, err := pc.httpClient.Do(req)
-	if err != nil {
+	if _, ok := err.(*PDHTTPError); !ok {
 		return err
 	}
-	defer DeferClose(res.Body, &err)
-	if res.StatusCode == http.StatusOK || res.StatusCode == http.StatusNotFound {
-		return nil
-	}
 
 	// pd will return an error with the body contains "scheduler not found" if the scheduler is not found
 	// this is not the standard response.
@@ -483,14 +575,13 @@ func (pc *pdClient) EndEvictLeader(storeID uint64) error {
 	//   - return nil if the scheduler is not found
 	//
 	// when PD returns standard json response, we should get rid of this verbose code.
-	err2 := readErrorBody(res.Body)
-	evictLeaderSchedulers, err := pc.GetEvictLeaderSchedulers()
-	if err != nil {
-		return err
+	evictLeaderSchedulers, err2 := pc.GetEvictLeaderSchedulers()
+	if err2 != nil {
+		return err2
 	}
 	for _, s := range evictLeaderSchedulers {
 		if s == sName {
-			return fmt.Errorf("failed %v to end leader evict scheduler of store [%d], error: %v", res.StatusCode, storeID, err2)
+			return fmt.Errorf("failed to end leader evict scheduler of store [%d], error: %v", storeID, err)
 		}
 	}
 
@@ -498,13 +589,7 @@ func (pc *pdClient) EndEvictLeader(storeID uint64) error {
 }
 
 func (pc *pdClient) GetEvictLeaderSchedulers() ([]string, error) {
-	apiURL := fmt.Sprintf("%s/%s", pc.url, schedulersPrefix)
-	body, err := pc.getBodyOK(apiURL)
-	if err != nil {
-		return nil, err
-	}
-	schedulers := []string{}
-	err = json.Unmarshal(body, &schedulers)
+	schedulers, err := pc.GetSchedulers()
 	if err != nil {
 		return nil, err
 	}
@@ -517,53 +602,303 @@ func (pc *pdClient) GetEvictLeaderSchedulers() ([]string, error) {
 	return evicts, nil
 }
 
-func (pc *pdClient) GetPDLeader() (*pdpb.Member, error) {
-	apiURL := fmt.Sprintf("%s/%s", pc.url, pdLeaderPrefix)
-	body, err := pc.getBodyOK(apiURL)
+func (pc *pdClient) GetSchedulers() ([]string, error) {
+	schedulers := []string{}
+	if err := pc.get(schedulersPrefix, &schedulers); err != nil {
+		return nil, err
+	}
+	return schedulers, nil
+}
+
+func (pc *pdClient) AddScheduler(name string, storeID uint64) error {
+	err := pc.do("POST", schedulersPrefix, &schedulerInfo{name, storeID}, nil)
 	if err != nil {
+		return fmt.Errorf("failed to add scheduler %s: %v", name, err)
+	}
+	return nil
+}
+
+func (pc *pdClient) RemoveScheduler(name string) error {
+	err := pc.do("DELETE", fmt.Sprintf("%s/%s", schedulersPrefix, name), nil, nil)
+	if err != nil && !IsPDNotFound(err) {
+		return fmt.Errorf("failed to remove scheduler %s: %v", name, err)
+	}
+	return nil
+}
+
+func (pc *pdClient) SetReplicationConfig(config *server.ReplicationConfig) error {
+	err := pc.do("POST", replicationPrefix, config, nil)
+	if err != nil {
+		return fmt.Errorf("failed to set replication config: %v", err)
+	}
+	return nil
+}
+
+func (pc *pdClient) GetScheduleConfig() (*server.ScheduleConfig, error) {
+	config := &server.ScheduleConfig{}
+	if err := pc.get(scheduleConfigPrefix, config); err != nil {
 		return nil, err
 	}
-	leader := &pdpb.Member{}
-	err = json.Unmarshal(body, leader)
+	return config, nil
+}
+
+func (pc *pdClient) SetScheduleConfig(config *server.ScheduleConfig) error {
+	err := pc.do("POST", scheduleConfigPrefix, config, nil)
 	if err != nil {
+		return fmt.Errorf("failed to set schedule config: %v", err)
+	}
+	return nil
+}
+
+func (pc *pdClient) GetRegions() (*RegionsInfo, error) {
+	regions := &RegionsInfo{}
+	if err := pc.get(regionsPrefix, regions); err != nil {
+		return nil, err
+	}
+	return regions, nil
+}
+
+func (pc *pdClient) GetRegion(regionID uint64) (*RegionInfo, error) {
+	region := &RegionInfo{}
+	if err := pc.get(fmt.Sprintf("%s/id/%d", regionPrefix, regionID), region); err != nil {
+		return nil, err
+	}
+	return region, nil
+}
+
+func (pc *pdClient) GetStoreRegions(storeID uint64) (*RegionsInfo, error) {
+	regions := &RegionsInfo{}
+	if err := pc.get(fmt.Sprintf("%s/store/%d", regionsPrefix, storeID), regions); err != nil {
+		return nil, err
+	}
+	return regions, nil
+}
+
+func (pc *pdClient) GetRegionStats() (*core.RegionStats, error) {
+	stats := &core.RegionStats{}
+	if err := pc.get(regionStatsPrefix, stats); err != nil {
+		return nil, err
+	}
+	return stats, nil
+}
+
+func (pc *pdClient) GetHotReadRegions() (*core.StoreHotRegionInfos, error) {
+	hotRegions := &core.StoreHotRegionInfos{}
+	if err := pc.get(hotReadRegionsPrefix, hotRegions); err != nil {
+		return nil, err
+	}
+	return hotRegions, nil
+}
+
+func (pc *pdClient) GetHotWriteRegions() (*core.StoreHotRegionInfos, error) {
+	hotRegions := &core.StoreHotRegionInfos{}
+	if err := pc.get(hotWriteRegionsPrefix, hotRegions); err != nil {
+		return nil, err
+	}
+	return hotRegions, nil
+}
+
+func (pc *pdClient) GetTopReadFlowRegions(limit int) (*RegionsInfo, error) {
+	regions := &RegionsInfo{}
+	if err := pc.get(fmt.Sprintf("%s?limit=%d", readFlowRegionsPrefix, limit), regions); err != nil {
+		return nil, err
+	}
+	return regions, nil
+}
+
+func (pc *pdClient) GetTopWriteFlowRegions(limit int) (*RegionsInfo, error) {
+	regions := &RegionsInfo{}
+	if err := pc.get(fmt.Sprintf("%s?limit=%d", writeFlowRegionsPrefix, limit), regions); err != nil {
+		return nil, err
+	}
+	return regions, nil
+}
+
+func (pc *pdClient) GetLabels() ([]*metapb.StoreLabel, error) {
+	labels := []*metapb.StoreLabel{}
+	if err := pc.get(labelsPrefix, &labels); err != nil {
+		return nil, err
+	}
+	return labels, nil
+}
+
+func (pc *pdClient) GetStoresByLabel(key, value string) (*StoresInfo, error) {
+	storesInfo := &StoresInfo{}
+	path := fmt.Sprintf("%s/stores?name=%s&value=%s", labelsPrefix, url.QueryEscape(key), url.QueryEscape(value))
+	if err := pc.get(path, storesInfo); err != nil {
+		return nil, err
+	}
+	return storesInfo, nil
+}
+
+func (pc *pdClient) GetStoreLimits() (map[uint64]float64, error) {
+	limits := map[uint64]float64{}
+	if err := pc.get(storesLimitPrefix, &limits); err != nil {
+		return nil, err
+	}
+	return limits, nil
+}
+
+func (pc *pdClient) SetStoreLimit(storeID uint64, rate float64) error {
+	err := pc.do("POST", fmt.Sprintf("%s/%d/limit", storePrefix, storeID), map[string]float64{"rate": rate}, nil)
+	if err != nil {
+		return fmt.Errorf("failed to set the limit of store %d: %v", storeID, err)
+	}
+	return nil
+}
+
+func (pc *pdClient) GetOperators() ([]string, error) {
+	operators := []string{}
+	if err := pc.get(operatorsPrefix, &operators); err != nil {
+		return nil, err
+	}
+	return operators, nil
+}
+
+func (pc *pdClient) AddOperator(operator *Operator) error {
+	err := pc.do("POST", operatorsPrefix, operator, nil)
+	if err != nil {
+		return fmt.Errorf("failed to add operator %s for region %d: %v", operator.Name, operator.RegionID, err)
+	}
+	return nil
+}
+
+func (pc *pdClient) RemoveOperator(regionID uint64) error {
+	err := pc.do("DELETE", fmt.Sprintf("%s/%d", operatorsPrefix, regionID), nil, nil)
+	if err != nil && !IsPDNotFound(err) {
+		return fmt.Errorf("failed to remove the operator of region %d: %v", regionID, err)
+	}
+	return nil
+}
+
+func (pc *pdClient) GetPDLeader() (*pdpb.Member, error) {
+	leader := &pdpb.Member{}
+	if err := pc.get(pdLeaderPrefix, leader); err != nil {
 		return nil, err
 	}
 	return leader, nil
 }
 
 func (pc *pdClient) TransferPDLeader(memberName string) error {
-	apiURL := fmt.Sprintf("%s/%s/%s", pc.url, pdLeaderTransferPrefix, memberName)
-	req, err := http.NewRequest("POST", apiURL, nil)
+	err := pc.do("POST", fmt.Sprintf("%s/%s", pdLeaderTransferPrefix, memberName), nil, nil)
+	if err != nil && !IsPDNotFound(err) {
+		return fmt.Errorf("failed to transfer pd leader to %s,error: %v", memberName, err)
+	}
+	return nil
+}
+
+// get sends a GET request to PD and decodes the JSON response into result
+func (pc *pdClient) get(path string, result interface{}) error {
+	return pc.do("GET", path, nil, result)
+}
+
+// do sends a request to PD, the body is encoded as JSON if it's not nil,
+// and the JSON response is decoded into result if it's not nil.
+// The request falls back to the next PD endpoint if the current one is unavailable,
+// the idempotent requests are also retried on all the endpoints until the context is done or pdRequestDeadline passes
+func (pc *pdClient) do(method, path string, body, result interface{}) error {
+	var data []byte
+	if body != nil {
+		var err error
+		data, err = json.Marshal(body)
+		if err != nil {
+			return err
+		}
+	}
+	idempotent := method == "GET" || method == "DELETE"
+	retries := 0
+	if idempotent {
+		retries = pdRequestRetries
+	}
+	ctx, cancel := context.WithTimeout(pc.ctx, pdRequestDeadline)
+	defer cancel()
+	urls := pc.endpoints.urls(pc.url, method != "GET")
+	for i := 0; ; i++ {
+		var err error
+		for _, u := range urls {
+			err = pc.doOnce(ctx, method, fmt.Sprintf("%s/%s", u, path), data, result)
+			// a write can only be sent again if it didn't reach PD
+			if err == nil || !isPDRetriable(err) || (!idempotent && !isDialError(err)) || ctx.Err() != nil {
+				return err
+			}
+		}
+		if i >= retries {
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("%v, the last error: %v", ctx.Err(), err)
+		case <-time.After(pdRequestRetryInterval):
+		}
+	}
+}
+
+func (pc *pdClient) doOnce(ctx context.Context, method, apiURL string, data []byte, result interface{}) (err error) {
+	req, err := http.NewRequest(method, apiURL, bytes.NewReader(data))
 	if err != nil {
 		return err
 	}
+	req = req.WithContext(ctx)
+	if data != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
 	res, err := pc.httpClient.Do(req)
 	if err != nil {
 		return err
 	}
 	defer DeferClose(res.Body, &err)
-	if res.StatusCode == http.StatusOK || res.StatusCode == http.StatusNotFound {
+	respBody, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		return err
+	}
+	if res.StatusCode < 200 || res.StatusCode >= 300 {
+		return &PDHTTPError{StatusCode: res.StatusCode, Method: method, URL: apiURL, Message: strings.TrimSpace(string(respBody))}
+	}
+	if result == nil {
 		return nil
 	}
-	err2 := readErrorBody(res.Body)
-	return fmt.Errorf("failed %v to transfer pd leader to %s,error: %v", res.StatusCode, memberName, err2)
+	return json.Unmarshal(respBody, result)
 }
 
-func (pc *pdClient) getBodyOK(apiURL string) ([]byte, error) {
-	res, err := pc.httpClient.Get(apiURL)
-	if err != nil {
-		return nil, err
+// PDHTTPError is the error of a non-2xx response from PD
+type PDHTTPError struct {
+	StatusCode int
+	Method     string
+	URL        string
+	Message    string
+}
+
+func (e *PDHTTPError) Error() string {
+	return fmt.Sprintf("failed %d to %s %s: %s", e.StatusCode, e.Method, e.URL, e.Message)
+}
+
+// IsPDNotFound returns true if the error is a 404 response from PD
+func IsPDNotFound(err error) bool {
+	httpErr, ok := err.(*PDHTTPError)
+	return ok && httpErr.StatusCode == http.StatusNotFound
+}
+
+// isDialError returns true if the error happened when connecting to PD
+func isDialError(err error) bool {
+	if urlErr, ok := err.(*url.Error); ok {
+		err = urlErr.Err
 	}
-	defer DeferClose(res.Body, &err)
-	if res.StatusCode >= 400 {
-		errMsg := fmt.Errorf(fmt.Sprintf("Error response %v URL %s", res.StatusCode, apiURL))
-		return nil, errMsg
+	opErr, ok := err.(*net.OpError)
+	return ok && opErr.Op == "dial"
+}
+
+// isPDRetriable returns true if the request may succeed in a retry, PD returns 500 for most of the invalid requests
+func isPDRetriable(err error) bool {
+	httpErr, ok := err.(*PDHTTPError)
+	if !ok {
+		// connection errors
+		return true
 	}
-	body, err := ioutil.ReadAll(res.Body)
-	if err != nil {
-		return nil, err
+	switch httpErr.StatusCode {
+	case http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
 	}
-	return body, err
+	return false
 }
 
 func getLeaderEvictSchedulerInfo(storeID uint64) *schedulerInfo {
@@ -603,7 +938,8 @@ func NewFakePDControl() *FakePDControl {
 }
 
 func (fpc *FakePDControl) SetPDClient(tc *v1alpha1.TidbCluster, pdclient PDClient) {
-	fpc.defaultPDControl.pdClients[pdClientKey(tc.Namespace, tc.Name)] = pdclient
+	ns, tcName := tc.PDClusterRef()
+	fpc.defaultPDControl.pdClients[pdClientKey(ns, tcName)] = pdclient
 }
 
 type ActionType string
@@ -617,12 +953,34 @@ const (
 	GetTombStoneStoresActionType       ActionType = "GetTombStoneStores"
 	GetStoreActionType                 ActionType = "GetStore"
 	DeleteStoreActionType              ActionType = "DeleteStore"
+	SetStoreStateActionType            ActionType = "SetStoreState"
 	DeleteMemberByIDActionType         ActionType = "DeleteMemberByID"
 	DeleteMemberActionType             ActionType = "DeleteMember "
 	SetStoreLabelsActionType           ActionType = "SetStoreLabels"
 	BeginEvictLeaderActionType         ActionType = "BeginEvictLeader"
 	EndEvictLeaderActionType           ActionType = "EndEvictLeader"
 	GetEvictLeaderSchedulersActionType ActionType = "GetEvictLeaderSchedulers"
+	GetSchedulersActionType            ActionType = "GetSchedulers"
+	AddSchedulerActionType             ActionType = "AddScheduler"
+	RemoveSchedulerActionType          ActionType = "RemoveScheduler"
+	SetReplicationConfigActionType     ActionType = "SetReplicationConfig"
+	GetScheduleConfigActionType        ActionType = "GetScheduleConfig"
+	SetScheduleConfigActionType        ActionType = "SetScheduleConfig"
+	GetRegionsActionType               ActionType = "GetRegions"
+	GetRegionActionType                ActionType = "GetRegion"
+	GetStoreRegionsActionType          ActionType = "GetStoreRegions"
+	GetRegionStatsActionType           ActionType = "GetRegionStats"
+	GetHotReadRegionsActionType        ActionType = "GetHotReadRegions"
+	GetHotWriteRegionsActionType       ActionType = "GetHotWriteRegions"
+	GetTopReadFlowRegionsActionType    ActionType = "GetTopReadFlowRegions"
+	GetTopWriteFlowRegionsActionType   ActionType = "GetTopWriteFlowRegions"
+	GetLabelsActionType                ActionType = "GetLabels"
+	GetStoresByLabelActionType         ActionType = "GetStoresByLabel"
+	GetStoreLimitsActionType           ActionType = "GetStoreLimits"
+	SetStoreLimitActionType            ActionType = "SetStoreLimit"
+	GetOperatorsActionType             ActionType = "GetOperators"
+	AddOperatorActionType              ActionType = "AddOperator"
+	RemoveOperatorActionType           ActionType = "RemoveOperator"
 	GetPDLeaderActionType              ActionType = "GetPDLeader"
 	TransferPDLeaderActionType         ActionType = "TransferPDLeader"
 )
@@ -636,9 +994,14 @@ func (nfr *NotFoundReaction) Error() string {
 }
 
 type Action struct {
-	ID     uint64
-	Name   string
-	Labels map[string]string
+	ID          uint64
+	Name        string
+	Labels      map[string]string
+	Replication *server.ReplicationConfig
+	Schedule    *server.ScheduleConfig
+	Rate        float64
+	Operator    *Operator
+	Limit       int
 }
 
 type Reaction func(action *Action) (interface{}, error)
@@ -647,6 +1010,8 @@ type FakePDClient struct {
 	reactions map[ActionType]Reaction
 }
 
+var _ PDClient = &FakePDClient{}
+
 func NewFakePDClient() *FakePDClient {
 	return &FakePDClient{reactions: map[ActionType]Reaction{}}
 }
@@ -741,6 +1106,15 @@ func (pc *FakePDClient) DeleteStore(id uint64) error {
 	return nil
 }
 
+func (pc *FakePDClient) SetStoreState(id uint64, state string) error {
+	if reaction, ok := pc.reactions[SetStoreStateActionType]; ok {
+		action := &Action{ID: id, Name: state}
+		_, err := reaction(action)
+		return err
+	}
+	return nil
+}
+
 func (pc *FakePDClient) DeleteMemberByID(id uint64) error {
 	if reaction, ok := pc.reactions[DeleteMemberByIDActionType]; ok {
 		action := &Action{ID: id}
@@ -796,6 +1170,199 @@ func (pc *FakePDClient) GetEvictLeaderSchedulers() ([]string, error) {
 	return nil, nil
 }
 
+func (pc *FakePDClient) GetSchedulers() ([]string, error) {
+	action := &Action{}
+	result, err := pc.fakeAPI(GetSchedulersActionType, action)
+	if err != nil {
+		return nil, err
+	}
+	return result.([]string), nil
+}
+
+func (pc *FakePDClient) AddScheduler(name string, storeID uint64) error {
+	if reaction, ok := pc.reactions[AddSchedulerActionType]; ok {
+		action := &Action{ID: storeID, Name: name}
+		_, err := reaction(action)
+		return err
+	}
+	return nil
+}
+
+func (pc *FakePDClient) RemoveScheduler(name string) error {
+	if reaction, ok := pc.reactions[RemoveSchedulerActionType]; ok {
+		action := &Action{Name: name}
+		_, err := reaction(action)
+		return err
+	}
+	return nil
+}
+
+func (pc *FakePDClient) SetReplicationConfig(config *server.ReplicationConfig) error {
+	if reaction, ok := pc.reactions[SetReplicationConfigActionType]; ok {
+		action := &Action{Replication: config}
+		_, err := reaction(action)
+		return err
+	}
+	return nil
+}
+
+func (pc *FakePDClient) GetScheduleConfig() (*server.ScheduleConfig, error) {
+	action := &Action{}
+	result, err := pc.fakeAPI(GetScheduleConfigActionType, action)
+	if err != nil {
+		return nil, err
+	}
+	return result.(*server.ScheduleConfig), nil
+}
+
+func (pc *FakePDClient) SetScheduleConfig(config *server.ScheduleConfig) error {
+	if reaction, ok := pc.reactions[SetScheduleConfigActionType]; ok {
+		action := &Action{Schedule: config}
+		_, err := reaction(action)
+		return err
+	}
+	return nil
+}
+
+func (pc *FakePDClient) GetRegions() (*RegionsInfo, error) {
+	action := &Action{}
+	result, err := pc.fakeAPI(GetRegionsActionType, action)
+	if err != nil {
+		return nil, err
+	}
+	return result.(*RegionsInfo), nil
+}
+
+func (pc *FakePDClient) GetRegion(regionID uint64) (*RegionInfo, error) {
+	action := &Action{ID: regionID}
+	result, err := pc.fakeAPI(GetRegionActionType, action)
+	if err != nil {
+		return nil, err
+	}
+	return result.(*RegionInfo), nil
+}
+
+func (pc *FakePDClient) GetStoreRegions(storeID uint64) (*RegionsInfo, error) {
+	action := &Action{ID: storeID}
+	result, err := pc.fakeAPI(GetStoreRegionsActionType, action)
+	if err != nil {
+		return nil, err
+	}
+	return result.(*RegionsInfo), nil
+}
+
+func (pc *FakePDClient) GetRegionStats() (*core.RegionStats, error) {
+	action := &Action{}
+	result, err := pc.fakeAPI(GetRegionStatsActionType, action)
+	if err != nil {
+		return nil, err
+	}
+	return result.(*core.RegionStats), nil
+}
+
+func (pc *FakePDClient) GetHotReadRegions() (*core.StoreHotRegionInfos, error) {
+	action := &Action{}
+	result, err := pc.fakeAPI(GetHotReadRegionsActionType, action)
+	if err != nil {
+		return nil, err
+	}
+	return result.(*core.StoreHotRegionInfos), nil
+}
+
+func (pc *FakePDClient) GetHotWriteRegions() (*core.StoreHotRegionInfos, error) {
+	action := &Action{}
+	result, err := pc.fakeAPI(GetHotWriteRegionsActionType, action)
+	if err != nil {
+		return nil, err
+	}
+	return result.(*core.StoreHotRegionInfos), nil
+}
+
+func (pc *FakePDClient) GetTopReadFlowRegions(limit int) (*RegionsInfo, error) {
+	action := &Action{Limit: limit}
+	result, err := pc.fakeAPI(GetTopReadFlowRegionsActionType, action)
+	if err != nil {
+		return nil, err
+	}
+	return result.(*RegionsInfo), nil
+}
+
+func (pc *FakePDClient) GetTopWriteFlowRegions(limit int) (*RegionsInfo, error) {
+	action := &Action{Limit: limit}
+	result, err := pc.fakeAPI(GetTopWriteFlowRegionsActionType, action)
+	if err != nil {
+		return nil, err
+	}
+	return result.(*RegionsInfo), nil
+}
+
+func (pc *FakePDClient) GetLabels() ([]*metapb.StoreLabel, error) {
+	action := &Action{}
+	result, err := pc.fakeAPI(GetLabelsActionType, action)
+	if err != nil {
+		return nil, err
+	}
+	return result.([]*metapb.StoreLabel), nil
+}
+
+func (pc *FakePDClient) GetStoresByLabel(key, value string) (*StoresInfo, error) {
+	action := &Action{Labels: map[string]string{key: value}}
+	result, err := pc.fakeAPI(GetStoresByLabelActionType, action)
+	if err != nil {
+		return nil, err
+	}
+	return result.(*StoresInfo), nil
+}
+
+func (pc *FakePDClient) GetStoreLimits() (map[uint64]float64, error) {
+	action := &Action{}
+	result, err := pc.fakeAPI(GetStoreLimitsActionType, action)
+	if err != nil {
+		return nil, err
+	}
+	return result.(map[uint64]float64), nil
+}
+
+func (pc *FakePDClient) SetStoreLimit(storeID uint64, rate float64) error {
+	if reaction, ok := pc.reactions[SetStoreLimitActionType]; ok {
+		action := &Action{ID: storeID, Rate: rate}
+		_, err := reaction(action)
+		return err
+	}
+	return nil
+}
+
+func (pc *FakePDClient) GetOperators() ([]string, error) {
+	action := &Action{}
+	result, err := pc.fakeAPI(GetOperatorsActionType, action)
+	if err != nil {
+		return nil, err
+	}
+	return result.([]string), nil
+}
+
+func (pc *FakePDClient) AddOperator(operator *Operator) error {
+	if reaction, ok := pc.reactions[AddOperatorActionType]; ok {
+		action := &Action{Operator: operator}
+		_, err := reaction(action)
+		return err
+	}
+	return nil
+}
+
+func (pc *FakePDClient) RemoveOperator(regionID uint64) error {
+	if reaction, ok := pc.reactions[RemoveOperatorActionType]; ok {
+		action := &Action{ID: regionID}
+		_, err := reaction(action)
+		return err
+	}
+	return nil
+}
+
+func (pc *FakePDClient) WithContext(_ context.Context) PDClient {
+	return pc
+}
+
 func (pc *FakePDClient) GetPDLeader() (*pdpb.Member, error) {
 	if reaction, ok := pc.reactions[GetPDLeaderActionType]; ok {
 		action := &Action{}
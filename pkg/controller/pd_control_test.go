@@ -14,6 +14,7 @@
 package controller
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -28,6 +29,8 @@ import (
 	"github.com/pingcap/kvproto/pkg/pdpb"
 	"github.com/pingcap/pd/pkg/typeutil"
 	"github.com/pingcap/pd/server"
+	"github.com/pingcap/pd/server/core"
+	"github.com/pingcap/tidb-operator/pkg/apis/pingcap.com/v1alpha1"
 )
 
 const (
@@ -356,6 +359,215 @@ func TestSetStoreLabels(t *testing.T) {
 	}
 }
 
+func TestSetStoreState(t *testing.T) {
+	g := NewGomegaWithT(t)
+	id := uint64(1)
+	tcs := []struct {
+		caseName string
+		path     string
+		method   string
+		want     bool
+	}{{
+		caseName: "success_SetStoreState",
+		path:     fmt.Sprintf("/%s/%d/state", storePrefix, id),
+		method:   "POST",
+		want:     true,
+	}, {
+		caseName: "failed_SetStoreState",
+		path:     fmt.Sprintf("/%s/%d/state", storePrefix, id),
+		method:   "POST",
+		want:     false,
+	},
+	}
+
+	for _, tc := range tcs {
+		svc := getClientServer(func(w http.ResponseWriter, request *http.Request) {
+			g.Expect(request.Method).To(Equal(tc.method), "check method")
+			g.Expect(request.URL.Path).To(Equal(tc.path), "check url")
+			g.Expect(request.URL.Query().Get("state")).To(Equal(v1alpha1.TiKVStateUp), "check state")
+
+			w.Header().Set("Content-Type", ContentTypeJSON)
+			if tc.want {
+				w.WriteHeader(http.StatusOK)
+			} else {
+				w.WriteHeader(http.StatusInternalServerError)
+			}
+		})
+		defer svc.Close()
+
+		pdClient := NewPDClient(svc.URL, timeout)
+		err := pdClient.SetStoreState(id, v1alpha1.TiKVStateUp)
+		if tc.want {
+			g.Expect(err).NotTo(HaveOccurred(), "check result")
+		} else {
+			g.Expect(err).To(HaveOccurred(), "check result")
+		}
+	}
+}
+
+func TestGetSchedulers(t *testing.T) {
+	g := NewGomegaWithT(t)
+	schedulers := []string{"balance-hot-region-scheduler", "evict-leader-scheduler-1"}
+	schedulersBytes, err := json.Marshal(schedulers)
+	g.Expect(err).NotTo(HaveOccurred())
+
+	svc := getClientServer(func(w http.ResponseWriter, request *http.Request) {
+		g.Expect(request.Method).To(Equal("GET"), "check method")
+		g.Expect(request.URL.Path).To(Equal(fmt.Sprintf("/%s", schedulersPrefix)), "check url")
+
+		w.Header().Set("Content-Type", ContentTypeJSON)
+		w.Write(schedulersBytes)
+	})
+	defer svc.Close()
+
+	pdClient := NewPDClient(svc.URL, timeout)
+	result, err := pdClient.GetSchedulers()
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(result).To(Equal(schedulers))
+
+	evicts, err := pdClient.GetEvictLeaderSchedulers()
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(evicts).To(Equal([]string{"evict-leader-scheduler-1"}))
+}
+
+func TestAddScheduler(t *testing.T) {
+	g := NewGomegaWithT(t)
+	tcs := []struct {
+		caseName string
+		name     string
+		storeID  uint64
+		want     bool
+	}{{
+		caseName: "success_AddScheduler",
+		name:     "balance-hot-region-scheduler",
+		want:     true,
+	}, {
+		caseName: "success_AddStoreScheduler",
+		name:     "evict-leader-scheduler",
+		storeID:  1,
+		want:     true,
+	}, {
+		caseName: "failed_AddScheduler",
+		name:     "unknown-scheduler",
+		want:     false,
+	},
+	}
+
+	for _, tc := range tcs {
+		svc := getClientServer(func(w http.ResponseWriter, request *http.Request) {
+			g.Expect(request.Method).To(Equal("POST"), "check method")
+			g.Expect(request.URL.Path).To(Equal(fmt.Sprintf("/%s", schedulersPrefix)), "check url")
+
+			info := &schedulerInfo{}
+			err := readJSON(request.Body, info)
+			g.Expect(err).NotTo(HaveOccurred())
+			g.Expect(info).To(Equal(&schedulerInfo{tc.name, tc.storeID}), "check scheduler")
+
+			w.Header().Set("Content-Type", ContentTypeJSON)
+			if tc.want {
+				w.WriteHeader(http.StatusOK)
+			} else {
+				w.WriteHeader(http.StatusInternalServerError)
+			}
+		})
+		defer svc.Close()
+
+		pdClient := NewPDClient(svc.URL, timeout)
+		err := pdClient.AddScheduler(tc.name, tc.storeID)
+		if tc.want {
+			g.Expect(err).NotTo(HaveOccurred(), "check result")
+		} else {
+			g.Expect(err).To(HaveOccurred(), "check result")
+		}
+	}
+}
+
+func TestRemoveScheduler(t *testing.T) {
+	g := NewGomegaWithT(t)
+	name := "balance-hot-region-scheduler"
+	tcs := []struct {
+		caseName string
+		status   int
+		want     bool
+	}{{
+		caseName: "success_RemoveScheduler",
+		status:   http.StatusOK,
+		want:     true,
+	}, {
+		caseName: "remove_not_exist_scheduler",
+		status:   http.StatusNotFound,
+		want:     true,
+	}, {
+		caseName: "failed_RemoveScheduler",
+		status:   http.StatusInternalServerError,
+		want:     false,
+	},
+	}
+
+	for _, tc := range tcs {
+		svc := getClientServer(func(w http.ResponseWriter, request *http.Request) {
+			g.Expect(request.Method).To(Equal("DELETE"), "check method")
+			g.Expect(request.URL.Path).To(Equal(fmt.Sprintf("/%s/%s", schedulersPrefix, name)), "check url")
+
+			w.Header().Set("Content-Type", ContentTypeJSON)
+			w.WriteHeader(tc.status)
+		})
+		defer svc.Close()
+
+		pdClient := NewPDClient(svc.URL, timeout)
+		err := pdClient.RemoveScheduler(name)
+		if tc.want {
+			g.Expect(err).NotTo(HaveOccurred(), "check result")
+		} else {
+			g.Expect(err).To(HaveOccurred(), "check result")
+		}
+	}
+}
+
+func TestSetReplicationConfig(t *testing.T) {
+	g := NewGomegaWithT(t)
+	config := &server.ReplicationConfig{MaxReplicas: 5, LocationLabels: typeutil.StringSlice{"zone", "host"}}
+	tcs := []struct {
+		caseName string
+		want     bool
+	}{{
+		caseName: "success_SetReplicationConfig",
+		want:     true,
+	}, {
+		caseName: "failed_SetReplicationConfig",
+		want:     false,
+	},
+	}
+
+	for _, tc := range tcs {
+		svc := getClientServer(func(w http.ResponseWriter, request *http.Request) {
+			g.Expect(request.Method).To(Equal("POST"), "check method")
+			g.Expect(request.URL.Path).To(Equal(fmt.Sprintf("/%s", replicationPrefix)), "check url")
+
+			data := &server.ReplicationConfig{}
+			err := readJSON(request.Body, data)
+			g.Expect(err).NotTo(HaveOccurred())
+			g.Expect(data).To(Equal(config), "check config")
+
+			w.Header().Set("Content-Type", ContentTypeJSON)
+			if tc.want {
+				w.WriteHeader(http.StatusOK)
+			} else {
+				w.WriteHeader(http.StatusInternalServerError)
+			}
+		})
+		defer svc.Close()
+
+		pdClient := NewPDClient(svc.URL, timeout)
+		err := pdClient.SetReplicationConfig(config)
+		if tc.want {
+			g.Expect(err).NotTo(HaveOccurred(), "check result")
+		} else {
+			g.Expect(err).To(HaveOccurred(), "check result")
+		}
+	}
+}
+
 func TestDeleteMember(t *testing.T) {
 	g := NewGomegaWithT(t)
 	name := "testMember"
@@ -650,3 +862,409 @@ func readJSON(r io.ReadCloser, data interface{}) error {
 
 	return nil
 }
+
+func TestRequestRetry(t *testing.T) {
+	g := NewGomegaWithT(t)
+	tcs := []struct {
+		caseName string
+		method   string
+		statuses []int
+		count    int
+		want     bool
+	}{{
+		caseName: "retry_unavailable_GET",
+		method:   "GET",
+		statuses: []int{http.StatusServiceUnavailable, http.StatusOK},
+		count:    2,
+		want:     true,
+	}, {
+		caseName: "not_retry_internal_error",
+		method:   "GET",
+		statuses: []int{http.StatusInternalServerError, http.StatusOK},
+		count:    1,
+		want:     false,
+	}, {
+		caseName: "not_retry_POST",
+		method:   "POST",
+		statuses: []int{http.StatusServiceUnavailable, http.StatusOK},
+		count:    1,
+		want:     false,
+	}, {
+		caseName: "exceed_retries",
+		method:   "DELETE",
+		statuses: []int{http.StatusBadGateway, http.StatusBadGateway, http.StatusBadGateway, http.StatusOK},
+		count:    pdRequestRetries + 1,
+		want:     false,
+	},
+	}
+
+	for _, tc := range tcs {
+		count := 0
+		svc := getClientServer(func(w http.ResponseWriter, request *http.Request) {
+			g.Expect(request.Method).To(Equal(tc.method), "check method")
+			w.WriteHeader(tc.statuses[count])
+			count++
+		})
+		defer svc.Close()
+
+		pdClient := NewPDClient(svc.URL, timeout).(*pdClient)
+		err := pdClient.do(tc.method, healthPrefix, nil, nil)
+		if tc.want {
+			g.Expect(err).NotTo(HaveOccurred(), tc.caseName)
+		} else {
+			g.Expect(err).To(HaveOccurred(), tc.caseName)
+		}
+		g.Expect(count).To(Equal(tc.count), tc.caseName)
+	}
+}
+
+func TestRequestCanceled(t *testing.T) {
+	g := NewGomegaWithT(t)
+	count := 0
+	svc := getClientServer(func(w http.ResponseWriter, request *http.Request) {
+		count++
+		w.WriteHeader(http.StatusServiceUnavailable)
+	})
+	defer svc.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	pdClient := NewPDClient(svc.URL, timeout).WithContext(ctx).(*pdClient)
+	err := pdClient.do("GET", healthPrefix, nil, nil)
+	g.Expect(err).To(HaveOccurred())
+	g.Expect(count).To(Equal(0))
+}
+
+func TestRequestDeadline(t *testing.T) {
+	g := NewGomegaWithT(t)
+	count := 0
+	svc := getClientServer(func(w http.ResponseWriter, request *http.Request) {
+		count++
+		w.WriteHeader(http.StatusServiceUnavailable)
+	})
+	defer svc.Close()
+
+	deadline := pdRequestDeadline
+	pdRequestDeadline = 100 * time.Millisecond
+	defer func() { pdRequestDeadline = deadline }()
+
+	pdClient := NewPDClient(svc.URL, timeout).(*pdClient)
+	start := time.Now()
+	err := pdClient.do("GET", healthPrefix, nil, nil)
+	g.Expect(err).To(HaveOccurred())
+	g.Expect(err.Error()).To(ContainSubstring(context.DeadlineExceeded.Error()))
+	g.Expect(time.Since(start)).To(BeNumerically("<", pdRequestRetryInterval))
+	g.Expect(count).To(Equal(1))
+}
+
+func TestGetRegions(t *testing.T) {
+	g := NewGomegaWithT(t)
+	region := &RegionInfo{
+		ID:       2,
+		StartKey: "7480000000000000FF1500000000000000F8",
+		Peers:    []*metapb.Peer{{Id: 3, StoreId: 1}, {Id: 4, StoreId: 4}},
+		Leader:   &metapb.Peer{Id: 3, StoreId: 1},
+	}
+	regions := &RegionsInfo{Count: 1, Regions: []*RegionInfo{region}}
+	regionBytes, err := json.Marshal(region)
+	g.Expect(err).NotTo(HaveOccurred())
+	regionsBytes, err := json.Marshal(regions)
+	g.Expect(err).NotTo(HaveOccurred())
+
+	svc := getClientServer(func(w http.ResponseWriter, request *http.Request) {
+		g.Expect(request.Method).To(Equal("GET"), "check method")
+		w.Header().Set("Content-Type", ContentTypeJSON)
+		switch request.URL.Path {
+		case fmt.Sprintf("/%s", regionsPrefix), fmt.Sprintf("/%s/store/1", regionsPrefix):
+			w.Write(regionsBytes)
+		case fmt.Sprintf("/%s/id/2", regionPrefix):
+			w.Write(regionBytes)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	})
+	defer svc.Close()
+
+	pdClient := NewPDClient(svc.URL, timeout)
+	result, err := pdClient.GetRegions()
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(result).To(Equal(regions))
+
+	result, err = pdClient.GetStoreRegions(1)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(result).To(Equal(regions))
+
+	regionResult, err := pdClient.GetRegion(2)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(regionResult).To(Equal(region))
+
+	_, err = pdClient.GetRegion(5)
+	g.Expect(IsPDNotFound(err)).To(BeTrue())
+}
+
+func TestGetHotRegions(t *testing.T) {
+	g := NewGomegaWithT(t)
+	hotRegions := &core.StoreHotRegionInfos{
+		AsPeer: core.StoreHotRegionsStat{
+			1: &core.HotRegionsStat{TotalFlowBytes: 1024, RegionsCount: 1, RegionsStat: core.RegionsStat{{RegionID: 2, FlowBytes: 1024}}},
+		},
+		AsLeader: core.StoreHotRegionsStat{},
+	}
+	hotRegionsBytes, err := json.Marshal(hotRegions)
+	g.Expect(err).NotTo(HaveOccurred())
+
+	for _, path := range []string{hotReadRegionsPrefix, hotWriteRegionsPrefix} {
+		svc := getClientServer(func(w http.ResponseWriter, request *http.Request) {
+			g.Expect(request.Method).To(Equal("GET"), "check method")
+			g.Expect(request.URL.Path).To(Equal(fmt.Sprintf("/%s", path)), "check url")
+			w.Header().Set("Content-Type", ContentTypeJSON)
+			w.Write(hotRegionsBytes)
+		})
+		defer svc.Close()
+
+		pdClient := NewPDClient(svc.URL, timeout)
+		var result *core.StoreHotRegionInfos
+		if path == hotReadRegionsPrefix {
+			result, err = pdClient.GetHotReadRegions()
+		} else {
+			result, err = pdClient.GetHotWriteRegions()
+		}
+		g.Expect(err).NotTo(HaveOccurred())
+		g.Expect(result.AsPeer[1].TotalFlowBytes).To(Equal(uint64(1024)))
+		g.Expect(result.AsPeer[1].RegionsStat[0].RegionID).To(Equal(uint64(2)))
+	}
+}
+
+func TestGetTopFlowRegions(t *testing.T) {
+	g := NewGomegaWithT(t)
+	regions := &RegionsInfo{
+		Count: 1,
+		Regions: []*RegionInfo{
+			{ID: 2, Leader: &metapb.Peer{Id: 3, StoreId: 1}, WrittenBytes: 1024, ReadBytes: 2048},
+		},
+	}
+	regionsBytes, err := json.Marshal(regions)
+	g.Expect(err).NotTo(HaveOccurred())
+
+	for _, path := range []string{readFlowRegionsPrefix, writeFlowRegionsPrefix} {
+		svc := getClientServer(func(w http.ResponseWriter, request *http.Request) {
+			g.Expect(request.Method).To(Equal("GET"), "check method")
+			g.Expect(request.URL.Path).To(Equal(fmt.Sprintf("/%s", path)), "check url")
+			g.Expect(request.URL.Query().Get("limit")).To(Equal("10"), "check limit")
+			w.Header().Set("Content-Type", ContentTypeJSON)
+			w.Write(regionsBytes)
+		})
+		defer svc.Close()
+
+		pdClient := NewPDClient(svc.URL, timeout)
+		var result *RegionsInfo
+		if path == readFlowRegionsPrefix {
+			result, err = pdClient.GetTopReadFlowRegions(10)
+		} else {
+			result, err = pdClient.GetTopWriteFlowRegions(10)
+		}
+		g.Expect(err).NotTo(HaveOccurred())
+		g.Expect(result).To(Equal(regions))
+	}
+}
+
+func TestGetLabels(t *testing.T) {
+	g := NewGomegaWithT(t)
+	labels := []*metapb.StoreLabel{{Key: "zone", Value: "z1"}, {Key: "host", Value: "h1"}}
+	labelsBytes, err := json.Marshal(labels)
+	g.Expect(err).NotTo(HaveOccurred())
+	stores := &StoresInfo{Count: 1, Stores: []*StoreInfo{{Store: &MetaStore{Store: &metapb.Store{Id: 1}}, Status: &StoreStatus{}}}}
+	storesBytes, err := json.Marshal(stores)
+	g.Expect(err).NotTo(HaveOccurred())
+
+	svc := getClientServer(func(w http.ResponseWriter, request *http.Request) {
+		g.Expect(request.Method).To(Equal("GET"), "check method")
+		w.Header().Set("Content-Type", ContentTypeJSON)
+		if request.URL.Path == fmt.Sprintf("/%s/stores", labelsPrefix) {
+			g.Expect(request.URL.Query().Get("name")).To(Equal("zone"))
+			g.Expect(request.URL.Query().Get("value")).To(Equal("z1"))
+			w.Write(storesBytes)
+			return
+		}
+		g.Expect(request.URL.Path).To(Equal(fmt.Sprintf("/%s", labelsPrefix)), "check url")
+		w.Write(labelsBytes)
+	})
+	defer svc.Close()
+
+	pdClient := NewPDClient(svc.URL, timeout)
+	result, err := pdClient.GetLabels()
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(result).To(Equal(labels))
+
+	storesResult, err := pdClient.GetStoresByLabel("zone", "z1")
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(storesResult.Count).To(Equal(1))
+}
+
+func TestStoreLimits(t *testing.T) {
+	g := NewGomegaWithT(t)
+	limitsBytes := []byte(`{"1": 15, "4": 30}`)
+
+	svc := getClientServer(func(w http.ResponseWriter, request *http.Request) {
+		w.Header().Set("Content-Type", ContentTypeJSON)
+		if request.Method == "GET" {
+			g.Expect(request.URL.Path).To(Equal(fmt.Sprintf("/%s", storesLimitPrefix)), "check url")
+			w.Write(limitsBytes)
+			return
+		}
+		g.Expect(request.Method).To(Equal("POST"), "check method")
+		g.Expect(request.URL.Path).To(Equal(fmt.Sprintf("/%s/4/limit", storePrefix)), "check url")
+		data := map[string]float64{}
+		err := readJSON(request.Body, &data)
+		g.Expect(err).NotTo(HaveOccurred())
+		g.Expect(data).To(Equal(map[string]float64{"rate": 20}))
+	})
+	defer svc.Close()
+
+	pdClient := NewPDClient(svc.URL, timeout)
+	limits, err := pdClient.GetStoreLimits()
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(limits).To(Equal(map[uint64]float64{1: 15, 4: 30}))
+
+	err = pdClient.SetStoreLimit(4, 20)
+	g.Expect(err).NotTo(HaveOccurred())
+}
+
+func TestOperators(t *testing.T) {
+	g := NewGomegaWithT(t)
+	operators := []string{`"admin-transfer-leader (kind:admin,leader, region:2(1,1), createAt:2019-07-01 00:00:00, currentStep:0, steps:[transfer leader from store 1 to store 4]) "`}
+	operatorsBytes, err := json.Marshal(operators)
+	g.Expect(err).NotTo(HaveOccurred())
+	operator := &Operator{Name: "transfer-leader", RegionID: 2, ToStoreID: 4}
+
+	svc := getClientServer(func(w http.ResponseWriter, request *http.Request) {
+		w.Header().Set("Content-Type", ContentTypeJSON)
+		switch request.Method {
+		case "GET":
+			g.Expect(request.URL.Path).To(Equal(fmt.Sprintf("/%s", operatorsPrefix)), "check url")
+			w.Write(operatorsBytes)
+		case "POST":
+			g.Expect(request.URL.Path).To(Equal(fmt.Sprintf("/%s", operatorsPrefix)), "check url")
+			data := map[string]interface{}{}
+			err := readJSON(request.Body, &data)
+			g.Expect(err).NotTo(HaveOccurred())
+			g.Expect(data).To(Equal(map[string]interface{}{"name": "transfer-leader", "region_id": float64(2), "to_store_id": float64(4)}))
+		case "DELETE":
+			g.Expect(request.URL.Path).To(Equal(fmt.Sprintf("/%s/2", operatorsPrefix)), "check url")
+			w.WriteHeader(http.StatusNotFound)
+		}
+	})
+	defer svc.Close()
+
+	pdClient := NewPDClient(svc.URL, timeout)
+	result, err := pdClient.GetOperators()
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(result).To(Equal(operators))
+
+	err = pdClient.AddOperator(operator)
+	g.Expect(err).NotTo(HaveOccurred())
+
+	err = pdClient.RemoveOperator(2)
+	g.Expect(err).NotTo(HaveOccurred())
+}
+
+func TestScheduleConfig(t *testing.T) {
+	g := NewGomegaWithT(t)
+	config := &server.ScheduleConfig{LeaderScheduleLimit: 4, RegionScheduleLimit: 64, LowSpaceRatio: 0.8}
+	configBytes, err := json.Marshal(config)
+	g.Expect(err).NotTo(HaveOccurred())
+
+	svc := getClientServer(func(w http.ResponseWriter, request *http.Request) {
+		g.Expect(request.URL.Path).To(Equal(fmt.Sprintf("/%s", scheduleConfigPrefix)), "check url")
+		w.Header().Set("Content-Type", ContentTypeJSON)
+		if request.Method == "GET" {
+			w.Write(configBytes)
+			return
+		}
+		g.Expect(request.Method).To(Equal("POST"), "check method")
+		data := &server.ScheduleConfig{}
+		err := readJSON(request.Body, data)
+		g.Expect(err).NotTo(HaveOccurred())
+		g.Expect(data.RegionScheduleLimit).To(Equal(uint64(32)))
+	})
+	defer svc.Close()
+
+	pdClient := NewPDClient(svc.URL, timeout)
+	result, err := pdClient.GetScheduleConfig()
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(result).To(Equal(config))
+
+	result.RegionScheduleLimit = 32
+	err = pdClient.SetScheduleConfig(result)
+	g.Expect(err).NotTo(HaveOccurred())
+}
+
+func TestRequestFallback(t *testing.T) {
+	g := NewGomegaWithT(t)
+	requests := []string{}
+	newServer := func(name string, status int) *httptest.Server {
+		return getClientServer(func(w http.ResponseWriter, request *http.Request) {
+			requests = append(requests, fmt.Sprintf("%s %s", request.Method, name))
+			w.WriteHeader(status)
+		})
+	}
+	down := newServer("service", http.StatusServiceUnavailable)
+	defer down.Close()
+	pd0 := newServer("pd-0", http.StatusOK)
+	defer pd0.Close()
+	pd1 := newServer("pd-1", http.StatusOK)
+	defer pd1.Close()
+	// nothing listens on the closed server
+	closed := newServer("pd-2", http.StatusOK)
+	closed.Close()
+
+	client := NewPDClient(down.URL, timeout).(*pdClient)
+	client.endpoints.set(closed.URL, []string{pd0.URL, pd1.URL, closed.URL})
+
+	// reads go to the service first and fall back to the members
+	g.Expect(client.do("GET", healthPrefix, nil, nil)).To(Succeed())
+	g.Expect(requests).To(Equal([]string{"GET service", "GET pd-0"}))
+
+	// writes go to the leader first and fall back if the leader can't be connected
+	writeClient := NewPDClient(pd1.URL, timeout).(*pdClient)
+	writeClient.endpoints.set(closed.URL, []string{closed.URL, pd0.URL})
+	requests = []string{}
+	g.Expect(writeClient.do("POST", schedulersPrefix, nil, nil)).To(Succeed())
+	g.Expect(requests).To(Equal([]string{"POST pd-1"}))
+
+	// writes are not sent again after reaching PD
+	client.endpoints.set("", []string{pd0.URL})
+	requests = []string{}
+	g.Expect(client.do("POST", schedulersPrefix, nil, nil)).NotTo(Succeed())
+	g.Expect(requests).To(Equal([]string{"POST service"}))
+}
+
+func TestGetPDClient(t *testing.T) {
+	g := NewGomegaWithT(t)
+	tc := &v1alpha1.TidbCluster{}
+	tc.Name = "demo"
+	tc.Namespace = "default"
+	tc.Status.PD.Leader = v1alpha1.PDMember{Name: "demo-pd-1", ClientURL: "http://demo-pd-1:2379", Health: true}
+	tc.Status.PD.Members = map[string]v1alpha1.PDMember{
+		"demo-pd-0": {Name: "demo-pd-0", ClientURL: "http://demo-pd-0:2379", Health: false},
+		"demo-pd-1": {Name: "demo-pd-1", ClientURL: "http://demo-pd-1:2379", Health: true},
+		"demo-pd-2": {Name: "demo-pd-2", ClientURL: "http://demo-pd-2:2379", Health: true},
+	}
+
+	pdControl := NewDefaultPDControl()
+	client := pdControl.GetPDClient(tc).(*pdClient)
+	g.Expect(client.url).To(Equal("http://demo-pd.default:2379"))
+	g.Expect(client.endpoints.urls(client.url, false)).To(Equal([]string{
+		"http://demo-pd.default:2379", "http://demo-pd-1:2379", "http://demo-pd-2:2379", "http://demo-pd-0:2379",
+	}))
+	g.Expect(client.endpoints.urls(client.url, true)).To(Equal([]string{
+		"http://demo-pd-1:2379", "http://demo-pd.default:2379", "http://demo-pd-2:2379", "http://demo-pd-0:2379",
+	}))
+	g.Expect(pdControl.GetPDClient(tc)).To(BeIdenticalTo(client))
+
+	ctxClient := client.WithContext(context.TODO()).(*pdClient)
+	g.Expect(ctxClient.endpoints).To(BeIdenticalTo(client.endpoints))
+
+	pdControl.RemovePDClient("default", "demo")
+	g.Expect(pdControl.GetPDClient(tc)).NotTo(BeIdenticalTo(client))
+}
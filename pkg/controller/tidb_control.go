@@ -38,7 +38,7 @@ type TiDBControlInterface interface {
 	// GetHealth returns tidb's health info
 	GetHealth(tc *v1alpha1.TidbCluster) map[string]bool
 	// ResignDDLOwner resigns the ddl owner of tidb, if the tidb node is not a ddl owner returns (true,nil),else returns (false,err)
-	ResignDDLOwner(tc *v1alpha1.TidbCluster, ordinal int32) (bool, error)
+	ResignDDLOwner(tc *v1alpha1.TidbCluster, podName string) (bool, error)
 	// Get TIDB info return tidb's dbInfo
 	GetInfo(tc *v1alpha1.TidbCluster, ordinal int32) (*dbInfo, error)
 	// GetSettings return the TiDB instance settings
@@ -60,9 +60,19 @@ func (tdc *defaultTiDBControl) GetHealth(tc *v1alpha1.TidbCluster) map[string]bo
 	tcName := tc.GetName()
 	ns := tc.GetNamespace()
 
-	result := map[string]bool{}
+	hostNames := []string{}
 	for i := 0; i < int(tc.TiDBRealReplicas()); i++ {
-		hostName := fmt.Sprintf("%s-%d", TiDBMemberName(tcName), i)
+		hostNames = append(hostNames, fmt.Sprintf("%s-%d", TiDBMemberName(tcName), i))
+	}
+	for i := range tc.Spec.TiDB.Groups {
+		group := &tc.Spec.TiDB.Groups[i]
+		for j := 0; j < int(tc.TiDBGroupRealReplicas(group)); j++ {
+			hostNames = append(hostNames, fmt.Sprintf("%s-%d", TiDBGroupMemberName(tcName, group.Name), j))
+		}
+	}
+
+	result := map[string]bool{}
+	for _, hostName := range hostNames {
 		url := fmt.Sprintf("http://%s.%s.%s:10080/status", hostName, TiDBPeerMemberName(tcName), ns)
 		_, err := tdc.getBodyOK(url)
 		if err != nil {
@@ -74,12 +84,11 @@ func (tdc *defaultTiDBControl) GetHealth(tc *v1alpha1.TidbCluster) map[string]bo
 	return result
 }
 
-func (tdc *defaultTiDBControl) ResignDDLOwner(tc *v1alpha1.TidbCluster, ordinal int32) (bool, error) {
+func (tdc *defaultTiDBControl) ResignDDLOwner(tc *v1alpha1.TidbCluster, podName string) (bool, error) {
 	tcName := tc.GetName()
 	ns := tc.GetNamespace()
 
-	hostName := fmt.Sprintf("%s-%d", TiDBMemberName(tcName), ordinal)
-	url := fmt.Sprintf("http://%s.%s.%s:10080/ddl/owner/resign", hostName, TiDBPeerMemberName(tcName), ns)
+	url := fmt.Sprintf("http://%s.%s.%s:10080/ddl/owner/resign", podName, TiDBPeerMemberName(tcName), ns)
 	req, err := http.NewRequest("POST", url, nil)
 	if err != nil {
 		return false, err
@@ -213,7 +222,7 @@ func (ftd *FakeTiDBControl) GetHealth(_ *v1alpha1.TidbCluster) map[string]bool {
 	return ftd.healthInfo
 }
 
-func (ftd *FakeTiDBControl) ResignDDLOwner(tc *v1alpha1.TidbCluster, ordinal int32) (bool, error) {
+func (ftd *FakeTiDBControl) ResignDDLOwner(tc *v1alpha1.TidbCluster, podName string) (bool, error) {
 	return ftd.notDDLOwner, ftd.resignDDLOwnerError
 }
 
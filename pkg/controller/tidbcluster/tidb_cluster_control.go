@@ -36,6 +36,7 @@ type ControlInterface interface {
 func NewDefaultTidbClusterControl(
 	tcControl controller.TidbClusterControlInterface,
 	pdMemberManager manager.Manager,
+	pdPlacementManager manager.Manager,
 	tikvMemberManager manager.Manager,
 	tidbMemberManager manager.Manager,
 	reclaimPolicyManager manager.Manager,
@@ -45,6 +46,7 @@ func NewDefaultTidbClusterControl(
 	return &defaultTidbClusterControl{
 		tcControl,
 		pdMemberManager,
+		pdPlacementManager,
 		tikvMemberManager,
 		tidbMemberManager,
 		reclaimPolicyManager,
@@ -57,6 +59,7 @@ func NewDefaultTidbClusterControl(
 type defaultTidbClusterControl struct {
 	tcControl            controller.TidbClusterControlInterface
 	pdMemberManager      manager.Manager
+	pdPlacementManager   manager.Manager
 	tikvMemberManager    manager.Manager
 	tidbMemberManager    manager.Manager
 	reclaimPolicyManager manager.Manager
@@ -109,6 +112,15 @@ func (tcc *defaultTidbClusterControl) updateTidbCluster(tc *v1alpha1.TidbCluster
 		return err
 	}
 
+	// reconciling the pd schedulers and replication config to the spec:
+	//   - add the missing schedulers and remove the ones removed from the spec
+	//   - set max-replicas and location-labels
+	//   - record the drift in the pd placement status
+	//   - record the failure in the pd placement status and as an event, the tikv and tidb clusters are still synced
+	if err := tcc.pdPlacementManager.Sync(tc); err != nil {
+		return err
+	}
+
 	// works that should do to making the tikv cluster current state match the desired state:
 	//   - waiting for the pd cluster available(pd cluster is in quorum)
 	//   - create or update tikv headless service
@@ -53,6 +53,8 @@ type Controller struct {
 	// control returns an interface capable of syncing a tidb cluster.
 	// Abstracted out for testing.
 	control ControlInterface
+	// pdControl caches the PD clients, the client of a deleted tidb cluster is evicted
+	pdControl controller.PDControlInterface
 	// tcLister is able to list/get tidbclusters from a shared informer's store
 	tcLister listers.TidbClusterLister
 	// tcListerSynced returns true if the tidbcluster shared informer has synced at least once
@@ -85,6 +87,7 @@ func NewController(
 	tcInformer := informerFactory.Pingcap().V1alpha1().TidbClusters()
 	setInformer := kubeInformerFactory.Apps().V1beta1().StatefulSets()
 	svcInformer := kubeInformerFactory.Core().V1().Services()
+	cmInformer := kubeInformerFactory.Core().V1().ConfigMaps()
 	epsInformer := kubeInformerFactory.Core().V1().Endpoints()
 	pvcInformer := kubeInformerFactory.Core().V1().PersistentVolumeClaims()
 	pvInformer := kubeInformerFactory.Core().V1().PersistentVolumes()
@@ -96,11 +99,12 @@ func NewController(
 	tidbControl := controller.NewDefaultTiDBControl()
 	setControl := controller.NewRealStatefuSetControl(kubeCli, setInformer.Lister(), recorder)
 	svcControl := controller.NewRealServiceControl(kubeCli, svcInformer.Lister(), recorder)
+	cmControl := controller.NewRealConfigMapControl(kubeCli, cmInformer.Lister(), recorder)
 	pvControl := controller.NewRealPVControl(kubeCli, pvcInformer.Lister(), pvInformer.Lister(), recorder)
 	pvcControl := controller.NewRealPVCControl(kubeCli, recorder, pvcInformer.Lister())
 	podControl := controller.NewRealPodControl(kubeCli, pdControl, podInformer.Lister(), recorder)
 	pdScaler := mm.NewPDScaler(pdControl, pvcInformer.Lister(), pvcControl)
-	tikvScaler := mm.NewTiKVScaler(pdControl, pvcInformer.Lister(), pvcControl, podInformer.Lister())
+	tikvScaler := mm.NewTiKVScaler(pdControl, pvcInformer.Lister(), pvcControl, podInformer.Lister(), recorder)
 	pdFailover := mm.NewPDFailover(cli, pdControl, pdFailoverPeriod, podInformer.Lister(), podControl, pvcInformer.Lister(), pvcControl, pvInformer.Lister())
 	tikvFailover := mm.NewTiKVFailover(tikvFailoverPeriod)
 	tidbFailover := mm.NewTiDBFailover(tidbFailoverPeriod)
@@ -111,6 +115,7 @@ func NewController(
 	tcc := &Controller{
 		kubeClient: kubeCli,
 		cli:        cli,
+		pdControl:  pdControl,
 		control: NewDefaultTidbClusterControl(
 			tcControl,
 			mm.NewPDMemberManager(
@@ -128,6 +133,7 @@ func NewController(
 				autoFailover,
 				pdFailover,
 			),
+			mm.NewPDPlacementManager(pdControl, recorder),
 			mm.NewTiKVMemberManager(
 				pdControl,
 				setControl,
@@ -144,9 +150,11 @@ func NewController(
 			mm.NewTiDBMemberManager(
 				setControl,
 				svcControl,
+				cmControl,
 				tidbControl,
 				setInformer.Lister(),
 				svcInformer.Lister(),
+				cmInformer.Lister(),
 				podInformer.Lister(),
 				tidbUpgrader,
 				autoFailover,
@@ -262,6 +270,7 @@ func (tcc *Controller) sync(key string) error {
 	tc, err := tcc.tcLister.TidbClusters(ns).Get(name)
 	if errors.IsNotFound(err) {
 		glog.Infof("TidbCluster has been deleted %v", key)
+		tcc.pdControl.RemovePDClient(ns, name)
 		return nil
 	}
 	if err != nil {
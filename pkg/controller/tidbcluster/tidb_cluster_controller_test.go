@@ -43,6 +43,20 @@ func TestTidbClusterControllerEnqueueTidbCluster(t *testing.T) {
 	g.Expect(tcc.queue.Len()).To(Equal(1))
 }
 
+func TestTidbClusterControllerSyncDeletedTidbCluster(t *testing.T) {
+	g := NewGomegaWithT(t)
+	tc := newTidbCluster()
+	tcc, _, _ := newFakeTidbClusterController()
+	pdControl := tcc.pdControl.(*controller.FakePDControl)
+	pdClient := controller.NewFakePDClient()
+	pdControl.SetPDClient(tc, pdClient)
+
+	key, err := cache.MetaNamespaceKeyFunc(tc)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(tcc.sync(key)).To(Succeed())
+	g.Expect(pdControl.GetPDClient(tc)).NotTo(BeIdenticalTo(pdClient))
+}
+
 func TestTidbClusterControllerAddStatefuSet(t *testing.T) {
 	g := NewGomegaWithT(t)
 	type testcase struct {
@@ -218,6 +232,7 @@ func newFakeTidbClusterController() (*Controller, cache.Indexer, cache.Indexer)
 
 	setInformer := kubeInformerFactory.Apps().V1beta1().StatefulSets()
 	svcInformer := kubeInformerFactory.Core().V1().Services()
+	cmInformer := kubeInformerFactory.Core().V1().ConfigMaps()
 	pvcInformer := kubeInformerFactory.Core().V1().PersistentVolumeClaims()
 	pvInformer := kubeInformerFactory.Core().V1().PersistentVolumes()
 	tcInformer := informerFactory.Pingcap().V1alpha1().TidbClusters()
@@ -256,7 +271,7 @@ func newFakeTidbClusterController() (*Controller, cache.Indexer, cache.Indexer)
 	pvcControl := controller.NewRealPVCControl(kubeCli, recorder, pvcInformer.Lister())
 	podControl := controller.NewRealPodControl(kubeCli, pdControl, podInformer.Lister(), recorder)
 	pdScaler := mm.NewPDScaler(pdControl, pvcInformer.Lister(), pvcControl)
-	tikvScaler := mm.NewTiKVScaler(pdControl, pvcInformer.Lister(), pvcControl, podInformer.Lister())
+	tikvScaler := mm.NewTiKVScaler(pdControl, pvcInformer.Lister(), pvcControl, podInformer.Lister(), recorder)
 	pdFailover := mm.NewFakePDFailover()
 	tikvFailover := mm.NewFakeTiKVFailover()
 	tidbFailover := mm.NewFakeTiDBFailover()
@@ -264,6 +279,7 @@ func newFakeTidbClusterController() (*Controller, cache.Indexer, cache.Indexer)
 	tikvUpgrader := mm.NewFakeTiKVUpgrader()
 	tidbUpgrader := mm.NewFakeTiDBUpgrader()
 
+	tcc.pdControl = pdControl
 	tcc.control = NewDefaultTidbClusterControl(
 		controller.NewRealTidbClusterControl(cli, tcInformer.Lister(), recorder),
 		mm.NewPDMemberManager(
@@ -281,6 +297,7 @@ func newFakeTidbClusterController() (*Controller, cache.Indexer, cache.Indexer)
 			autoFailover,
 			pdFailover,
 		),
+		mm.NewPDPlacementManager(pdControl, recorder),
 		mm.NewTiKVMemberManager(
 			pdControl,
 			setControl,
@@ -301,9 +318,11 @@ func newFakeTidbClusterController() (*Controller, cache.Indexer, cache.Indexer)
 				recorder,
 			),
 			svcControl,
+			controller.NewRealConfigMapControl(kubeCli, cmInformer.Lister(), recorder),
 			tidbControl,
 			setInformer.Lister(),
 			svcInformer.Lister(),
+			cmInformer.Lister(),
 			podInformer.Lister(),
 			tidbUpgrader,
 			autoFailover,
@@ -82,6 +82,11 @@ func (td *tidbDiscovery) Discover(advertisePeerUrl string) (string, error) {
 	// TODO: the replicas should be the total replicas of pd sets.
 	replicas := tc.Spec.PD.Replicas
 
+	// the PD of a TidbCluster referencing another TidbCluster always joins the referenced PD
+	if tc.HasClusterRef() {
+		return td.joinArgs(tc)
+	}
+
 	currentCluster := td.clusters[keyName]
 	if currentCluster == nil || currentCluster.resourceVersion != tc.ResourceVersion {
 		td.clusters[keyName] = &clusterInfo{
@@ -97,6 +102,15 @@ func (td *tidbDiscovery) Discover(advertisePeerUrl string) (string, error) {
 		return fmt.Sprintf("--initial-cluster=%s=http://%s", podName, advertisePeerUrl), nil
 	}
 
+	args, err := td.joinArgs(tc)
+	if err != nil {
+		return "", err
+	}
+	delete(currentCluster.peers, podName)
+	return args, nil
+}
+
+func (td *tidbDiscovery) joinArgs(tc *v1alpha1.TidbCluster) (string, error) {
 	pdClient := td.pdControl.GetPDClient(tc)
 	membersInfo, err := pdClient.GetMembers()
 	if err != nil {
@@ -107,7 +121,6 @@ func (td *tidbDiscovery) Discover(advertisePeerUrl string) (string, error) {
 	for _, member := range membersInfo.Members {
 		membersArr = append(membersArr, member.PeerUrls[0])
 	}
-	delete(currentCluster.peers, podName)
 	return fmt.Sprintf("--join=%s", strings.Join(membersArr, ",")), nil
 }
 
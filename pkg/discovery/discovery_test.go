@@ -394,6 +394,31 @@ func TestDiscoveryDiscovery(t *testing.T) {
 				g.Expect(s).To(Equal("--join=demo-pd-0.demo-pd-peer.default.svc:2380,demo-pd-1.demo-pd-peer.default.svc:2380,demo-pd-2.demo-pd-peer.default.svc:2380,demo-pd-3.demo-pd-peer.default.svc:2380"))
 			},
 		},
+		{
+			name: "cluster references another cluster, join the referenced pd",
+			ns:   "default",
+			url:  "demo-pd-0.demo-pd-peer.default.svc:2380",
+			tcFn: func() (*v1alpha1.TidbCluster, error) {
+				tc, _ := newTC()
+				tc.Spec.Cluster = &v1alpha1.TidbClusterRef{Name: "demo-1"}
+				return tc, nil
+			},
+			getMembersFn: func() (*controller.MembersInfo, error) {
+				return &controller.MembersInfo{
+					Members: []*pdpb.Member{
+						{
+							PeerUrls: []string{"demo-1-pd-0.demo-1-pd-peer.default.svc:2380"},
+						},
+					},
+				}, nil
+			},
+			clusters: map[string]*clusterInfo{},
+			expectFn: func(g *GomegaWithT, td *tidbDiscovery, s string, err error) {
+				g.Expect(err).NotTo(HaveOccurred())
+				g.Expect(len(td.clusters)).To(BeZero())
+				g.Expect(s).To(Equal("--join=demo-1-pd-0.demo-1-pd-peer.default.svc:2380"))
+			},
+		},
 	}
 	for i := range tests {
 		testFn(&tests[i], t)
@@ -29,7 +29,7 @@ var (
 )
 
 const (
-	// StableScheduling controls stable scheduling of TiDB members.
+	// StableScheduling controls stable scheduling of TiDB, TiKV and PD members.
 	StableScheduling string = "StableScheduling"
 )
 
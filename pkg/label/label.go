@@ -44,12 +44,19 @@ const (
 	StoreIDLabelKey string = "tidb.pingcap.com/store-id"
 	// MemberIDLabelKey is member id label key
 	MemberIDLabelKey string = "tidb.pingcap.com/member-id"
+	// GroupLabelKey is the label key of the group a pod belongs to, pods of the default TiKV group don't have
+	// this label, while pods of the default TiDB group have it with an empty value which Services can select
+	// once the TiDB groups are configured
+	GroupLabelKey string = "tidb.pingcap.com/group"
 	// AnnPodNameKey is pod name annotation key used in PV/PVC for synchronizing tidb cluster meta info
 	AnnPodNameKey string = "tidb.pingcap.com/pod-name"
 	// AnnPVCDeferDeleting is pvc defer deletion annotation key used in PVC for defer deleting PVC
 	AnnPVCDeferDeleting = "tidb.pingcap.com/pvc-defer-deleting"
 	// AnnPVCPodScheduling is pod scheduling annotation key, it represents whether the pod is scheduling
+	// Deprecated: the scheduling lock is kept in the ConfigMap annotated with AnnSchedulingLock
 	AnnPVCPodScheduling = "tidb.pingcap.com/pod-scheduling"
+	// AnnSchedulingLock is the annotation key of the scheduling lock ConfigMap, its value is the lock record
+	AnnSchedulingLock = "tidb.pingcap.com/scheduling-lock"
 	// AnnTiDBPartition is pod annotation which TiDB pod chould upgrade to
 	AnnTiDBPartition string = "tidb.pingcap.com/tidb-partition"
 
@@ -118,6 +125,12 @@ func (l Label) TiKV() Label {
 	return l
 }
 
+// Group adds group kv pair to label
+func (l Label) Group(name string) Label {
+	l[GroupLabelKey] = name
+	return l
+}
+
 // IsTiKV returns whether label is a TiKV
 func (l Label) IsTiKV() bool {
 	return l[ComponentLabelKey] == TiKVLabelVal
@@ -138,6 +151,21 @@ func (l Label) LabelSelector() *metav1.LabelSelector {
 	return &metav1.LabelSelector{MatchLabels: l}
 }
 
+// DefaultGroupSelector gets labels.Selector from label which doesn't match the pods of any group
+func (l Label) DefaultGroupSelector() (labels.Selector, error) {
+	return metav1.LabelSelectorAsSelector(l.DefaultGroupLabelSelector())
+}
+
+// DefaultGroupLabelSelector gets LabelSelector from label which requires the pods to have no group label
+func (l Label) DefaultGroupLabelSelector() *metav1.LabelSelector {
+	return &metav1.LabelSelector{
+		MatchLabels: l,
+		MatchExpressions: []metav1.LabelSelectorRequirement{
+			{Key: GroupLabelKey, Operator: metav1.LabelSelectorOpDoesNotExist},
+		},
+	}
+}
+
 // Labels converts label to map[string]string
 func (l Label) Labels() map[string]string {
 	return l
@@ -113,6 +113,24 @@ func TestLabelLabelSelector(t *testing.T) {
 	g.Expect(ls.MatchLabels).To(Equal(m))
 }
 
+func TestLabelDefaultGroupSelector(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	l := New().Instance("demo").TiKV()
+	s, err := l.DefaultGroupSelector()
+	g.Expect(err).NotTo(HaveOccurred())
+
+	st := labels.Set(map[string]string{
+		NameLabelKey:      "tidb-cluster",
+		ManagedByLabelKey: "tidb-operator",
+		ComponentLabelKey: "tikv",
+		InstanceLabelKey:  "demo",
+	})
+	g.Expect(s.Matches(st)).To(BeTrue())
+	st[GroupLabelKey] = "ssd"
+	g.Expect(s.Matches(st)).To(BeFalse())
+}
+
 func TestLabelLabels(t *testing.T) {
 	g := NewGomegaWithT(t)
 
@@ -80,6 +80,11 @@ func NewPDMemberManager(pdControl controller.PDControlInterface,
 }
 
 func (pmm *pdMemberManager) Sync(tc *v1alpha1.TidbCluster) error {
+	// The PD is run by the referenced TidbCluster, only aggregate its status
+	if tc.HasClusterRef() {
+		return pmm.syncSharedPDStatus(tc)
+	}
+
 	// Sync PD Service
 	if err := pmm.syncPDServiceForTidbCluster(tc); err != nil {
 		return err
@@ -118,15 +123,12 @@ func (pmm *pdMemberManager) syncPDServiceForTidbCluster(tc *v1alpha1.TidbCluster
 		return err
 	}
 	if !equal {
-		svc := *oldSvc
-		svc.Spec = newSvc.Spec
-		// TODO add unit test
-		svc.Spec.ClusterIP = oldSvc.Spec.ClusterIP
-		err = SetServiceLastAppliedConfigAnnotation(&svc)
+		err = SetServiceLastAppliedConfigAnnotation(newSvc)
 		if err != nil {
 			return err
 		}
-		_, err = pmm.svcControl.UpdateService(tc, &svc)
+		svc := updateServiceSpec(newSvc, oldSvc)
+		_, err = pmm.svcControl.UpdateService(tc, svc)
 		return err
 	}
 
@@ -244,9 +246,6 @@ func (pmm *pdMemberManager) syncPDStatefulSetForTidbCluster(tc *v1alpha1.TidbClu
 }
 
 func (pmm *pdMemberManager) syncTidbClusterStatus(tc *v1alpha1.TidbCluster, set *apps.StatefulSet) error {
-	ns := tc.GetNamespace()
-	tcName := tc.GetName()
-
 	tc.Status.PD.StatefulSet = &set.Status
 
 	upgrading, err := pmm.pdStatefulSetIsUpgrading(set, tc)
@@ -259,19 +258,35 @@ func (pmm *pdMemberManager) syncTidbClusterStatus(tc *v1alpha1.TidbCluster, set
 		tc.Status.PD.Phase = v1alpha1.NormalPhase
 	}
 
+	return pmm.syncPDMembersStatus(tc)
+}
+
+// syncSharedPDStatus syncs the status of the PD shared from the referenced TidbCluster
+func (pmm *pdMemberManager) syncSharedPDStatus(tc *v1alpha1.TidbCluster) error {
+	tc.Status.PD.StatefulSet = nil
+	tc.Status.PD.FailureMembers = nil
+	tc.Status.PD.Phase = v1alpha1.NormalPhase
+	return pmm.syncPDMembersStatus(tc)
+}
+
+func (pmm *pdMemberManager) syncPDMembersStatus(tc *v1alpha1.TidbCluster) error {
+	ns := tc.GetNamespace()
+	tcName := tc.GetName()
+	pdNs, pdTCName := tc.PDClusterRef()
+
 	pdClient := pmm.pdControl.GetPDClient(tc)
 
 	healthInfo, err := pdClient.GetHealth()
 	if err != nil {
 		tc.Status.PD.Synced = false
 		// get endpoints info
-		eps, epErr := pmm.epsLister.Endpoints(ns).Get(controller.PDMemberName(tcName))
+		eps, epErr := pmm.epsLister.Endpoints(pdNs).Get(controller.PDMemberName(pdTCName))
 		if epErr != nil {
 			return fmt.Errorf("%s, %s", err, epErr)
 		}
 		// pd service has no endpoints
 		if eps != nil && len(eps.Subsets) == 0 {
-			return fmt.Errorf("%s, service %s/%s has no endpoints", err, ns, controller.PDMemberName(tcName))
+			return fmt.Errorf("%s, service %s/%s has no endpoints", err, pdNs, controller.PDMemberName(pdTCName))
 		}
 		return err
 	}
@@ -312,10 +327,19 @@ func (pmm *pdMemberManager) syncTidbClusterStatus(tc *v1alpha1.TidbCluster, set
 		oldPDMember, exist := tc.Status.PD.Members[name]
 		if exist {
 			status.LastTransitionTime = oldPDMember.LastTransitionTime
+			status.NodeName = oldPDMember.NodeName
 		}
 		if !exist || status.Health != oldPDMember.Health {
 			status.LastTransitionTime = metav1.Now()
 		}
+		pod, err := pmm.podLister.Pods(ns).Get(name)
+		if err != nil && !errors.IsNotFound(err) {
+			return err
+		}
+		if pod != nil && pod.Spec.NodeName != "" {
+			// Update assiged node if pod exists and is scheduled
+			status.NodeName = pod.Spec.NodeName
+		}
 
 		pdStatus[name] = status
 	}
@@ -334,7 +358,7 @@ func (pmm *pdMemberManager) getNewPDServiceForTidbCluster(tc *v1alpha1.TidbClust
 	instanceName := tc.GetLabels()[label.InstanceLabelKey]
 	pdLabel := label.New().Instance(instanceName).PD().Labels()
 
-	return &corev1.Service{
+	svc := &corev1.Service{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:            svcName,
 			Namespace:       ns,
@@ -354,6 +378,8 @@ func (pmm *pdMemberManager) getNewPDServiceForTidbCluster(tc *v1alpha1.TidbClust
 			Selector: pdLabel,
 		},
 	}
+	applyServiceSpec(svc, tc.Spec.PD.Service)
+	return svc
 }
 
 func (pmm *pdMemberManager) getNewPDHeadlessServiceForTidbCluster(tc *v1alpha1.TidbCluster) *corev1.Service {
@@ -572,6 +598,7 @@ func (pmm *pdMemberManager) getNewPDSetForTidbCluster(tc *v1alpha1.TidbCluster)
 		},
 	}
 
+	applyPodTemplateOverride(pdSet, v1alpha1.PDMemberType.String(), tc.Spec.PD.PodTemplate)
 	return pdSet, nil
 }
 
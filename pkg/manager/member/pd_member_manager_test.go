@@ -337,6 +337,34 @@ func TestPDMemberManagerSyncUpdate(t *testing.T) {
 				g.Expect(tc.Status.PD.Members["pd3"].Health).To(Equal(false))
 			},
 		},
+		{
+			name: "customize pd service",
+			modify: func(tc *v1alpha1.TidbCluster) {
+				tc.Spec.PD.Service = &v1alpha1.ServiceSpec{
+					Type:                     corev1.ServiceTypeLoadBalancer,
+					Annotations:              map[string]string{"cloud.google.com/load-balancer-type": "Internal"},
+					LoadBalancerSourceRanges: []string{"10.0.0.0/8"},
+					ExternalTrafficPolicy:    corev1.ServiceExternalTrafficPolicyTypeLocal,
+					NodePorts:                map[string]int32{"client": 32379},
+					SessionAffinity:          corev1.ServiceAffinityClientIP,
+				}
+			},
+			pdHealth: &controller.HealthInfo{Healths: []controller.MemberHealth{
+				{Name: "pd1", MemberID: uint64(1), ClientUrls: []string{"http://pd1:2379"}, Health: true},
+				{Name: "pd2", MemberID: uint64(2), ClientUrls: []string{"http://pd2:2379"}, Health: true},
+				{Name: "pd3", MemberID: uint64(3), ClientUrls: []string{"http://pd3:2379"}, Health: true},
+			}},
+			err: false,
+			expectPDServiceFn: func(g *GomegaWithT, svc *corev1.Service, err error) {
+				g.Expect(err).NotTo(HaveOccurred())
+				g.Expect(svc.Spec.Type).To(Equal(corev1.ServiceTypeLoadBalancer))
+				g.Expect(svc.Annotations["cloud.google.com/load-balancer-type"]).To(Equal("Internal"))
+				g.Expect(svc.Spec.LoadBalancerSourceRanges).To(Equal([]string{"10.0.0.0/8"}))
+				g.Expect(svc.Spec.ExternalTrafficPolicy).To(Equal(corev1.ServiceExternalTrafficPolicyTypeLocal))
+				g.Expect(svc.Spec.Ports[0].NodePort).To(Equal(int32(32379)))
+				g.Expect(svc.Spec.SessionAffinity).To(Equal(corev1.ServiceAffinityClientIP))
+			},
+		},
 		{
 			name: "tidbcluster's storage format is wrong",
 			modify: func(tc *v1alpha1.TidbCluster) {
@@ -445,6 +473,45 @@ func TestPDMemberManagerSyncUpdate(t *testing.T) {
 	}
 }
 
+func TestPDMemberManagerSyncSharedPD(t *testing.T) {
+	g := NewGomegaWithT(t)
+	tc := newTidbClusterForPD()
+	tc.Spec.Cluster = &v1alpha1.TidbClusterRef{Namespace: "shared-ns", Name: "shared"}
+	tc.Status.PD.StatefulSet = &apps.StatefulSetStatus{Replicas: 3}
+	ns := tc.Namespace
+	tcName := tc.Name
+
+	pmm, _, _, fakePDControl, _, _, _ := newFakePDMemberManager()
+	pdClient := controller.NewFakePDClient()
+	fakePDControl.SetPDClient(tc, pdClient)
+	pdClient.AddReaction(controller.GetHealthActionType, func(action *controller.Action) (interface{}, error) {
+		return &controller.HealthInfo{Healths: []controller.MemberHealth{
+			{Name: "shared-pd-0", MemberID: uint64(1), ClientUrls: []string{"http://shared-pd-0.shared-pd-peer.shared-ns.svc:2379"}, Health: true},
+			{Name: "shared-pd-1", MemberID: uint64(2), ClientUrls: []string{"http://shared-pd-1.shared-pd-peer.shared-ns.svc:2379"}, Health: true},
+		}}, nil
+	})
+	pdClient.AddReaction(controller.GetClusterActionType, func(action *controller.Action) (interface{}, error) {
+		return &metapb.Cluster{Id: uint64(1)}, nil
+	})
+
+	err := pmm.Sync(tc)
+	g.Expect(err).NotTo(HaveOccurred())
+
+	_, err = pmm.svcLister.Services(ns).Get(controller.PDMemberName(tcName))
+	expectErrIsNotFound(g, err)
+	_, err = pmm.svcLister.Services(ns).Get(controller.PDPeerMemberName(tcName))
+	expectErrIsNotFound(g, err)
+	_, err = pmm.setLister.StatefulSets(ns).Get(controller.PDMemberName(tcName))
+	expectErrIsNotFound(g, err)
+
+	g.Expect(tc.Status.ClusterID).To(Equal("1"))
+	g.Expect(tc.Status.PD.Synced).To(BeTrue())
+	g.Expect(tc.Status.PD.StatefulSet).To(BeNil())
+	g.Expect(tc.Status.PD.Phase).To(Equal(v1alpha1.NormalPhase))
+	g.Expect(len(tc.Status.PD.Members)).To(Equal(2))
+	g.Expect(tc.PDIsAvailable()).To(BeTrue())
+}
+
 func TestPDMemberManagerPdStatefulSetIsUpgrading(t *testing.T) {
 	g := NewGomegaWithT(t)
 	type testcase struct {
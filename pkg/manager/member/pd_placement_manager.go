@@ -0,0 +1,255 @@
+// Copyright 2019 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package member
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+
+	"github.com/golang/glog"
+	"github.com/pingcap/pd/pkg/typeutil"
+	"github.com/pingcap/pd/server"
+	"github.com/pingcap/tidb-operator/pkg/apis/pingcap.com/v1alpha1"
+	"github.com/pingcap/tidb-operator/pkg/controller"
+	"github.com/pingcap/tidb-operator/pkg/manager"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/tools/record"
+)
+
+const (
+	// pdPlacementSyncFailedReason is the reason of event that the PD schedulers or replication config failed to sync
+	pdPlacementSyncFailedReason = "PDPlacementSyncFailed"
+)
+
+// the schedulers for a store are named by PD as <name>-<store id>
+var storeSchedulers = map[string]bool{
+	"evict-leader-scheduler": true,
+	"grant-leader-scheduler": true,
+}
+
+type pdPlacementManager struct {
+	pdControl controller.PDControlInterface
+	recorder  record.EventRecorder
+}
+
+// NewPDPlacementManager returns a manager reconciling the PD schedulers and replication config to the spec
+func NewPDPlacementManager(pdControl controller.PDControlInterface, recorder record.EventRecorder) manager.Manager {
+	return &pdPlacementManager{pdControl, recorder}
+}
+
+// Sync never fails, the failure is recorded in the placement status and as an event,
+// it's retried in the next sync without blocking the TiKV and TiDB members
+func (ppm *pdPlacementManager) Sync(tc *v1alpha1.TidbCluster) error {
+	if err := ppm.syncPlacement(tc); err != nil {
+		placement := &tc.Status.PD.Placement
+		placement.Synced = false
+		placement.Drift = append(placement.Drift, fmt.Sprintf("failed to sync: %v", err))
+		msg := fmt.Sprintf("failed to sync the PD schedulers and replication config: %v", err)
+		ppm.recorder.Event(tc, corev1.EventTypeWarning, pdPlacementSyncFailedReason, msg)
+		glog.Errorf("TidbCluster: [%s/%s] %s", tc.GetNamespace(), tc.GetName(), msg)
+	}
+	return nil
+}
+
+func (ppm *pdPlacementManager) syncPlacement(tc *v1alpha1.TidbCluster) error {
+	// the PD run by the referenced TidbCluster is managed by that TidbCluster
+	if tc.HasClusterRef() {
+		return nil
+	}
+	placement := &tc.Status.PD.Placement
+	if len(tc.Spec.PD.Schedulers) == 0 && tc.Spec.PD.Replication == nil && len(placement.Schedulers) == 0 {
+		return nil
+	}
+	// wait for the PD cluster available
+	if !tc.Status.PD.Synced {
+		return nil
+	}
+
+	ns := tc.GetNamespace()
+	tcName := tc.GetName()
+	pdClient := ppm.pdControl.GetPDClient(tc)
+
+	// the drift of the last sync is stale
+	placement.Drift = nil
+	desired := map[string]v1alpha1.PDSchedulerSpec{}
+	storeIDs := map[string]uint64{}
+	// the invalid schedulers are skipped, the valid ones are still synced
+	invalid := []string{}
+	for _, scheduler := range tc.Spec.PD.Schedulers {
+		name, storeID, err := pdSchedulerName(scheduler)
+		if err != nil {
+			invalid = append(invalid, err.Error())
+			continue
+		}
+		desired[name] = scheduler
+		storeIDs[name] = storeID
+	}
+	current, err := pdClient.GetSchedulers()
+	if err != nil {
+		return err
+	}
+	running := map[string]bool{}
+	for _, name := range current {
+		running[name] = true
+	}
+	config, err := pdClient.GetConfig()
+	if err != nil {
+		return err
+	}
+
+	replication, drift := desiredReplicationConfig(tc.Spec.PD.Replication, &config.Replication)
+	// only the schedulers added by tidb-operator are managed, the ones already running in PD
+	// before being added to the spec, e.g. the PD defaults, are never removed
+	previous := map[string]bool{}
+	for _, name := range placement.Schedulers {
+		previous[name] = true
+	}
+	managed := map[string]bool{}
+	toAdd := []string{}
+	for _, name := range sortedDesiredNames(desired) {
+		if running[name] {
+			if previous[name] {
+				managed[name] = true
+			}
+			continue
+		}
+		drift = append(drift, fmt.Sprintf("scheduler %s is missing", name))
+		toAdd = append(toAdd, name)
+	}
+	toRemove := []string{}
+	for _, name := range placement.Schedulers {
+		if _, ok := desired[name]; ok {
+			continue
+		}
+		if running[name] {
+			drift = append(drift, fmt.Sprintf("scheduler %s is not in the spec", name))
+			toRemove = append(toRemove, name)
+			// keep tracking it until it's removed
+			managed[name] = true
+		}
+	}
+
+	placement.Drift = append(drift, invalid...)
+	placement.Synced = len(placement.Drift) == 0
+	placement.Schedulers = sortedKeys(managed)
+	if placement.Synced {
+		return nil
+	}
+
+	if replication != nil {
+		if err := pdClient.SetReplicationConfig(replication); err != nil {
+			return err
+		}
+		glog.Infof("TidbCluster: [%s/%s] set the replication config of PD, max-replicas: %d, location-labels: %v",
+			ns, tcName, replication.MaxReplicas, replication.LocationLabels)
+	}
+	// the managed schedulers are recorded as soon as they change, so that a failure in between doesn't lose them
+	for _, name := range toAdd {
+		if err := pdClient.AddScheduler(desired[name].Name, storeIDs[name]); err != nil {
+			return err
+		}
+		managed[name] = true
+		placement.Schedulers = sortedKeys(managed)
+		glog.Infof("TidbCluster: [%s/%s] added PD scheduler %s", ns, tcName, name)
+	}
+	for _, name := range toRemove {
+		if err := pdClient.RemoveScheduler(name); err != nil {
+			return err
+		}
+		delete(managed, name)
+		placement.Schedulers = sortedKeys(managed)
+		glog.Infof("TidbCluster: [%s/%s] removed PD scheduler %s", ns, tcName, name)
+	}
+	placement.Synced = len(invalid) == 0
+	return nil
+}
+
+// desiredReplicationConfig returns the replication config to set and how the current one differs from the spec,
+// the config is nil if there is no difference
+func desiredReplicationConfig(spec *v1alpha1.PDReplicationSpec, current *server.ReplicationConfig) (*server.ReplicationConfig, []string) {
+	if spec == nil {
+		return nil, nil
+	}
+	var drift []string
+	config := &server.ReplicationConfig{
+		MaxReplicas:    current.MaxReplicas,
+		LocationLabels: current.LocationLabels,
+	}
+	if spec.MaxReplicas != nil && uint64(*spec.MaxReplicas) != current.MaxReplicas {
+		drift = append(drift, fmt.Sprintf("max-replicas is %d, desired %d", current.MaxReplicas, *spec.MaxReplicas))
+		config.MaxReplicas = uint64(*spec.MaxReplicas)
+	}
+	if spec.LocationLabels != nil && !reflect.DeepEqual([]string(current.LocationLabels), spec.LocationLabels) {
+		drift = append(drift, fmt.Sprintf("location-labels is %v, desired %v", []string(current.LocationLabels), spec.LocationLabels))
+		config.LocationLabels = typeutil.StringSlice(spec.LocationLabels)
+	}
+	if len(drift) == 0 {
+		return nil, nil
+	}
+	return config, drift
+}
+
+// pdSchedulerName returns the name of the scheduler in PD and the store it schedules
+func pdSchedulerName(scheduler v1alpha1.PDSchedulerSpec) (string, uint64, error) {
+	if !storeSchedulers[scheduler.Name] {
+		return scheduler.Name, 0, nil
+	}
+	storeID, err := strconv.ParseUint(scheduler.StoreID, 10, 64)
+	if err != nil {
+		return "", 0, fmt.Errorf("invalid store id %q of PD scheduler %s: %v", scheduler.StoreID, scheduler.Name, err)
+	}
+	return fmt.Sprintf("%s-%d", scheduler.Name, storeID), storeID, nil
+}
+
+func sortedDesiredNames(desired map[string]v1alpha1.PDSchedulerSpec) []string {
+	names := make([]string, 0, len(desired))
+	for name := range desired {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// sortedKeys returns nil for an empty map, so that the status doesn't flip between nil and empty
+func sortedKeys(m map[string]bool) []string {
+	if len(m) == 0 {
+		return nil
+	}
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+var _ manager.Manager = &pdPlacementManager{}
+
+type FakePDPlacementManager struct {
+	err error
+}
+
+func NewFakePDPlacementManager() *FakePDPlacementManager {
+	return &FakePDPlacementManager{}
+}
+
+func (fppm *FakePDPlacementManager) SetSyncError(err error) {
+	fppm.err = err
+}
+
+func (fppm *FakePDPlacementManager) Sync(_ *v1alpha1.TidbCluster) error {
+	return fppm.err
+}
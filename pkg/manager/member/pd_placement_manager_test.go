@@ -0,0 +1,254 @@
+// Copyright 2019 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package member
+
+import (
+	"fmt"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/pingcap/pd/pkg/typeutil"
+	"github.com/pingcap/pd/server"
+	"github.com/pingcap/tidb-operator/pkg/apis/pingcap.com/v1alpha1"
+	"github.com/pingcap/tidb-operator/pkg/controller"
+	"k8s.io/client-go/tools/record"
+)
+
+func TestPDPlacementManagerSync(t *testing.T) {
+	g := NewGomegaWithT(t)
+	type testcase struct {
+		name            string
+		update          func(tc *v1alpha1.TidbCluster)
+		schedulers      []string
+		replication     server.ReplicationConfig
+		addSchedulerErr bool
+		getConfigErr    bool
+		expectEvents    int
+		expectAdded     []string
+		expectRemoved   []string
+		expectConfig    *server.ReplicationConfig
+		expectStatus    v1alpha1.PDPlacementStatus
+	}
+	testFn := func(test *testcase, t *testing.T) {
+		t.Log(test.name)
+		tc := newTidbClusterForPD()
+		tc.Status.PD.Synced = true
+		test.update(tc)
+
+		pdControl := controller.NewFakePDControl()
+		pdClient := controller.NewFakePDClient()
+		pdControl.SetPDClient(tc, pdClient)
+		pdClient.AddReaction(controller.GetSchedulersActionType, func(action *controller.Action) (interface{}, error) {
+			return test.schedulers, nil
+		})
+		pdClient.AddReaction(controller.GetConfigActionType, func(action *controller.Action) (interface{}, error) {
+			if test.getConfigErr {
+				return nil, fmt.Errorf("failed to get config")
+			}
+			return &server.Config{Replication: test.replication}, nil
+		})
+		added := []string{}
+		pdClient.AddReaction(controller.AddSchedulerActionType, func(action *controller.Action) (interface{}, error) {
+			if test.addSchedulerErr {
+				return nil, fmt.Errorf("failed to add scheduler")
+			}
+			added = append(added, fmt.Sprintf("%s/%d", action.Name, action.ID))
+			return nil, nil
+		})
+		removed := []string{}
+		pdClient.AddReaction(controller.RemoveSchedulerActionType, func(action *controller.Action) (interface{}, error) {
+			removed = append(removed, action.Name)
+			return nil, nil
+		})
+		var config *server.ReplicationConfig
+		pdClient.AddReaction(controller.SetReplicationConfigActionType, func(action *controller.Action) (interface{}, error) {
+			config = action.Replication
+			return nil, nil
+		})
+
+		recorder := record.NewFakeRecorder(10)
+		err := NewPDPlacementManager(pdControl, recorder).Sync(tc)
+		g.Expect(err).NotTo(HaveOccurred())
+		g.Expect(recorder.Events).To(HaveLen(test.expectEvents))
+		g.Expect(added).To(ConsistOf(test.expectAdded))
+		g.Expect(removed).To(ConsistOf(test.expectRemoved))
+		g.Expect(config).To(Equal(test.expectConfig))
+		g.Expect(tc.Status.PD.Placement).To(Equal(test.expectStatus))
+	}
+
+	maxReplicas := int32(5)
+	tests := []testcase{
+		{
+			name:          "nothing is managed",
+			update:        func(tc *v1alpha1.TidbCluster) {},
+			expectAdded:   []string{},
+			expectRemoved: []string{},
+			expectStatus:  v1alpha1.PDPlacementStatus{},
+		},
+		{
+			name: "pd is not synced",
+			update: func(tc *v1alpha1.TidbCluster) {
+				tc.Status.PD.Synced = false
+				tc.Spec.PD.Schedulers = []v1alpha1.PDSchedulerSpec{{Name: "balance-hot-region-scheduler"}}
+			},
+			expectAdded:   []string{},
+			expectRemoved: []string{},
+			expectStatus:  v1alpha1.PDPlacementStatus{},
+		},
+		{
+			name: "pd matches the spec",
+			update: func(tc *v1alpha1.TidbCluster) {
+				tc.Spec.PD.Schedulers = []v1alpha1.PDSchedulerSpec{{Name: "balance-hot-region-scheduler"}}
+				tc.Spec.PD.Replication = &v1alpha1.PDReplicationSpec{MaxReplicas: &maxReplicas}
+				tc.Status.PD.Placement.Schedulers = []string{"balance-hot-region-scheduler"}
+			},
+			schedulers:    []string{"balance-hot-region-scheduler", "balance-leader-scheduler"},
+			replication:   server.ReplicationConfig{MaxReplicas: 5},
+			expectAdded:   []string{},
+			expectRemoved: []string{},
+			expectStatus: v1alpha1.PDPlacementStatus{
+				Synced:     true,
+				Schedulers: []string{"balance-hot-region-scheduler"},
+			},
+		},
+		{
+			name: "add the missing schedulers and set the replication config",
+			update: func(tc *v1alpha1.TidbCluster) {
+				tc.Spec.PD.Schedulers = []v1alpha1.PDSchedulerSpec{
+					{Name: "balance-hot-region-scheduler"},
+					{Name: "evict-leader-scheduler", StoreID: "4"},
+				}
+				tc.Spec.PD.Replication = &v1alpha1.PDReplicationSpec{LocationLabels: []string{"zone", "host"}}
+			},
+			schedulers:    []string{"balance-hot-region-scheduler"},
+			replication:   server.ReplicationConfig{MaxReplicas: 3, LocationLabels: typeutil.StringSlice{"host"}},
+			expectAdded:   []string{"evict-leader-scheduler/4"},
+			expectRemoved: []string{},
+			expectConfig:  &server.ReplicationConfig{MaxReplicas: 3, LocationLabels: typeutil.StringSlice{"zone", "host"}},
+			expectStatus: v1alpha1.PDPlacementStatus{
+				Synced:     true,
+				Schedulers: []string{"evict-leader-scheduler-4"},
+				Drift: []string{
+					"location-labels is [host], desired [zone host]",
+					"scheduler evict-leader-scheduler-4 is missing",
+				},
+			},
+		},
+		{
+			name: "the scheduler running before being added to the spec is not managed",
+			update: func(tc *v1alpha1.TidbCluster) {
+				tc.Spec.PD.Schedulers = []v1alpha1.PDSchedulerSpec{{Name: "balance-leader-scheduler"}}
+			},
+			schedulers:    []string{"balance-leader-scheduler", "balance-region-scheduler"},
+			expectAdded:   []string{},
+			expectRemoved: []string{},
+			expectStatus: v1alpha1.PDPlacementStatus{
+				Synced: true,
+			},
+		},
+		{
+			name: "the scheduler not managed is not removed after being removed from the spec",
+			update: func(tc *v1alpha1.TidbCluster) {
+				tc.Spec.PD.Schedulers = []v1alpha1.PDSchedulerSpec{{Name: "label-scheduler"}}
+			},
+			schedulers:    []string{"balance-leader-scheduler", "balance-region-scheduler"},
+			expectAdded:   []string{"label-scheduler/0"},
+			expectRemoved: []string{},
+			expectStatus: v1alpha1.PDPlacementStatus{
+				Synced:     true,
+				Schedulers: []string{"label-scheduler"},
+				Drift:      []string{"scheduler label-scheduler is missing"},
+			},
+		},
+		{
+			name: "remove the scheduler removed from the spec",
+			update: func(tc *v1alpha1.TidbCluster) {
+				tc.Spec.PD.Schedulers = []v1alpha1.PDSchedulerSpec{{Name: "balance-hot-region-scheduler"}}
+				tc.Status.PD.Placement.Schedulers = []string{"balance-hot-region-scheduler", "label-scheduler", "shuffle-leader-scheduler"}
+			},
+			schedulers:    []string{"balance-hot-region-scheduler", "label-scheduler", "evict-leader-scheduler-1"},
+			expectAdded:   []string{},
+			expectRemoved: []string{"label-scheduler"},
+			expectStatus: v1alpha1.PDPlacementStatus{
+				Synced:     true,
+				Schedulers: []string{"balance-hot-region-scheduler"},
+				Drift:      []string{"scheduler label-scheduler is not in the spec"},
+			},
+		},
+		{
+			name: "failed to add scheduler",
+			update: func(tc *v1alpha1.TidbCluster) {
+				tc.Spec.PD.Schedulers = []v1alpha1.PDSchedulerSpec{{Name: "balance-hot-region-scheduler"}}
+				tc.Status.PD.Placement.Schedulers = []string{"label-scheduler"}
+			},
+			schedulers:      []string{"label-scheduler"},
+			addSchedulerErr: true,
+			expectEvents:    1,
+			expectAdded:     []string{},
+			expectRemoved:   []string{},
+			expectStatus: v1alpha1.PDPlacementStatus{
+				Synced:     false,
+				Schedulers: []string{"label-scheduler"},
+				Drift: []string{
+					"scheduler balance-hot-region-scheduler is missing",
+					"scheduler label-scheduler is not in the spec",
+					"failed to sync: failed to add scheduler",
+				},
+			},
+		},
+		{
+			name: "invalid store id, sync the other schedulers",
+			update: func(tc *v1alpha1.TidbCluster) {
+				tc.Spec.PD.Schedulers = []v1alpha1.PDSchedulerSpec{
+					{Name: "grant-leader-scheduler"},
+					{Name: "balance-hot-region-scheduler"},
+				}
+			},
+			expectAdded:   []string{"balance-hot-region-scheduler/0"},
+			expectRemoved: []string{},
+			expectStatus: v1alpha1.PDPlacementStatus{
+				Synced:     false,
+				Schedulers: []string{"balance-hot-region-scheduler"},
+				Drift: []string{
+					"scheduler balance-hot-region-scheduler is missing",
+					`invalid store id "" of PD scheduler grant-leader-scheduler: strconv.ParseUint: parsing "": invalid syntax`,
+				},
+			},
+		},
+		{
+			name: "failed to get config",
+			update: func(tc *v1alpha1.TidbCluster) {
+				tc.Spec.PD.Schedulers = []v1alpha1.PDSchedulerSpec{{Name: "balance-hot-region-scheduler"}}
+				tc.Status.PD.Placement = v1alpha1.PDPlacementStatus{
+					Synced:     true,
+					Schedulers: []string{"balance-hot-region-scheduler"},
+					Drift:      []string{"scheduler balance-hot-region-scheduler is missing"},
+				}
+			},
+			getConfigErr:  true,
+			expectEvents:  1,
+			expectAdded:   []string{},
+			expectRemoved: []string{},
+			expectStatus: v1alpha1.PDPlacementStatus{
+				Synced:     false,
+				Schedulers: []string{"balance-hot-region-scheduler"},
+				Drift:      []string{"failed to sync: failed to get config"},
+			},
+		},
+	}
+
+	for i := range tests {
+		testFn(&tests[i], t)
+	}
+}
@@ -14,9 +14,14 @@
 package member
 
 import (
+	"bytes"
 	"fmt"
+	"reflect"
+	"sort"
 	"strconv"
+	"strings"
 
+	"github.com/BurntSushi/toml"
 	"github.com/pingcap/tidb-operator/pkg/apis/pingcap.com/v1alpha1"
 	"github.com/pingcap/tidb-operator/pkg/controller"
 	"github.com/pingcap/tidb-operator/pkg/label"
@@ -41,9 +46,11 @@ const (
 type tidbMemberManager struct {
 	setControl                   controller.StatefulSetControlInterface
 	svcControl                   controller.ServiceControlInterface
+	cmControl                    controller.ConfigMapControlInterface
 	tidbControl                  controller.TiDBControlInterface
 	setLister                    v1beta1.StatefulSetLister
 	svcLister                    corelisters.ServiceLister
+	cmLister                     corelisters.ConfigMapLister
 	podLister                    corelisters.PodLister
 	tidbUpgrader                 Upgrader
 	autoFailover                 bool
@@ -54,9 +61,11 @@ type tidbMemberManager struct {
 // NewTiDBMemberManager returns a *tidbMemberManager
 func NewTiDBMemberManager(setControl controller.StatefulSetControlInterface,
 	svcControl controller.ServiceControlInterface,
+	cmControl controller.ConfigMapControlInterface,
 	tidbControl controller.TiDBControlInterface,
 	setLister v1beta1.StatefulSetLister,
 	svcLister corelisters.ServiceLister,
+	cmLister corelisters.ConfigMapLister,
 	podLister corelisters.PodLister,
 	tidbUpgrader Upgrader,
 	autoFailover bool,
@@ -64,9 +73,11 @@ func NewTiDBMemberManager(setControl controller.StatefulSetControlInterface,
 	return &tidbMemberManager{
 		setControl:                   setControl,
 		svcControl:                   svcControl,
+		cmControl:                    cmControl,
 		tidbControl:                  tidbControl,
 		setLister:                    setLister,
 		svcLister:                    svcLister,
+		cmLister:                     cmLister,
 		podLister:                    podLister,
 		tidbUpgrader:                 tidbUpgrader,
 		autoFailover:                 autoFailover,
@@ -79,6 +90,14 @@ func (tmm *tidbMemberManager) Sync(tc *v1alpha1.TidbCluster) error {
 	ns := tc.GetNamespace()
 	tcName := tc.GetName()
 
+	names := make([]string, 0, len(tc.Spec.TiDB.Groups))
+	for _, group := range tc.Spec.TiDB.Groups {
+		names = append(names, group.Name)
+	}
+	if err := validateGroupNames(names); err != nil {
+		return fmt.Errorf("TidbCluster: [%s/%s], TiDB groups: %v", ns, tcName, err)
+	}
+
 	if !tc.TiKVIsAvailable() {
 		return controller.RequeueErrorf("TidbCluster: [%s/%s], waiting for TiKV cluster running", ns, tcName)
 	}
@@ -89,7 +108,133 @@ func (tmm *tidbMemberManager) Sync(tc *v1alpha1.TidbCluster) error {
 	}
 
 	// Sync Tidb StatefulSet
-	return tmm.syncTiDBStatefulSetForTidbCluster(tc)
+	if err := tmm.syncTiDBStatefulSetForTidbCluster(tc, nil); err != nil {
+		return err
+	}
+
+	if tc.Spec.TiDB.Service != nil {
+		if err := tmm.syncTiDBServiceForTidbCluster(tc, nil); err != nil {
+			return err
+		}
+	}
+	for i := range tc.Spec.TiDB.Groups {
+		group := &tc.Spec.TiDB.Groups[i]
+		if group.Service != nil {
+			if err := tmm.syncTiDBServiceForTidbCluster(tc, group); err != nil {
+				return err
+			}
+		}
+		if err := tmm.syncTiDBGroupConfigMap(tc, group); err != nil {
+			return err
+		}
+		if err := tmm.syncTiDBStatefulSetForTidbCluster(tc, group); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// syncTiDBGroupConfigMap syncs the ConfigMap of the TiDB group which has its own config,
+// it is generated from the TiDB ConfigMap of the cluster with the config of the group merged over the config file
+func (tmm *tidbMemberManager) syncTiDBGroupConfigMap(tc *v1alpha1.TidbCluster, group *v1alpha1.TiDBGroupSpec) error {
+	if len(group.Config) == 0 {
+		return nil
+	}
+	ns := tc.GetNamespace()
+
+	baseCM, err := tmm.cmLister.ConfigMaps(ns).Get(controller.MemberConfigMapName(tc, v1alpha1.TiDBMemberType))
+	if err != nil {
+		return err
+	}
+	newCM, err := getNewTiDBGroupConfigMap(tc, group, baseCM)
+	if err != nil {
+		return err
+	}
+
+	oldCM, err := tmm.cmLister.ConfigMaps(ns).Get(newCM.GetName())
+	if errors.IsNotFound(err) {
+		return tmm.cmControl.CreateConfigMap(tc, newCM)
+	}
+	if err != nil {
+		return err
+	}
+	if !reflect.DeepEqual(oldCM.Data, newCM.Data) {
+		cm := oldCM.DeepCopy()
+		cm.Data = newCM.Data
+		_, err = tmm.cmControl.UpdateConfigMap(tc, cm)
+		return err
+	}
+
+	return nil
+}
+
+func getNewTiDBGroupConfigMap(tc *v1alpha1.TidbCluster, group *v1alpha1.TiDBGroupSpec, baseCM *corev1.ConfigMap) (*corev1.ConfigMap, error) {
+	data := map[string]string{}
+	for k, v := range baseCM.Data {
+		data[k] = v
+	}
+	configFile, err := mergeTiDBConfig(data["config-file"], group.Config)
+	if err != nil {
+		return nil, fmt.Errorf("TidbCluster: [%s/%s], invalid config of TiDB group %s: %v", tc.GetNamespace(), tc.GetName(), group.Name, err)
+	}
+	data["config-file"] = configFile
+
+	instanceName := tc.GetLabels()[label.InstanceLabelKey]
+	return &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            tidbGroupConfigMapName(tc, group.Name),
+			Namespace:       tc.GetNamespace(),
+			Labels:          label.New().Instance(instanceName).TiDB().Group(group.Name).Labels(),
+			OwnerReferences: []metav1.OwnerReference{controller.GetOwnerRef(tc)},
+		},
+		Data: data,
+	}, nil
+}
+
+// tidbGroupConfigMapName returns the name of the ConfigMap generated for the TiDB group,
+// it carries the same suffix as the TiDB ConfigMap of the cluster, so the pods of the group are rolled along with it
+func tidbGroupConfigMapName(tc *v1alpha1.TidbCluster, group string) string {
+	suffix := strings.TrimPrefix(controller.MemberConfigMapName(tc, v1alpha1.TiDBMemberType), controller.TiDBMemberName(tc.GetName()))
+	return controller.TiDBGroupMemberName(tc.GetName(), group) + suffix
+}
+
+// mergeTiDBConfig merges the config items over the TiDB config file, the keys of the items are the dotted paths
+// of the config items and the values are TOML values
+func mergeTiDBConfig(configFile string, items map[string]string) (string, error) {
+	config := map[string]interface{}{}
+	if _, err := toml.Decode(configFile, &config); err != nil {
+		return "", err
+	}
+
+	keys := make([]string, 0, len(items))
+	for key := range items {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		item := map[string]interface{}{}
+		if _, err := toml.Decode("value = "+items[key], &item); err != nil {
+			return "", fmt.Errorf("invalid value of %s: %v", key, err)
+		}
+
+		path := strings.Split(key, ".")
+		table := config
+		for _, name := range path[:len(path)-1] {
+			sub, ok := table[name].(map[string]interface{})
+			if !ok {
+				sub = map[string]interface{}{}
+				table[name] = sub
+			}
+			table = sub
+		}
+		table[path[len(path)-1]] = item["value"]
+	}
+
+	buf := new(bytes.Buffer)
+	if err := toml.NewEncoder(buf).Encode(config); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
 }
 
 func (tmm *tidbMemberManager) syncTiDBHeadlessServiceForTidbCluster(tc *v1alpha1.TidbCluster) error {
@@ -129,12 +274,53 @@ func (tmm *tidbMemberManager) syncTiDBHeadlessServiceForTidbCluster(tc *v1alpha1
 	return nil
 }
 
-func (tmm *tidbMemberManager) syncTiDBStatefulSetForTidbCluster(tc *v1alpha1.TidbCluster) error {
+// syncTiDBServiceForTidbCluster syncs the client Service of the TiDB group, a nil group means the default TiDB client Service
+func (tmm *tidbMemberManager) syncTiDBServiceForTidbCluster(tc *v1alpha1.TidbCluster, group *v1alpha1.TiDBGroupSpec) error {
 	ns := tc.GetNamespace()
-	tcName := tc.GetName()
 
-	newTiDBSet := tmm.getNewTiDBSetForTidbCluster(tc)
-	oldTiDBSetTemp, err := tmm.setLister.StatefulSets(ns).Get(controller.TiDBMemberName(tcName))
+	newSvc := tmm.getNewTiDBServiceForTidbCluster(tc, group)
+	oldSvcTmp, err := tmm.svcLister.Services(ns).Get(newSvc.GetName())
+	if errors.IsNotFound(err) {
+		err = SetServiceLastAppliedConfigAnnotation(newSvc)
+		if err != nil {
+			return err
+		}
+		return tmm.svcControl.CreateService(tc, newSvc)
+	}
+	if err != nil {
+		return err
+	}
+	// the Service created by others, e.g. by the tidb-cluster chart, is never adopted and overwritten
+	if !metav1.IsControlledBy(oldSvcTmp, tc) {
+		return fmt.Errorf("TidbCluster: [%s/%s], Service %s/%s is not created by tidb-operator, delete it or unset the service",
+			ns, tc.GetName(), ns, newSvc.GetName())
+	}
+
+	oldSvc := oldSvcTmp.DeepCopy()
+
+	equal, err := serviceEqual(newSvc, oldSvc)
+	if err != nil {
+		return err
+	}
+	if !equal {
+		err = SetServiceLastAppliedConfigAnnotation(newSvc)
+		if err != nil {
+			return err
+		}
+		svc := updateServiceSpec(newSvc, oldSvc)
+		_, err = tmm.svcControl.UpdateService(tc, svc)
+		return err
+	}
+
+	return nil
+}
+
+// syncTiDBStatefulSetForTidbCluster syncs the StatefulSet of the TiDB group, a nil group means the default TiDB StatefulSet
+func (tmm *tidbMemberManager) syncTiDBStatefulSetForTidbCluster(tc *v1alpha1.TidbCluster, group *v1alpha1.TiDBGroupSpec) error {
+	ns := tc.GetNamespace()
+
+	newTiDBSet := tmm.getNewTiDBSetForTidbCluster(tc, group)
+	oldTiDBSetTemp, err := tmm.setLister.StatefulSets(ns).Get(newTiDBSet.GetName())
 	if errors.IsNotFound(err) {
 		err = SetLastAppliedConfigAnnotation(newTiDBSet)
 		if err != nil {
@@ -144,25 +330,45 @@ func (tmm *tidbMemberManager) syncTiDBStatefulSetForTidbCluster(tc *v1alpha1.Tid
 		if err != nil {
 			return err
 		}
-		tc.Status.TiDB.StatefulSet = &apps.StatefulSetStatus{}
+		if group == nil {
+			tc.Status.TiDB.StatefulSet = &apps.StatefulSetStatus{}
+		} else {
+			setTiDBGroupStatus(tc, group.Name, v1alpha1.TiDBGroupStatus{StatefulSet: &apps.StatefulSetStatus{}})
+		}
 		return nil
 	}
-	oldTiDBSet := oldTiDBSetTemp.DeepCopy()
 	if err != nil {
 		return err
 	}
+	oldTiDBSet := oldTiDBSetTemp.DeepCopy()
+	// the selector is immutable, the pods of the default StatefulSet created with groups keep the empty group label
+	if group == nil && oldTiDBSet.Spec.Selector != nil {
+		if value, ok := oldTiDBSet.Spec.Selector.MatchLabels[label.GroupLabelKey]; ok {
+			newTiDBSet.Spec.Template.Labels[label.GroupLabelKey] = value
+		}
+	}
 
-	if err = tmm.syncTidbClusterStatus(tc, oldTiDBSet); err != nil {
-		return err
+	var phase v1alpha1.MemberPhase
+	if group == nil {
+		if err = tmm.syncTidbClusterStatus(tc, oldTiDBSet); err != nil {
+			return err
+		}
+		phase = tc.Status.TiDB.Phase
+	} else {
+		if err = tmm.syncTiDBGroupStatus(tc, group, oldTiDBSet); err != nil {
+			return err
+		}
+		phase = tc.Status.TiDB.Groups[group.Name].Phase
 	}
 
-	if !templateEqual(newTiDBSet.Spec.Template, oldTiDBSet.Spec.Template) || tc.Status.TiDB.Phase == v1alpha1.UpgradePhase {
+	if !templateEqual(newTiDBSet.Spec.Template, oldTiDBSet.Spec.Template) || phase == v1alpha1.UpgradePhase {
 		if err := tmm.tidbUpgrader.Upgrade(tc, oldTiDBSet, newTiDBSet); err != nil {
 			return err
 		}
 	}
 
-	if tmm.autoFailover {
+	// failover marks the failed members of all the groups, each StatefulSet then scales out by its own failure members
+	if tmm.autoFailover && group == nil {
 		if tc.TiDBAllPodsStarted() && tc.TiDBAllMembersReady() && tc.Status.TiDB.FailureMembers != nil {
 			tmm.tidbFailover.Recover(tc)
 		} else if tc.TiDBAllPodsStarted() && !tc.TiDBAllMembersReady() {
@@ -217,11 +423,82 @@ func (tmm *tidbMemberManager) getNewTiDBHeadlessServiceForTidbCluster(tc *v1alph
 	}
 }
 
-func (tmm *tidbMemberManager) getNewTiDBSetForTidbCluster(tc *v1alpha1.TidbCluster) *apps.StatefulSet {
+func (tmm *tidbMemberManager) getNewTiDBServiceForTidbCluster(tc *v1alpha1.TidbCluster, group *v1alpha1.TiDBGroupSpec) *corev1.Service {
+	instanceName := tc.GetLabels()[label.InstanceLabelKey]
+	tidbLabel := label.New().Instance(instanceName).TiDB()
+	svcName := controller.TiDBMemberName(tc.GetName())
+	svcSpec := tc.Spec.TiDB.Service
+	selector := label.New().Instance(instanceName).TiDB()
+	if group != nil {
+		tidbLabel = tidbLabel.Group(group.Name)
+		selector = selector.Group(group.Name)
+		svcName = controller.TiDBGroupMemberName(tc.GetName(), group.Name)
+		svcSpec = group.Service
+	} else if len(tc.Spec.TiDB.Groups) > 0 {
+		// the default TiDB pods created by the earlier versions are not labeled with the empty group,
+		// only select them by the group when there are other groups to tell them apart from
+		selector = selector.Group("")
+	}
+
+	svc := &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            svcName,
+			Namespace:       tc.GetNamespace(),
+			Labels:          tidbLabel.Labels(),
+			OwnerReferences: []metav1.OwnerReference{controller.GetOwnerRef(tc)},
+		},
+		Spec: corev1.ServiceSpec{
+			Ports: []corev1.ServicePort{
+				{
+					Name:       "mysql-client",
+					Port:       4000,
+					TargetPort: intstr.FromInt(4000),
+					Protocol:   corev1.ProtocolTCP,
+				},
+				{
+					Name:       "status",
+					Port:       10080,
+					TargetPort: intstr.FromInt(10080),
+					Protocol:   corev1.ProtocolTCP,
+				},
+			},
+			Selector: selector.Labels(),
+		},
+	}
+	applyServiceSpec(svc, svcSpec)
+	return svc
+}
+
+func (tmm *tidbMemberManager) getNewTiDBSetForTidbCluster(tc *v1alpha1.TidbCluster, group *v1alpha1.TiDBGroupSpec) *apps.StatefulSet {
 	ns := tc.GetNamespace()
 	tcName := tc.GetName()
 	instanceName := tc.GetLabels()[label.InstanceLabelKey]
+
+	// when there are groups, the pods of the default group are labeled with the empty group, which the StatefulSets
+	// and Services of the other groups don't select, and vice versa. The label is left out without groups,
+	// so the pods of the clusters without groups are not rolled by the label
+	tidbLabel := label.New().Instance(instanceName).TiDB()
+	if len(tc.Spec.TiDB.Groups) > 0 {
+		tidbLabel = tidbLabel.Group("")
+	}
+	setName := controller.TiDBMemberName(tcName)
+	replicas := tc.TiDBRealReplicas()
+	containerSpec := tc.Spec.TiDB.ContainerSpec
 	tidbConfigMap := controller.MemberConfigMapName(tc, v1alpha1.TiDBMemberType)
+	if group != nil {
+		tidbLabel = tidbLabel.Group(group.Name)
+		setName = controller.TiDBGroupMemberName(tcName, group.Name)
+		replicas = tc.TiDBGroupRealReplicas(group)
+		if group.Requests != nil {
+			containerSpec.Requests = group.Requests
+		}
+		if group.Limits != nil {
+			containerSpec.Limits = group.Limits
+		}
+		if len(group.Config) > 0 {
+			tidbConfigMap = tidbGroupConfigMapName(tc, group.Name)
+		}
+	}
 
 	annMount, annVolume := annotationsMountVolume()
 	volMounts := []corev1.VolumeMount{
@@ -297,6 +574,12 @@ func (tmm *tidbMemberManager) getNewTiDBSetForTidbCluster(tc *v1alpha1.TidbClust
 			Value: slowLogFileEnvVal,
 		},
 	}
+	if tc.HasClusterRef() {
+		envs = append(envs, corev1.EnvVar{
+			Name:  "PD_ADDR",
+			Value: controller.PDClientAddr(tc),
+		})
+	}
 
 	containers = append(containers, corev1.Container{
 		Name:            v1alpha1.TiDBMemberType.String(),
@@ -316,7 +599,7 @@ func (tmm *tidbMemberManager) getNewTiDBSetForTidbCluster(tc *v1alpha1.TidbClust
 			},
 		},
 		VolumeMounts: volMounts,
-		Resources:    util.ResourceRequirement(tc.Spec.TiDB.ContainerSpec),
+		Resources:    util.ResourceRequirement(containerSpec),
 		Env:          envs,
 		ReadinessProbe: &corev1.Probe{
 			Handler: corev1.Handler{
@@ -329,17 +612,16 @@ func (tmm *tidbMemberManager) getNewTiDBSetForTidbCluster(tc *v1alpha1.TidbClust
 		},
 	})
 
-	tidbLabel := label.New().Instance(instanceName).TiDB()
 	podAnnotations := CombineAnnotations(controller.AnnProm(10080), tc.Spec.TiDB.Annotations)
 	tidbSet := &apps.StatefulSet{
 		ObjectMeta: metav1.ObjectMeta{
-			Name:            controller.TiDBMemberName(tcName),
+			Name:            setName,
 			Namespace:       ns,
 			Labels:          tidbLabel.Labels(),
 			OwnerReferences: []metav1.OwnerReference{controller.GetOwnerRef(tc)},
 		},
 		Spec: apps.StatefulSetSpec{
-			Replicas: func() *int32 { r := tc.TiDBRealReplicas(); return &r }(),
+			Replicas: func() *int32 { r := replicas; return &r }(),
 			Selector: tidbLabel.LabelSelector(),
 			Template: corev1.PodTemplateSpec{
 				ObjectMeta: metav1.ObjectMeta{
@@ -359,10 +641,11 @@ func (tmm *tidbMemberManager) getNewTiDBSetForTidbCluster(tc *v1alpha1.TidbClust
 			ServiceName:         controller.TiDBPeerMemberName(tcName),
 			PodManagementPolicy: apps.ParallelPodManagement,
 			UpdateStrategy: apps.StatefulSetUpdateStrategy{Type: apps.RollingUpdateStatefulSetStrategyType,
-				RollingUpdate: &apps.RollingUpdateStatefulSetStrategy{Partition: func() *int32 { r := tc.TiDBRealReplicas(); return &r }()},
+				RollingUpdate: &apps.RollingUpdateStatefulSetStrategy{Partition: func() *int32 { r := replicas; return &r }()},
 			},
 		},
 	}
+	applyPodTemplateOverride(tidbSet, v1alpha1.TiDBMemberType.String(), tc.Spec.TiDB.PodTemplate)
 	return tidbSet
 }
 
@@ -390,6 +673,7 @@ func (tmm *tidbMemberManager) syncTidbClusterStatus(tc *v1alpha1.TidbCluster, se
 		if exist {
 			newTidbMember.LastTransitionTime = oldTidbMember.LastTransitionTime
 			newTidbMember.NodeName = oldTidbMember.NodeName
+			newTidbMember.Group = oldTidbMember.Group
 		}
 		if !exist || oldTidbMember.Health != newTidbMember.Health {
 			newTidbMember.LastTransitionTime = metav1.Now()
@@ -402,6 +686,9 @@ func (tmm *tidbMemberManager) syncTidbClusterStatus(tc *v1alpha1.TidbCluster, se
 			// Update assiged node if pod exists and is scheduled
 			newTidbMember.NodeName = pod.Spec.NodeName
 		}
+		if pod != nil {
+			newTidbMember.Group = pod.Labels[label.GroupLabelKey]
+		}
 		tidbStatus[name] = newTidbMember
 	}
 	tc.Status.TiDB.Members = tidbStatus
@@ -409,6 +696,29 @@ func (tmm *tidbMemberManager) syncTidbClusterStatus(tc *v1alpha1.TidbCluster, se
 	return nil
 }
 
+// syncTiDBGroupStatus syncs the StatefulSet status of the TiDB group,
+// the members of all the groups are synced along with the default TiDB StatefulSet
+func (tmm *tidbMemberManager) syncTiDBGroupStatus(tc *v1alpha1.TidbCluster, group *v1alpha1.TiDBGroupSpec, set *apps.StatefulSet) error {
+	status := v1alpha1.TiDBGroupStatus{StatefulSet: &set.Status, Phase: v1alpha1.NormalPhase}
+	upgrading, err := tmm.tidbStatefulSetIsUpgradingFn(tmm.podLister, set, tc)
+	if err != nil {
+		return err
+	}
+	if upgrading && tc.Status.TiKV.Phase != v1alpha1.UpgradePhase && tc.Status.PD.Phase != v1alpha1.UpgradePhase {
+		status.Phase = v1alpha1.UpgradePhase
+		tc.Status.TiDB.Phase = v1alpha1.UpgradePhase
+	}
+	setTiDBGroupStatus(tc, group.Name, status)
+	return nil
+}
+
+func setTiDBGroupStatus(tc *v1alpha1.TidbCluster, name string, status v1alpha1.TiDBGroupStatus) {
+	if tc.Status.TiDB.Groups == nil {
+		tc.Status.TiDB.Groups = map[string]v1alpha1.TiDBGroupStatus{}
+	}
+	tc.Status.TiDB.Groups[name] = status
+}
+
 func tidbStatefulSetIsUpgrading(podLister corelisters.PodLister, set *apps.StatefulSet, tc *v1alpha1.TidbCluster) (bool, error) {
 	if statefulSetIsUpgrading(set) {
 		return true, nil
@@ -424,12 +734,21 @@ func tidbStatefulSetIsUpgrading(podLister corelisters.PodLister, set *apps.State
 	if err != nil {
 		return false, err
 	}
+	group := set.Labels[label.GroupLabelKey]
+	updateRevision := tc.Status.TiDB.StatefulSet.UpdateRevision
+	if group != "" {
+		updateRevision = set.Status.UpdateRevision
+	}
 	for _, pod := range tidbPods {
+		// the selector of the default TiDB StatefulSet created by the earlier versions matches the pods of all the groups
+		if pod.Labels[label.GroupLabelKey] != group {
+			continue
+		}
 		revisionHash, exist := pod.Labels[apps.ControllerRevisionHashLabelKey]
 		if !exist {
 			return false, nil
 		}
-		if revisionHash != tc.Status.TiDB.StatefulSet.UpdateRevision {
+		if revisionHash != updateRevision {
 			return true, nil
 		}
 	}
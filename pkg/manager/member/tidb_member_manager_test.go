@@ -57,10 +57,10 @@ func TestTiDBMemberManagerSyncCreate(t *testing.T) {
 
 		ns := tc.GetNamespace()
 		tcName := tc.GetName()
-		oldSpec := tc.Spec
 		if test.prepare != nil {
 			test.prepare(tc)
 		}
+		oldSpec := tc.Spec.DeepCopy()
 
 		tmm, fakeSetControl, _, _ := newFakeTiDBMemberManager()
 
@@ -75,12 +75,14 @@ func TestTiDBMemberManagerSyncCreate(t *testing.T) {
 			g.Expect(err).NotTo(HaveOccurred())
 		}
 
-		g.Expect(tc.Spec).To(Equal(oldSpec))
+		g.Expect(tc.Spec).To(Equal(*oldSpec))
 
 		tc1, err := tmm.setLister.StatefulSets(ns).Get(controller.TiDBMemberName(tcName))
 		if test.setCreated {
 			g.Expect(err).NotTo(HaveOccurred())
 			g.Expect(tc1).NotTo(Equal(nil))
+			g.Expect(tc1.Spec.Selector.MatchLabels).NotTo(HaveKey(label.GroupLabelKey))
+			g.Expect(tc1.Spec.Template.Labels).NotTo(HaveKey(label.GroupLabelKey))
 		} else {
 			expectErrIsNotFound(g, err)
 		}
@@ -103,6 +105,15 @@ func TestTiDBMemberManagerSyncCreate(t *testing.T) {
 			err:                      true,
 			setCreated:               false,
 		},
+		{
+			name: "tidb group name is reserved",
+			prepare: func(tc *v1alpha1.TidbCluster) {
+				tc.Spec.TiDB.Groups = []v1alpha1.TiDBGroupSpec{{Name: "peer", Replicas: 1}}
+			},
+			errWhenCreateStatefulSet: false,
+			err:                      true,
+			setCreated:               false,
+		},
 		{
 			name:                     "error when create statefulset",
 			prepare:                  nil,
@@ -117,6 +128,215 @@ func TestTiDBMemberManagerSyncCreate(t *testing.T) {
 	}
 }
 
+func TestTiDBMemberManagerSyncCreateGroups(t *testing.T) {
+	g := NewGomegaWithT(t)
+	tc := newTidbClusterForTiDB()
+	tc.Status.TiKV.Stores = map[string]v1alpha1.TiKVStore{
+		"tikv-0": {PodName: "tikv-0", State: v1alpha1.TiKVStateUp},
+	}
+	tc.Status.TiKV.StatefulSet = &apps.StatefulSetStatus{ReadyReplicas: 1}
+	tc.Spec.TiDB.Groups = []v1alpha1.TiDBGroupSpec{
+		{
+			Name:     "olap",
+			Replicas: 2,
+			Requests: &v1alpha1.ResourceRequirement{
+				CPU:    "8",
+				Memory: "16Gi",
+			},
+			Config:  map[string]string{"performance.max-procs": "8"},
+			Service: &v1alpha1.ServiceSpec{Type: corev1.ServiceTypeNodePort},
+		},
+		{
+			Name:     "internal",
+			Replicas: 1,
+		},
+	}
+	tc.Spec.TiDB.Service = &v1alpha1.ServiceSpec{Type: corev1.ServiceTypeNodePort}
+	ns := tc.GetNamespace()
+	tcName := tc.GetName()
+
+	tmm, _, _, _ := newFakeTiDBMemberManager()
+	cmIndexer := tmm.cmControl.(*controller.FakeConfigMapControl).CmIndexer
+	err := cmIndexer.Add(&corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-tidb", Namespace: ns},
+		Data: map[string]string{
+			"config-file":    "lease = \"45s\"\n\n[performance]\nmax-procs = 0\n",
+			"startup-script": "exec /tidb-server",
+		},
+	})
+	g.Expect(err).NotTo(HaveOccurred())
+	err = tmm.Sync(tc)
+	g.Expect(err).NotTo(HaveOccurred())
+
+	set, err := tmm.setLister.StatefulSets(ns).Get(controller.TiDBMemberName(tcName))
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(*set.Spec.Replicas).To(Equal(int32(3)))
+	g.Expect(set.Spec.Selector.MatchLabels).To(HaveKeyWithValue(label.GroupLabelKey, ""))
+	g.Expect(set.Spec.Template.Labels).To(HaveKeyWithValue(label.GroupLabelKey, ""))
+	g.Expect(set.Spec.Template.Spec.Volumes[1].ConfigMap.Name).To(Equal("test-tidb"))
+
+	svc, err := tmm.svcLister.Services(ns).Get(controller.TiDBMemberName(tcName))
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(svc.Spec.Selector).To(HaveKeyWithValue(label.GroupLabelKey, ""))
+
+	cm, err := tmm.cmLister.ConfigMaps(ns).Get("test-tidb-olap")
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(cm.Data["startup-script"]).To(Equal("exec /tidb-server"))
+	g.Expect(cm.Data["config-file"]).To(ContainSubstring("max-procs = 8"))
+	g.Expect(cm.Data["config-file"]).To(ContainSubstring(`lease = "45s"`))
+
+	groupSet, err := tmm.setLister.StatefulSets(ns).Get(controller.TiDBGroupMemberName(tcName, "olap"))
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(*groupSet.Spec.Replicas).To(Equal(int32(2)))
+	g.Expect(groupSet.Spec.Selector.MatchLabels[label.GroupLabelKey]).To(Equal("olap"))
+	g.Expect(groupSet.Spec.ServiceName).To(Equal(controller.TiDBPeerMemberName(tcName)))
+	g.Expect(groupSet.Spec.Template.Spec.Volumes[1].ConfigMap.Name).To(Equal("test-tidb-olap"))
+	cpu := groupSet.Spec.Template.Spec.Containers[0].Resources.Requests[corev1.ResourceCPU]
+	g.Expect(cpu.String()).To(Equal("8"))
+	g.Expect(tc.Status.TiDB.Groups["olap"].StatefulSet).NotTo(BeNil())
+
+	svc, err = tmm.svcLister.Services(ns).Get(controller.TiDBGroupMemberName(tcName, "olap"))
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(svc.Spec.Type).To(Equal(corev1.ServiceTypeNodePort))
+	g.Expect(svc.Spec.Selector[label.GroupLabelKey]).To(Equal("olap"))
+
+	internalSet, err := tmm.setLister.StatefulSets(ns).Get(controller.TiDBGroupMemberName(tcName, "internal"))
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(internalSet.Spec.Template.Spec.Volumes[1].ConfigMap.Name).To(Equal("test-tidb"))
+	_, err = tmm.svcLister.Services(ns).Get(controller.TiDBGroupMemberName(tcName, "internal"))
+	expectErrIsNotFound(g, err)
+	_, err = tmm.cmLister.ConfigMaps(ns).Get("test-tidb-internal")
+	expectErrIsNotFound(g, err)
+
+	// the selector is immutable, the default pods keep the empty group label after the groups are removed
+	tc.Spec.TiDB.Groups = nil
+	err = tmm.Sync(tc)
+	g.Expect(err).NotTo(HaveOccurred())
+	set, err = tmm.setLister.StatefulSets(ns).Get(controller.TiDBMemberName(tcName))
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(set.Spec.Selector.MatchLabels).To(HaveKeyWithValue(label.GroupLabelKey, ""))
+	g.Expect(set.Spec.Template.Labels).To(HaveKeyWithValue(label.GroupLabelKey, ""))
+}
+
+func TestMergeTiDBConfig(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	type testcase struct {
+		name       string
+		configFile string
+		items      map[string]string
+		expect     string
+		err        bool
+	}
+
+	tests := []testcase{
+		{
+			name:       "override and add items",
+			configFile: "lease = \"45s\"\n\n[log]\nlevel = \"info\"\n",
+			items: map[string]string{
+				"log.level":             `"warn"`,
+				"performance.max-procs": "8",
+			},
+			expect: "lease = \"45s\"\n\n[log]\n  level = \"warn\"\n\n[performance]\n  max-procs = 8\n",
+		},
+		{
+			name:       "invalid value",
+			configFile: "",
+			items:      map[string]string{"log.level": "warn"},
+			err:        true,
+		},
+		{
+			name:       "invalid config file",
+			configFile: "[log",
+			items:      map[string]string{"log.level": `"warn"`},
+			err:        true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Log(test.name)
+		configFile, err := mergeTiDBConfig(test.configFile, test.items)
+		if test.err {
+			g.Expect(err).To(HaveOccurred())
+			continue
+		}
+		g.Expect(err).NotTo(HaveOccurred())
+		g.Expect(configFile).To(Equal(test.expect))
+	}
+}
+
+func TestTiDBMemberManagerSyncService(t *testing.T) {
+	g := NewGomegaWithT(t)
+	tc := newTidbClusterForTiDB()
+	tc.Status.TiKV.Stores = map[string]v1alpha1.TiKVStore{
+		"tikv-0": {PodName: "tikv-0", State: v1alpha1.TiKVStateUp},
+	}
+	tc.Status.TiKV.StatefulSet = &apps.StatefulSetStatus{ReadyReplicas: 1}
+	ns := tc.GetNamespace()
+	tcName := tc.GetName()
+
+	tmm, _, _, _ := newFakeTiDBMemberManager()
+	err := tmm.Sync(tc)
+	g.Expect(err).NotTo(HaveOccurred())
+	_, err = tmm.svcLister.Services(ns).Get(controller.TiDBMemberName(tcName))
+	expectErrIsNotFound(g, err)
+
+	tc.Spec.TiDB.Service = &v1alpha1.ServiceSpec{
+		Type:        corev1.ServiceTypeNodePort,
+		Annotations: map[string]string{"foo": "bar"},
+		NodePorts:   map[string]int32{"mysql-client": 30000},
+	}
+	err = tmm.Sync(tc)
+	g.Expect(err).NotTo(HaveOccurred())
+	svc, err := tmm.svcLister.Services(ns).Get(controller.TiDBMemberName(tcName))
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(svc.Spec.Type).To(Equal(corev1.ServiceTypeNodePort))
+	g.Expect(svc.Annotations["foo"]).To(Equal("bar"))
+	g.Expect(svc.Spec.Ports[0].NodePort).To(Equal(int32(30000)))
+
+	tc.Spec.TiDB.Service.Annotations["foo"] = "baz"
+	tc.Spec.TiDB.Service.SessionAffinity = corev1.ServiceAffinityClientIP
+	err = tmm.Sync(tc)
+	g.Expect(err).NotTo(HaveOccurred())
+	svc, err = tmm.svcLister.Services(ns).Get(controller.TiDBMemberName(tcName))
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(svc.Annotations["foo"]).To(Equal("baz"))
+	g.Expect(svc.Spec.SessionAffinity).To(Equal(corev1.ServiceAffinityClientIP))
+}
+
+func TestTiDBMemberManagerSyncServiceNotAdopted(t *testing.T) {
+	g := NewGomegaWithT(t)
+	tc := newTidbClusterForTiDB()
+	tc.Status.TiKV.Stores = map[string]v1alpha1.TiKVStore{
+		"tikv-0": {PodName: "tikv-0", State: v1alpha1.TiKVStateUp},
+	}
+	tc.Status.TiKV.StatefulSet = &apps.StatefulSetStatus{ReadyReplicas: 1}
+	tc.Spec.TiDB.Service = &v1alpha1.ServiceSpec{Type: corev1.ServiceTypeNodePort}
+	ns := tc.GetNamespace()
+	tcName := tc.GetName()
+
+	tmm, _, _, _ := newFakeTiDBMemberManager()
+	// the Service rendered by the tidb-cluster chart
+	svcIndexer := tmm.svcControl.(*controller.FakeServiceControl).SvcIndexer
+	err := svcIndexer.Add(&corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      controller.TiDBMemberName(tcName),
+			Namespace: ns,
+			Labels:    map[string]string{"app.kubernetes.io/managed-by": "Tiller"},
+		},
+		Spec: corev1.ServiceSpec{Type: corev1.ServiceTypeLoadBalancer},
+	})
+	g.Expect(err).NotTo(HaveOccurred())
+
+	err = tmm.Sync(tc)
+	g.Expect(err).To(HaveOccurred())
+	g.Expect(err.Error()).To(ContainSubstring("is not created by tidb-operator"))
+	svc, err := tmm.svcLister.Services(ns).Get(controller.TiDBMemberName(tcName))
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(svc.Spec.Type).To(Equal(corev1.ServiceTypeLoadBalancer))
+	g.Expect(svc.Annotations).NotTo(HaveKey(LastAppliedConfigAnnotation))
+}
+
 func TestTiDBMemberManagerSyncUpdate(t *testing.T) {
 	g := NewGomegaWithT(t)
 	type testcase struct {
@@ -528,9 +748,11 @@ func newFakeTiDBMemberManager() (*tidbMemberManager, *controller.FakeStatefulSet
 	tcInformer := informers.NewSharedInformerFactory(cli, 0).Pingcap().V1alpha1().TidbClusters()
 	svcInformer := kubeinformers.NewSharedInformerFactory(kubeCli, 0).Core().V1().Services()
 	epsInformer := kubeinformers.NewSharedInformerFactory(kubeCli, 0).Core().V1().Endpoints()
+	cmInformer := kubeinformers.NewSharedInformerFactory(kubeCli, 0).Core().V1().ConfigMaps()
 	podInformer := kubeinformers.NewSharedInformerFactory(kubeCli, 0).Core().V1().Pods()
 	setControl := controller.NewFakeStatefulSetControl(setInformer, tcInformer)
 	svcControl := controller.NewFakeServiceControl(svcInformer, epsInformer, tcInformer)
+	cmControl := controller.NewFakeConfigMapControl(cmInformer)
 	tidbUpgrader := NewFakeTiDBUpgrader()
 	tidbFailover := NewFakeTiDBFailover()
 	tidbControl := controller.NewFakeTiDBControl()
@@ -538,9 +760,11 @@ func newFakeTiDBMemberManager() (*tidbMemberManager, *controller.FakeStatefulSet
 	tmm := &tidbMemberManager{
 		setControl,
 		svcControl,
+		cmControl,
 		tidbControl,
 		setInformer.Lister(),
 		svcInformer.Lister(),
+		cmInformer.Lister(),
 		podInformer.Lister(),
 		tidbUpgrader,
 		true,
@@ -56,9 +56,10 @@ func (tdu *tidbUpgrader) Upgrade(tc *v1alpha1.TidbCluster, oldSet *apps.Stateful
 		return nil
 	}
 
+	setName := oldSet.GetName()
 	setUpgradePartition(newSet, *oldSet.Spec.UpdateStrategy.RollingUpdate.Partition)
-	for i := tc.Status.TiDB.StatefulSet.Replicas - 1; i >= 0; i-- {
-		podName := tidbPodName(tcName, i)
+	for i := oldSet.Status.Replicas - 1; i >= 0; i-- {
+		podName := setPodName(setName, i)
 		pod, err := tdu.podLister.Pods(ns).Get(podName)
 		if err != nil {
 			return err
@@ -68,23 +69,23 @@ func (tdu *tidbUpgrader) Upgrade(tc *v1alpha1.TidbCluster, oldSet *apps.Stateful
 			return controller.RequeueErrorf("tidbcluster: [%s/%s]'s tidb pod: [%s] has no label: %s", ns, tcName, podName, apps.ControllerRevisionHashLabelKey)
 		}
 
-		if revision == tc.Status.TiDB.StatefulSet.UpdateRevision {
+		if revision == oldSet.Status.UpdateRevision {
 			if member, exist := tc.Status.TiDB.Members[podName]; !exist || !member.Health {
 				return controller.RequeueErrorf("tidbcluster: [%s/%s]'s tidb upgraded pod: [%s] is not ready", ns, tcName, podName)
 			}
 			continue
 		}
-		return tdu.upgradeTiDBPod(tc, i, newSet)
+		return tdu.upgradeTiDBPod(tc, setName, i, newSet)
 	}
 
 	return nil
 }
 
-func (tdu *tidbUpgrader) upgradeTiDBPod(tc *v1alpha1.TidbCluster, ordinal int32, newSet *apps.StatefulSet) error {
-	tcName := tc.GetName()
-	if tc.Spec.TiDB.Replicas > 1 {
-		if member, exist := tc.Status.TiDB.Members[tidbPodName(tcName, ordinal)]; exist && member.Health {
-			hasResign, err := tdu.tidbControl.ResignDDLOwner(tc, ordinal)
+func (tdu *tidbUpgrader) upgradeTiDBPod(tc *v1alpha1.TidbCluster, setName string, ordinal int32, newSet *apps.StatefulSet) error {
+	podName := setPodName(setName, ordinal)
+	if tc.Spec.TiDB.Replicas+tc.TiDBGroupsReplicas() > 1 {
+		if member, exist := tc.Status.TiDB.Members[podName]; exist && member.Health {
+			hasResign, err := tdu.tidbControl.ResignDDLOwner(tc, podName)
 			if (!hasResign || err != nil) && tc.Status.TiDB.ResignDDLOwnerRetryCount < MaxResignDDLOwnerCount {
 				tc.Status.TiDB.ResignDDLOwnerRetryCount++
 				return err
@@ -29,9 +29,18 @@ func NewTiKVFailover(tikvFailoverPeriod time.Duration) Failover {
 }
 
 func (tf *tikvFailover) Failover(tc *v1alpha1.TidbCluster) error {
+	// the stores of a group whose pods are still being created are not failed yet,
+	// check it before any failure store is added, which raises the replicas of the group
+	started := map[string]bool{}
+	for _, store := range tc.Status.TiKV.Stores {
+		if _, ok := started[store.Group]; !ok {
+			started[store.Group] = tc.TiKVGroupPodsStarted(store.Group)
+		}
+	}
+
 	for storeID, store := range tc.Status.TiKV.Stores {
 		podName := store.PodName
-		if store.LastTransitionTime.IsZero() {
+		if store.LastTransitionTime.IsZero() || !started[store.Group] {
 			continue
 		}
 		deadline := store.LastTransitionTime.Add(tf.tikvFailoverPeriod)
@@ -49,6 +58,7 @@ func (tf *tikvFailover) Failover(tc *v1alpha1.TidbCluster) error {
 			tc.Status.TiKV.FailureStores[storeID] = v1alpha1.TiKVFailureStore{
 				PodName: podName,
 				StoreID: store.ID,
+				Group:   store.Group,
 			}
 		}
 	}
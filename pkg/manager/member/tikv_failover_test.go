@@ -19,6 +19,7 @@ import (
 
 	. "github.com/onsi/gomega"
 	"github.com/pingcap/tidb-operator/pkg/apis/pingcap.com/v1alpha1"
+	apps "k8s.io/api/apps/v1beta1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
@@ -34,6 +35,7 @@ func TestTiKVFailoverFailover(t *testing.T) {
 	testFn := func(test *testcase, t *testing.T) {
 		t.Log(test.name)
 		tc := newTidbClusterForPD()
+		tc.Status.TiKV.StatefulSet = &apps.StatefulSetStatus{Replicas: 3}
 		test.update(tc)
 		tikvFailover := newFakeTiKVFailover()
 
@@ -138,6 +140,59 @@ func TestTiKVFailoverFailover(t *testing.T) {
 				g.Expect(len(tc.Status.TiKV.FailureStores)).To(Equal(1))
 			},
 		},
+		{
+			name: "failure store of a group",
+			update: func(tc *v1alpha1.TidbCluster) {
+				tc.Spec.TiKV.Groups = []v1alpha1.TiKVGroupSpec{{Name: "ssd", Replicas: 2}}
+				tc.Status.TiKV.Groups = map[string]v1alpha1.TiKVGroupStatus{
+					"ssd": {StatefulSet: &apps.StatefulSetStatus{Replicas: 2}},
+				}
+				tc.Status.TiKV.Stores = map[string]v1alpha1.TiKVStore{
+					"1": {
+						ID:                 "1",
+						State:              v1alpha1.TiKVStateDown,
+						PodName:            "tikv-ssd-1",
+						Group:              "ssd",
+						LastTransitionTime: metav1.Time{Time: time.Now().Add(-70 * time.Minute)},
+					},
+				}
+			},
+			err: false,
+			expectFn: func(tc *v1alpha1.TidbCluster) {
+				g.Expect(tc.Status.TiKV.FailureStores["1"].Group).To(Equal("ssd"))
+				g.Expect(tc.TiKVRealReplicas()).To(Equal(int32(3)))
+				g.Expect(tc.TiKVGroupRealReplicas(tc.TiKVGroup("ssd"))).To(Equal(int32(3)))
+			},
+		},
+		{
+			name: "pods of the group are not all started",
+			update: func(tc *v1alpha1.TidbCluster) {
+				tc.Spec.TiKV.Groups = []v1alpha1.TiKVGroupSpec{{Name: "ssd", Replicas: 2}}
+				tc.Status.TiKV.Groups = map[string]v1alpha1.TiKVGroupStatus{
+					"ssd": {StatefulSet: &apps.StatefulSetStatus{Replicas: 1}},
+				}
+				tc.Status.TiKV.Stores = map[string]v1alpha1.TiKVStore{
+					"1": {
+						ID:                 "1",
+						State:              v1alpha1.TiKVStateDown,
+						PodName:            "tikv-ssd-1",
+						Group:              "ssd",
+						LastTransitionTime: metav1.Time{Time: time.Now().Add(-70 * time.Minute)},
+					},
+					"2": {
+						ID:                 "2",
+						State:              v1alpha1.TiKVStateDown,
+						PodName:            "tikv-2",
+						LastTransitionTime: metav1.Time{Time: time.Now().Add(-70 * time.Minute)},
+					},
+				}
+			},
+			err: false,
+			expectFn: func(tc *v1alpha1.TidbCluster) {
+				g.Expect(len(tc.Status.TiKV.FailureStores)).To(Equal(1))
+				g.Expect(tc.Status.TiKV.FailureStores["2"].Group).To(Equal(""))
+			},
+		},
 	}
 	for i := range tests {
 		testFn(&tests[i], t)
@@ -47,7 +47,7 @@ type tikvMemberManager struct {
 	nodeLister                   corelisters.NodeLister
 	autoFailover                 bool
 	tikvFailover                 Failover
-	tikvScaler                   Scaler
+	tikvScaler                   TiKVScaler
 	tikvUpgrader                 Upgrader
 	tikvStatefulSetIsUpgradingFn func(corelisters.PodLister, controller.PDControlInterface, *apps.StatefulSet, *v1alpha1.TidbCluster) (bool, error)
 }
@@ -62,7 +62,7 @@ func NewTiKVMemberManager(pdControl controller.PDControlInterface,
 	nodeLister corelisters.NodeLister,
 	autoFailover bool,
 	tikvFailover Failover,
-	tikvScaler Scaler,
+	tikvScaler TiKVScaler,
 	tikvUpgrader Upgrader) manager.Manager {
 	kvmm := tikvMemberManager{
 		pdControl:    pdControl,
@@ -95,6 +95,14 @@ func (tkmm *tikvMemberManager) Sync(tc *v1alpha1.TidbCluster) error {
 	ns := tc.GetNamespace()
 	tcName := tc.GetName()
 
+	names := make([]string, 0, len(tc.Spec.TiKV.Groups))
+	for _, group := range tc.Spec.TiKV.Groups {
+		names = append(names, group.Name)
+	}
+	if err := validateGroupNames(names); err != nil {
+		return fmt.Errorf("TidbCluster: [%s/%s], TiKV groups: %v", ns, tcName, err)
+	}
+
 	if !tc.PDIsAvailable() {
 		return controller.RequeueErrorf("TidbCluster: [%s/%s], waiting for PD cluster running", ns, tcName)
 	}
@@ -113,7 +121,15 @@ func (tkmm *tikvMemberManager) Sync(tc *v1alpha1.TidbCluster) error {
 			return err
 		}
 	}
-	return tkmm.syncStatefulSetForTidbCluster(tc)
+	if err := tkmm.syncStatefulSetForTidbCluster(tc, nil); err != nil {
+		return err
+	}
+	for i := range tc.Spec.TiKV.Groups {
+		if err := tkmm.syncStatefulSetForTidbCluster(tc, &tc.Spec.TiKV.Groups[i]); err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
 func (tkmm *tikvMemberManager) syncServiceForTidbCluster(tc *v1alpha1.TidbCluster, svcConfig SvcConfig) error {
@@ -155,16 +171,16 @@ func (tkmm *tikvMemberManager) syncServiceForTidbCluster(tc *v1alpha1.TidbCluste
 	return nil
 }
 
-func (tkmm *tikvMemberManager) syncStatefulSetForTidbCluster(tc *v1alpha1.TidbCluster) error {
+// syncStatefulSetForTidbCluster syncs the StatefulSet of the TiKV group, a nil group means the default TiKV StatefulSet
+func (tkmm *tikvMemberManager) syncStatefulSetForTidbCluster(tc *v1alpha1.TidbCluster, group *v1alpha1.TiKVGroupSpec) error {
 	ns := tc.GetNamespace()
-	tcName := tc.GetName()
 
-	newSet, err := tkmm.getNewSetForTidbCluster(tc)
+	newSet, err := tkmm.getNewSetForTidbCluster(tc, group)
 	if err != nil {
 		return err
 	}
 
-	oldSetTmp, err := tkmm.setLister.StatefulSets(ns).Get(controller.TiKVMemberName(tcName))
+	oldSetTmp, err := tkmm.setLister.StatefulSets(ns).Get(newSet.GetName())
 	if err != nil && !errors.IsNotFound(err) {
 		return err
 	}
@@ -177,26 +193,46 @@ func (tkmm *tikvMemberManager) syncStatefulSetForTidbCluster(tc *v1alpha1.TidbCl
 		if err != nil {
 			return err
 		}
-		tc.Status.TiKV.StatefulSet = &apps.StatefulSetStatus{}
+		if group == nil {
+			tc.Status.TiKV.StatefulSet = &apps.StatefulSetStatus{}
+		} else {
+			setTiKVGroupStatus(tc, group.Name, v1alpha1.TiKVGroupStatus{StatefulSet: &apps.StatefulSetStatus{}})
+		}
 		return nil
 	}
 
 	oldSet := oldSetTmp.DeepCopy()
 
-	if err := tkmm.syncTidbClusterStatus(tc, oldSet); err != nil {
-		return err
-	}
+	var phase v1alpha1.MemberPhase
+	if group == nil {
+		if err := tkmm.syncTidbClusterStatus(tc, oldSet); err != nil {
+			return err
+		}
 
-	if _, err := tkmm.setStoreLabelsForTiKV(tc); err != nil {
-		return err
+		if _, err := tkmm.setStoreLabelsForTiKV(tc); err != nil {
+			return err
+		}
+		phase = tc.Status.TiKV.Phase
+	} else {
+		if err := tkmm.syncTiKVGroupStatus(tc, group, oldSet); err != nil {
+			return err
+		}
+		phase = tc.Status.TiKV.Groups[group.Name].Phase
 	}
 
-	if !templateEqual(newSet.Spec.Template, oldSet.Spec.Template) || tc.Status.TiKV.Phase == v1alpha1.UpgradePhase {
+	if !templateEqual(newSet.Spec.Template, oldSet.Spec.Template) || phase == v1alpha1.UpgradePhase {
 		if err := tkmm.tikvUpgrader.Upgrade(tc, oldSet, newSet); err != nil {
 			return err
 		}
 	}
 
+	// the replicas may be raised again while a store is leaving
+	if *newSet.Spec.Replicas >= *oldSet.Spec.Replicas {
+		if err := tkmm.tikvScaler.CancelScaleIn(tc, oldSet, newSet); err != nil {
+			return err
+		}
+	}
+
 	if *newSet.Spec.Replicas > *oldSet.Spec.Replicas {
 		if err := tkmm.tikvScaler.ScaleOut(tc, oldSet, newSet); err != nil {
 			return err
@@ -209,8 +245,10 @@ func (tkmm *tikvMemberManager) syncStatefulSetForTidbCluster(tc *v1alpha1.TidbCl
 		}
 	}
 
-	if tkmm.autoFailover {
-		if tc.TiKVAllPodsStarted() && !tc.TiKVAllStoresReady() {
+	// the stores of all the groups are synced along with the default TiKV StatefulSet, so is the failover,
+	// each failure store is replaced by a new pod in the StatefulSet of its own group
+	if tkmm.autoFailover && group == nil {
+		if !tc.TiKVAllStoresReady() {
 			if err := tkmm.tikvFailover.Failover(tc); err != nil {
 				return err
 			}
@@ -267,9 +305,37 @@ func (tkmm *tikvMemberManager) getNewServiceForTidbCluster(tc *v1alpha1.TidbClus
 	return &svc
 }
 
-func (tkmm *tikvMemberManager) getNewSetForTidbCluster(tc *v1alpha1.TidbCluster) (*apps.StatefulSet, error) {
+func (tkmm *tikvMemberManager) getNewSetForTidbCluster(tc *v1alpha1.TidbCluster, group *v1alpha1.TiKVGroupSpec) (*apps.StatefulSet, error) {
 	ns := tc.GetNamespace()
 	tcName := tc.GetName()
+
+	tikvLabel := tkmm.labelTiKV(tc)
+	setName := controller.TiKVMemberName(tcName)
+	replicas := tc.TiKVRealReplicas()
+	containerSpec := tc.Spec.TiKV.ContainerSpec
+	storageClassName := tc.Spec.TiKV.StorageClassName
+	nodeSelector := tc.Spec.TiKV.NodeSelector
+	if group != nil {
+		tikvLabel = tikvLabel.Group(group.Name)
+		setName = controller.TiKVGroupMemberName(tcName, group.Name)
+		replicas = tc.TiKVGroupRealReplicas(group)
+		if group.Requests != nil {
+			containerSpec.Requests = group.Requests
+		}
+		if group.Limits != nil {
+			containerSpec.Limits = group.Limits
+		}
+		if group.StorageClassName != "" {
+			storageClassName = group.StorageClassName
+		}
+		if group.NodeSelector != nil {
+			nodeSelector = group.NodeSelector
+		}
+	}
+	if storageClassName == "" {
+		storageClassName = controller.DefaultStorageClassName
+	}
+
 	tikvConfigMap := controller.MemberConfigMapName(tc, v1alpha1.TiKVMemberType)
 	annMount, annVolume := annotationsMountVolume()
 	volMounts := []corev1.VolumeMount{
@@ -301,22 +367,55 @@ func (tkmm *tikvMemberManager) getNewSetForTidbCluster(tc *v1alpha1.TidbCluster)
 	var q resource.Quantity
 	var err error
 
-	if tc.Spec.TiKV.Requests != nil {
-		size := tc.Spec.TiKV.Requests.Storage
+	if containerSpec.Requests != nil {
+		size := containerSpec.Requests.Storage
 		q, err = resource.ParseQuantity(size)
 		if err != nil {
 			return nil, fmt.Errorf("cant' get storage size: %s for TidbCluster: %s/%s, %v", size, ns, tcName, err)
 		}
 	}
 
-	tikvLabel := tkmm.labelTiKV(tc)
-	setName := controller.TiKVMemberName(tcName)
 	podAnnotations := CombineAnnotations(controller.AnnProm(20180), tc.Spec.TiKV.Annotations)
-	capacity := controller.TiKVCapacity(tc.Spec.TiKV.Limits)
+	capacity := controller.TiKVCapacity(containerSpec.Limits)
 	headlessSvcName := controller.TiKVPeerMemberName(tcName)
-	storageClassName := tc.Spec.TiKV.StorageClassName
-	if storageClassName == "" {
-		storageClassName = controller.DefaultStorageClassName
+
+	envs := []corev1.EnvVar{
+		{
+			Name: "NAMESPACE",
+			ValueFrom: &corev1.EnvVarSource{
+				FieldRef: &corev1.ObjectFieldSelector{
+					FieldPath: "metadata.namespace",
+				},
+			},
+		},
+		{
+			Name:  "CLUSTER_NAME",
+			Value: tcName,
+		},
+		{
+			Name:  "HEADLESS_SERVICE_NAME",
+			Value: headlessSvcName,
+		},
+		{
+			Name:  "CAPACITY",
+			Value: capacity,
+		},
+		{
+			Name:  "TZ",
+			Value: tc.Spec.Timezone,
+		},
+	}
+	if tc.HasClusterRef() {
+		envs = append(envs, corev1.EnvVar{
+			Name:  "PD_ADDR",
+			Value: controller.PDClientAddr(tc),
+		})
+	}
+
+	// the pods of the groups are labeled with their group, which the default TiKV StatefulSet must not select
+	selector := tikvLabel.DefaultGroupLabelSelector()
+	if group != nil {
+		selector = tikvLabel.LabelSelector()
 	}
 
 	tikvset := &apps.StatefulSet{
@@ -327,8 +426,8 @@ func (tkmm *tikvMemberManager) getNewSetForTidbCluster(tc *v1alpha1.TidbCluster)
 			OwnerReferences: []metav1.OwnerReference{controller.GetOwnerRef(tc)},
 		},
 		Spec: apps.StatefulSetSpec{
-			Replicas: func() *int32 { r := tc.TiKVRealReplicas(); return &r }(),
-			Selector: tikvLabel.LabelSelector(),
+			Replicas: func() *int32 { r := replicas; return &r }(),
+			Selector: selector,
 			Template: corev1.PodTemplateSpec{
 				ObjectMeta: metav1.ObjectMeta{
 					Labels:      tikvLabel.Labels(),
@@ -337,7 +436,7 @@ func (tkmm *tikvMemberManager) getNewSetForTidbCluster(tc *v1alpha1.TidbCluster)
 				Spec: corev1.PodSpec{
 					SchedulerName: tc.Spec.SchedulerName,
 					Affinity:      tc.Spec.TiKV.Affinity,
-					NodeSelector:  tc.Spec.TiKV.NodeSelector,
+					NodeSelector:  nodeSelector,
 					Containers: []corev1.Container{
 						{
 							Name:            v1alpha1.TiKVMemberType.String(),
@@ -355,33 +454,8 @@ func (tkmm *tikvMemberManager) getNewSetForTidbCluster(tc *v1alpha1.TidbCluster)
 								},
 							},
 							VolumeMounts: volMounts,
-							Resources:    util.ResourceRequirement(tc.Spec.TiKV.ContainerSpec),
-							Env: []corev1.EnvVar{
-								{
-									Name: "NAMESPACE",
-									ValueFrom: &corev1.EnvVarSource{
-										FieldRef: &corev1.ObjectFieldSelector{
-											FieldPath: "metadata.namespace",
-										},
-									},
-								},
-								{
-									Name:  "CLUSTER_NAME",
-									Value: tcName,
-								},
-								{
-									Name:  "HEADLESS_SERVICE_NAME",
-									Value: headlessSvcName,
-								},
-								{
-									Name:  "CAPACITY",
-									Value: capacity,
-								},
-								{
-									Name:  "TZ",
-									Value: tc.Spec.Timezone,
-								},
-							},
+							Resources:    util.ResourceRequirement(containerSpec),
+							Env:          envs,
 						},
 					},
 					RestartPolicy: corev1.RestartPolicyAlways,
@@ -397,11 +471,12 @@ func (tkmm *tikvMemberManager) getNewSetForTidbCluster(tc *v1alpha1.TidbCluster)
 			UpdateStrategy: apps.StatefulSetUpdateStrategy{
 				Type: apps.RollingUpdateStatefulSetStrategyType,
 				RollingUpdate: &apps.RollingUpdateStatefulSetStrategy{
-					Partition: func() *int32 { r := tc.TiKVRealReplicas(); return &r }(),
+					Partition: func() *int32 { r := replicas; return &r }(),
 				},
 			},
 		},
 	}
+	applyPodTemplateOverride(tikvset, v1alpha1.TiKVMemberType.String(), tc.Spec.TiKV.PodTemplate)
 	return tikvset, nil
 }
 
@@ -452,7 +527,7 @@ func (tkmm *tikvMemberManager) syncTidbClusterStatus(tc *v1alpha1.TidbCluster, s
 	}
 
 	for _, store := range storesInfo.Stores {
-		status := tkmm.getTiKVStore(store)
+		status := tkmm.getTiKVStore(tc, store)
 		if status == nil {
 			continue
 		}
@@ -467,10 +542,23 @@ func (tkmm *tikvMemberManager) syncTidbClusterStatus(tc *v1alpha1.TidbCluster, s
 		oldStore, exist := previousStores[status.ID]
 		if exist {
 			status.LastTransitionTime = oldStore.LastTransitionTime
+			status.NodeName = oldStore.NodeName
+			status.Group = oldStore.Group
 		}
 		if !exist || status.State != oldStore.State {
 			status.LastTransitionTime = metav1.Now()
 		}
+		pod, err := tkmm.podLister.Pods(tc.GetNamespace()).Get(status.PodName)
+		if err != nil && !errors.IsNotFound(err) {
+			return err
+		}
+		if pod != nil && pod.Spec.NodeName != "" {
+			// Update assiged node if pod exists and is scheduled
+			status.NodeName = pod.Spec.NodeName
+		}
+		if pod != nil {
+			status.Group = pod.Labels[label.GroupLabelKey]
+		}
 
 		stores[status.ID] = *status
 	}
@@ -482,7 +570,7 @@ func (tkmm *tikvMemberManager) syncTidbClusterStatus(tc *v1alpha1.TidbCluster, s
 		return err
 	}
 	for _, store := range tombstoneStoresInfo.Stores {
-		status := tkmm.getTiKVStore(store)
+		status := tkmm.getTiKVStore(tc, store)
 		if status == nil {
 			continue
 		}
@@ -495,24 +583,62 @@ func (tkmm *tikvMemberManager) syncTidbClusterStatus(tc *v1alpha1.TidbCluster, s
 	return nil
 }
 
-func (tkmm *tikvMemberManager) getTiKVStore(store *controller.StoreInfo) *v1alpha1.TiKVStore {
+// syncTiKVGroupStatus syncs the StatefulSet status of the TiKV group,
+// the stores of all the groups are synced along with the default TiKV StatefulSet
+func (tkmm *tikvMemberManager) syncTiKVGroupStatus(tc *v1alpha1.TidbCluster, group *v1alpha1.TiKVGroupSpec, set *apps.StatefulSet) error {
+	status := v1alpha1.TiKVGroupStatus{StatefulSet: &set.Status, Phase: v1alpha1.NormalPhase}
+	upgrading, err := tkmm.tikvStatefulSetIsUpgradingFn(tkmm.podLister, tkmm.pdControl, set, tc)
+	if err != nil {
+		return err
+	}
+	if upgrading && tc.Status.PD.Phase != v1alpha1.UpgradePhase {
+		status.Phase = v1alpha1.UpgradePhase
+		tc.Status.TiKV.Phase = v1alpha1.UpgradePhase
+	}
+	setTiKVGroupStatus(tc, group.Name, status)
+	return nil
+}
+
+func setTiKVGroupStatus(tc *v1alpha1.TidbCluster, name string, status v1alpha1.TiKVGroupStatus) {
+	if tc.Status.TiKV.Groups == nil {
+		tc.Status.TiKV.Groups = map[string]v1alpha1.TiKVGroupStatus{}
+	}
+	tc.Status.TiKV.Groups[name] = status
+}
+
+func (tkmm *tikvMemberManager) getTiKVStore(tc *v1alpha1.TidbCluster, store *controller.StoreInfo) *v1alpha1.TiKVStore {
 	if store.Store == nil || store.Status == nil {
 		return nil
 	}
 	storeID := fmt.Sprintf("%d", store.Store.GetId())
 	ip := strings.Split(store.Store.GetAddress(), ":")[0]
 	podName := strings.Split(ip, ".")[0]
+	if !storeBelongsToTidbCluster(tc, ip) {
+		return nil
+	}
 
 	return &v1alpha1.TiKVStore{
 		ID:                storeID,
 		PodName:           podName,
 		IP:                ip,
 		LeaderCount:       int32(store.Status.LeaderCount),
+		RegionCount:       int32(store.Status.RegionCount),
 		State:             store.Store.StateName,
 		LastHeartbeatTime: metav1.Time{Time: store.Status.LastHeartbeatTS},
 	}
 }
 
+// storeBelongsToTidbCluster checks whether the store advertised by the given address is run by the TidbCluster,
+// the PD shared by several TidbClusters returns the stores of all of them
+func storeBelongsToTidbCluster(tc *v1alpha1.TidbCluster, addr string) bool {
+	// the advertise address is <pod>.<tikv-peer-service>.<namespace>.svc
+	parts := strings.Split(addr, ".")
+	if len(parts) < 3 {
+		return true
+	}
+	return parts[1] == controller.TiKVPeerMemberName(tc.GetName()) && parts[2] == tc.GetNamespace()
+}
+
 func (tkmm *tikvMemberManager) setStoreLabelsForTiKV(tc *v1alpha1.TidbCluster) (int, error) {
 	ns := tc.GetNamespace()
 	// for unit test
@@ -525,7 +651,7 @@ func (tkmm *tikvMemberManager) setStoreLabelsForTiKV(tc *v1alpha1.TidbCluster) (
 	}
 
 	for _, store := range storesInfo.Stores {
-		status := tkmm.getTiKVStore(store)
+		status := tkmm.getTiKVStore(tc, store)
 		if status == nil {
 			continue
 		}
@@ -537,11 +663,16 @@ func (tkmm *tikvMemberManager) setStoreLabelsForTiKV(tc *v1alpha1.TidbCluster) (
 		}
 
 		nodeName := pod.Spec.NodeName
-		ls, err := tkmm.getNodeLabels(nodeName)
+		ls, err := tkmm.getNodeLabels(tc, nodeName)
 		if err != nil {
 			glog.Warningf("node: [%s] has no node labels, skipping set store labels for Pod: [%s/%s]", nodeName, ns, podName)
 			continue
 		}
+		if group := tc.TiKVGroup(pod.Labels[label.GroupLabelKey]); group != nil {
+			for k, v := range group.StoreLabels {
+				ls[k] = v
+			}
+		}
 
 		if !tkmm.storeLabelsEqualNodeLabels(store.Store.Labels, ls) {
 			set, err := pdCli.SetStoreLabels(store.Store.Id, ls)
@@ -559,7 +690,7 @@ func (tkmm *tikvMemberManager) setStoreLabelsForTiKV(tc *v1alpha1.TidbCluster) (
 	return setCount, nil
 }
 
-func (tkmm *tikvMemberManager) getNodeLabels(nodeName string) (map[string]string, error) {
+func (tkmm *tikvMemberManager) getNodeLabels(tc *v1alpha1.TidbCluster, nodeName string) (map[string]string, error) {
 	node, err := tkmm.nodeLister.Get(nodeName)
 	if err != nil {
 		return nil, err
@@ -578,6 +709,11 @@ func (tkmm *tikvMemberManager) getNodeLabels(nodeName string) (map[string]string
 		if host, found := ls[apis.LabelHostname]; found {
 			labels["host"] = host
 		}
+		// the HA topology domain of the pod is also the location of the store,
+		// so that PD places the region replicas the same way as the pods are spread
+		if domain, found := ls[tc.HATopologyKey()]; found {
+			labels[tc.HATopologyStoreLabel()] = domain
+		}
 		return labels, nil
 	}
 	return nil, fmt.Errorf("labels not found")
@@ -602,7 +738,14 @@ func tikvStatefulSetIsUpgrading(podLister corelisters.PodLister, pdControl contr
 		return true, nil
 	}
 	instanceName := tc.GetLabels()[label.InstanceLabelKey]
-	selector, err := label.New().Instance(instanceName).TiKV().Selector()
+	tikvLabel := label.New().Instance(instanceName).TiKV()
+	updateRevision := tc.Status.TiKV.StatefulSet.UpdateRevision
+	// the selector of the default TiKV StatefulSet created by the earlier versions also matches the pods of the groups
+	selector, err := tikvLabel.DefaultGroupSelector()
+	if group := set.Labels[label.GroupLabelKey]; group != "" {
+		updateRevision = set.Status.UpdateRevision
+		selector, err = tikvLabel.Group(group).Selector()
+	}
 	if err != nil {
 		return false, err
 	}
@@ -615,7 +758,7 @@ func tikvStatefulSetIsUpgrading(podLister corelisters.PodLister, pdControl contr
 		if !exist {
 			return false, nil
 		}
-		if revisionHash != tc.Status.TiKV.StatefulSet.UpdateRevision {
+		if revisionHash != updateRevision {
 			return true, nil
 		}
 	}
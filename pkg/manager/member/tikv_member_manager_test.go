@@ -65,10 +65,10 @@ func TestTiKVMemberManagerSyncCreate(t *testing.T) {
 
 		ns := tc.Namespace
 		tcName := tc.Name
-		oldSpec := tc.Spec
 		if test.prepare != nil {
 			test.prepare(tc)
 		}
+		oldSpec := tc.Spec.DeepCopy()
 
 		tkmm, fakeSetControl, fakeSvcControl, pdClient, _, _ := newFakeTiKVMemberManager(tc)
 
@@ -102,7 +102,7 @@ func TestTiKVMemberManagerSyncCreate(t *testing.T) {
 			g.Expect(err).NotTo(HaveOccurred())
 		}
 
-		g.Expect(tc.Spec).To(Equal(oldSpec))
+		g.Expect(tc.Spec).To(Equal(*oldSpec))
 
 		svc, err := tkmm.svcLister.Services(ns).Get(controller.TiKVPeerMemberName(tcName))
 		if test.tikvPeerSvcCreated {
@@ -159,6 +159,19 @@ func TestTiKVMemberManagerSyncCreate(t *testing.T) {
 			pdStores:                     &controller.StoresInfo{Count: 0, Stores: []*controller.StoreInfo{}},
 			tombstoneStores:              &controller.StoresInfo{Count: 0, Stores: []*controller.StoreInfo{}},
 		},
+		{
+			name: "tikv group name is duplicated",
+			prepare: func(tc *v1alpha1.TidbCluster) {
+				tc.Spec.TiKV.Groups = []v1alpha1.TiKVGroupSpec{{Name: "ssd", Replicas: 1}, {Name: "ssd", Replicas: 2}}
+			},
+			errWhenCreateStatefulSet:     false,
+			errWhenCreateTiKVPeerService: false,
+			err:                          true,
+			tikvPeerSvcCreated:           false,
+			setCreated:                   false,
+			pdStores:                     &controller.StoresInfo{Count: 0, Stores: []*controller.StoreInfo{}},
+			tombstoneStores:              &controller.StoresInfo{Count: 0, Stores: []*controller.StoreInfo{}},
+		},
 		{
 			name:                         "error when create statefulset",
 			prepare:                      nil,
@@ -188,6 +201,54 @@ func TestTiKVMemberManagerSyncCreate(t *testing.T) {
 	}
 }
 
+func TestTiKVMemberManagerSyncCreateGroups(t *testing.T) {
+	g := NewGomegaWithT(t)
+	tc := newTidbClusterForPD()
+	tc.Status.PD.Members = map[string]v1alpha1.PDMember{
+		"pd-0": {Name: "pd-0", Health: true},
+		"pd-1": {Name: "pd-1", Health: true},
+		"pd-2": {Name: "pd-2", Health: true},
+	}
+	tc.Status.PD.StatefulSet = &apps.StatefulSetStatus{ReadyReplicas: 3}
+	tc.Spec.TiKV.Groups = []v1alpha1.TiKVGroupSpec{
+		{
+			Name:             "ssd",
+			Replicas:         2,
+			StorageClassName: "local-ssd",
+			NodeSelector:     map[string]string{"disk": "ssd"},
+			Requests: &v1alpha1.ResourceRequirement{
+				CPU:     "4",
+				Memory:  "8Gi",
+				Storage: "500Gi",
+			},
+			StoreLabels: map[string]string{"disk": "ssd"},
+		},
+	}
+	ns := tc.Namespace
+	tcName := tc.Name
+
+	tkmm, _, _, _, _, _ := newFakeTiKVMemberManager(tc)
+	err := tkmm.Sync(tc)
+	g.Expect(err).NotTo(HaveOccurred())
+
+	set, err := tkmm.setLister.StatefulSets(ns).Get(controller.TiKVMemberName(tcName))
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(*set.Spec.Replicas).To(Equal(int32(3)))
+	g.Expect(*set.Spec.VolumeClaimTemplates[0].Spec.StorageClassName).To(Equal("my-storage-class"))
+
+	groupSet, err := tkmm.setLister.StatefulSets(ns).Get(controller.TiKVGroupMemberName(tcName, "ssd"))
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(*groupSet.Spec.Replicas).To(Equal(int32(2)))
+	g.Expect(groupSet.Spec.Template.Labels[label.GroupLabelKey]).To(Equal("ssd"))
+	g.Expect(groupSet.Spec.Selector.MatchLabels[label.GroupLabelKey]).To(Equal("ssd"))
+	g.Expect(groupSet.Spec.Template.Spec.NodeSelector).To(Equal(map[string]string{"disk": "ssd"}))
+	g.Expect(groupSet.Spec.ServiceName).To(Equal(controller.TiKVPeerMemberName(tcName)))
+	g.Expect(*groupSet.Spec.VolumeClaimTemplates[0].Spec.StorageClassName).To(Equal("local-ssd"))
+	storage := groupSet.Spec.VolumeClaimTemplates[0].Spec.Resources.Requests[corev1.ResourceStorage]
+	g.Expect(storage.String()).To(Equal("500Gi"))
+	g.Expect(tc.Status.TiKV.Groups["ssd"].StatefulSet).NotTo(BeNil())
+}
+
 func TestTiKVMemberManagerSyncUpdate(t *testing.T) {
 	g := NewGomegaWithT(t)
 	type testcase struct {
@@ -1122,6 +1183,46 @@ func TestTiKVMemberManagerSyncTidbClusterStatus(t *testing.T) {
 				g.Expect(tc.Status.TiKV.Synced).To(BeTrue())
 			},
 		},
+		{
+			name: "keep the node name of the store whose pod is gone",
+			updateTC: func(tc *v1alpha1.TidbCluster) {
+				tc.Status.TiKV.Stores = map[string]v1alpha1.TiKVStore{}
+				tc.Status.TiKV.Stores["333"] = v1alpha1.TiKVStore{
+					State:    v1alpha1.TiKVStateUp,
+					NodeName: "node-1",
+				}
+			},
+			upgradingFn: func(lister corelisters.PodLister, controlInterface controller.PDControlInterface, set *apps.StatefulSet, cluster *v1alpha1.TidbCluster) (bool, error) {
+				return false, nil
+			},
+			errWhenGetStores: false,
+			storeInfo: &controller.StoresInfo{
+				Stores: []*controller.StoreInfo{
+					{
+						Store: &controller.MetaStore{
+							Store: &metapb.Store{
+								Id:      333,
+								Address: "pod-1.ns-1",
+							},
+							StateName: "Down",
+						},
+						Status: &controller.StoreStatus{
+							LastHeartbeatTS: time.Now(),
+						},
+					},
+				},
+			},
+			errWhenGetTombstoneStores: false,
+			tombstoneStoreInfo: &controller.StoresInfo{
+				Stores: []*controller.StoreInfo{},
+			},
+			errExpectFn: errExpectNil,
+			tcExpectFn: func(g *GomegaWithT, tc *v1alpha1.TidbCluster) {
+				g.Expect(len(tc.Status.TiKV.Stores)).To(Equal(1))
+				g.Expect(tc.Status.TiKV.Stores["333"].NodeName).To(Equal("node-1"))
+				g.Expect(tc.Status.TiKV.Synced).To(BeTrue())
+			},
+		},
 		{
 			name: "set LastTransitionTime first time",
 			updateTC: func(tc *v1alpha1.TidbCluster) {
@@ -1331,6 +1432,54 @@ func TestTiKVMemberManagerSyncTidbClusterStatus(t *testing.T) {
 				g.Expect(tc.Status.TiKV.Synced).To(BeTrue())
 			},
 		},
+		{
+			name: "pd is shared, stores of other clusters are skipped",
+			updateTC: func(tc *v1alpha1.TidbCluster) {
+				tc.Spec.Cluster = &v1alpha1.TidbClusterRef{Name: "shared"}
+			},
+			upgradingFn: func(lister corelisters.PodLister, controlInterface controller.PDControlInterface, set *apps.StatefulSet, cluster *v1alpha1.TidbCluster) (bool, error) {
+				return false, nil
+			},
+			errWhenGetStores: false,
+			storeInfo: &controller.StoresInfo{
+				Stores: []*controller.StoreInfo{
+					{
+						Store: &controller.MetaStore{
+							Store: &metapb.Store{
+								Id:      333,
+								Address: "test-tikv-0.test-tikv-peer.default.svc:20160",
+							},
+							StateName: "Up",
+						},
+						Status: &controller.StoreStatus{
+							LastHeartbeatTS: time.Now(),
+						},
+					},
+					{
+						Store: &controller.MetaStore{
+							Store: &metapb.Store{
+								Id:      334,
+								Address: "shared-tikv-0.shared-tikv-peer.default.svc:20160",
+							},
+							StateName: "Up",
+						},
+						Status: &controller.StoreStatus{
+							LastHeartbeatTS: time.Now(),
+						},
+					},
+				},
+			},
+			errWhenGetTombstoneStores: false,
+			tombstoneStoreInfo: &controller.StoresInfo{
+				Stores: []*controller.StoreInfo{},
+			},
+			errExpectFn: errExpectNil,
+			tcExpectFn: func(g *GomegaWithT, tc *v1alpha1.TidbCluster) {
+				g.Expect(len(tc.Status.TiKV.Stores)).To(Equal(1))
+				g.Expect(tc.Status.TiKV.Stores["333"].PodName).To(Equal("test-tikv-0"))
+				g.Expect(tc.Status.TiKV.Synced).To(BeTrue())
+			},
+		},
 	}
 
 	for i := range tests {
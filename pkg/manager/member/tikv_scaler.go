@@ -23,20 +23,41 @@ import (
 	"github.com/pingcap/tidb-operator/pkg/controller"
 	"github.com/pingcap/tidb-operator/pkg/label"
 	apps "k8s.io/api/apps/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	corelisters "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/record"
 )
 
+const (
+	// tikvScaleInRefusedReason is the reason of event that the scale-in of TiKV is refused because it's unsafe
+	tikvScaleInRefusedReason = "ScaleInRefused"
+	// tikvScaleInCanceledReason is the reason of event that the leaving store is brought back up
+	tikvScaleInCanceledReason = "ScaleInCanceled"
+	// defaultLowSpaceRatio is the default low-space-ratio of PD, PD doesn't move regions to the stores using more space than it
+	defaultLowSpaceRatio = 0.8
+)
+
+// TiKVScaler implements the logic for scaling TiKV, it can also cancel an in-progress scale-in
+type TiKVScaler interface {
+	Scaler
+	// CancelScaleIn brings the leaving stores which are kept by the new replicas back up
+	CancelScaleIn(*v1alpha1.TidbCluster, *apps.StatefulSet, *apps.StatefulSet) error
+}
+
 type tikvScaler struct {
 	generalScaler
 	podLister corelisters.PodLister
+	recorder  record.EventRecorder
 }
 
 // NewTiKVScaler returns a tikv Scaler
 func NewTiKVScaler(pdControl controller.PDControlInterface,
 	pvcLister corelisters.PersistentVolumeClaimLister,
 	pvcControl controller.PVCControlInterface,
-	podLister corelisters.PodLister) Scaler {
-	return &tikvScaler{generalScaler{pdControl, pvcLister, pvcControl}, podLister}
+	podLister corelisters.PodLister,
+	recorder record.EventRecorder) TiKVScaler {
+	return &tikvScaler{generalScaler{pdControl, pvcLister, pvcControl}, podLister, recorder}
 }
 
 func (tsd *tikvScaler) ScaleOut(tc *v1alpha1.TidbCluster, oldSet *apps.StatefulSet, newSet *apps.StatefulSet) error {
@@ -71,7 +92,7 @@ func (tsd *tikvScaler) ScaleIn(tc *v1alpha1.TidbCluster, oldSet *apps.StatefulSe
 	}
 
 	// We need remove member from cluster before reducing statefulset replicas
-	podName := ordinalPodName(v1alpha1.TiKVMemberType, tcName, ordinal)
+	podName := setPodName(setName, ordinal)
 	pod, err := tsd.podLister.Pods(ns).Get(podName)
 	if err != nil {
 		resetReplicas(newSet, oldSet)
@@ -86,13 +107,38 @@ func (tsd *tikvScaler) ScaleIn(tc *v1alpha1.TidbCluster, oldSet *apps.StatefulSe
 				return err
 			}
 			if state != v1alpha1.TiKVStateOffline {
+				refusal, err := tsd.checkScaleIn(tc, id)
+				if err != nil {
+					resetReplicas(newSet, oldSet)
+					return err
+				}
+				if refusal != "" {
+					resetReplicas(newSet, oldSet)
+					msg := fmt.Sprintf("refuse to scale in TiKV %s: %s", podName, refusal)
+					glog.Warningf("TidbCluster: [%s/%s] %s", ns, tcName, msg)
+					tsd.recorder.Event(tc, corev1.EventTypeWarning, tikvScaleInRefusedReason, msg)
+					return nil
+				}
 				if err := tsd.pdControl.GetPDClient(tc).DeleteStore(id); err != nil {
 					resetReplicas(newSet, oldSet)
 					return err
 				}
+				setTiKVLeavingStore(tc, store.ID, v1alpha1.TiKVLeavingStore{
+					PodName:            podName,
+					InitialRegionCount: store.RegionCount,
+					StartTime:          metav1.Now(),
+				})
+			}
+			leavingStore, ok := tc.Status.TiKV.LeavingStores[store.ID]
+			if !ok {
+				// the store was deleted from PD by others
+				leavingStore = v1alpha1.TiKVLeavingStore{PodName: podName, InitialRegionCount: store.RegionCount, StartTime: metav1.Now()}
 			}
+			leavingStore.RegionCount = store.RegionCount
+			setTiKVLeavingStore(tc, store.ID, leavingStore)
 			resetReplicas(newSet, oldSet)
-			return controller.RequeueErrorf("TiKV %s/%s store %d  still in cluster, state: %s", ns, podName, id, state)
+			return controller.RequeueErrorf("TiKV %s/%s store %d still in cluster, state: %s, %d of %d regions remained",
+				ns, podName, id, state, leavingStore.RegionCount, leavingStore.InitialRegionCount)
 		}
 	}
 	for id, store := range tc.Status.TiKV.TombstoneStores {
@@ -122,6 +168,7 @@ func (tsd *tikvScaler) ScaleIn(tc *v1alpha1.TidbCluster, oldSet *apps.StatefulSe
 				return err
 			}
 
+			delete(tc.Status.TiKV.LeavingStores, store.ID)
 			decreaseReplicas(newSet, oldSet)
 			return nil
 		}
@@ -134,10 +181,110 @@ func (tsd *tikvScaler) ScaleIn(tc *v1alpha1.TidbCluster, oldSet *apps.StatefulSe
 	return fmt.Errorf("TiKV %s/%s not found in cluster", ns, podName)
 }
 
+func (tsd *tikvScaler) CancelScaleIn(tc *v1alpha1.TidbCluster, oldSet *apps.StatefulSet, newSet *apps.StatefulSet) error {
+	if len(tc.Status.TiKV.LeavingStores) == 0 {
+		return nil
+	}
+
+	ns := tc.GetNamespace()
+	tcName := tc.GetName()
+	setName := oldSet.GetName()
+	keptPods := map[string]bool{}
+	for ordinal := int32(0); ordinal < *newSet.Spec.Replicas; ordinal++ {
+		keptPods[setPodName(setName, ordinal)] = true
+	}
+
+	for id, leavingStore := range tc.Status.TiKV.LeavingStores {
+		if !keptPods[leavingStore.PodName] {
+			continue
+		}
+		store, ok := tc.Status.TiKV.Stores[id]
+		if !ok {
+			// the store has become tombstone, it can't be brought back
+			glog.Warningf("TidbCluster: [%s/%s] can't cancel the scale-in of TiKV %s, store %s is not in cluster",
+				ns, tcName, leavingStore.PodName, id)
+			continue
+		}
+		if store.State == v1alpha1.TiKVStateOffline {
+			storeID, err := strconv.ParseUint(id, 10, 64)
+			if err != nil {
+				return err
+			}
+			if err := tsd.pdControl.GetPDClient(tc).SetStoreState(storeID, v1alpha1.TiKVStateUp); err != nil {
+				return err
+			}
+			store.State = v1alpha1.TiKVStateUp
+			tc.Status.TiKV.Stores[id] = store
+			msg := fmt.Sprintf("cancel the scale-in of TiKV %s, store %s is up again", leavingStore.PodName, id)
+			glog.Infof("TidbCluster: [%s/%s] %s", ns, tcName, msg)
+			tsd.recorder.Event(tc, corev1.EventTypeNormal, tikvScaleInCanceledReason, msg)
+		}
+		delete(tc.Status.TiKV.LeavingStores, id)
+	}
+	return nil
+}
+
+// checkScaleIn returns the reason why removing the store is unsafe, empty if it's safe:
+//   - the remaining Up stores must be no less than max-replicas, otherwise the regions can't be moved away
+//   - the remaining Up stores must have enough space below PD's low-space-ratio for the data of the store
+func (tsd *tikvScaler) checkScaleIn(tc *v1alpha1.TidbCluster, storeID uint64) (string, error) {
+	pdClient := tsd.pdControl.GetPDClient(tc)
+	config, err := pdClient.GetConfig()
+	if err != nil {
+		return "", err
+	}
+	storesInfo, err := pdClient.GetStores()
+	if err != nil {
+		return "", err
+	}
+
+	lowSpaceRatio := config.Schedule.LowSpaceRatio
+	if lowSpaceRatio <= 0 || lowSpaceRatio > 1 {
+		lowSpaceRatio = defaultLowSpaceRatio
+	}
+	var remainingStores uint64
+	var used, headroom float64
+	for _, store := range storesInfo.Stores {
+		if store.Store == nil || store.Status == nil {
+			continue
+		}
+		capacity := float64(store.Status.Capacity)
+		available := float64(store.Status.Available)
+		if store.Store.GetId() == storeID {
+			used = capacity - available
+			continue
+		}
+		if store.Store.StateName != v1alpha1.TiKVStateUp {
+			continue
+		}
+		remainingStores++
+		if h := available - capacity*(1-lowSpaceRatio); h > 0 {
+			headroom += h
+		}
+	}
+
+	maxReplicas := config.Replication.MaxReplicas
+	if remainingStores < maxReplicas {
+		return fmt.Sprintf("%d Up stores remain after removing store %d, fewer than max-replicas %d", remainingStores, storeID, maxReplicas), nil
+	}
+	if used > headroom {
+		return fmt.Sprintf("the remaining stores have %.0f bytes below low-space-ratio %v, not enough for the %.0f bytes of store %d",
+			headroom, lowSpaceRatio, used, storeID), nil
+	}
+	return "", nil
+}
+
+func setTiKVLeavingStore(tc *v1alpha1.TidbCluster, storeID string, store v1alpha1.TiKVLeavingStore) {
+	if tc.Status.TiKV.LeavingStores == nil {
+		tc.Status.TiKV.LeavingStores = map[string]v1alpha1.TiKVLeavingStore{}
+	}
+	tc.Status.TiKV.LeavingStores[storeID] = store
+}
+
 type fakeTiKVScaler struct{}
 
 // NewFakeTiKVScaler returns a fake tikv Scaler
-func NewFakeTiKVScaler() Scaler {
+func NewFakeTiKVScaler() TiKVScaler {
 	return &fakeTiKVScaler{}
 }
 
@@ -150,3 +297,7 @@ func (fsd *fakeTiKVScaler) ScaleIn(_ *v1alpha1.TidbCluster, oldSet *apps.Statefu
 	decreaseReplicas(newSet, oldSet)
 	return nil
 }
+
+func (fsd *fakeTiKVScaler) CancelScaleIn(_ *v1alpha1.TidbCluster, _ *apps.StatefulSet, _ *apps.StatefulSet) error {
+	return nil
+}
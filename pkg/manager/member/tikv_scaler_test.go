@@ -19,6 +19,9 @@ import (
 	"time"
 
 	. "github.com/onsi/gomega"
+	"github.com/pingcap/kvproto/pkg/metapb"
+	"github.com/pingcap/pd/pkg/typeutil"
+	"github.com/pingcap/pd/server"
 	"github.com/pingcap/tidb-operator/pkg/apis/pingcap.com/v1alpha1"
 	"github.com/pingcap/tidb-operator/pkg/controller"
 	"github.com/pingcap/tidb-operator/pkg/label"
@@ -28,6 +31,7 @@ import (
 	kubeinformers "k8s.io/client-go/informers"
 	kubefake "k8s.io/client-go/kubernetes/fake"
 	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/record"
 )
 
 func TestTiKVScalerScaleOut(t *testing.T) {
@@ -152,6 +156,7 @@ func TestTiKVScalerScaleIn(t *testing.T) {
 		}
 
 		oldSet := newStatefulSetForPDScale()
+		oldSet.Name = controller.TiKVMemberName(tc.GetName())
 		newSet := oldSet.DeepCopy()
 		newSet.Spec.Replicas = int32Pointer(3)
 
@@ -178,6 +183,7 @@ func TestTiKVScalerScaleIn(t *testing.T) {
 
 		pdClient := controller.NewFakePDClient()
 		pdControl.SetPDClient(tc, pdClient)
+		addScaleInSafeReactions(pdClient)
 
 		if test.delStoreErr {
 			pdClient.AddReaction(controller.DeleteStoreActionType, func(action *controller.Action) (interface{}, error) {
@@ -360,6 +366,296 @@ func TestTiKVScalerScaleIn(t *testing.T) {
 	}
 }
 
+func TestTiKVScalerScaleInSafety(t *testing.T) {
+	g := NewGomegaWithT(t)
+	type testcase struct {
+		name        string
+		storeFun    func(tc *v1alpha1.TidbCluster)
+		maxReplicas uint64
+		stores      []*controller.StoreInfo
+		errExpectFn func(*GomegaWithT, error)
+		refused     bool
+		tcExpectFn  func(*GomegaWithT, *v1alpha1.TidbCluster)
+	}
+
+	testFn := func(test *testcase, t *testing.T) {
+		t.Log(test.name)
+		tc := newTidbClusterForPD()
+		test.storeFun(tc)
+
+		oldSet := newStatefulSetForPDScale()
+		oldSet.Name = controller.TiKVMemberName(tc.GetName())
+		newSet := oldSet.DeepCopy()
+		newSet.Spec.Replicas = int32Pointer(3)
+
+		scaler, pdControl, _, podIndexer, _ := newFakeTiKVScaler()
+		recorder := record.NewFakeRecorder(10)
+		scaler.recorder = recorder
+		podIndexer.Add(&corev1.Pod{
+			TypeMeta: metav1.TypeMeta{Kind: "Pod", APIVersion: "v1"},
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      tikvPodName(tc.GetName(), 4),
+				Namespace: corev1.NamespaceDefault,
+			},
+		})
+
+		pdClient := controller.NewFakePDClient()
+		pdControl.SetPDClient(tc, pdClient)
+		pdClient.AddReaction(controller.GetConfigActionType, func(action *controller.Action) (interface{}, error) {
+			config := &server.Config{}
+			config.Replication.MaxReplicas = test.maxReplicas
+			return config, nil
+		})
+		pdClient.AddReaction(controller.GetStoresActionType, func(action *controller.Action) (interface{}, error) {
+			return &controller.StoresInfo{Stores: test.stores}, nil
+		})
+		deleted := false
+		pdClient.AddReaction(controller.DeleteStoreActionType, func(action *controller.Action) (interface{}, error) {
+			deleted = true
+			return nil, nil
+		})
+
+		err := scaler.ScaleIn(tc, oldSet, newSet)
+		test.errExpectFn(g, err)
+		g.Expect(int(*newSet.Spec.Replicas)).To(Equal(5))
+		events := collectEvents(recorder.Events)
+		if test.refused {
+			g.Expect(deleted).To(BeFalse())
+			g.Expect(events).To(HaveLen(1))
+			g.Expect(events[0]).To(ContainSubstring(tikvScaleInRefusedReason))
+		} else {
+			g.Expect(events).To(HaveLen(0))
+		}
+		if test.tcExpectFn != nil {
+			test.tcExpectFn(g, tc)
+		}
+	}
+
+	gb := uint64(1 << 30)
+	tests := []testcase{
+		{
+			name: "safe to scale in",
+			storeFun: func(tc *v1alpha1.TidbCluster) {
+				normalStoreFun(tc)
+				store := tc.Status.TiKV.Stores["1"]
+				store.RegionCount = 100
+				tc.Status.TiKV.Stores["1"] = store
+			},
+			maxReplicas: 3,
+			stores: []*controller.StoreInfo{
+				newStoreInfo(1, v1alpha1.TiKVStateUp, 100*gb, 50*gb),
+				newStoreInfo(2, v1alpha1.TiKVStateUp, 100*gb, 80*gb),
+				newStoreInfo(3, v1alpha1.TiKVStateUp, 100*gb, 80*gb),
+				newStoreInfo(4, v1alpha1.TiKVStateUp, 100*gb, 80*gb),
+			},
+			errExpectFn: errExpectRequeue,
+			tcExpectFn: func(g *GomegaWithT, tc *v1alpha1.TidbCluster) {
+				g.Expect(tc.Status.TiKV.LeavingStores).To(HaveKey("1"))
+				g.Expect(tc.Status.TiKV.LeavingStores["1"].InitialRegionCount).To(Equal(int32(100)))
+				g.Expect(tc.Status.TiKV.LeavingStores["1"].RegionCount).To(Equal(int32(100)))
+			},
+		},
+		{
+			name:        "fewer stores than max-replicas remain",
+			storeFun:    normalStoreFun,
+			maxReplicas: 3,
+			stores: []*controller.StoreInfo{
+				newStoreInfo(1, v1alpha1.TiKVStateUp, 100*gb, 50*gb),
+				newStoreInfo(2, v1alpha1.TiKVStateUp, 100*gb, 80*gb),
+				newStoreInfo(3, v1alpha1.TiKVStateUp, 100*gb, 80*gb),
+				newStoreInfo(4, v1alpha1.TiKVStateDown, 100*gb, 80*gb),
+			},
+			errExpectFn: errExpectNil,
+			refused:     true,
+			tcExpectFn: func(g *GomegaWithT, tc *v1alpha1.TidbCluster) {
+				g.Expect(tc.Status.TiKV.LeavingStores).To(BeEmpty())
+			},
+		},
+		{
+			name:        "remaining stores don't have enough space",
+			storeFun:    normalStoreFun,
+			maxReplicas: 3,
+			stores: []*controller.StoreInfo{
+				newStoreInfo(1, v1alpha1.TiKVStateUp, 100*gb, 10*gb),
+				newStoreInfo(2, v1alpha1.TiKVStateUp, 100*gb, 40*gb),
+				newStoreInfo(3, v1alpha1.TiKVStateUp, 100*gb, 40*gb),
+				newStoreInfo(4, v1alpha1.TiKVStateUp, 100*gb, 40*gb),
+			},
+			errExpectFn: errExpectNil,
+			refused:     true,
+		},
+		{
+			name: "report the progress of the leaving store",
+			storeFun: func(tc *v1alpha1.TidbCluster) {
+				normalStoreFun(tc)
+				store := tc.Status.TiKV.Stores["1"]
+				store.State = v1alpha1.TiKVStateOffline
+				store.RegionCount = 30
+				tc.Status.TiKV.Stores["1"] = store
+				tc.Status.TiKV.LeavingStores = map[string]v1alpha1.TiKVLeavingStore{
+					"1": {PodName: store.PodName, InitialRegionCount: 100, RegionCount: 60},
+				}
+			},
+			errExpectFn: func(g *GomegaWithT, err error) {
+				g.Expect(controller.IsRequeueError(err)).To(BeTrue())
+				g.Expect(err.Error()).To(ContainSubstring("30 of 100 regions remained"))
+			},
+			tcExpectFn: func(g *GomegaWithT, tc *v1alpha1.TidbCluster) {
+				g.Expect(tc.Status.TiKV.LeavingStores["1"].RegionCount).To(Equal(int32(30)))
+			},
+		},
+	}
+
+	for i := range tests {
+		testFn(&tests[i], t)
+	}
+}
+
+func TestTiKVScalerCancelScaleIn(t *testing.T) {
+	g := NewGomegaWithT(t)
+	type testcase struct {
+		name          string
+		storeFun      func(tc *v1alpha1.TidbCluster)
+		replicas      int32
+		setStateErr   bool
+		errExpectFn   func(*GomegaWithT, error)
+		stateSet      bool
+		leavingRemain bool
+	}
+
+	leavingStoreFun := func(state string) func(tc *v1alpha1.TidbCluster) {
+		return func(tc *v1alpha1.TidbCluster) {
+			normalStoreFun(tc)
+			store := tc.Status.TiKV.Stores["1"]
+			store.State = state
+			tc.Status.TiKV.Stores["1"] = store
+			tc.Status.TiKV.LeavingStores = map[string]v1alpha1.TiKVLeavingStore{
+				"1": {PodName: store.PodName, InitialRegionCount: 100, RegionCount: 60},
+			}
+		}
+	}
+
+	testFn := func(test *testcase, t *testing.T) {
+		t.Log(test.name)
+		tc := newTidbClusterForPD()
+		test.storeFun(tc)
+
+		oldSet := newStatefulSetForPDScale()
+		oldSet.Name = controller.TiKVMemberName(tc.GetName())
+		newSet := oldSet.DeepCopy()
+		newSet.Spec.Replicas = int32Pointer(int(test.replicas))
+
+		scaler, pdControl, _, _, _ := newFakeTiKVScaler()
+		pdClient := controller.NewFakePDClient()
+		pdControl.SetPDClient(tc, pdClient)
+		stateSet := false
+		pdClient.AddReaction(controller.SetStoreStateActionType, func(action *controller.Action) (interface{}, error) {
+			if test.setStateErr {
+				return nil, fmt.Errorf("failed to set store state")
+			}
+			g.Expect(action.ID).To(Equal(uint64(1)))
+			g.Expect(action.Name).To(Equal(v1alpha1.TiKVStateUp))
+			stateSet = true
+			return nil, nil
+		})
+
+		err := scaler.CancelScaleIn(tc, oldSet, newSet)
+		test.errExpectFn(g, err)
+		g.Expect(stateSet).To(Equal(test.stateSet))
+		if test.leavingRemain {
+			g.Expect(tc.Status.TiKV.LeavingStores).To(HaveKey("1"))
+		} else {
+			g.Expect(tc.Status.TiKV.LeavingStores).NotTo(HaveKey("1"))
+		}
+		if test.stateSet {
+			g.Expect(tc.Status.TiKV.Stores["1"].State).To(Equal(v1alpha1.TiKVStateUp))
+		}
+	}
+
+	tests := []testcase{
+		{
+			name:          "no leaving store",
+			storeFun:      normalStoreFun,
+			replicas:      5,
+			errExpectFn:   errExpectNil,
+			stateSet:      false,
+			leavingRemain: false,
+		},
+		{
+			name:          "offline store is kept by the replicas",
+			storeFun:      leavingStoreFun(v1alpha1.TiKVStateOffline),
+			replicas:      5,
+			errExpectFn:   errExpectNil,
+			stateSet:      true,
+			leavingRemain: false,
+		},
+		{
+			name:          "offline store is still being removed",
+			storeFun:      leavingStoreFun(v1alpha1.TiKVStateOffline),
+			replicas:      4,
+			errExpectFn:   errExpectNil,
+			stateSet:      false,
+			leavingRemain: true,
+		},
+		{
+			name:          "set store state failed",
+			storeFun:      leavingStoreFun(v1alpha1.TiKVStateOffline),
+			replicas:      5,
+			setStateErr:   true,
+			errExpectFn:   errExpectNotNil,
+			stateSet:      false,
+			leavingRemain: true,
+		},
+		{
+			name:          "store is up already",
+			storeFun:      leavingStoreFun(v1alpha1.TiKVStateUp),
+			replicas:      5,
+			errExpectFn:   errExpectNil,
+			stateSet:      false,
+			leavingRemain: false,
+		},
+		{
+			name: "store has become tombstone",
+			storeFun: func(tc *v1alpha1.TidbCluster) {
+				leavingStoreFun(v1alpha1.TiKVStateOffline)(tc)
+				tc.Status.TiKV.Stores = map[string]v1alpha1.TiKVStore{}
+			},
+			replicas:      5,
+			errExpectFn:   errExpectNil,
+			stateSet:      false,
+			leavingRemain: true,
+		},
+	}
+
+	for i := range tests {
+		testFn(&tests[i], t)
+	}
+}
+
+func newStoreInfo(id uint64, state string, capacity, available uint64) *controller.StoreInfo {
+	return &controller.StoreInfo{
+		Store: &controller.MetaStore{
+			Store:     &metapb.Store{Id: id},
+			StateName: state,
+		},
+		Status: &controller.StoreStatus{
+			Capacity:    typeutil.ByteSize(capacity),
+			Available:   typeutil.ByteSize(available),
+			RegionCount: 100,
+		},
+	}
+}
+
+// addScaleInSafeReactions makes the scale-in of any store safe
+func addScaleInSafeReactions(pdClient *controller.FakePDClient) {
+	pdClient.AddReaction(controller.GetConfigActionType, func(action *controller.Action) (interface{}, error) {
+		return &server.Config{}, nil
+	})
+	pdClient.AddReaction(controller.GetStoresActionType, func(action *controller.Action) (interface{}, error) {
+		return &controller.StoresInfo{Stores: []*controller.StoreInfo{}}, nil
+	})
+}
+
 func newFakeTiKVScaler() (*tikvScaler, *controller.FakePDControl, cache.Indexer, cache.Indexer, *controller.FakePVCControl) {
 	kubeCli := kubefake.NewSimpleClientset()
 
@@ -369,7 +665,7 @@ func newFakeTiKVScaler() (*tikvScaler, *controller.FakePDControl, cache.Indexer,
 	pdControl := controller.NewFakePDControl()
 	pvcControl := controller.NewFakePVCControl(pvcInformer)
 
-	return &tikvScaler{generalScaler{pdControl, pvcInformer.Lister(), pvcControl}, podInformer.Lister()},
+	return &tikvScaler{generalScaler{pdControl, pvcInformer.Lister(), pvcControl}, podInformer.Lister(), record.NewFakeRecorder(10)},
 		pdControl, pvcInformer.Informer().GetIndexer(), podInformer.Informer().GetIndexer(), pvcControl
 }
 
@@ -396,3 +692,17 @@ func tombstoneStoreFun(tc *v1alpha1.TidbCluster) {
 func errExpectRequeue(g *GomegaWithT, err error) {
 	g.Expect(controller.IsRequeueError(err)).To(Equal(true))
 }
+
+func collectEvents(source <-chan string) []string {
+	done := false
+	events := make([]string, 0)
+	for !done {
+		select {
+		case event := <-source:
+			events = append(events, event)
+		default:
+			done = true
+		}
+	}
+	return events
+}
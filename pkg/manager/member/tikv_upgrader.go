@@ -71,13 +71,14 @@ func (tku *tikvUpgrader) Upgrade(tc *v1alpha1.TidbCluster, oldSet *apps.Stateful
 		return nil
 	}
 
+	setName := oldSet.GetName()
 	setUpgradePartition(newSet, *oldSet.Spec.UpdateStrategy.RollingUpdate.Partition)
-	for i := tc.Status.TiKV.StatefulSet.Replicas - 1; i >= 0; i-- {
-		store := tku.getStoreByOrdinal(tc, i)
+	for i := oldSet.Status.Replicas - 1; i >= 0; i-- {
+		store := tku.getStoreByOrdinal(tc, setName, i)
 		if store == nil {
 			continue
 		}
-		podName := tikvPodName(tcName, i)
+		podName := setPodName(setName, i)
 		pod, err := tku.podLister.Pods(ns).Get(podName)
 		if err != nil {
 			return err
@@ -87,7 +88,7 @@ func (tku *tikvUpgrader) Upgrade(tc *v1alpha1.TidbCluster, oldSet *apps.Stateful
 			return controller.RequeueErrorf("tidbcluster: [%s/%s]'s tikv pod: [%s] has no label: %s", ns, tcName, podName, apps.ControllerRevisionHashLabelKey)
 		}
 
-		if revision == tc.Status.TiKV.StatefulSet.UpdateRevision {
+		if revision == oldSet.Status.UpdateRevision {
 
 			if pod.Status.Phase != corev1.PodRunning {
 				return controller.RequeueErrorf("tidbcluster: [%s/%s]'s upgraded tikv pod: [%s] is not running", ns, tcName, podName)
@@ -99,16 +100,16 @@ func (tku *tikvUpgrader) Upgrade(tc *v1alpha1.TidbCluster, oldSet *apps.Stateful
 			continue
 		}
 
-		return tku.upgradeTiKVPod(tc, i, newSet)
+		return tku.upgradeTiKVPod(tc, setName, i, newSet)
 	}
 
 	return nil
 }
 
-func (tku *tikvUpgrader) upgradeTiKVPod(tc *v1alpha1.TidbCluster, ordinal int32, newSet *apps.StatefulSet) error {
+func (tku *tikvUpgrader) upgradeTiKVPod(tc *v1alpha1.TidbCluster, setName string, ordinal int32, newSet *apps.StatefulSet) error {
 	ns := tc.GetNamespace()
 	tcName := tc.GetName()
-	upgradePodName := tikvPodName(tcName, ordinal)
+	upgradePodName := setPodName(setName, ordinal)
 	upgradePod, err := tku.podLister.Pods(ns).Get(upgradePodName)
 	if err != nil {
 		return err
@@ -123,7 +124,7 @@ func (tku *tikvUpgrader) upgradeTiKVPod(tc *v1alpha1.TidbCluster, ordinal int32,
 			_, evicting := upgradePod.Annotations[EvictLeaderBeginTime]
 
 			if tku.readyToUpgrade(upgradePod, store) {
-				err := tku.endEvictLeader(tc, ordinal)
+				err := tku.endEvictLeader(tc, setName, ordinal)
 				if err != nil {
 					return err
 				}
@@ -171,13 +172,13 @@ func (tku *tikvUpgrader) beginEvictLeader(tc *v1alpha1.TidbCluster, storeID uint
 	return err
 }
 
-func (tku *tikvUpgrader) endEvictLeader(tc *v1alpha1.TidbCluster, ordinal int32) error {
-	store := tku.getStoreByOrdinal(tc, ordinal)
+func (tku *tikvUpgrader) endEvictLeader(tc *v1alpha1.TidbCluster, setName string, ordinal int32) error {
+	store := tku.getStoreByOrdinal(tc, setName, ordinal)
 	storeID, err := strconv.ParseUint(store.ID, 10, 64)
 	if err != nil {
 		return err
 	}
-	upgradedPodName := tikvPodName(tc.GetName(), ordinal)
+	upgradedPodName := setPodName(setName, ordinal)
 	upgradedPod, err := tku.podLister.Pods(tc.GetNamespace()).Get(upgradedPodName)
 	if err != nil {
 		return err
@@ -198,8 +199,8 @@ func (tku *tikvUpgrader) endEvictLeader(tc *v1alpha1.TidbCluster, ordinal int32)
 	return nil
 }
 
-func (tku *tikvUpgrader) getStoreByOrdinal(tc *v1alpha1.TidbCluster, ordinal int32) *v1alpha1.TiKVStore {
-	podName := tikvPodName(tc.GetName(), ordinal)
+func (tku *tikvUpgrader) getStoreByOrdinal(tc *v1alpha1.TidbCluster, setName string, ordinal int32) *v1alpha1.TiKVStore {
+	podName := setPodName(setName, ordinal)
 	for _, store := range tc.Status.TiKV.Stores {
 		if store.PodName == podName {
 			return &store
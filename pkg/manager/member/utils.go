@@ -16,12 +16,16 @@ package member
 import (
 	"encoding/json"
 	"fmt"
+	"sort"
+	"strings"
 
 	"github.com/golang/glog"
+	"github.com/pingcap/tidb-operator/pkg/apis/pingcap.com/v1alpha1"
 	"github.com/pingcap/tidb-operator/pkg/controller"
 	apps "k8s.io/api/apps/v1beta1"
 	corev1 "k8s.io/api/core/v1"
 	apiequality "k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/util/validation"
 )
 
 const (
@@ -31,6 +35,8 @@ const (
 	ImagePullBackOff = "ImagePullBackOff"
 	// ErrImagePull is the pod state of image pull failed
 	ErrImagePull = "ErrImagePull"
+	// reservedGroupName is the group name taken by the headless Service, <cluster>-<component>-peer
+	reservedGroupName = "peer"
 )
 
 func annotationsMountVolume() (corev1.VolumeMount, corev1.Volume) {
@@ -150,9 +156,24 @@ func templateEqual(new corev1.PodTemplateSpec, old corev1.PodTemplateSpec) bool
 	return false
 }
 
-// SetServiceLastAppliedConfigAnnotation set last applied config info to Service's annotation
+// serviceAppliedConfig is the last applied config of Service, it is a ServiceSpec with the keys of
+// the annotations managed by the operator, so that the annotations no longer desired can be removed
+type serviceAppliedConfig struct {
+	corev1.ServiceSpec
+	Annotations []string `json:"annotations,omitempty"`
+}
+
+// SetServiceLastAppliedConfigAnnotation set last applied config info to Service's annotation,
+// all the annotations of the Service are recorded as managed by the operator
 func SetServiceLastAppliedConfigAnnotation(svc *corev1.Service) error {
-	svcApply, err := encode(svc.Spec)
+	config := serviceAppliedConfig{ServiceSpec: svc.Spec}
+	for k := range svc.Annotations {
+		if k != LastAppliedConfigAnnotation {
+			config.Annotations = append(config.Annotations, k)
+		}
+	}
+	sort.Strings(config.Annotations)
+	svcApply, err := encode(config)
 	if err != nil {
 		return err
 	}
@@ -165,18 +186,156 @@ func SetServiceLastAppliedConfigAnnotation(svc *corev1.Service) error {
 
 // serviceEqual compares the new Service's spec with old Service's last applied config
 func serviceEqual(new, old *corev1.Service) (bool, error) {
-	oldSpec := corev1.ServiceSpec{}
+	oldConfig := serviceAppliedConfig{}
 	if lastAppliedConfig, ok := old.Annotations[LastAppliedConfigAnnotation]; ok {
-		err := json.Unmarshal([]byte(lastAppliedConfig), &oldSpec)
+		err := json.Unmarshal([]byte(lastAppliedConfig), &oldConfig)
 		if err != nil {
 			glog.Errorf("unmarshal ServiceSpec: [%s/%s]'s applied config failed,error: %v", old.GetNamespace(), old.GetName(), err)
 			return false, err
 		}
-		return apiequality.Semantic.DeepEqual(oldSpec, new.Spec), nil
+		if !apiequality.Semantic.DeepEqual(oldConfig.ServiceSpec, new.Spec) {
+			return false, nil
+		}
+		for k, v := range new.Annotations {
+			if k == LastAppliedConfigAnnotation {
+				continue
+			}
+			if old.Annotations[k] != v {
+				return false, nil
+			}
+		}
+		for _, k := range oldConfig.Annotations {
+			if _, ok := new.Annotations[k]; !ok {
+				return false, nil
+			}
+		}
+		return true, nil
 	}
 	return false, nil
 }
 
+// applyServiceSpec applies the user specified ServiceSpec to the Service
+func applyServiceSpec(svc *corev1.Service, spec *v1alpha1.ServiceSpec) {
+	if spec == nil {
+		return
+	}
+	if spec.Type != "" {
+		svc.Spec.Type = spec.Type
+	}
+	if len(spec.Annotations) > 0 {
+		svc.Annotations = CombineAnnotations(svc.Annotations, spec.Annotations)
+	}
+	svc.Spec.LoadBalancerIP = spec.LoadBalancerIP
+	svc.Spec.LoadBalancerSourceRanges = spec.LoadBalancerSourceRanges
+	svc.Spec.SessionAffinity = spec.SessionAffinity
+	if svc.Spec.Type != corev1.ServiceTypeNodePort && svc.Spec.Type != corev1.ServiceTypeLoadBalancer {
+		return
+	}
+	svc.Spec.ExternalTrafficPolicy = spec.ExternalTrafficPolicy
+	for i := range svc.Spec.Ports {
+		if nodePort, ok := spec.NodePorts[svc.Spec.Ports[i].Name]; ok {
+			svc.Spec.Ports[i].NodePort = nodePort
+		}
+	}
+}
+
+// applyPodTemplateOverride merges the user specified pod template fields into the StatefulSet,
+// containerName is the name of the component container
+func applyPodTemplateOverride(set *apps.StatefulSet, containerName string, override *v1alpha1.PodTemplateOverride) {
+	if override == nil {
+		return
+	}
+	podTemplate := &set.Spec.Template
+	if len(override.Labels) > 0 {
+		labels := map[string]string{}
+		for k, v := range override.Labels {
+			labels[k] = v
+		}
+		for k, v := range podTemplate.Labels {
+			labels[k] = v
+		}
+		podTemplate.Labels = labels
+	}
+
+	podSpec := &podTemplate.Spec
+	for i := range podSpec.Containers {
+		container := &podSpec.Containers[i]
+		if container.Name != containerName {
+			continue
+		}
+		for _, env := range override.Env {
+			merged := false
+			for j := range container.Env {
+				if container.Env[j].Name == env.Name {
+					container.Env[j] = env
+					merged = true
+					break
+				}
+			}
+			if !merged {
+				container.Env = append(container.Env, env)
+			}
+		}
+		container.VolumeMounts = append(container.VolumeMounts, override.VolumeMounts...)
+	}
+	podSpec.Containers = append(podSpec.Containers, override.Sidecars...)
+	podSpec.InitContainers = append(podSpec.InitContainers, override.InitContainers...)
+	podSpec.Volumes = append(podSpec.Volumes, override.Volumes...)
+	if override.PriorityClassName != "" {
+		podSpec.PriorityClassName = override.PriorityClassName
+	}
+	if override.SecurityContext != nil {
+		podSpec.SecurityContext = override.SecurityContext
+	}
+	if override.HostNetwork {
+		podSpec.HostNetwork = true
+		podSpec.DNSPolicy = corev1.DNSClusterFirstWithHostNet
+	}
+	if override.TerminationGracePeriodSeconds != nil {
+		podSpec.TerminationGracePeriodSeconds = override.TerminationGracePeriodSeconds
+	}
+}
+
+// updateServiceSpec updates the old Service in place with the new Service, keeping the cluster IP and
+// the node ports allocated by Kubernetes, the last applied config of the new Service must be set already
+func updateServiceSpec(new, old *corev1.Service) *corev1.Service {
+	svc := *old
+	svc.Spec = new.Spec
+	svc.Spec.ClusterIP = old.Spec.ClusterIP
+	if svc.Spec.Type == corev1.ServiceTypeNodePort || svc.Spec.Type == corev1.ServiceTypeLoadBalancer {
+		svc.Spec.Ports = make([]corev1.ServicePort, len(new.Spec.Ports))
+		copy(svc.Spec.Ports, new.Spec.Ports)
+		for i := range svc.Spec.Ports {
+			if svc.Spec.Ports[i].NodePort != 0 {
+				continue
+			}
+			for _, oldPort := range old.Spec.Ports {
+				if oldPort.Name == svc.Spec.Ports[i].Name {
+					svc.Spec.Ports[i].NodePort = oldPort.NodePort
+				}
+			}
+		}
+	}
+	svc.Annotations = map[string]string{}
+	for k, v := range old.Annotations {
+		svc.Annotations[k] = v
+	}
+	// the annotations added by others are kept, while the ones managed by the operator but no longer desired are removed
+	oldConfig := serviceAppliedConfig{}
+	if lastAppliedConfig, ok := old.Annotations[LastAppliedConfigAnnotation]; ok {
+		if err := json.Unmarshal([]byte(lastAppliedConfig), &oldConfig); err != nil {
+			glog.Errorf("unmarshal ServiceSpec: [%s/%s]'s applied config failed,error: %v", old.GetNamespace(), old.GetName(), err)
+		}
+	}
+	for _, k := range oldConfig.Annotations {
+		delete(svc.Annotations, k)
+	}
+	for k, v := range new.Annotations {
+		svc.Annotations[k] = v
+	}
+	return &svc
+}
+
 // setUpgradePartition set statefulSet's rolling update partition
 func setUpgradePartition(set *apps.StatefulSet, upgradeOrdinal int32) {
 	set.Spec.UpdateStrategy.RollingUpdate = &apps.RollingUpdateStatefulSetStrategy{Partition: &upgradeOrdinal}
@@ -192,6 +351,11 @@ func imagePullFailed(pod *corev1.Pod) bool {
 	return false
 }
 
+// setPodName returns the name of the pod with the ordinal in the StatefulSet
+func setPodName(setName string, ordinal int32) string {
+	return fmt.Sprintf("%s-%d", setName, ordinal)
+}
+
 func tikvPodName(tcName string, ordinal int32) string {
 	return fmt.Sprintf("%s-%d", controller.TiKVMemberName(tcName), ordinal)
 }
@@ -214,3 +378,22 @@ func CombineAnnotations(a, b map[string]string) map[string]string {
 	}
 	return a
 }
+
+// validateGroupNames checks that the group names are unique DNS-1123 labels other than the reserved one,
+// since they are part of the StatefulSet, Service and ConfigMap names and of the group label
+func validateGroupNames(names []string) error {
+	seen := map[string]bool{}
+	for _, name := range names {
+		if errs := validation.IsDNS1123Label(name); len(errs) > 0 {
+			return fmt.Errorf("invalid group name %q: %s", name, strings.Join(errs, ", "))
+		}
+		if name == reservedGroupName {
+			return fmt.Errorf("invalid group name %q: it is reserved by the headless Service", name)
+		}
+		if seen[name] {
+			return fmt.Errorf("duplicated group name %q", name)
+		}
+		seen[name] = true
+	}
+	return nil
+}
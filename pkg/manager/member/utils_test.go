@@ -17,7 +17,9 @@ import (
 	"testing"
 
 	. "github.com/onsi/gomega"
+	"github.com/pingcap/tidb-operator/pkg/apis/pingcap.com/v1alpha1"
 	apps "k8s.io/api/apps/v1beta1"
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
@@ -94,3 +96,230 @@ func TestStatefulSetIsUpgrading(t *testing.T) {
 		testFn(test, t)
 	}
 }
+
+func TestApplyServiceSpec(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	newSvc := func() *corev1.Service {
+		return &corev1.Service{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        "test",
+				Namespace:   metav1.NamespaceDefault,
+				Annotations: map[string]string{"a": "b"},
+			},
+			Spec: corev1.ServiceSpec{
+				Type: corev1.ServiceTypeClusterIP,
+				Ports: []corev1.ServicePort{
+					{Name: "mysql-client", Port: 4000},
+					{Name: "status", Port: 10080},
+				},
+			},
+		}
+	}
+
+	svc := newSvc()
+	applyServiceSpec(svc, nil)
+	g.Expect(svc).To(Equal(newSvc()))
+
+	svc = newSvc()
+	applyServiceSpec(svc, &v1alpha1.ServiceSpec{
+		ExternalTrafficPolicy: corev1.ServiceExternalTrafficPolicyTypeLocal,
+		NodePorts:             map[string]int32{"mysql-client": 30000},
+		SessionAffinity:       corev1.ServiceAffinityClientIP,
+	})
+	g.Expect(svc.Spec.Type).To(Equal(corev1.ServiceTypeClusterIP))
+	g.Expect(svc.Spec.ExternalTrafficPolicy).To(BeEmpty())
+	g.Expect(svc.Spec.Ports[0].NodePort).To(Equal(int32(0)))
+	g.Expect(svc.Spec.SessionAffinity).To(Equal(corev1.ServiceAffinityClientIP))
+
+	svc = newSvc()
+	applyServiceSpec(svc, &v1alpha1.ServiceSpec{
+		Type:                  corev1.ServiceTypeNodePort,
+		Annotations:           map[string]string{"c": "d"},
+		ExternalTrafficPolicy: corev1.ServiceExternalTrafficPolicyTypeLocal,
+		NodePorts:             map[string]int32{"mysql-client": 30000},
+	})
+	g.Expect(svc.Spec.Type).To(Equal(corev1.ServiceTypeNodePort))
+	g.Expect(svc.Annotations).To(Equal(map[string]string{"a": "b", "c": "d"}))
+	g.Expect(svc.Spec.ExternalTrafficPolicy).To(Equal(corev1.ServiceExternalTrafficPolicyTypeLocal))
+	g.Expect(svc.Spec.Ports[0].NodePort).To(Equal(int32(30000)))
+	g.Expect(svc.Spec.Ports[1].NodePort).To(Equal(int32(0)))
+}
+
+func TestUpdateServiceSpec(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	oldSvc := &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test",
+			Namespace: metav1.NamespaceDefault,
+		},
+		Spec: corev1.ServiceSpec{
+			Type:      corev1.ServiceTypeNodePort,
+			ClusterIP: "10.0.0.1",
+			Ports: []corev1.ServicePort{
+				{Name: "mysql-client", Port: 4000, NodePort: 30000},
+				{Name: "status", Port: 10080, NodePort: 30001},
+			},
+		},
+	}
+	g.Expect(SetServiceLastAppliedConfigAnnotation(oldSvc)).To(Succeed())
+
+	newSvc := &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "test",
+			Namespace:   metav1.NamespaceDefault,
+			Annotations: map[string]string{"a": "b"},
+		},
+		Spec: corev1.ServiceSpec{
+			Type: corev1.ServiceTypeNodePort,
+			Ports: []corev1.ServicePort{
+				{Name: "mysql-client", Port: 4000, NodePort: 31000},
+				{Name: "status", Port: 10080},
+			},
+		},
+	}
+	equal, err := serviceEqual(newSvc, oldSvc)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(equal).To(BeFalse())
+
+	svc := updateServiceSpec(newSvc, oldSvc)
+	g.Expect(svc.Spec.ClusterIP).To(Equal("10.0.0.1"))
+	g.Expect(svc.Spec.Ports[0].NodePort).To(Equal(int32(31000)))
+	g.Expect(svc.Spec.Ports[1].NodePort).To(Equal(int32(30001)))
+	g.Expect(svc.Annotations["a"]).To(Equal("b"))
+	g.Expect(newSvc.Spec.Ports[1].NodePort).To(Equal(int32(0)))
+
+	// only the annotations are changed
+	newSvc.Spec = oldSvc.Spec
+	equal, err = serviceEqual(newSvc, oldSvc)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(equal).To(BeFalse())
+	oldSvc.Annotations["a"] = "b"
+	equal, err = serviceEqual(newSvc, oldSvc)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(equal).To(BeTrue())
+
+	// the annotation managed by the operator is removed, while the one added by others is kept
+	g.Expect(SetServiceLastAppliedConfigAnnotation(newSvc)).To(Succeed())
+	oldSvc = updateServiceSpec(newSvc, oldSvc)
+	oldSvc.Annotations["c"] = "d"
+	newSvc = &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test",
+			Namespace: metav1.NamespaceDefault,
+		},
+		Spec: oldSvc.Spec,
+	}
+	equal, err = serviceEqual(newSvc, oldSvc)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(equal).To(BeFalse())
+	g.Expect(SetServiceLastAppliedConfigAnnotation(newSvc)).To(Succeed())
+	svc = updateServiceSpec(newSvc, oldSvc)
+	g.Expect(svc.Annotations).NotTo(HaveKey("a"))
+	g.Expect(svc.Annotations).To(HaveKeyWithValue("c", "d"))
+	equal, err = serviceEqual(newSvc, svc)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(equal).To(BeTrue())
+}
+
+func TestApplyPodTemplateOverride(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	newSet := func() *apps.StatefulSet {
+		return &apps.StatefulSet{
+			Spec: apps.StatefulSetSpec{
+				Template: corev1.PodTemplateSpec{
+					ObjectMeta: metav1.ObjectMeta{
+						Labels: map[string]string{"app.kubernetes.io/component": "tidb"},
+					},
+					Spec: corev1.PodSpec{
+						Containers: []corev1.Container{
+							{
+								Name: "slowlog",
+							},
+							{
+								Name: "tidb",
+								Env: []corev1.EnvVar{
+									{Name: "TZ", Value: "UTC"},
+								},
+							},
+						},
+						Volumes: []corev1.Volume{{Name: "config"}},
+					},
+				},
+			},
+		}
+	}
+
+	set := newSet()
+	applyPodTemplateOverride(set, "tidb", nil)
+	g.Expect(set).To(Equal(newSet()))
+
+	gracePeriod := int64(60)
+	set = newSet()
+	applyPodTemplateOverride(set, "tidb", &v1alpha1.PodTemplateOverride{
+		Labels: map[string]string{
+			"app.kubernetes.io/component": "foo",
+			"team":                        "db",
+		},
+		Env: []corev1.EnvVar{
+			{Name: "TZ", Value: "Asia/Shanghai"},
+			{Name: "FOO", Value: "bar"},
+		},
+		PriorityClassName:             "high",
+		SecurityContext:               &corev1.PodSecurityContext{RunAsUser: func() *int64 { i := int64(1000); return &i }()},
+		HostNetwork:                   true,
+		InitContainers:                []corev1.Container{{Name: "init"}},
+		Sidecars:                      []corev1.Container{{Name: "agent"}},
+		Volumes:                       []corev1.Volume{{Name: "data"}},
+		VolumeMounts:                  []corev1.VolumeMount{{Name: "data", MountPath: "/data"}},
+		TerminationGracePeriodSeconds: &gracePeriod,
+	})
+	podSpec := set.Spec.Template.Spec
+	g.Expect(set.Spec.Template.Labels).To(Equal(map[string]string{
+		"app.kubernetes.io/component": "tidb",
+		"team":                        "db",
+	}))
+	g.Expect(podSpec.Containers[0].Env).To(BeEmpty())
+	g.Expect(podSpec.Containers[0].VolumeMounts).To(BeEmpty())
+	g.Expect(podSpec.Containers[1].Env).To(Equal([]corev1.EnvVar{
+		{Name: "TZ", Value: "Asia/Shanghai"},
+		{Name: "FOO", Value: "bar"},
+	}))
+	g.Expect(podSpec.Containers[1].VolumeMounts).To(Equal([]corev1.VolumeMount{{Name: "data", MountPath: "/data"}}))
+	g.Expect(podSpec.Containers[2].Name).To(Equal("agent"))
+	g.Expect(podSpec.InitContainers).To(Equal([]corev1.Container{{Name: "init"}}))
+	g.Expect(podSpec.Volumes).To(Equal([]corev1.Volume{{Name: "config"}, {Name: "data"}}))
+	g.Expect(podSpec.PriorityClassName).To(Equal("high"))
+	g.Expect(*podSpec.SecurityContext.RunAsUser).To(Equal(int64(1000)))
+	g.Expect(podSpec.HostNetwork).To(BeTrue())
+	g.Expect(podSpec.DNSPolicy).To(Equal(corev1.DNSClusterFirstWithHostNet))
+	g.Expect(*podSpec.TerminationGracePeriodSeconds).To(Equal(int64(60)))
+}
+
+func TestValidateGroupNames(t *testing.T) {
+	g := NewGomegaWithT(t)
+	type testcase struct {
+		name  string
+		names []string
+		err   bool
+	}
+	tests := []testcase{
+		{name: "no groups", names: nil, err: false},
+		{name: "valid names", names: []string{"olap", "internal-1"}, err: false},
+		{name: "reserved name", names: []string{"olap", "peer"}, err: true},
+		{name: "duplicated name", names: []string{"olap", "olap"}, err: true},
+		{name: "empty name", names: []string{""}, err: true},
+		{name: "not a DNS-1123 label", names: []string{"OLAP_1"}, err: true},
+	}
+	for _, test := range tests {
+		t.Log(test.name)
+		err := validateGroupNames(test.names)
+		if test.err {
+			g.Expect(err).To(HaveOccurred())
+		} else {
+			g.Expect(err).NotTo(HaveOccurred())
+		}
+	}
+}
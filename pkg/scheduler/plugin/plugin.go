@@ -0,0 +1,283 @@
+// Copyright 2019 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package plugin
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/golang/glog"
+	"github.com/pingcap/tidb-operator/pkg/client/clientset/versioned"
+	"github.com/pingcap/tidb-operator/pkg/label"
+	"github.com/pingcap/tidb-operator/pkg/scheduler"
+	"github.com/pingcap/tidb-operator/pkg/scheduler/predicates"
+	"github.com/pingcap/tidb-operator/pkg/scheduler/priorities"
+	apiv1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/kubernetes/pkg/scheduler/algorithm"
+	schedulerapi "k8s.io/kubernetes/pkg/scheduler/api"
+	schedulercache "k8s.io/kubernetes/pkg/scheduler/cache"
+)
+
+const (
+	// FitPredicateName is the name of the fit predicate registered into the kube-scheduler
+	FitPredicateName = "TiDBOperatorFitPredicate"
+	// PredicatePriorityName is the name of the priority function preferring the nodes kept by the predicates
+	PredicatePriorityName = "TiDBOperatorPredicatePriority"
+	// PriorityName is the name of the priority function summing up the scores of the priorities
+	PriorityName = "TiDBOperatorPriority"
+)
+
+// nodeFitsTTL is how long the node fits prepared for a pod are reused, it covers the predicates of a scheduling cycle
+var nodeFitsTTL = time.Second
+
+// Registry registers the plugin into the kube-scheduler, the functions are the ones
+// with the same names in k8s.io/kubernetes/pkg/scheduler/factory
+type Registry struct {
+	RegisterFitPredicate     func(name string, predicate algorithm.FitPredicate) string
+	RegisterPriorityFunction func(name string, function algorithm.PriorityFunction, weight int) string
+}
+
+// Plugin runs the predicates and priorities of tidb-scheduler in the kube-scheduler process,
+// it's the in-process alternative to the tidb-scheduler extender
+type Plugin struct {
+	// component => predicates
+	predicates map[string][]predicates.Predicate
+	// component => priorities
+	priorities map[string][]priorities.Priority
+
+	lock sync.Mutex
+	// pod uid => the node fits of the pod in the current scheduling cycle
+	nodeFits map[types.UID]*podNodeFits
+}
+
+// podNodeFits are the node fits of the predicates prepared once for a pod,
+// kube-scheduler calls the fit predicate on every node, these calls share the same podNodeFits
+type podNodeFits struct {
+	once    sync.Once
+	created time.Time
+	fits    []namedNodeFit
+	failure algorithm.PredicateFailureReason
+}
+
+type namedNodeFit struct {
+	name string
+	predicates.NodeFit
+}
+
+// New returns a Plugin sharing the predicates and priorities with the tidb-scheduler extender
+func New(kubeCli kubernetes.Interface, cli versioned.Interface) *Plugin {
+	recorder := scheduler.NewEventRecorder(kubeCli)
+	return &Plugin{
+		predicates: scheduler.NewPredicates(kubeCli, cli, recorder),
+		priorities: scheduler.NewPriorities(kubeCli, cli),
+		nodeFits:   map[types.UID]*podNodeFits{},
+	}
+}
+
+// Register registers the fit predicate and the priority functions with the given weights
+func (p *Plugin) Register(registry Registry, predicateWeight, priorityWeight int) {
+	registry.RegisterFitPredicate(FitPredicateName, p.FitPredicate)
+	registry.RegisterPriorityFunction(PredicatePriorityName, p.PredicatePriority, predicateWeight)
+	registry.RegisterPriorityFunction(PriorityName, p.Priority, priorityWeight)
+}
+
+// FitPredicate checks the node with the predicates of the pod's component.
+// The kube-scheduler checks every node on its own, so the predicates are prepared once per pod and
+// only reject the nodes violating their rules here, e.g. the majority rule of the HA predicate,
+// the preference among the fit nodes is left to PredicatePriority.
+func (p *Plugin) FitPredicate(pod *apiv1.Pod, _ algorithm.PredicateMetadata, nodeInfo *schedulercache.NodeInfo) (bool, []algorithm.PredicateFailureReason, error) {
+	node := nodeInfo.Node()
+	if node == nil {
+		return false, nil, fmt.Errorf("node not found")
+	}
+	instanceName, preds := p.predicatesFor(pod)
+	if len(preds) == 0 {
+		return true, nil, nil
+	}
+	nodeFits := p.nodeFitsFor(instanceName, pod, preds)
+	if nodeFits.failure != nil {
+		// the failure tells why the pod can't be scheduled to any node,
+		// returning it as an error would fail the scheduling without the reason
+		return false, []algorithm.PredicateFailureReason{nodeFits.failure}, nil
+	}
+	for _, fit := range nodeFits.fits {
+		if err := fit.Fit(node); err != nil {
+			return false, []algorithm.PredicateFailureReason{newFailureReason(fit.name, err.Error())}, nil
+		}
+	}
+	return true, nil, nil
+}
+
+// PredicatePriority filters the nodes fitting the pod with the prepared predicates of the pod's component,
+// the nodes kept by all the predicates get MaxPriority, e.g. the previous node of the pod under stable scheduling.
+// It's the final decision of the predicates, the events are only emitted here.
+func (p *Plugin) PredicatePriority(pod *apiv1.Pod, _ map[string]*schedulercache.NodeInfo, nodes []*apiv1.Node) (schedulerapi.HostPriorityList, error) {
+	kubeNodes := make([]apiv1.Node, 0, len(nodes))
+	for _, node := range nodes {
+		kubeNodes = append(kubeNodes, *node)
+	}
+
+	preferred := kubeNodes
+	instanceName, preds := p.predicatesFor(pod)
+	if len(preds) > 0 {
+		nodeFits := p.nodeFitsFor(instanceName, pod, preds)
+		// the scheduling cycle of the pod ends with the priorities
+		defer p.forgetNodeFits(pod)
+		if nodeFits.failure != nil {
+			glog.Warningf("predicates failed for pod: %s/%s, skipping: %s", pod.GetNamespace(), pod.GetName(), nodeFits.failure.GetReason())
+		}
+		for _, fit := range nodeFits.fits {
+			filtered, err := fit.Filter(preferred)
+			if err != nil {
+				glog.Warningf("predicate: %s failed for pod: %s/%s, skipping: %v", fit.name, pod.GetNamespace(), pod.GetName(), err)
+				continue
+			}
+			preferred = filtered
+		}
+	}
+
+	preferredNames := map[string]bool{}
+	if len(preferred) < len(kubeNodes) {
+		for _, node := range preferred {
+			preferredNames[node.GetName()] = true
+		}
+	}
+	result := schedulerapi.HostPriorityList{}
+	for _, node := range kubeNodes {
+		score := 0
+		if preferredNames[node.GetName()] {
+			score = schedulerapi.MaxPriority
+		}
+		result = append(result, schedulerapi.HostPriority{Host: node.GetName(), Score: score})
+	}
+	return result, nil
+}
+
+// Priority sums up the scores of the priorities of the pod's component, a failed priority is skipped
+func (p *Plugin) Priority(pod *apiv1.Pod, _ map[string]*schedulercache.NodeInfo, nodes []*apiv1.Node) (schedulerapi.HostPriorityList, error) {
+	kubeNodes := make([]apiv1.Node, 0, len(nodes))
+	for _, node := range nodes {
+		kubeNodes = append(kubeNodes, *node)
+	}
+
+	scores := map[string]int{}
+	instanceName, component := pod.Labels[label.InstanceLabelKey], pod.Labels[label.ComponentLabelKey]
+	if instanceName != "" {
+		for _, priority := range p.priorities[component] {
+			hostPriorities, err := priority.Score(instanceName, pod, kubeNodes)
+			if err != nil {
+				glog.Warningf("priority: %s failed for pod: %s/%s, skipping: %v", priority.Name(), pod.GetNamespace(), pod.GetName(), err)
+				continue
+			}
+			for _, hostPriority := range hostPriorities {
+				scores[hostPriority.Host] += hostPriority.Score
+			}
+		}
+	}
+
+	result := schedulerapi.HostPriorityList{}
+	for _, node := range kubeNodes {
+		result = append(result, schedulerapi.HostPriority{Host: node.GetName(), Score: scores[node.GetName()]})
+	}
+	return result, nil
+}
+
+// predicatesFor returns the instance name and the predicates of the pod, no predicates if the pod is not a member of a tidb cluster
+func (p *Plugin) predicatesFor(pod *apiv1.Pod) (string, []predicates.Predicate) {
+	instanceName, ok := pod.Labels[label.InstanceLabelKey]
+	if !ok {
+		return "", nil
+	}
+	return instanceName, p.predicates[pod.Labels[label.ComponentLabelKey]]
+}
+
+// nodeFitsFor returns the node fits of the pod, they are prepared by the first call in the scheduling cycle
+func (p *Plugin) nodeFitsFor(instanceName string, pod *apiv1.Pod, preds []predicates.Predicate) *podNodeFits {
+	p.lock.Lock()
+	now := time.Now()
+	for uid, nodeFits := range p.nodeFits {
+		if now.Sub(nodeFits.created) > nodeFitsTTL {
+			delete(p.nodeFits, uid)
+		}
+	}
+	nodeFits, ok := p.nodeFits[pod.GetUID()]
+	if !ok {
+		nodeFits = &podNodeFits{created: now}
+		p.nodeFits[pod.GetUID()] = nodeFits
+	}
+	p.lock.Unlock()
+
+	nodeFits.once.Do(func() {
+		for _, predicate := range preds {
+			fit, err := prepareNodeFit(predicate, instanceName, pod)
+			if err != nil {
+				nodeFits.failure = newFailureReason(predicate.Name(), err.Error())
+				return
+			}
+			nodeFits.fits = append(nodeFits.fits, namedNodeFit{name: predicate.Name(), NodeFit: fit})
+		}
+	})
+	return nodeFits
+}
+
+func (p *Plugin) forgetNodeFits(pod *apiv1.Pod) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	delete(p.nodeFits, pod.GetUID())
+}
+
+func prepareNodeFit(predicate predicates.Predicate, instanceName string, pod *apiv1.Pod) (predicates.NodeFit, error) {
+	if nodePredicate, ok := predicate.(predicates.NodePredicate); ok {
+		return nodePredicate.Prepare(instanceName, pod)
+	}
+	return &filterNodeFit{predicate: predicate, instanceName: instanceName, pod: pod}, nil
+}
+
+// filterNodeFit checks the nodes with the Filter of a predicate that isn't a NodePredicate
+type filterNodeFit struct {
+	predicate    predicates.Predicate
+	instanceName string
+	pod          *apiv1.Pod
+}
+
+func (f *filterNodeFit) Fit(node *apiv1.Node) error {
+	nodes, err := f.predicate.Filter(f.instanceName, f.pod, []apiv1.Node{*node})
+	if err != nil {
+		return err
+	}
+	if len(nodes) == 0 {
+		return errors.New("node is filtered out")
+	}
+	return nil
+}
+
+func (f *filterNodeFit) Filter(nodes []apiv1.Node) ([]apiv1.Node, error) {
+	return f.predicate.Filter(f.instanceName, f.pod, nodes)
+}
+
+type failureReason struct {
+	reason string
+}
+
+func newFailureReason(predicateName, msg string) algorithm.PredicateFailureReason {
+	return &failureReason{reason: fmt.Sprintf("%s: %s", predicateName, msg)}
+}
+
+func (r *failureReason) GetReason() string {
+	return r.reason
+}
@@ -0,0 +1,299 @@
+// Copyright 2019 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package plugin
+
+import (
+	"fmt"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/pingcap/tidb-operator/pkg/label"
+	"github.com/pingcap/tidb-operator/pkg/scheduler/predicates"
+	"github.com/pingcap/tidb-operator/pkg/scheduler/priorities"
+	apiv1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	schedulerapi "k8s.io/kubernetes/pkg/scheduler/api"
+	schedulerapiv1 "k8s.io/kubernetes/pkg/scheduler/api/v1"
+	schedulercache "k8s.io/kubernetes/pkg/scheduler/cache"
+)
+
+func TestPluginFitPredicate(t *testing.T) {
+	g := NewGomegaWithT(t)
+	type testcase struct {
+		name       string
+		pod        *apiv1.Pod
+		predicates []predicates.Predicate
+		node       string
+		expectFit  bool
+	}
+
+	testFn := func(test *testcase, t *testing.T) {
+		t.Log(test.name)
+
+		p := &Plugin{
+			predicates: map[string][]predicates.Predicate{label.TiKVLabelVal: test.predicates},
+			nodeFits:   map[types.UID]*podNodeFits{},
+		}
+		nodeInfo := schedulercache.NewNodeInfo()
+		g.Expect(nodeInfo.SetNode(newNode(test.node))).To(Succeed())
+		fit, reasons, err := p.FitPredicate(test.pod, nil, nodeInfo)
+		g.Expect(err).NotTo(HaveOccurred())
+		g.Expect(fit).To(Equal(test.expectFit))
+		if !fit {
+			g.Expect(reasons).To(HaveLen(1))
+		}
+	}
+
+	tests := []testcase{
+		{
+			name:       "pod is not a tidb cluster member",
+			pod:        &apiv1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "pod-1"}},
+			predicates: []predicates.Predicate{newFakePredicate(nil, nil)},
+			node:       "node-1",
+			expectFit:  true,
+		},
+		{
+			name:       "node is kept by the predicates",
+			pod:        newTiKVPod(),
+			predicates: []predicates.Predicate{newFakePredicate([]string{"node-1"}, nil)},
+			node:       "node-1",
+			expectFit:  true,
+		},
+		{
+			name:       "node is filtered out",
+			pod:        newTiKVPod(),
+			predicates: []predicates.Predicate{newFakePredicate([]string{"node-2"}, nil)},
+			node:       "node-1",
+			expectFit:  false,
+		},
+		{
+			name:       "predicate failed",
+			pod:        newTiKVPod(),
+			predicates: []predicates.Predicate{newFakePredicate(nil, fmt.Errorf("waiting for Pod scheduling"))},
+			node:       "node-1",
+			expectFit:  false,
+		},
+	}
+
+	for i := range tests {
+		testFn(&tests[i], t)
+	}
+}
+
+func TestPluginPredicatePriority(t *testing.T) {
+	g := NewGomegaWithT(t)
+	type testcase struct {
+		name       string
+		predicates []predicates.Predicate
+		expect     map[string]int
+	}
+
+	testFn := func(test *testcase, t *testing.T) {
+		t.Log(test.name)
+
+		p := &Plugin{
+			predicates: map[string][]predicates.Predicate{label.TiKVLabelVal: test.predicates},
+			nodeFits:   map[types.UID]*podNodeFits{},
+		}
+		nodes := []*apiv1.Node{newNode("node-1"), newNode("node-2"), newNode("node-3")}
+		result, err := p.PredicatePriority(newTiKVPod(), nil, nodes)
+		g.Expect(err).NotTo(HaveOccurred())
+		g.Expect(scoresOf(result)).To(Equal(test.expect))
+	}
+
+	tests := []testcase{
+		{
+			name:       "all nodes are kept",
+			predicates: []predicates.Predicate{newFakePredicate([]string{"node-1", "node-2", "node-3"}, nil)},
+			expect:     map[string]int{"node-1": 0, "node-2": 0, "node-3": 0},
+		},
+		{
+			name: "prefer the nodes kept by all the predicates",
+			predicates: []predicates.Predicate{
+				newFakePredicate([]string{"node-1", "node-2"}, nil),
+				newFakePredicate([]string{"node-2", "node-3"}, nil),
+			},
+			expect: map[string]int{"node-1": 0, "node-2": schedulerapi.MaxPriority, "node-3": 0},
+		},
+		{
+			name: "skip the failed predicate",
+			predicates: []predicates.Predicate{
+				newFakePredicate(nil, fmt.Errorf("failed")),
+				newFakePredicate([]string{"node-3"}, nil),
+			},
+			expect: map[string]int{"node-1": 0, "node-2": 0, "node-3": schedulerapi.MaxPriority},
+		},
+	}
+
+	for i := range tests {
+		testFn(&tests[i], t)
+	}
+}
+
+func TestPluginPrepareOncePerPod(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	predicate := &fakeNodePredicate{fakePredicate: newFakePredicate([]string{"node-2"}, nil), fitNodeNames: []string{"node-1", "node-2"}}
+	p := &Plugin{
+		predicates: map[string][]predicates.Predicate{label.TiKVLabelVal: {predicate}},
+		nodeFits:   map[types.UID]*podNodeFits{},
+	}
+	pod := newTiKVPod()
+	nodes := []*apiv1.Node{newNode("node-1"), newNode("node-2"), newNode("node-3")}
+	fitNodes := []*apiv1.Node{}
+	for _, node := range nodes {
+		nodeInfo := schedulercache.NewNodeInfo()
+		g.Expect(nodeInfo.SetNode(node)).To(Succeed())
+		fit, _, err := p.FitPredicate(pod, nil, nodeInfo)
+		g.Expect(err).NotTo(HaveOccurred())
+		if fit {
+			fitNodes = append(fitNodes, node)
+		}
+	}
+	g.Expect(fitNodes).To(HaveLen(2))
+
+	result, err := p.PredicatePriority(pod, nil, fitNodes)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(scoresOf(result)).To(Equal(map[string]int{"node-1": 0, "node-2": schedulerapi.MaxPriority}))
+	g.Expect(predicate.prepared).To(Equal(1))
+	g.Expect(p.nodeFits).To(BeEmpty())
+
+	// the next scheduling cycle prepares the predicate again
+	_, err = p.PredicatePriority(pod, nil, fitNodes)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(predicate.prepared).To(Equal(2))
+}
+
+func TestPluginPriority(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	p := &Plugin{
+		priorities: map[string][]priorities.Priority{
+			label.TiKVLabelVal: {
+				newFakePriority(map[string]int{"node-1": 10, "node-2": 5}, nil),
+				newFakePriority(map[string]int{"node-2": 10}, nil),
+				newFakePriority(nil, fmt.Errorf("failed")),
+			},
+		},
+	}
+	nodes := []*apiv1.Node{newNode("node-1"), newNode("node-2"), newNode("node-3")}
+	result, err := p.Priority(newTiKVPod(), nil, nodes)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(scoresOf(result)).To(Equal(map[string]int{"node-1": 10, "node-2": 15, "node-3": 0}))
+}
+
+func newTiKVPod() *apiv1.Pod {
+	return &apiv1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "demo-tikv-0",
+			Namespace: metav1.NamespaceDefault,
+			Labels:    label.New().Instance("demo").TiKV().Labels(),
+		},
+	}
+}
+
+func newNode(name string) *apiv1.Node {
+	return &apiv1.Node{ObjectMeta: metav1.ObjectMeta{Name: name}}
+}
+
+func scoresOf(result schedulerapi.HostPriorityList) map[string]int {
+	scores := map[string]int{}
+	for _, hostPriority := range result {
+		scores[hostPriority.Host] = hostPriority.Score
+	}
+	return scores
+}
+
+type fakePredicate struct {
+	nodeNames []string
+	err       error
+}
+
+func newFakePredicate(nodeNames []string, err error) *fakePredicate {
+	return &fakePredicate{nodeNames: nodeNames, err: err}
+}
+
+func (fp *fakePredicate) Name() string {
+	return "fakePredicate"
+}
+
+func (fp *fakePredicate) Filter(_ string, _ *apiv1.Pod, nodes []apiv1.Node) ([]apiv1.Node, error) {
+	if fp.err != nil {
+		return nil, fp.err
+	}
+	result := []apiv1.Node{}
+	for _, node := range nodes {
+		for _, name := range fp.nodeNames {
+			if node.GetName() == name {
+				result = append(result, node)
+			}
+		}
+	}
+	return result, nil
+}
+
+type fakeNodePredicate struct {
+	*fakePredicate
+	fitNodeNames []string
+	prepared     int
+}
+
+func (fp *fakeNodePredicate) Prepare(instanceName string, pod *apiv1.Pod) (predicates.NodeFit, error) {
+	fp.prepared++
+	return &fakeNodeFit{fp: fp, instanceName: instanceName, pod: pod}, nil
+}
+
+type fakeNodeFit struct {
+	fp           *fakeNodePredicate
+	instanceName string
+	pod          *apiv1.Pod
+}
+
+func (f *fakeNodeFit) Fit(node *apiv1.Node) error {
+	for _, name := range f.fp.fitNodeNames {
+		if node.GetName() == name {
+			return nil
+		}
+	}
+	return fmt.Errorf("node %s doesn't fit", node.GetName())
+}
+
+func (f *fakeNodeFit) Filter(nodes []apiv1.Node) ([]apiv1.Node, error) {
+	return f.fp.Filter(f.instanceName, f.pod, nodes)
+}
+
+type fakePriority struct {
+	scores map[string]int
+	err    error
+}
+
+func newFakePriority(scores map[string]int, err error) *fakePriority {
+	return &fakePriority{scores: scores, err: err}
+}
+
+func (fp *fakePriority) Name() string {
+	return "fakePriority"
+}
+
+func (fp *fakePriority) Score(_ string, _ *apiv1.Pod, nodes []apiv1.Node) (schedulerapiv1.HostPriorityList, error) {
+	if fp.err != nil {
+		return nil, fp.err
+	}
+	result := schedulerapiv1.HostPriorityList{}
+	for _, node := range nodes {
+		result = append(result, schedulerapiv1.HostPriority{Host: node.GetName(), Score: fp.scores[node.GetName()]})
+	}
+	return result, nil
+}
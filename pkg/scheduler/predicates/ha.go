@@ -38,12 +38,14 @@ type ha struct {
 	cli           versioned.Interface
 	podListFn     func(ns, instanceName, component string) (*apiv1.PodList, error)
 	podGetFn      func(ns, podName string) (*apiv1.Pod, error)
+	nodeGetFn     func(nodeName string) (*apiv1.Node, error)
 	pvcGetFn      func(ns, pvcName string) (*apiv1.PersistentVolumeClaim, error)
 	tcGetFn       func(ns, tcName string) (*v1alpha1.TidbCluster, error)
-	pvcListFn     func(ns, instanceName, component string) (*apiv1.PersistentVolumeClaimList, error)
-	updatePVCFn   func(*apiv1.PersistentVolumeClaim) error
-	acquireLockFn func(*apiv1.Pod) (*apiv1.PersistentVolumeClaim, *apiv1.PersistentVolumeClaim, error)
+	acquireLockFn func(*apiv1.Pod) error
 	recorder      record.EventRecorder
+	// identity of this tidb-scheduler recorded in the scheduling lock
+	identity string
+	lockTTL  time.Duration
 }
 
 // NewHA returns a Predicate
@@ -52,13 +54,14 @@ func NewHA(kubeCli kubernetes.Interface, cli versioned.Interface, recorder recor
 		kubeCli:  kubeCli,
 		cli:      cli,
 		recorder: recorder,
+		identity: getSchedulingLockIdentity(),
+		lockTTL:  SchedulingLockTTL,
 	}
 	h.podListFn = h.realPodListFn
 	h.podGetFn = h.realPodGetFn
+	h.nodeGetFn = h.realNodeGetFn
 	h.pvcGetFn = h.realPVCGetFn
 	h.tcGetFn = h.realTCGetFn
-	h.pvcListFn = h.realPVCListFn
-	h.updatePVCFn = h.realUpdatePVCFn
 	h.acquireLockFn = h.realAcquireLock
 	return h
 }
@@ -68,7 +71,8 @@ func (h *ha) Name() string {
 }
 
 // 1. return the node to kube-scheduler if there is only one node and the pod's pvc is bound
-// 2. return these nodes that have least pods and its pods count is less than (replicas+1)/2 to kube-scheduler
+// 2. return these nodes in the topology domains that have least pods and its pods count is less than (replicas+1)/2 to kube-scheduler,
+//    a topology domain is a set of nodes with the same value of the TidbCluster's HA topology key, e.g. a node, a rack or a zone
 // 3. let kube-scheduler to make the final decision
 func (h *ha) Filter(instanceName string, pod *apiv1.Pod, nodes []apiv1.Node) ([]apiv1.Node, error) {
 	h.lock.Lock()
@@ -76,27 +80,75 @@ func (h *ha) Filter(instanceName string, pod *apiv1.Pod, nodes []apiv1.Node) ([]
 
 	ns := pod.GetNamespace()
 	podName := pod.GetName()
-	component := pod.Labels[label.ComponentLabelKey]
-	tcName := getTCNameFromPod(pod, component)
 
 	if len(nodes) == 0 {
 		return nil, fmt.Errorf("kube nodes is empty")
 	}
-	if _, _, err := h.acquireLockFn(pod); err != nil {
+	if err := h.acquireLockFn(pod); err != nil {
 		return nil, err
 	}
 
 	if len(nodes) == 1 {
-		pvcName := pvcName(component, podName)
-		pvc, err := h.pvcGetFn(ns, pvcName)
+		bound, err := h.pvcBound(pod)
 		if err != nil {
 			return nil, err
 		}
-		if pvc.Status.Phase == apiv1.ClaimBound {
+		if bound {
 			return nodes, nil
 		}
 	}
 
+	fit, err := h.newNodeFit(instanceName, pod, nodes)
+	if err != nil {
+		return nil, err
+	}
+	if unlabeledNodes := fit.unlabeledNodes(nodes); len(unlabeledNodes) > 0 {
+		glog.Warningf("pod %s/%s can't be scheduled to nodes %v, because they have no topology label %s", ns, podName, unlabeledNodes, fit.topologyKey)
+	}
+	result, err := fit.Filter(nodes)
+	if err != nil {
+		h.recorder.Event(pod, apiv1.EventTypeWarning, "FailedScheduling", err.Error())
+		return nil, err
+	}
+	return result, nil
+}
+
+// Prepare acquires the scheduling lock and counts the pods in each topology domain once for the pod,
+// the nodes are checked one by one without any API call then
+func (h *ha) Prepare(instanceName string, pod *apiv1.Pod) (NodeFit, error) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	if err := h.acquireLockFn(pod); err != nil {
+		return nil, err
+	}
+	bound, err := h.pvcBound(pod)
+	if err != nil {
+		return nil, err
+	}
+	if bound {
+		// the pod can only be scheduled to the node of its local PV
+		return &haNodeFit{bound: true}, nil
+	}
+	return h.newNodeFit(instanceName, pod, nil)
+}
+
+func (h *ha) pvcBound(pod *apiv1.Pod) (bool, error) {
+	component := pod.Labels[label.ComponentLabelKey]
+	pvc, err := h.pvcGetFn(pod.GetNamespace(), pvcName(component, pod.GetName()))
+	if err != nil {
+		return false, err
+	}
+	return pvc.Status.Phase == apiv1.ClaimBound, nil
+}
+
+// newNodeFit counts the scheduled pods of the pod's component in each topology domain,
+// the domains of the given nodes are known without getting them
+func (h *ha) newNodeFit(instanceName string, pod *apiv1.Pod, nodes []apiv1.Node) (*haNodeFit, error) {
+	ns := pod.GetNamespace()
+	component := pod.Labels[label.ComponentLabelKey]
+	tcName := getTCNameFromPod(pod, component)
+
 	podList, err := h.podListFn(ns, instanceName, component)
 	if err != nil {
 		return nil, err
@@ -105,34 +157,103 @@ func (h *ha) Filter(instanceName string, pod *apiv1.Pod, nodes []apiv1.Node) ([]
 	if err != nil {
 		return nil, err
 	}
-	replicas := getReplicasFrom(tc, component)
+	fit := &haNodeFit{
+		component:   component,
+		replicas:    getReplicasFrom(tc, component),
+		topologyKey: tc.HATopologyKey(),
+		domainPods:  make(map[string][]string),
+	}
 
-	nodeMap := make(map[string][]string)
+	nodeDomains := make(map[string]string)
 	for _, node := range nodes {
-		nodeMap[node.GetName()] = make([]string, 0)
+		if domain, ok := getTopologyDomain(&node, fit.topologyKey); ok {
+			nodeDomains[node.GetName()] = domain
+		}
 	}
 	for _, pod := range podList.Items {
-		pName := pod.GetName()
 		nodeName := pod.Spec.NodeName
-		if nodeName == "" || nodeMap[nodeName] == nil {
+		if nodeName == "" {
+			continue
+		}
+		domain, ok := nodeDomains[nodeName]
+		if !ok {
+			if fit.topologyKey == v1alpha1.DefaultHATopologyKey {
+				domain, ok = nodeName, true
+			} else {
+				// the pod may run on a node which is not a candidate but in the same topology domain as the candidates
+				node, err := h.nodeGetFn(nodeName)
+				if err != nil {
+					return nil, err
+				}
+				domain, ok = getTopologyDomain(node, fit.topologyKey)
+			}
+		}
+		if !ok {
 			continue
 		}
+		fit.domainPods[domain] = append(fit.domainPods[domain], pod.GetName())
+	}
+	glog.V(4).Infof("domainPods: %+v", fit.domainPods)
+	return fit, nil
+}
+
+// haNodeFit holds the pods of a component scheduled to each topology domain
+type haNodeFit struct {
+	// bound is true if the pvc of the pod is bound
+	bound       bool
+	component   string
+	replicas    int32
+	topologyKey string
+	// topology domain => names of the pods scheduled to it
+	domainPods map[string][]string
+}
+
+// Fit rejects the node if it has no topology label or scheduling the pod to its topology domain violates the majority rule
+func (f *haNodeFit) Fit(node *apiv1.Node) error {
+	if f.bound {
+		return nil
+	}
+	domain, ok := getTopologyDomain(node, f.topologyKey)
+	if !ok {
+		return fmt.Errorf("node has no topology label %s", f.topologyKey)
+	}
+	// replicas less than 3 cannot achieve high availability
+	if f.replicas >= 3 && len(f.domainPods[domain]) >= maxPodsPerDomain(f.replicas) {
+		return errors.New(f.capMessage())
+	}
+	return nil
+}
+
+// Filter returns the nodes in the topology domains that have least pods and don't violate the majority rule
+func (f *haNodeFit) Filter(nodes []apiv1.Node) ([]apiv1.Node, error) {
+	if f.bound {
+		return nodes, nil
+	}
 
-		nodeMap[nodeName] = append(nodeMap[nodeName], pName)
+	nodeDomains := make(map[string]string)
+	domainMap := make(map[string][]string)
+	for _, node := range nodes {
+		domain, ok := getTopologyDomain(&node, f.topologyKey)
+		if !ok {
+			continue
+		}
+		nodeDomains[node.GetName()] = domain
+		domainMap[domain] = append(make([]string, 0), f.domainPods[domain]...)
 	}
-	glog.V(4).Infof("nodeMap: %+v", nodeMap)
+	glog.V(4).Infof("domainMap: %+v", domainMap)
 
 	min := -1
-	minNodeNames := make([]string, 0)
-	for nodeName, podNames := range nodeMap {
+	minDomains := make([]string, 0)
+	maxPods := maxPodsPerDomain(f.replicas)
+	for domain, podNames := range domainMap {
 		// replicas less than 3 cannot achieve high availability
-		if replicas < 3 {
-			minNodeNames = append(minNodeNames, nodeName)
+		if f.replicas < 3 {
+			minDomains = append(minDomains, domain)
 			continue
 		}
 
 		podsCount := len(podNames)
-		if podsCount+1 >= int(replicas+1)/2 {
+		if podsCount >= maxPods {
 			continue
 		}
 		if min == -1 {
@@ -144,74 +265,60 @@ func (h *ha) Filter(instanceName string, pod *apiv1.Pod, nodes []apiv1.Node) ([]
 		}
 		if podsCount < min {
 			min = podsCount
-			minNodeNames = make([]string, 0)
+			minDomains = make([]string, 0)
 		}
-		minNodeNames = append(minNodeNames, nodeName)
-	}
-
-	if len(minNodeNames) == 0 {
-		msg := fmt.Sprintf("can't schedule to nodes: %v, because these pods had been scheduled to nodes: %v", GetNodeNames(nodes), nodeMap)
-		h.recorder.Event(pod, apiv1.EventTypeWarning, "FailedScheduling", msg)
-		return nil, errors.New(msg)
-	}
-	return getNodeFromNames(nodes, minNodeNames), nil
-}
-
-// kubernetes scheduling is parallel, to achieve HA, we must ensure the scheduling is serial,
-// so when a pod is scheduling, we set an annotation to its PVC, other pods can't be scheduled at this time,
-// delete the PVC's annotation when the pod is scheduled(PVC is bound and the pod's nodeName is set)
-func (h *ha) realAcquireLock(pod *apiv1.Pod) (*apiv1.PersistentVolumeClaim, *apiv1.PersistentVolumeClaim, error) {
-	ns := pod.GetNamespace()
-	component := pod.Labels[label.ComponentLabelKey]
-	instanceName := pod.Labels[label.InstanceLabelKey]
-	podName := pod.GetName()
-	pvcList, err := h.pvcListFn(ns, instanceName, component)
-	if err != nil {
-		return nil, nil, err
+		minDomains = append(minDomains, domain)
 	}
 
-	currentPVCName := pvcName(component, podName)
-	var currentPVC *apiv1.PersistentVolumeClaim
-	var schedulingPVC *apiv1.PersistentVolumeClaim
-	items := pvcList.Items
-	for i := range items {
-		if items[i].GetName() == currentPVCName {
-			currentPVC = &items[i]
+	if len(minDomains) == 0 {
+		var msg string
+		if f.topologyKey == v1alpha1.DefaultHATopologyKey {
+			msg = fmt.Sprintf("can't schedule to nodes: %v, because these pods had been scheduled to nodes: %v", GetNodeNames(nodes), domainMap)
+		} else {
+			msg = fmt.Sprintf("can't schedule to nodes: %v, because these pods had been scheduled to %s: %v", GetNodeNames(nodes), f.topologyKey, domainMap)
 		}
-		if items[i].Annotations[label.AnnPVCPodScheduling] != "" && schedulingPVC == nil {
-			schedulingPVC = &items[i]
+		if f.replicas >= 3 {
+			msg += ", " + f.capMessage()
+			if minDomains := MinTopologyDomains(f.replicas); len(domainMap) < int(minDomains) {
+				msg += fmt.Sprintf(", %d replicas need at least %d %ss but only %d are available", f.replicas, minDomains, f.domainName(), len(domainMap))
+			}
 		}
+		if unlabeledNodes := f.unlabeledNodes(nodes); len(unlabeledNodes) > 0 {
+			msg += fmt.Sprintf(", nodes %v are skipped for lack of the topology label %s", unlabeledNodes, f.topologyKey)
+		}
+		return nil, errors.New(msg)
 	}
 
-	if currentPVC == nil {
-		return schedulingPVC, currentPVC, fmt.Errorf("can't find current Pod %s/%s's PVC", ns, podName)
-	}
-	if schedulingPVC == nil {
-		return schedulingPVC, currentPVC, h.setCurrentPodScheduling(currentPVC)
-	}
-	if schedulingPVC == currentPVC {
-		return schedulingPVC, currentPVC, nil
+	minNodeNames := make([]string, 0)
+	for _, domain := range minDomains {
+		for nodeName, nodeDomain := range nodeDomains {
+			if nodeDomain == domain {
+				minNodeNames = append(minNodeNames, nodeName)
+			}
+		}
 	}
+	return getNodeFromNames(nodes, minNodeNames), nil
+}
 
-	// if pvc is not defer deleting(has AnnPVCDeferDeleting annotation means defer deleting), we must wait for its scheduling
-	// else clear its AnnPVCPodScheduling annotation and acquire the lock
-	if schedulingPVC.Annotations[label.AnnPVCDeferDeleting] == "" {
-		schedulingPodName := getPodNameFromPVC(schedulingPVC)
-		schedulingPod, err := h.podGetFn(ns, schedulingPodName)
-		if err != nil {
-			return schedulingPVC, currentPVC, err
-		}
-		if schedulingPVC.Status.Phase != apiv1.ClaimBound || schedulingPod.Spec.NodeName == "" {
-			return schedulingPVC, currentPVC, fmt.Errorf("waiting for Pod %s/%s scheduling", ns, strings.TrimPrefix(schedulingPVC.GetName(), component+"-"))
+func (f *haNodeFit) unlabeledNodes(nodes []apiv1.Node) []string {
+	nodeNames := make([]string, 0)
+	for _, node := range nodes {
+		if _, ok := getTopologyDomain(&node, f.topologyKey); !ok {
+			nodeNames = append(nodeNames, node.GetName())
 		}
 	}
+	return nodeNames
+}
 
-	delete(schedulingPVC.Annotations, label.AnnPVCPodScheduling)
-	err = h.updatePVCFn(schedulingPVC)
-	if err != nil {
-		return schedulingPVC, currentPVC, err
+func (f *haNodeFit) capMessage() string {
+	return fmt.Sprintf("at most %d of the %d %s pods can be scheduled to one %s", maxPodsPerDomain(f.replicas), f.replicas, f.component, f.domainName())
+}
+
+func (f *haNodeFit) domainName() string {
+	if f.topologyKey == v1alpha1.DefaultHATopologyKey {
+		return "node"
 	}
-	return schedulingPVC, currentPVC, h.setCurrentPodScheduling(currentPVC)
+	return fmt.Sprintf("%s domain", f.topologyKey)
 }
 
 func (h *ha) realPodListFn(ns, instanceName, component string) (*apiv1.PodList, error) {
@@ -225,16 +332,8 @@ func (h *ha) realPodGetFn(ns, podName string) (*apiv1.Pod, error) {
 	return h.kubeCli.CoreV1().Pods(ns).Get(podName, metav1.GetOptions{})
 }
 
-func (h *ha) realPVCListFn(ns, instanceName, component string) (*apiv1.PersistentVolumeClaimList, error) {
-	selector := label.New().Instance(instanceName).Component(component).Labels()
-	return h.kubeCli.CoreV1().PersistentVolumeClaims(ns).List(metav1.ListOptions{
-		LabelSelector: labels.SelectorFromSet(selector).String(),
-	})
-}
-
-func (h *ha) realUpdatePVCFn(pvc *apiv1.PersistentVolumeClaim) error {
-	_, err := h.kubeCli.CoreV1().PersistentVolumeClaims(pvc.GetNamespace()).Update(pvc)
-	return err
+func (h *ha) realNodeGetFn(nodeName string) (*apiv1.Node, error) {
+	return h.kubeCli.CoreV1().Nodes().Get(nodeName, metav1.GetOptions{})
 }
 
 func (h *ha) realPVCGetFn(ns, pvcName string) (*apiv1.PersistentVolumeClaim, error) {
@@ -245,15 +344,10 @@ func (h *ha) realTCGetFn(ns, tcName string) (*v1alpha1.TidbCluster, error) {
 	return h.cli.PingcapV1alpha1().TidbClusters(ns).Get(tcName, metav1.GetOptions{})
 }
 
-func (h *ha) setCurrentPodScheduling(pvc *apiv1.PersistentVolumeClaim) error {
-	if pvc.Annotations == nil {
-		pvc.Annotations = map[string]string{}
-	}
-	pvc.Annotations[label.AnnPVCPodScheduling] = time.Now().Format(time.RFC3339)
-	return h.updatePVCFn(pvc)
-}
-
 func getTCNameFromPod(pod *apiv1.Pod, component string) string {
+	if group := pod.Labels[label.GroupLabelKey]; group != "" {
+		return strings.TrimSuffix(pod.GenerateName, fmt.Sprintf("-%s-%s-", component, group))
+	}
 	return strings.TrimSuffix(pod.GenerateName, fmt.Sprintf("-%s-", component))
 }
 
@@ -262,7 +356,33 @@ func getReplicasFrom(tc *v1alpha1.TidbCluster, component string) int32 {
 		return tc.Spec.PD.Replicas
 	}
 
-	return tc.Spec.TiKV.Replicas
+	// the pods of all the TiKV groups hold the region replicas together
+	return tc.Spec.TiKV.Replicas + tc.TiKVGroupsReplicas()
+}
+
+// maxPodsPerDomain returns the max number of pods in one topology domain, which is less than half of the replicas,
+// so that losing one domain doesn't lose the majority
+func maxPodsPerDomain(replicas int32) int {
+	return int(replicas+1)/2 - 1
+}
+
+// MinTopologyDomains returns the min number of topology domains the HA predicate needs to schedule all the replicas,
+// e.g. 3 replicas need 3 domains, 4 replicas need 4 domains and 5 replicas need 3 domains
+func MinTopologyDomains(replicas int32) int32 {
+	if replicas < 3 {
+		return 1
+	}
+	maxPods := int32(maxPodsPerDomain(replicas))
+	return (replicas + maxPods - 1) / maxPods
+}
+
+// getTopologyDomain returns the topology domain of the node, the node name is the domain of the default topology key
+func getTopologyDomain(node *apiv1.Node, topologyKey string) (string, bool) {
+	if topologyKey == v1alpha1.DefaultHATopologyKey {
+		return node.GetName(), true
+	}
+	domain, ok := node.Labels[topologyKey]
+	return domain, ok
 }
 
 func pvcName(component, podName string) string {
@@ -277,7 +397,3 @@ func GetNodeNames(nodes []apiv1.Node) []string {
 	sort.Strings(nodeNames)
 	return nodeNames
 }
-
-func getPodNameFromPVC(pvc *apiv1.PersistentVolumeClaim) string {
-	return strings.TrimPrefix(pvc.Name, fmt.Sprintf("%s-", pvc.Labels[label.ComponentLabelKey]))
-}
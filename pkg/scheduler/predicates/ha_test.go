@@ -43,385 +43,11 @@ func TestMapAndIntNil(t *testing.T) {
 	g.Expect(m["a"] == nil).To(Equal(false))
 }
 
-func TestHARealAcquireLockFn(t *testing.T) {
+func TestMinTopologyDomains(t *testing.T) {
 	g := NewGomegaWithT(t)
-	type testcase struct {
-		name        string
-		podFn       func(string, string, int32) *apiv1.Pod
-		podGetFn    func(string, string) (*apiv1.Pod, error)
-		pvcListFn   func(ns, instanceName, component string) (*apiv1.PersistentVolumeClaimList, error)
-		updatePVCFn func(*apiv1.PersistentVolumeClaim) error
-		expectFn    func(*apiv1.PersistentVolumeClaim, *apiv1.PersistentVolumeClaim, error)
-	}
 
-	testFn := func(test *testcase, t *testing.T) {
-		t.Log(test.name)
-		instanceName := "demo"
-		clusterName := "cluster-1"
-
-		ha := ha{
-			pvcListFn:   test.pvcListFn,
-			updatePVCFn: test.updatePVCFn,
-			podGetFn:    podGetScheduled(),
-		}
-		if test.podGetFn != nil {
-			ha.podGetFn = test.podGetFn
-		}
-		pod := test.podFn(instanceName, clusterName, 0)
-
-		test.expectFn(ha.realAcquireLock(pod))
-	}
-
-	tests := []testcase{
-		{
-			name:  "pvcListFn failed",
-			podFn: newHAPDPod,
-			pvcListFn: func(ns, instanceName, component string) (*corev1.PersistentVolumeClaimList, error) {
-				return nil, fmt.Errorf("failed to list pvc")
-			},
-			expectFn: func(schedulingPVC, currentPVC *apiv1.PersistentVolumeClaim, err error) {
-				g.Expect(err).To(HaveOccurred())
-				g.Expect(strings.Contains(err.Error(), "failed to list pvc")).To(BeTrue())
-			},
-		},
-		{
-			name:  "can't find current pvc",
-			podFn: newHAPDPod,
-			pvcListFn: func(ns, instanceName, component string) (*corev1.PersistentVolumeClaimList, error) {
-				return &corev1.PersistentVolumeClaimList{
-					TypeMeta: metav1.TypeMeta{Kind: "PersistentVolumeClaimList", APIVersion: "v1"},
-					Items: []corev1.PersistentVolumeClaim{
-						{
-							TypeMeta: metav1.TypeMeta{Kind: "PersistentVolumeClaim", APIVersion: "v1"},
-							ObjectMeta: metav1.ObjectMeta{
-								Namespace: metav1.NamespaceDefault,
-								Name:      "pd-cluster-1-pd-1",
-							},
-						},
-						{
-							TypeMeta: metav1.TypeMeta{Kind: "PersistentVolumeClaim", APIVersion: "v1"},
-							ObjectMeta: metav1.ObjectMeta{
-								Namespace: metav1.NamespaceDefault,
-								Name:      "pd-cluster-1-pd-2",
-							},
-						},
-					},
-				}, nil
-			},
-			expectFn: func(schedulingPVC, currentPVC *apiv1.PersistentVolumeClaim, err error) {
-				g.Expect(err).To(HaveOccurred())
-				g.Expect(strings.Contains(err.Error(), "can't find current Pod")).To(BeTrue())
-			},
-		},
-		{
-			name:  "no scheduling pod, setCurrentPodScheduling success",
-			podFn: newHAPDPod,
-			pvcListFn: func(ns, instanceName, component string) (*corev1.PersistentVolumeClaimList, error) {
-				return &corev1.PersistentVolumeClaimList{
-					TypeMeta: metav1.TypeMeta{Kind: "PersistentVolumeClaimList", APIVersion: "v1"},
-					Items: []corev1.PersistentVolumeClaim{
-						{
-							TypeMeta: metav1.TypeMeta{Kind: "PersistentVolumeClaim", APIVersion: "v1"},
-							ObjectMeta: metav1.ObjectMeta{
-								Namespace: metav1.NamespaceDefault,
-								Name:      "pd-cluster-1-pd-0",
-							},
-						},
-						{
-							TypeMeta: metav1.TypeMeta{Kind: "PersistentVolumeClaim", APIVersion: "v1"},
-							ObjectMeta: metav1.ObjectMeta{
-								Namespace: metav1.NamespaceDefault,
-								Name:      "pd-cluster-1-pd-1",
-							},
-						},
-					},
-				}, nil
-			},
-			updatePVCFn: func(claim *corev1.PersistentVolumeClaim) error {
-				return nil
-			},
-			expectFn: func(schedulingPVC, currentPVC *apiv1.PersistentVolumeClaim, err error) {
-				g.Expect(err).NotTo(HaveOccurred())
-				g.Expect(schedulingPVC).To(BeNil())
-				g.Expect(currentPVC.Annotations[label.AnnPVCPodScheduling]).NotTo(BeEmpty())
-			},
-		},
-		{
-			name:  "no scheduling pod, setCurrentPodScheduling failed",
-			podFn: newHAPDPod,
-			pvcListFn: func(ns, instanceName, component string) (*corev1.PersistentVolumeClaimList, error) {
-				return &corev1.PersistentVolumeClaimList{
-					TypeMeta: metav1.TypeMeta{Kind: "PersistentVolumeClaimList", APIVersion: "v1"},
-					Items: []corev1.PersistentVolumeClaim{
-						{
-							TypeMeta: metav1.TypeMeta{Kind: "PersistentVolumeClaim", APIVersion: "v1"},
-							ObjectMeta: metav1.ObjectMeta{
-								Namespace: metav1.NamespaceDefault,
-								Name:      "pd-cluster-1-pd-0",
-							},
-						},
-						{
-							TypeMeta: metav1.TypeMeta{Kind: "PersistentVolumeClaim", APIVersion: "v1"},
-							ObjectMeta: metav1.ObjectMeta{
-								Namespace: metav1.NamespaceDefault,
-								Name:      "pd-cluster-1-pd-1",
-							},
-						},
-					},
-				}, nil
-			},
-			updatePVCFn: func(claim *corev1.PersistentVolumeClaim) error {
-				return fmt.Errorf("setCurrentPodScheduling failed")
-			},
-			expectFn: func(schedulingPVC, currentPVC *apiv1.PersistentVolumeClaim, err error) {
-				g.Expect(err).To(HaveOccurred())
-				g.Expect(schedulingPVC).To(BeNil())
-				g.Expect(strings.Contains(err.Error(), "setCurrentPodScheduling failed")).To(BeTrue())
-			},
-		},
-		{
-			name:  "current pvc is scheduling",
-			podFn: newHAPDPod,
-			pvcListFn: func(ns, instanceName, component string) (*corev1.PersistentVolumeClaimList, error) {
-				return &corev1.PersistentVolumeClaimList{
-					TypeMeta: metav1.TypeMeta{Kind: "PersistentVolumeClaimList", APIVersion: "v1"},
-					Items: []corev1.PersistentVolumeClaim{
-						{
-							TypeMeta: metav1.TypeMeta{Kind: "PersistentVolumeClaim", APIVersion: "v1"},
-							ObjectMeta: metav1.ObjectMeta{
-								Namespace:   metav1.NamespaceDefault,
-								Name:        "pd-cluster-1-pd-0",
-								Annotations: map[string]string{label.AnnPVCPodScheduling: "true"},
-							},
-						},
-						{
-							TypeMeta: metav1.TypeMeta{Kind: "PersistentVolumeClaim", APIVersion: "v1"},
-							ObjectMeta: metav1.ObjectMeta{
-								Namespace: metav1.NamespaceDefault,
-								Name:      "pd-cluster-1-pd-1",
-							},
-						},
-					},
-				}, nil
-			},
-			expectFn: func(schedulingPVC, currentPVC *apiv1.PersistentVolumeClaim, err error) {
-				g.Expect(err).NotTo(HaveOccurred())
-				g.Expect(schedulingPVC).To(Equal(currentPVC))
-			},
-		},
-		{
-			name:  "get scheduling pvc's pod error",
-			podFn: newHAPDPod,
-			pvcListFn: func(ns, instanceName, component string) (*corev1.PersistentVolumeClaimList, error) {
-				return &corev1.PersistentVolumeClaimList{
-					TypeMeta: metav1.TypeMeta{Kind: "PersistentVolumeClaimList", APIVersion: "v1"},
-					Items: []corev1.PersistentVolumeClaim{
-						{
-							TypeMeta: metav1.TypeMeta{Kind: "PersistentVolumeClaim", APIVersion: "v1"},
-							ObjectMeta: metav1.ObjectMeta{
-								Namespace: metav1.NamespaceDefault,
-								Name:      "pd-cluster-1-pd-0",
-							},
-						},
-						{
-							TypeMeta: metav1.TypeMeta{Kind: "PersistentVolumeClaim", APIVersion: "v1"},
-							ObjectMeta: metav1.ObjectMeta{
-								Namespace:   metav1.NamespaceDefault,
-								Name:        "pd-cluster-1-pd-1",
-								Annotations: map[string]string{label.AnnPVCPodScheduling: "true"},
-							},
-							Status: corev1.PersistentVolumeClaimStatus{Phase: corev1.ClaimBound},
-						},
-					},
-				}, nil
-			},
-			podGetFn: podGetErr(),
-			expectFn: func(schedulingPVC, currentPVC *apiv1.PersistentVolumeClaim, err error) {
-				g.Expect(err).To(HaveOccurred())
-				g.Expect(schedulingPVC.Annotations[label.AnnPVCPodScheduling]).NotTo(BeEmpty())
-				g.Expect(strings.Contains(err.Error(), "get pod failed")).To(BeTrue())
-			},
-		},
-		{
-			name:  "scheduling pvc is not bound",
-			podFn: newHAPDPod,
-			pvcListFn: func(ns, instanceName, component string) (*corev1.PersistentVolumeClaimList, error) {
-				return &corev1.PersistentVolumeClaimList{
-					TypeMeta: metav1.TypeMeta{Kind: "PersistentVolumeClaimList", APIVersion: "v1"},
-					Items: []corev1.PersistentVolumeClaim{
-						{
-							TypeMeta: metav1.TypeMeta{Kind: "PersistentVolumeClaim", APIVersion: "v1"},
-							ObjectMeta: metav1.ObjectMeta{
-								Namespace: metav1.NamespaceDefault,
-								Name:      "pd-cluster-1-pd-0",
-							},
-						},
-						{
-							TypeMeta: metav1.TypeMeta{Kind: "PersistentVolumeClaim", APIVersion: "v1"},
-							ObjectMeta: metav1.ObjectMeta{
-								Namespace:   metav1.NamespaceDefault,
-								Name:        "pd-cluster-1-pd-1",
-								Annotations: map[string]string{label.AnnPVCPodScheduling: "true"},
-							},
-							Status: corev1.PersistentVolumeClaimStatus{Phase: corev1.ClaimPending},
-						},
-					},
-				}, nil
-			},
-			expectFn: func(schedulingPVC, currentPVC *apiv1.PersistentVolumeClaim, err error) {
-				g.Expect(err).To(HaveOccurred())
-				g.Expect(strings.Contains(err.Error(), "waiting for Pod ")).To(BeTrue())
-			},
-		},
-		{
-			name:  "scheduling pvc is bound, but pod not scheduled",
-			podFn: newHAPDPod,
-			pvcListFn: func(ns, instanceName, component string) (*corev1.PersistentVolumeClaimList, error) {
-				return &corev1.PersistentVolumeClaimList{
-					TypeMeta: metav1.TypeMeta{Kind: "PersistentVolumeClaimList", APIVersion: "v1"},
-					Items: []corev1.PersistentVolumeClaim{
-						{
-							TypeMeta: metav1.TypeMeta{Kind: "PersistentVolumeClaim", APIVersion: "v1"},
-							ObjectMeta: metav1.ObjectMeta{
-								Namespace: metav1.NamespaceDefault,
-								Name:      "pd-cluster-1-pd-0",
-							},
-						},
-						{
-							TypeMeta: metav1.TypeMeta{Kind: "PersistentVolumeClaim", APIVersion: "v1"},
-							ObjectMeta: metav1.ObjectMeta{
-								Namespace:   metav1.NamespaceDefault,
-								Name:        "pd-cluster-1-pd-1",
-								Annotations: map[string]string{label.AnnPVCPodScheduling: "true"},
-							},
-							Status: corev1.PersistentVolumeClaimStatus{Phase: corev1.ClaimBound},
-						},
-					},
-				}, nil
-			},
-			podGetFn: podGetNotScheduled(),
-			updatePVCFn: func(claim *corev1.PersistentVolumeClaim) error {
-				return nil
-			},
-			expectFn: func(schedulingPVC, currentPVC *apiv1.PersistentVolumeClaim, err error) {
-				g.Expect(err).To(HaveOccurred())
-				g.Expect(schedulingPVC.Annotations[label.AnnPVCPodScheduling]).NotTo(BeEmpty())
-				g.Expect(strings.Contains(err.Error(), "waiting for Pod ")).To(BeTrue())
-			},
-		},
-		{
-			name:  "scheduling pvc is bound, update pvc failed",
-			podFn: newHAPDPod,
-			pvcListFn: func(ns, instanceName, component string) (*corev1.PersistentVolumeClaimList, error) {
-				return &corev1.PersistentVolumeClaimList{
-					TypeMeta: metav1.TypeMeta{Kind: "PersistentVolumeClaimList", APIVersion: "v1"},
-					Items: []corev1.PersistentVolumeClaim{
-						{
-							TypeMeta: metav1.TypeMeta{Kind: "PersistentVolumeClaim", APIVersion: "v1"},
-							ObjectMeta: metav1.ObjectMeta{
-								Namespace: metav1.NamespaceDefault,
-								Name:      "pd-cluster-1-pd-0",
-							},
-						},
-						{
-							TypeMeta: metav1.TypeMeta{Kind: "PersistentVolumeClaim", APIVersion: "v1"},
-							ObjectMeta: metav1.ObjectMeta{
-								Namespace:   metav1.NamespaceDefault,
-								Name:        "pd-cluster-1-pd-1",
-								Annotations: map[string]string{label.AnnPVCPodScheduling: "true"},
-							},
-							Status: corev1.PersistentVolumeClaimStatus{Phase: corev1.ClaimBound},
-						},
-					},
-				}, nil
-			},
-			updatePVCFn: func(claim *corev1.PersistentVolumeClaim) error {
-				return fmt.Errorf("failed to update pvc")
-			},
-			expectFn: func(schedulingPVC, currentPVC *apiv1.PersistentVolumeClaim, err error) {
-				g.Expect(err).To(HaveOccurred())
-				g.Expect(schedulingPVC.Annotations[label.AnnPVCPodScheduling]).To(BeEmpty())
-				g.Expect(strings.Contains(err.Error(), "failed to update pvc")).To(BeTrue())
-			},
-		},
-		{
-			name:  "scheduling pvc is bound, update success",
-			podFn: newHAPDPod,
-			pvcListFn: func(ns, instanceName, component string) (*corev1.PersistentVolumeClaimList, error) {
-				return &corev1.PersistentVolumeClaimList{
-					TypeMeta: metav1.TypeMeta{Kind: "PersistentVolumeClaimList", APIVersion: "v1"},
-					Items: []corev1.PersistentVolumeClaim{
-						{
-							TypeMeta: metav1.TypeMeta{Kind: "PersistentVolumeClaim", APIVersion: "v1"},
-							ObjectMeta: metav1.ObjectMeta{
-								Namespace: metav1.NamespaceDefault,
-								Name:      "pd-cluster-1-pd-0",
-							},
-						},
-						{
-							TypeMeta: metav1.TypeMeta{Kind: "PersistentVolumeClaim", APIVersion: "v1"},
-							ObjectMeta: metav1.ObjectMeta{
-								Namespace:   metav1.NamespaceDefault,
-								Name:        "pd-cluster-1-pd-1",
-								Annotations: map[string]string{label.AnnPVCPodScheduling: "true"},
-							},
-							Status: corev1.PersistentVolumeClaimStatus{Phase: corev1.ClaimBound},
-						},
-					},
-				}, nil
-			},
-			updatePVCFn: func(claim *corev1.PersistentVolumeClaim) error {
-				return nil
-			},
-			expectFn: func(schedulingPVC, currentPVC *apiv1.PersistentVolumeClaim, err error) {
-				g.Expect(err).NotTo(HaveOccurred())
-				g.Expect(schedulingPVC.Annotations[label.AnnPVCPodScheduling]).To(BeEmpty())
-				g.Expect(currentPVC.Annotations[label.AnnPVCPodScheduling]).NotTo(BeEmpty())
-			},
-		},
-		{
-			name:  "scheduling pvc is defer deleting, current pvc acquire lock",
-			podFn: newHAPDPod,
-			pvcListFn: func(ns, instanceName, component string) (*corev1.PersistentVolumeClaimList, error) {
-				return &corev1.PersistentVolumeClaimList{
-					TypeMeta: metav1.TypeMeta{Kind: "PersistentVolumeClaimList", APIVersion: "v1"},
-					Items: []corev1.PersistentVolumeClaim{
-						{
-							TypeMeta: metav1.TypeMeta{Kind: "PersistentVolumeClaim", APIVersion: "v1"},
-							ObjectMeta: metav1.ObjectMeta{
-								Namespace: metav1.NamespaceDefault,
-								Name:      "pd-cluster-1-pd-0",
-							},
-						},
-						{
-							TypeMeta: metav1.TypeMeta{Kind: "PersistentVolumeClaim", APIVersion: "v1"},
-							ObjectMeta: metav1.ObjectMeta{
-								Namespace: metav1.NamespaceDefault,
-								Name:      "pd-cluster-1-pd-1",
-								Annotations: map[string]string{
-									label.AnnPVCPodScheduling: "true",
-									label.AnnPVCDeferDeleting: "true",
-								},
-							},
-							Status: corev1.PersistentVolumeClaimStatus{Phase: corev1.ClaimBound},
-						},
-					},
-				}, nil
-			},
-			podGetFn: podGetErr(),
-			updatePVCFn: func(claim *corev1.PersistentVolumeClaim) error {
-				return nil
-			},
-			expectFn: func(schedulingPVC, currentPVC *apiv1.PersistentVolumeClaim, err error) {
-				g.Expect(err).NotTo(HaveOccurred())
-				g.Expect(schedulingPVC.Annotations[label.AnnPVCPodScheduling]).To(BeEmpty())
-				g.Expect(currentPVC.Annotations[label.AnnPVCPodScheduling]).NotTo(BeEmpty())
-			},
-		},
-	}
-
-	for i := range tests {
-		testFn(&tests[i], t)
+	for replicas, domains := range map[int32]int32{1: 1, 2: 1, 3: 3, 4: 4, 5: 3, 6: 3, 7: 3} {
+		g.Expect(MinTopologyDomains(replicas)).To(Equal(domains), "replicas: %d", replicas)
 	}
 }
 
@@ -435,7 +61,8 @@ func TestHAFilter(t *testing.T) {
 		podGetFn      func(string, string) (*apiv1.Pod, error)
 		pvcGetFn      func(string, string) (*apiv1.PersistentVolumeClaim, error)
 		tcGetFn       func(string, string) (*v1alpha1.TidbCluster, error)
-		acquireLockFn func(*apiv1.Pod) (*apiv1.PersistentVolumeClaim, *apiv1.PersistentVolumeClaim, error)
+		nodeGetFn     func(string) (*apiv1.Node, error)
+		acquireLockFn func(*apiv1.Pod) error
 		expectFn      func([]apiv1.Node, error, record.FakeRecorder)
 	}
 
@@ -452,6 +79,7 @@ func TestHAFilter(t *testing.T) {
 			podListFn:     test.podListFn,
 			pvcGetFn:      test.pvcGetFn,
 			tcGetFn:       test.tcGetFn,
+			nodeGetFn:     test.nodeGetFn,
 			acquireLockFn: test.acquireLockFn,
 			recorder:      recorder,
 		}
@@ -489,8 +117,8 @@ func TestHAFilter(t *testing.T) {
 					Status:     corev1.PersistentVolumeClaimStatus{Phase: corev1.ClaimBound},
 				}, nil
 			},
-			acquireLockFn: func(pod *corev1.Pod) (*apiv1.PersistentVolumeClaim, *apiv1.PersistentVolumeClaim, error) {
-				return nil, nil, fmt.Errorf("failed to acquire the lock")
+			acquireLockFn: func(pod *corev1.Pod) error {
+				return fmt.Errorf("failed to acquire the lock")
 			},
 			expectFn: func(nodes []apiv1.Node, err error, _ record.FakeRecorder) {
 				g.Expect(err).To(HaveOccurred())
@@ -890,6 +518,82 @@ func TestHAFilter(t *testing.T) {
 				g.Expect(getSortedNodeNames(nodes)).To(Equal([]string{"kube-node-2"}))
 			},
 		},
+		{
+			name:          "topology key is zone, one pod scheduled in zone-a, return the nodes in the other zones",
+			podFn:         newHAPDPod,
+			nodesFn:       fakeZoneNodes,
+			podListFn:     podListFn(map[string][]int32{"kube-node-1": {0}}),
+			tcGetFn:       tcGetZoneFn,
+			nodeGetFn:     nodeGetZoneFn,
+			acquireLockFn: acquireSuccess,
+			expectFn: func(nodes []apiv1.Node, err error, _ record.FakeRecorder) {
+				g.Expect(err).NotTo(HaveOccurred())
+				g.Expect(getSortedNodeNames(nodes)).To(Equal([]string{"kube-node-2", "kube-node-3"}))
+			},
+		},
+		{
+			name:          "topology key is zone, pods scheduled in zone-a and on a non-candidate node in zone-b, return the node in zone-c",
+			podFn:         newHAPDPod,
+			nodesFn:       fakeZoneNodes,
+			podListFn:     podListFn(map[string][]int32{"kube-node-4": {0}, "kube-node-5": {1}}),
+			tcGetFn:       tcGetZoneFn,
+			nodeGetFn:     nodeGetZoneFn,
+			acquireLockFn: acquireSuccess,
+			expectFn: func(nodes []apiv1.Node, err error, _ record.FakeRecorder) {
+				g.Expect(err).NotTo(HaveOccurred())
+				g.Expect(getSortedNodeNames(nodes)).To(Equal([]string{"kube-node-3"}))
+			},
+		},
+		{
+			name:          "topology key is zone, get node failed",
+			podFn:         newHAPDPod,
+			nodesFn:       fakeZoneNodes,
+			podListFn:     podListFn(map[string][]int32{"kube-node-6": {0}}),
+			tcGetFn:       tcGetZoneFn,
+			nodeGetFn:     nodeGetZoneFn,
+			acquireLockFn: acquireSuccess,
+			expectFn: func(nodes []apiv1.Node, err error, _ record.FakeRecorder) {
+				g.Expect(err).To(HaveOccurred())
+				g.Expect(strings.Contains(err.Error(), "not found")).To(BeTrue())
+			},
+		},
+		{
+			name:          "topology key is zone, pods scheduled in all the zones, can't scheduled",
+			podFn:         newHAPDPod,
+			nodesFn:       fakeZoneNodes,
+			podListFn:     podListFn(map[string][]int32{"kube-node-1": {0}, "kube-node-2": {1}, "kube-node-3": {2}}),
+			tcGetFn:       tcGetZoneFn,
+			nodeGetFn:     nodeGetZoneFn,
+			acquireLockFn: acquireSuccess,
+			expectFn: func(nodes []apiv1.Node, err error, recorder record.FakeRecorder) {
+				g.Expect(err).To(HaveOccurred())
+				events := collectEvents(recorder.Events)
+				g.Expect(events).To(HaveLen(1))
+				g.Expect(events[0]).To(ContainSubstring("FailedScheduling"))
+				g.Expect(strings.Contains(err.Error(), "had been scheduled to zone")).To(BeTrue())
+				g.Expect(err.Error()).To(ContainSubstring("at most 1 of the 3 pd pods can be scheduled to one zone domain"))
+				g.Expect(err.Error()).To(ContainSubstring("nodes [kube-node-no-zone] are skipped for lack of the topology label zone"))
+			},
+		},
+		{
+			name:      "topology key is zone, 4 replicas in 3 zones, can't scheduled the 4th pod",
+			podFn:     newHAPDPod,
+			nodesFn:   fakeZoneNodes,
+			podListFn: podListFn(map[string][]int32{"kube-node-1": {0}, "kube-node-2": {1}, "kube-node-3": {2}}),
+			tcGetFn: func(ns string, tcName string) (*v1alpha1.TidbCluster, error) {
+				tc, _ := tcGetZoneFn(ns, tcName)
+				tc.Spec.PD.Replicas = 4
+				return tc, nil
+			},
+			nodeGetFn:     nodeGetZoneFn,
+			acquireLockFn: acquireSuccess,
+			expectFn: func(nodes []apiv1.Node, err error, recorder record.FakeRecorder) {
+				g.Expect(err).To(HaveOccurred())
+				events := collectEvents(recorder.Events)
+				g.Expect(events).To(HaveLen(1))
+				g.Expect(events[0]).To(ContainSubstring("4 replicas need at least 4 zone domains but only 3 are available"))
+			},
+		},
 	}
 
 	for i := range tests {
@@ -897,6 +601,58 @@ func TestHAFilter(t *testing.T) {
 	}
 }
 
+func TestHAPrepare(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	acquired := 0
+	recorder := record.NewFakeRecorder(10)
+	ha := ha{
+		podListFn: podListFn(map[string][]int32{"kube-node-1": {0}}),
+		pvcGetFn: func(ns string, pvcName string) (*corev1.PersistentVolumeClaim, error) {
+			return &corev1.PersistentVolumeClaim{
+				ObjectMeta: metav1.ObjectMeta{Name: pvcName},
+				Status:     corev1.PersistentVolumeClaimStatus{Phase: corev1.ClaimPending},
+			}, nil
+		},
+		tcGetFn: tcGetZoneFn,
+		nodeGetFn: func(nodeName string) (*apiv1.Node, error) {
+			nodes := getNodeFromNames(fakeZoneNodes(), []string{nodeName})
+			g.Expect(nodes).To(HaveLen(1))
+			return &nodes[0], nil
+		},
+		acquireLockFn: func(*apiv1.Pod) error {
+			acquired++
+			return nil
+		},
+		recorder: recorder,
+	}
+	fit, err := ha.Prepare("demo", newHAPDPod("demo", "cluster-1", 1))
+	g.Expect(err).NotTo(HaveOccurred())
+
+	fitNodes := make([]string, 0)
+	reasons := make([]string, 0)
+	nodes := fakeZoneNodes()
+	for i := range nodes {
+		if err := fit.Fit(&nodes[i]); err != nil {
+			reasons = append(reasons, err.Error())
+			continue
+		}
+		fitNodes = append(fitNodes, nodes[i].GetName())
+	}
+	g.Expect(fitNodes).To(Equal([]string{"kube-node-2", "kube-node-3"}))
+	g.Expect(reasons).To(Equal([]string{
+		"at most 1 of the 3 pd pods can be scheduled to one zone domain",
+		"at most 1 of the 3 pd pods can be scheduled to one zone domain",
+		"node has no topology label zone",
+	}))
+
+	filtered, err := fit.Filter(getNodeFromNames(nodes, fitNodes))
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(getSortedNodeNames(filtered)).To(Equal([]string{"kube-node-2", "kube-node-3"}))
+	g.Expect(acquired).To(Equal(1))
+	g.Expect(collectEvents(recorder.Events)).To(BeEmpty())
+}
+
 func newHAPDPod(instanceName, clusterName string, ordinal int32) *apiv1.Pod {
 	return &apiv1.Pod{
 		TypeMeta: metav1.TypeMeta{Kind: "Pod", APIVersion: "v1"},
@@ -980,6 +736,22 @@ func tcGetFn(ns string, tcName string) (*v1alpha1.TidbCluster, error) {
 	}, nil
 }
 
+func tcGetZoneFn(ns string, tcName string) (*v1alpha1.TidbCluster, error) {
+	tc, _ := tcGetFn(ns, tcName)
+	tc.Spec.HATopologyKey = "zone"
+	return tc, nil
+}
+
+func nodeGetZoneFn(nodeName string) (*apiv1.Node, error) {
+	if nodeName != "kube-node-5" {
+		return nil, fmt.Errorf("node %s not found", nodeName)
+	}
+	return &apiv1.Node{
+		TypeMeta:   metav1.TypeMeta{Kind: "Node", APIVersion: "v1"},
+		ObjectMeta: metav1.ObjectMeta{Name: nodeName, Labels: map[string]string{"zone": "zone-b"}},
+	}, nil
+}
+
 func tcGetOneReplicasFn(ns string, tcName string) (*v1alpha1.TidbCluster, error) {
 	return &v1alpha1.TidbCluster{
 		TypeMeta: metav1.TypeMeta{Kind: "TidbCluster", APIVersion: "v1alpha1"},
@@ -1015,8 +787,8 @@ func getSortedNodeNames(nodes []apiv1.Node) []string {
 	return arr
 }
 
-func acquireSuccess(*apiv1.Pod) (*apiv1.PersistentVolumeClaim, *apiv1.PersistentVolumeClaim, error) {
-	return nil, nil, nil
+func acquireSuccess(*apiv1.Pod) error {
+	return nil
 }
 
 func collectEvents(source <-chan string) []string {
@@ -26,6 +26,24 @@ type Predicate interface {
 	Filter(string, *apiv1.Pod, []apiv1.Node) ([]apiv1.Node, error)
 }
 
+// NodePredicate is a Predicate that can do its per-pod work once, e.g. acquiring the scheduling lock
+// and counting the pods in each topology domain, the kube-scheduler plugin checks the nodes one by one with it
+type NodePredicate interface {
+	Predicate
+
+	// Prepare does the per-pod work and returns the NodeFit of the pod
+	Prepare(string, *apiv1.Pod) (NodeFit, error)
+}
+
+// NodeFit checks the nodes for a pod without doing the per-pod work again
+type NodeFit interface {
+	// Fit returns the reason why the pod can't be scheduled to the node, nil if it fits
+	Fit(*apiv1.Node) error
+
+	// Filter receives a set of fit nodes and returns the ones the predicate prefers, it's the final decision of the predicate
+	Filter([]apiv1.Node) ([]apiv1.Node, error)
+}
+
 func getNodeFromNames(nodes []apiv1.Node, nodeNames []string) []apiv1.Node {
 	var retNodes []apiv1.Node
 	for _, node := range nodes {
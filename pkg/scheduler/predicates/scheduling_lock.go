@@ -0,0 +1,257 @@
+// Copyright 2019 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package predicates
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/golang/glog"
+	"github.com/pingcap/tidb-operator/pkg/controller"
+	"github.com/pingcap/tidb-operator/pkg/label"
+	"github.com/prometheus/client_golang/prometheus"
+	apiv1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+const (
+	// SchedulingLockAcquiredReason represents the reason of event that the pod acquired the scheduling lock
+	SchedulingLockAcquiredReason = "SchedulingLockAcquired"
+	// SchedulingLockExpiredReason represents the reason of event that the pod took over an expired scheduling lock
+	SchedulingLockExpiredReason = "SchedulingLockExpired"
+)
+
+var (
+	// SchedulingLockTTL is the time a pod can hold the scheduling lock,
+	// other pods can take over the lock after it expires
+	SchedulingLockTTL = 5 * time.Minute
+
+	schedulingLockHolder = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: "tidb_scheduler",
+			Name:      "scheduling_lock_holder",
+			Help:      "The pod holding the scheduling lock of the cluster component, the value is the unix time it acquired the lock",
+		}, []string{"namespace", "cluster", "component", "holder", "owner"})
+	schedulingLockAcquisitions = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "tidb_scheduler",
+			Name:      "scheduling_lock_acquisitions_total",
+			Help:      "Counter of the scheduling lock acquisitions by result: acquired, expired, waiting or failed",
+		}, []string{"namespace", "cluster", "component", "result"})
+)
+
+func init() {
+	prometheus.MustRegister(schedulingLockHolder, schedulingLockAcquisitions)
+}
+
+// schedulingLockRecord is stored in the annotation of the scheduling lock ConfigMap
+type schedulingLockRecord struct {
+	// HolderIdentity is the namespace/name of the pod holding the lock
+	HolderIdentity string `json:"holderIdentity"`
+	// HolderUID is the uid of the pod holding the lock, it tells the recreated pod from the old one
+	HolderUID types.UID `json:"holderUID"`
+	// OwnerIdentity is the identity of the tidb-scheduler granted the lock
+	OwnerIdentity string      `json:"ownerIdentity"`
+	TTLSeconds    int64       `json:"ttlSeconds"`
+	AcquireTime   metav1.Time `json:"acquireTime"`
+}
+
+func (r *schedulingLockRecord) expired(now time.Time) bool {
+	return r.AcquireTime.Add(time.Duration(r.TTLSeconds) * time.Second).Before(now)
+}
+
+// kubernetes scheduling is parallel, to achieve HA, we must ensure the scheduling is serial,
+// so a pod must hold the scheduling lock of its cluster component before it's scheduled.
+// The lock is a ConfigMap recording the holder, it's released when the holder is scheduled
+// (its pvc is bound and its nodeName is set) or deleted, and expires after SchedulingLockTTL.
+func (h *ha) realAcquireLock(pod *apiv1.Pod) error {
+	ns := pod.GetNamespace()
+	component := pod.Labels[label.ComponentLabelKey]
+	tcName := getTCNameFromPod(pod, component)
+	cmName := schedulingLockName(tcName, component)
+	record := schedulingLockRecord{
+		HolderIdentity: fmt.Sprintf("%s/%s", ns, pod.GetName()),
+		HolderUID:      pod.GetUID(),
+		OwnerIdentity:  h.identity,
+		TTLSeconds:     int64(h.lockTTL / time.Second),
+		AcquireTime:    metav1.Now(),
+	}
+	acquisitions := schedulingLockAcquisitions.MustCurryWith(prometheus.Labels{"namespace": ns, "cluster": tcName, "component": component})
+
+	cm, err := h.kubeCli.CoreV1().ConfigMaps(ns).Get(cmName, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		if err := h.createSchedulingLock(pod, tcName, cmName, &record); err != nil {
+			acquisitions.WithLabelValues("failed").Inc()
+			return err
+		}
+		h.schedulingLockAcquired(pod, tcName, nil, &record)
+		acquisitions.WithLabelValues("acquired").Inc()
+		return nil
+	}
+	if err != nil {
+		acquisitions.WithLabelValues("failed").Inc()
+		return err
+	}
+
+	oldRecord := getSchedulingLockRecord(cm)
+	if oldRecord != nil {
+		if oldRecord.HolderIdentity == record.HolderIdentity && oldRecord.HolderUID == record.HolderUID {
+			return nil
+		}
+		if oldRecord.expired(time.Now()) {
+			msg := fmt.Sprintf("the scheduling lock held by pod %s since %s expired", oldRecord.HolderIdentity, oldRecord.AcquireTime.Format(time.RFC3339))
+			h.recorder.Event(pod, apiv1.EventTypeWarning, SchedulingLockExpiredReason, msg)
+			acquisitions.WithLabelValues("expired").Inc()
+		} else {
+			released, err := h.schedulingLockReleased(component, oldRecord)
+			if err != nil {
+				acquisitions.WithLabelValues("failed").Inc()
+				return err
+			}
+			if !released {
+				acquisitions.WithLabelValues("waiting").Inc()
+				return fmt.Errorf("waiting for Pod %s scheduling, it holds the scheduling lock granted by %s since %s",
+					oldRecord.HolderIdentity, oldRecord.OwnerIdentity, oldRecord.AcquireTime.Format(time.RFC3339))
+			}
+		}
+	}
+
+	if err := setSchedulingLockRecord(cm, &record); err != nil {
+		return err
+	}
+	// the update fails on conflict if another tidb-scheduler has taken the lock
+	if _, err := h.kubeCli.CoreV1().ConfigMaps(ns).Update(cm); err != nil {
+		acquisitions.WithLabelValues("failed").Inc()
+		return err
+	}
+	h.schedulingLockAcquired(pod, tcName, oldRecord, &record)
+	acquisitions.WithLabelValues("acquired").Inc()
+	return nil
+}
+
+func (h *ha) createSchedulingLock(pod *apiv1.Pod, tcName, cmName string, record *schedulingLockRecord) error {
+	ns := pod.GetNamespace()
+	tc, err := h.tcGetFn(ns, tcName)
+	if err != nil {
+		return err
+	}
+	ownerRef := controller.GetOwnerRef(tc)
+	// the tidb-scheduler is not permitted to update the finalizers of the TidbCluster
+	ownerRef.BlockOwnerDeletion = nil
+	cm := &apiv1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            cmName,
+			Namespace:       ns,
+			Labels:          label.New().Instance(pod.Labels[label.InstanceLabelKey]).Labels(),
+			OwnerReferences: []metav1.OwnerReference{ownerRef},
+		},
+	}
+	if err := setSchedulingLockRecord(cm, record); err != nil {
+		return err
+	}
+	// the creation fails if another tidb-scheduler has created the lock
+	_, err = h.kubeCli.CoreV1().ConfigMaps(ns).Create(cm)
+	return err
+}
+
+// schedulingLockReleased returns true if the lock holder has been scheduled or deleted
+func (h *ha) schedulingLockReleased(component string, record *schedulingLockRecord) (bool, error) {
+	parts := strings.SplitN(record.HolderIdentity, "/", 2)
+	if len(parts) != 2 {
+		return true, nil
+	}
+	ns, podName := parts[0], parts[1]
+	holder, err := h.podGetFn(ns, podName)
+	if apierrors.IsNotFound(err) {
+		return true, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	if holder.GetUID() != record.HolderUID {
+		// the holder has been deleted and recreated
+		return true, nil
+	}
+
+	pvc, err := h.pvcGetFn(ns, pvcName(component, podName))
+	if apierrors.IsNotFound(err) {
+		return true, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	// the pvc is defer deleting means the holder is being scaled in
+	if pvc.Annotations[label.AnnPVCDeferDeleting] != "" {
+		return true, nil
+	}
+	return pvc.Status.Phase == apiv1.ClaimBound && holder.Spec.NodeName != "", nil
+}
+
+func (h *ha) schedulingLockAcquired(pod *apiv1.Pod, tcName string, oldRecord, record *schedulingLockRecord) {
+	ns := pod.GetNamespace()
+	component := pod.Labels[label.ComponentLabelKey]
+	if oldRecord != nil {
+		schedulingLockHolder.DeleteLabelValues(ns, tcName, component, oldRecord.HolderIdentity, oldRecord.OwnerIdentity)
+	}
+	schedulingLockHolder.WithLabelValues(ns, tcName, component, record.HolderIdentity, record.OwnerIdentity).
+		Set(float64(record.AcquireTime.Unix()))
+
+	msg := fmt.Sprintf("acquired the scheduling lock of %s %s/%s from %s", component, ns, tcName, record.OwnerIdentity)
+	h.recorder.Event(pod, apiv1.EventTypeNormal, SchedulingLockAcquiredReason, msg)
+	glog.Infof("pod %s %s", record.HolderIdentity, msg)
+}
+
+func getSchedulingLockRecord(cm *apiv1.ConfigMap) *schedulingLockRecord {
+	data, ok := cm.Annotations[label.AnnSchedulingLock]
+	if !ok {
+		return nil
+	}
+	record := &schedulingLockRecord{}
+	if err := json.Unmarshal([]byte(data), record); err != nil {
+		glog.Warningf("invalid scheduling lock record of ConfigMap %s/%s: %v", cm.GetNamespace(), cm.GetName(), err)
+		return nil
+	}
+	return record
+}
+
+func setSchedulingLockRecord(cm *apiv1.ConfigMap, record *schedulingLockRecord) error {
+	data, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	if cm.Annotations == nil {
+		cm.Annotations = map[string]string{}
+	}
+	cm.Annotations[label.AnnSchedulingLock] = string(data)
+	return nil
+}
+
+func schedulingLockName(tcName, component string) string {
+	return fmt.Sprintf("%s-%s-scheduling-lock", tcName, component)
+}
+
+// getSchedulingLockIdentity returns the identity of this tidb-scheduler, it's the pod name in kubernetes
+func getSchedulingLockIdentity() string {
+	hostname, err := os.Hostname()
+	if err != nil {
+		glog.Warningf("failed to get hostname: %v", err)
+		return "tidb-scheduler"
+	}
+	return hostname
+}
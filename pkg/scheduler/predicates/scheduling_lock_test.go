@@ -0,0 +1,224 @@
+// Copyright 2019 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package predicates
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	"github.com/pingcap/tidb-operator/pkg/apis/pingcap.com/v1alpha1"
+	"github.com/pingcap/tidb-operator/pkg/label"
+	apiv1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/tools/record"
+)
+
+func TestHARealAcquireLock(t *testing.T) {
+	g := NewGomegaWithT(t)
+	type testcase struct {
+		name        string
+		lockRecord  *schedulingLockRecord
+		podGetFn    func(string, string) (*apiv1.Pod, error)
+		pvcGetFn    func(string, string) (*apiv1.PersistentVolumeClaim, error)
+		tcGetFailed bool
+		expectFn    func(error, *schedulingLockRecord, []string)
+	}
+
+	testFn := func(test *testcase, t *testing.T) {
+		t.Log(test.name)
+
+		kubeCli := kubefake.NewSimpleClientset()
+		if test.lockRecord != nil {
+			cm := &apiv1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      schedulingLockName("cluster-1", label.PDLabelVal),
+					Namespace: metav1.NamespaceDefault,
+				},
+			}
+			g.Expect(setSchedulingLockRecord(cm, test.lockRecord)).To(Succeed())
+			_, err := kubeCli.CoreV1().ConfigMaps(metav1.NamespaceDefault).Create(cm)
+			g.Expect(err).NotTo(HaveOccurred())
+		}
+		recorder := record.NewFakeRecorder(10)
+		h := &ha{
+			kubeCli:  kubeCli,
+			podGetFn: test.podGetFn,
+			pvcGetFn: test.pvcGetFn,
+			tcGetFn:  tcGetFn,
+			recorder: recorder,
+			identity: "tidb-scheduler-0",
+			lockTTL:  time.Minute,
+		}
+		if test.tcGetFailed {
+			h.tcGetFn = tcGetErr
+		}
+
+		pod := newHAPDPod("demo", "cluster-1", 0)
+		pod.GenerateName = "cluster-1-pd-"
+		pod.UID = "uid-0"
+		err := h.realAcquireLock(pod)
+
+		var lockRecord *schedulingLockRecord
+		cm, getErr := kubeCli.CoreV1().ConfigMaps(metav1.NamespaceDefault).Get(schedulingLockName("cluster-1", label.PDLabelVal), metav1.GetOptions{})
+		if getErr == nil {
+			lockRecord = getSchedulingLockRecord(cm)
+		}
+		test.expectFn(err, lockRecord, collectEvents(recorder.Events))
+	}
+
+	heldByOtherPod := &schedulingLockRecord{
+		HolderIdentity: "default/cluster-1-pd-1",
+		HolderUID:      "uid-1",
+		OwnerIdentity:  "tidb-scheduler-1",
+		TTLSeconds:     60,
+		AcquireTime:    metav1.Now(),
+	}
+	expectAcquired := func(err error, lockRecord *schedulingLockRecord, events []string) {
+		g.Expect(err).NotTo(HaveOccurred())
+		g.Expect(lockRecord).NotTo(BeNil())
+		g.Expect(lockRecord.HolderIdentity).To(Equal("default/cluster-1-pd-0"))
+		g.Expect(string(lockRecord.HolderUID)).To(Equal("uid-0"))
+		g.Expect(lockRecord.OwnerIdentity).To(Equal("tidb-scheduler-0"))
+		g.Expect(lockRecord.TTLSeconds).To(Equal(int64(60)))
+		g.Expect(events).To(HaveLen(1))
+		g.Expect(events[0]).To(ContainSubstring(SchedulingLockAcquiredReason))
+	}
+
+	tests := []testcase{
+		{
+			name:     "no lock, create the lock",
+			expectFn: expectAcquired,
+		},
+		{
+			name:        "no lock, get tidbcluster failed",
+			tcGetFailed: true,
+			expectFn: func(err error, lockRecord *schedulingLockRecord, events []string) {
+				g.Expect(err).To(HaveOccurred())
+				g.Expect(lockRecord).To(BeNil())
+				g.Expect(events).To(HaveLen(0))
+			},
+		},
+		{
+			name: "the lock is held by the pod itself",
+			lockRecord: &schedulingLockRecord{
+				HolderIdentity: "default/cluster-1-pd-0",
+				HolderUID:      "uid-0",
+				OwnerIdentity:  "tidb-scheduler-1",
+				TTLSeconds:     60,
+				AcquireTime:    metav1.Now(),
+			},
+			expectFn: func(err error, lockRecord *schedulingLockRecord, events []string) {
+				g.Expect(err).NotTo(HaveOccurred())
+				g.Expect(lockRecord.OwnerIdentity).To(Equal("tidb-scheduler-1"))
+				g.Expect(events).To(HaveLen(0))
+			},
+		},
+		{
+			name:       "the lock is held by a pod not scheduled yet",
+			lockRecord: heldByOtherPod,
+			podGetFn:   podGetWithUID(podGetNotScheduled(), "uid-1"),
+			pvcGetFn:   pvcGetWithPhase(apiv1.ClaimPending),
+			expectFn: func(err error, lockRecord *schedulingLockRecord, events []string) {
+				g.Expect(err).To(HaveOccurred())
+				g.Expect(strings.Contains(err.Error(), "waiting for Pod default/cluster-1-pd-1 scheduling")).To(BeTrue())
+				g.Expect(lockRecord.HolderIdentity).To(Equal("default/cluster-1-pd-1"))
+				g.Expect(events).To(HaveLen(0))
+			},
+		},
+		{
+			name:       "get the pod holding the lock failed",
+			lockRecord: heldByOtherPod,
+			podGetFn:   podGetErr(),
+			expectFn: func(err error, lockRecord *schedulingLockRecord, events []string) {
+				g.Expect(err).To(HaveOccurred())
+				g.Expect(strings.Contains(err.Error(), "get pod failed")).To(BeTrue())
+				g.Expect(lockRecord.HolderIdentity).To(Equal("default/cluster-1-pd-1"))
+			},
+		},
+		{
+			name:       "the pod holding the lock is scheduled",
+			lockRecord: heldByOtherPod,
+			podGetFn:   podGetWithUID(podGetScheduled(), "uid-1"),
+			pvcGetFn:   pvcGetWithPhase(apiv1.ClaimBound),
+			expectFn:   expectAcquired,
+		},
+		{
+			name:       "the pod holding the lock is deleted",
+			lockRecord: heldByOtherPod,
+			podGetFn: func(ns, podName string) (*apiv1.Pod, error) {
+				return nil, apierrors.NewNotFound(schema.GroupResource{Resource: "pods"}, podName)
+			},
+			expectFn: expectAcquired,
+		},
+		{
+			name:       "the pod holding the lock is recreated",
+			lockRecord: heldByOtherPod,
+			podGetFn:   podGetWithUID(podGetNotScheduled(), "uid-2"),
+			expectFn:   expectAcquired,
+		},
+		{
+			name: "the lock expired",
+			lockRecord: &schedulingLockRecord{
+				HolderIdentity: "default/cluster-1-pd-1",
+				HolderUID:      "uid-1",
+				OwnerIdentity:  "tidb-scheduler-1",
+				TTLSeconds:     60,
+				AcquireTime:    metav1.NewTime(time.Now().Add(-2 * time.Minute)),
+			},
+			podGetFn: podGetWithUID(podGetNotScheduled(), "uid-1"),
+			pvcGetFn: pvcGetWithPhase(apiv1.ClaimPending),
+			expectFn: func(err error, lockRecord *schedulingLockRecord, events []string) {
+				g.Expect(err).NotTo(HaveOccurred())
+				g.Expect(lockRecord.HolderIdentity).To(Equal("default/cluster-1-pd-0"))
+				g.Expect(events).To(HaveLen(2))
+				g.Expect(events[0]).To(ContainSubstring(SchedulingLockExpiredReason))
+				g.Expect(events[1]).To(ContainSubstring(SchedulingLockAcquiredReason))
+			},
+		},
+	}
+
+	for i := range tests {
+		testFn(&tests[i], t)
+	}
+}
+
+func podGetWithUID(fn func(string, string) (*apiv1.Pod, error), uid string) func(string, string) (*apiv1.Pod, error) {
+	return func(ns, podName string) (*apiv1.Pod, error) {
+		pod, err := fn(ns, podName)
+		if pod != nil {
+			pod.UID = types.UID(uid)
+		}
+		return pod, err
+	}
+}
+
+func pvcGetWithPhase(phase apiv1.PersistentVolumeClaimPhase) func(string, string) (*apiv1.PersistentVolumeClaim, error) {
+	return func(ns, pvcName string) (*apiv1.PersistentVolumeClaim, error) {
+		return &apiv1.PersistentVolumeClaim{
+			ObjectMeta: metav1.ObjectMeta{Name: pvcName, Namespace: ns},
+			Status:     apiv1.PersistentVolumeClaimStatus{Phase: phase},
+		}, nil
+	}
+}
+
+func tcGetErr(ns string, tcName string) (*v1alpha1.TidbCluster, error) {
+	return nil, fmt.Errorf("failed to get tidbcluster %s/%s", ns, tcName)
+}
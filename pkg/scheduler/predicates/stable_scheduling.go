@@ -36,7 +36,10 @@ const (
 
 var (
 	// supportedComponents holds the supported components
-	supportedComponents = sets.NewString(label.TiDBLabelVal)
+	supportedComponents = sets.NewString(label.TiDBLabelVal, label.TiKVLabelVal, label.PDLabelVal)
+	// localVolumeComponents holds the components whose data is stored in PVs,
+	// the previous node only matters when the pod's PVC is not bound
+	localVolumeComponents = sets.NewString(label.TiKVLabelVal, label.PDLabelVal)
 )
 
 type stableScheduling struct {
@@ -59,26 +62,61 @@ func (p *stableScheduling) Name() string {
 	return "StableScheduling"
 }
 
-func (p *stableScheduling) findPreviousNodeInTC(tc *v1alpha1.TidbCluster, pod *apiv1.Pod) string {
-	members := tc.Status.TiDB.Members
-	if members == nil {
-		return ""
+func (p *stableScheduling) findPreviousNodeInTC(tc *v1alpha1.TidbCluster, pod *apiv1.Pod, component string) string {
+	switch component {
+	case label.TiDBLabelVal:
+		return tc.Status.TiDB.Members[pod.Name].NodeName
+	case label.PDLabelVal:
+		return tc.Status.PD.Members[pod.Name].NodeName
+	case label.TiKVLabelVal:
+		for _, store := range tc.Status.TiKV.Stores {
+			if store.PodName == pod.Name && store.NodeName != "" {
+				return store.NodeName
+			}
+		}
 	}
-	tidbMember, ok := tc.Status.TiDB.Members[pod.Name]
-	if !ok {
-		return ""
+	return ""
+}
+
+// hasBoundPVC returns true if any PVC of the pod is bound, the bound local PV
+// has already pinned the pod to the node of it
+func (p *stableScheduling) hasBoundPVC(pod *apiv1.Pod) (bool, error) {
+	for _, vol := range pod.Spec.Volumes {
+		if vol.PersistentVolumeClaim == nil {
+			continue
+		}
+		pvc, err := p.kubeCli.CoreV1().PersistentVolumeClaims(pod.GetNamespace()).Get(vol.PersistentVolumeClaim.ClaimName, metav1.GetOptions{})
+		if apierrors.IsNotFound(err) {
+			continue
+		}
+		if err != nil {
+			return false, err
+		}
+		if pvc.Spec.VolumeName != "" {
+			return true, nil
+		}
 	}
-	return tidbMember.NodeName
+	return false, nil
 }
 
 func (p *stableScheduling) Filter(instanceName string, pod *apiv1.Pod, nodes []apiv1.Node) ([]apiv1.Node, error) {
+	fit, err := p.Prepare(instanceName, pod)
+	if err != nil {
+		return nil, err
+	}
+	return fit.Filter(nodes)
+}
+
+// Prepare finds the previous node of the pod once, all the nodes fit the pod and the previous one is preferred
+func (p *stableScheduling) Prepare(instanceName string, pod *apiv1.Pod) (NodeFit, error) {
 	ns := pod.GetNamespace()
 	podName := pod.GetName()
 	component := pod.Labels[label.ComponentLabelKey]
 	tcName := getTCNameFromPod(pod, component)
+	fit := &stableNodeFit{recorder: p.recorder, pod: pod, tcName: tcName}
 
 	if !supportedComponents.Has(component) {
-		return nodes, nil
+		return fit, nil
 	}
 
 	tc, err := p.cli.PingcapV1alpha1().TidbClusters(ns).Get(tcName, metav1.GetOptions{})
@@ -87,26 +125,56 @@ func (p *stableScheduling) Filter(instanceName string, pod *apiv1.Pod, nodes []a
 			// However tidb-operator will delete pods when tidb cluster does
 			// not exist anymore, it does no harm to fail the pod. But it's
 			// best to not make any assumptions here.
-			return nodes, nil
+			return fit, nil
 		}
 		return nil, err
 	}
 
-	nodeName := p.findPreviousNodeInTC(tc, pod)
-
-	if nodeName != "" {
-		glog.V(2).Infof("found previous node %q for pod %q in TiDB cluster %q", nodeName, podName, tcName)
-		for _, node := range nodes {
-			if node.Name == nodeName {
-				glog.V(2).Infof("previous node %q for pod %q in TiDB cluster %q exists in candicates, filter out other nodes", nodeName, podName, tcName)
-				return []apiv1.Node{node}, nil
-			}
+	if localVolumeComponents.Has(component) {
+		bound, err := p.hasBoundPVC(pod)
+		if err != nil {
+			return nil, err
 		}
-		msg := fmt.Sprintf("cannot run on its previous node %q", nodeName)
-		p.recorder.Event(pod, apiv1.EventTypeWarning, UnableToRunOnPreviousNodeReason, msg)
+		if bound {
+			glog.V(4).Infof("pod %s/%s has bound pvc, skip stable scheduling", ns, podName)
+			return fit, nil
+		}
+	}
+
+	fit.previousNode = p.findPreviousNodeInTC(tc, pod, component)
+	if fit.previousNode != "" {
+		glog.V(2).Infof("found previous node %q for pod %q in TiDB cluster %q", fit.previousNode, podName, tcName)
 	} else {
 		glog.V(2).Infof("no previous node exists for pod %q in TiDB cluster %s/%q", podName, ns, tcName)
 	}
+	return fit, nil
+}
+
+// stableNodeFit holds the previous node of a pod
+type stableNodeFit struct {
+	recorder     record.EventRecorder
+	pod          *apiv1.Pod
+	tcName       string
+	previousNode string
+}
+
+// Fit never rejects a node, stable scheduling only prefers the previous node
+func (f *stableNodeFit) Fit(_ *apiv1.Node) error {
+	return nil
+}
 
+// Filter returns the previous node if it's a candidate, otherwise all the nodes
+func (f *stableNodeFit) Filter(nodes []apiv1.Node) ([]apiv1.Node, error) {
+	if f.previousNode == "" {
+		return nodes, nil
+	}
+	for _, node := range nodes {
+		if node.Name == f.previousNode {
+			glog.V(2).Infof("previous node %q for pod %q in TiDB cluster %q exists in candicates, filter out other nodes", f.previousNode, f.pod.GetName(), f.tcName)
+			return []apiv1.Node{node}, nil
+		}
+	}
+	msg := fmt.Sprintf("cannot run on its previous node %q", f.previousNode)
+	f.recorder.Event(f.pod, apiv1.EventTypeWarning, UnableToRunOnPreviousNodeReason, msg)
 	return nodes, nil
 }
@@ -48,6 +48,47 @@ func makeTidbCluster(name, node string) *v1alpha1.TidbCluster {
 	return tc
 }
 
+func makePDTidbCluster(name, node string) *v1alpha1.TidbCluster {
+	tc := makeTidbCluster("", "")
+	tc.Status.PD.Members = map[string]v1alpha1.PDMember{
+		name: {Name: name, NodeName: node},
+	}
+	return tc
+}
+
+func makeTiKVTidbCluster(name, node string) *v1alpha1.TidbCluster {
+	tc := makeTidbCluster("", "")
+	tc.Status.TiKV.Stores = map[string]v1alpha1.TiKVStore{
+		"1": {ID: "1", PodName: name, NodeName: node},
+	}
+	return tc
+}
+
+func makePodWithPVC(name string, component string, pvcName string) *v1.Pod {
+	pod := makePod(name, component)
+	pod.Spec.Volumes = []v1.Volume{
+		{
+			Name: component,
+			VolumeSource: v1.VolumeSource{
+				PersistentVolumeClaim: &v1.PersistentVolumeClaimVolumeSource{ClaimName: pvcName},
+			},
+		},
+	}
+	return pod
+}
+
+func makePVC(name string, volumeName string) *v1.PersistentVolumeClaim {
+	return &v1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: v1.NamespaceDefault,
+		},
+		Spec: v1.PersistentVolumeClaimSpec{
+			VolumeName: volumeName,
+		},
+	}
+}
+
 func makePod(name string, component string) *v1.Pod {
 	return &v1.Pod{
 		ObjectMeta: metav1.ObjectMeta{
@@ -75,14 +116,15 @@ func TestStableSchedulingFilter(t *testing.T) {
 		pod            *v1.Pod
 		candicateNodes []v1.Node
 		tidbCluster    *v1alpha1.TidbCluster
+		pvc            *v1.PersistentVolumeClaim
 		expectFn       func([]v1.Node, error, *record.FakeRecorder)
 	}
 
 	tests := []testcase{
 		{
-			name:         "cannot schedule to previous node because it's not a TiDB pod",
+			name:         "cannot schedule to previous node because the component is not supported",
 			instanceName: "demo",
-			pod:          makePod("demo-tikv-0", label.TiKVLabelVal),
+			pod:          makePod("demo-monitor-0", "monitor"),
 			tidbCluster:  nil,
 			candicateNodes: []v1.Node{
 				makeNode("node-1"),
@@ -158,12 +200,80 @@ func TestStableSchedulingFilter(t *testing.T) {
 				g.Expect(getSortedNodeNames(nodes)).To(Equal([]string{"node-2"}))
 			},
 		},
+		{
+			name:         "schedule the pd pod to previous node",
+			instanceName: "demo",
+			pod:          makePodWithPVC("demo-pd-0", label.PDLabelVal, "pd-demo-pd-0"),
+			tidbCluster:  makePDTidbCluster("demo-pd-0", "node-2"),
+			candicateNodes: []v1.Node{
+				makeNode("node-1"),
+				makeNode("node-2"),
+				makeNode("node-3"),
+			},
+			expectFn: func(nodes []v1.Node, err error, recorder *record.FakeRecorder) {
+				g.Expect(err).NotTo(HaveOccurred())
+				g.Expect(getSortedNodeNames(nodes)).To(Equal([]string{"node-2"}))
+			},
+		},
+		{
+			name:         "schedule the tikv pod with unbound pvc to previous node",
+			instanceName: "demo",
+			pod:          makePodWithPVC("demo-tikv-0", label.TiKVLabelVal, "tikv-demo-tikv-0"),
+			tidbCluster:  makeTiKVTidbCluster("demo-tikv-0", "node-3"),
+			pvc:          makePVC("tikv-demo-tikv-0", ""),
+			candicateNodes: []v1.Node{
+				makeNode("node-1"),
+				makeNode("node-2"),
+				makeNode("node-3"),
+			},
+			expectFn: func(nodes []v1.Node, err error, recorder *record.FakeRecorder) {
+				g.Expect(err).NotTo(HaveOccurred())
+				g.Expect(getSortedNodeNames(nodes)).To(Equal([]string{"node-3"}))
+			},
+		},
+		{
+			name:         "skip the tikv pod with bound pvc",
+			instanceName: "demo",
+			pod:          makePodWithPVC("demo-tikv-0", label.TiKVLabelVal, "tikv-demo-tikv-0"),
+			tidbCluster:  makeTiKVTidbCluster("demo-tikv-0", "node-3"),
+			pvc:          makePVC("tikv-demo-tikv-0", "pv-1"),
+			candicateNodes: []v1.Node{
+				makeNode("node-1"),
+				makeNode("node-2"),
+			},
+			expectFn: func(nodes []v1.Node, err error, recorder *record.FakeRecorder) {
+				g.Expect(err).NotTo(HaveOccurred())
+				g.Expect(collectEvents(recorder.Events)).To(HaveLen(0))
+				g.Expect(len(nodes)).To(Equal(2))
+			},
+		},
+		{
+			name:         "cannot schedule the tikv pod to previous node because previous node does not exist in candicates",
+			instanceName: "demo",
+			pod:          makePodWithPVC("demo-tikv-0", label.TiKVLabelVal, "tikv-demo-tikv-0"),
+			tidbCluster:  makeTiKVTidbCluster("demo-tikv-0", "node-3"),
+			candicateNodes: []v1.Node{
+				makeNode("node-1"),
+				makeNode("node-2"),
+			},
+			expectFn: func(nodes []v1.Node, err error, recorder *record.FakeRecorder) {
+				g.Expect(err).NotTo(HaveOccurred())
+				events := collectEvents(recorder.Events)
+				g.Expect(events).To(HaveLen(1))
+				g.Expect(events[0]).To(ContainSubstring(UnableToRunOnPreviousNodeReason))
+				g.Expect(len(nodes)).To(Equal(2))
+			},
+		},
 	}
 
 	for _, tc := range tests {
 		t.Log(tc.name)
 		recorder := record.NewFakeRecorder(10)
 		kubeCli := fake.NewSimpleClientset()
+		if tc.pvc != nil {
+			_, err := kubeCli.CoreV1().PersistentVolumeClaims(v1.NamespaceDefault).Create(tc.pvc)
+			g.Expect(err).NotTo(HaveOccurred())
+		}
 		if tc.pod != nil {
 			_, err := kubeCli.CoreV1().Pods(v1.NamespaceDefault).Create(tc.pod)
 			g.Expect(err).NotTo(HaveOccurred())
@@ -182,3 +292,32 @@ func TestStableSchedulingFilter(t *testing.T) {
 		tc.expectFn(nodes, err, recorder)
 	}
 }
+
+func TestStableSchedulingPrepare(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	recorder := record.NewFakeRecorder(10)
+	cli := pingcapfake.NewSimpleClientset()
+	_, err := cli.PingcapV1alpha1().TidbClusters(v1.NamespaceDefault).Create(makeTidbCluster("demo-tidb-0", "node-3"))
+	g.Expect(err).NotTo(HaveOccurred())
+	p := stableScheduling{
+		kubeCli:  fake.NewSimpleClientset(),
+		cli:      cli,
+		recorder: recorder,
+	}
+	fit, err := p.Prepare("demo", makePod("demo-tidb-0", label.TiDBLabelVal))
+	g.Expect(err).NotTo(HaveOccurred())
+
+	nodes := []v1.Node{makeNode("node-1"), makeNode("node-2")}
+	for i := range nodes {
+		g.Expect(fit.Fit(&nodes[i])).To(Succeed())
+	}
+	g.Expect(collectEvents(recorder.Events)).To(BeEmpty())
+
+	filtered, err := fit.Filter(nodes)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(getSortedNodeNames(filtered)).To(Equal([]string{"node-1", "node-2"}))
+	events := collectEvents(recorder.Events)
+	g.Expect(events).To(HaveLen(1))
+	g.Expect(events[0]).To(ContainSubstring(UnableToRunOnPreviousNodeReason))
+}
@@ -56,6 +56,31 @@ func fakeFourNodes() []apiv1.Node {
 	}
 }
 
+func fakeZoneNodes() []apiv1.Node {
+	return []apiv1.Node{
+		{
+			TypeMeta:   metav1.TypeMeta{Kind: "Node", APIVersion: "v1"},
+			ObjectMeta: metav1.ObjectMeta{Name: "kube-node-1", Labels: map[string]string{"zone": "zone-a"}},
+		},
+		{
+			TypeMeta:   metav1.TypeMeta{Kind: "Node", APIVersion: "v1"},
+			ObjectMeta: metav1.ObjectMeta{Name: "kube-node-2", Labels: map[string]string{"zone": "zone-b"}},
+		},
+		{
+			TypeMeta:   metav1.TypeMeta{Kind: "Node", APIVersion: "v1"},
+			ObjectMeta: metav1.ObjectMeta{Name: "kube-node-3", Labels: map[string]string{"zone": "zone-c"}},
+		},
+		{
+			TypeMeta:   metav1.TypeMeta{Kind: "Node", APIVersion: "v1"},
+			ObjectMeta: metav1.ObjectMeta{Name: "kube-node-4", Labels: map[string]string{"zone": "zone-a"}},
+		},
+		{
+			TypeMeta:   metav1.TypeMeta{Kind: "Node", APIVersion: "v1"},
+			ObjectMeta: metav1.ObjectMeta{Name: "kube-node-no-zone"},
+		},
+	}
+}
+
 func fakeTwoNodes() []apiv1.Node {
 	return []apiv1.Node{
 		{
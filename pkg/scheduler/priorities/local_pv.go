@@ -0,0 +1,109 @@
+// Copyright 2019 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package priorities
+
+import (
+	apiv1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	kubeletapis "k8s.io/kubernetes/pkg/kubelet/apis"
+	schedulerapiv1 "k8s.io/kubernetes/pkg/scheduler/api/v1"
+)
+
+type localPVCapacity struct {
+	kubeCli kubernetes.Interface
+}
+
+// NewLocalPVCapacity returns a Priority which prefers the nodes with more free local PV capacity
+// of the storage class requested by the pod
+func NewLocalPVCapacity(kubeCli kubernetes.Interface) Priority {
+	return &localPVCapacity{kubeCli: kubeCli}
+}
+
+func (p *localPVCapacity) Name() string {
+	return "LocalPVCapacity"
+}
+
+func (p *localPVCapacity) Score(_ string, pod *apiv1.Pod, nodes []apiv1.Node) (schedulerapiv1.HostPriorityList, error) {
+	ns := pod.GetNamespace()
+	freeCapacity := map[string]int64{}
+
+	pvcs := []*apiv1.PersistentVolumeClaim{}
+	for _, vol := range pod.Spec.Volumes {
+		if vol.PersistentVolumeClaim == nil {
+			continue
+		}
+		pvc, err := p.kubeCli.CoreV1().PersistentVolumeClaims(ns).Get(vol.PersistentVolumeClaim.ClaimName, metav1.GetOptions{})
+		if err != nil {
+			return nil, err
+		}
+		// a bound local PV pins the pod to its node already
+		if pvc.Spec.VolumeName == "" && pvc.Spec.StorageClassName != nil {
+			pvcs = append(pvcs, pvc)
+		}
+	}
+	if len(pvcs) == 0 {
+		return scoreByValues(nodes, freeCapacity), nil
+	}
+
+	pvList, err := p.kubeCli.CoreV1().PersistentVolumes().List(metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	hostNodes := map[string]string{}
+	for _, node := range nodes {
+		hostNodes[getNodeHostname(&node)] = node.GetName()
+	}
+	for _, pv := range pvList.Items {
+		if pv.Spec.Local == nil || pv.Status.Phase != apiv1.VolumeAvailable || pv.Spec.ClaimRef != nil {
+			continue
+		}
+		nodeName, ok := hostNodes[getPVHostname(&pv)]
+		if !ok {
+			continue
+		}
+		capacity := pv.Spec.Capacity[apiv1.ResourceStorage]
+		for _, pvc := range pvcs {
+			request := pvc.Spec.Resources.Requests[apiv1.ResourceStorage]
+			if pv.Spec.StorageClassName == *pvc.Spec.StorageClassName && capacity.Cmp(request) >= 0 {
+				freeCapacity[nodeName] += capacity.Value()
+				break
+			}
+		}
+	}
+
+	return scoreByValues(nodes, freeCapacity), nil
+}
+
+func getNodeHostname(node *apiv1.Node) string {
+	if hostname, ok := node.Labels[kubeletapis.LabelHostname]; ok {
+		return hostname
+	}
+	return node.GetName()
+}
+
+// getPVHostname returns the hostname in the node affinity of the local PV
+func getPVHostname(pv *apiv1.PersistentVolume) string {
+	if pv.Spec.NodeAffinity == nil || pv.Spec.NodeAffinity.Required == nil {
+		return ""
+	}
+	for _, term := range pv.Spec.NodeAffinity.Required.NodeSelectorTerms {
+		for _, expr := range term.MatchExpressions {
+			if expr.Key == kubeletapis.LabelHostname && expr.Operator == apiv1.NodeSelectorOpIn && len(expr.Values) > 0 {
+				return expr.Values[0]
+			}
+		}
+	}
+	return ""
+}
@@ -0,0 +1,127 @@
+// Copyright 2019 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package priorities
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/pingcap/tidb-operator/pkg/label"
+	apiv1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+	kubeletapis "k8s.io/kubernetes/pkg/kubelet/apis"
+)
+
+func TestLocalPVCapacityScore(t *testing.T) {
+	g := NewGomegaWithT(t)
+	type testcase struct {
+		name       string
+		volumeName string
+		pvs        []*apiv1.PersistentVolume
+		expect     map[string]int
+	}
+
+	testFn := func(test *testcase, t *testing.T) {
+		t.Log(test.name)
+
+		kubeCli := kubefake.NewSimpleClientset()
+		scName := "local-storage"
+		pvc := &apiv1.PersistentVolumeClaim{
+			ObjectMeta: metav1.ObjectMeta{Name: "tikv-demo-tikv-0", Namespace: metav1.NamespaceDefault},
+			Spec: apiv1.PersistentVolumeClaimSpec{
+				StorageClassName: &scName,
+				VolumeName:       test.volumeName,
+				Resources: apiv1.ResourceRequirements{
+					Requests: apiv1.ResourceList{apiv1.ResourceStorage: resource.MustParse("10Gi")},
+				},
+			},
+		}
+		kubeCli.CoreV1().PersistentVolumeClaims(metav1.NamespaceDefault).Create(pvc)
+		for _, pv := range test.pvs {
+			kubeCli.CoreV1().PersistentVolumes().Create(pv)
+		}
+
+		pod := newPod("demo-tikv-0", label.TiKVLabelVal, "")
+		pod.Spec.Volumes = []apiv1.Volume{
+			{
+				Name: "tikv",
+				VolumeSource: apiv1.VolumeSource{
+					PersistentVolumeClaim: &apiv1.PersistentVolumeClaimVolumeSource{ClaimName: pvc.GetName()},
+				},
+			},
+		}
+		p := NewLocalPVCapacity(kubeCli)
+		result, err := p.Score("demo", pod, newNodes("node-1", "node-2", "node-3"))
+		g.Expect(err).NotTo(HaveOccurred())
+		g.Expect(scoresOf(result)).To(Equal(test.expect))
+	}
+
+	tests := []testcase{
+		{
+			name:       "pvc is bound",
+			volumeName: "pv-1",
+			pvs:        []*apiv1.PersistentVolume{newLocalPV("pv-2", "node-1", "local-storage", "10Gi")},
+			expect:     map[string]int{"node-1": 0, "node-2": 0, "node-3": 0},
+		},
+		{
+			name: "prefer the nodes with more free capacity",
+			pvs: []*apiv1.PersistentVolume{
+				newLocalPV("pv-1", "node-1", "local-storage", "10Gi"),
+				newLocalPV("pv-2", "node-2", "local-storage", "10Gi"),
+				newLocalPV("pv-3", "node-2", "local-storage", "10Gi"),
+			},
+			expect: map[string]int{"node-1": MaxPriority / 2, "node-2": MaxPriority, "node-3": 0},
+		},
+		{
+			name: "ignore the pvs not matching the claim",
+			pvs: []*apiv1.PersistentVolume{
+				newLocalPV("pv-1", "node-1", "local-storage", "10Gi"),
+				newLocalPV("pv-2", "node-2", "local-storage", "5Gi"),
+				newLocalPV("pv-3", "node-3", "other-storage", "10Gi"),
+			},
+			expect: map[string]int{"node-1": MaxPriority, "node-2": 0, "node-3": 0},
+		},
+	}
+
+	for i := range tests {
+		testFn(&tests[i], t)
+	}
+}
+
+func newLocalPV(name, hostname, scName, capacity string) *apiv1.PersistentVolume {
+	return &apiv1.PersistentVolume{
+		ObjectMeta: metav1.ObjectMeta{Name: name},
+		Spec: apiv1.PersistentVolumeSpec{
+			StorageClassName: scName,
+			Capacity:         apiv1.ResourceList{apiv1.ResourceStorage: resource.MustParse(capacity)},
+			PersistentVolumeSource: apiv1.PersistentVolumeSource{
+				Local: &apiv1.LocalVolumeSource{Path: "/mnt/disks/" + name},
+			},
+			NodeAffinity: &apiv1.VolumeNodeAffinity{
+				Required: &apiv1.NodeSelector{
+					NodeSelectorTerms: []apiv1.NodeSelectorTerm{
+						{
+							MatchExpressions: []apiv1.NodeSelectorRequirement{
+								{Key: kubeletapis.LabelHostname, Operator: apiv1.NodeSelectorOpIn, Values: []string{hostname}},
+							},
+						},
+					},
+				},
+			},
+		},
+		Status: apiv1.PersistentVolumeStatus{Phase: apiv1.VolumeAvailable},
+	}
+}
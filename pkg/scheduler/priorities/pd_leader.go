@@ -0,0 +1,143 @@
+// Copyright 2019 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package priorities
+
+import (
+	"sync"
+	"time"
+
+	"github.com/golang/glog"
+	"github.com/pingcap/tidb-operator/pkg/apis/pingcap.com/v1alpha1"
+	"github.com/pingcap/tidb-operator/pkg/client/clientset/versioned"
+	"github.com/pingcap/tidb-operator/pkg/label"
+	apiv1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	schedulerapiv1 "k8s.io/kubernetes/pkg/scheduler/api/v1"
+)
+
+// pdLeadersTTL is how long the listed PD leaders are reused, the pods of a cluster are scheduled in a burst
+// and the PD leaders rarely move, so the TidbClusters are not listed for every pod
+var pdLeadersTTL = 5 * time.Second
+
+type pdLeaderAvoidance struct {
+	cli versioned.Interface
+
+	lock sync.Mutex
+	// namespaced is true once listing the TidbClusters of all the namespaces is forbidden
+	namespaced bool
+	// namespace listed => PD leaders, the empty namespace for all the namespaces
+	leaders map[string]*listedPDLeaders
+}
+
+type listedPDLeaders struct {
+	listed  time.Time
+	leaders []pdLeader
+}
+
+// pdLeader is the node running the PD leader of a TidbCluster
+type pdLeader struct {
+	namespace    string
+	instanceName string
+	nodeName     string
+}
+
+// NewPDLeaderAvoidance returns a Priority which prefers the nodes not running the PD leader of another cluster
+func NewPDLeaderAvoidance(cli versioned.Interface) Priority {
+	return &pdLeaderAvoidance{cli: cli, leaders: map[string]*listedPDLeaders{}}
+}
+
+func (p *pdLeaderAvoidance) Name() string {
+	return "PDLeaderAvoidance"
+}
+
+func (p *pdLeaderAvoidance) Score(instanceName string, pod *apiv1.Pod, nodes []apiv1.Node) (schedulerapiv1.HostPriorityList, error) {
+	ns := pod.GetNamespace()
+	leaders, err := p.getPDLeaders(ns)
+	if err != nil {
+		return nil, err
+	}
+
+	leaderNodes := map[string]bool{}
+	for _, leader := range leaders {
+		if leader.namespace == ns && leader.instanceName == instanceName {
+			continue
+		}
+		leaderNodes[leader.nodeName] = true
+	}
+
+	values := map[string]int64{}
+	for _, node := range nodes {
+		if !leaderNodes[node.GetName()] {
+			values[node.GetName()] = 1
+		}
+	}
+	return scoreByValues(nodes, values), nil
+}
+
+// getPDLeaders returns the PD leaders of the TidbClusters visible to the pod in the namespace,
+// they are listed again after pdLeadersTTL
+func (p *pdLeaderAvoidance) getPDLeaders(ns string) ([]pdLeader, error) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	listNs := metav1.NamespaceAll
+	if p.namespaced {
+		listNs = ns
+	}
+	if cached, ok := p.leaders[listNs]; ok && time.Since(cached.listed) < pdLeadersTTL {
+		return cached.leaders, nil
+	}
+
+	tcList, err := p.cli.PingcapV1alpha1().TidbClusters(listNs).List(metav1.ListOptions{})
+	if apierrors.IsForbidden(err) && listNs == metav1.NamespaceAll {
+		// tidb-scheduler may only be permitted to access the TidbClusters in its namespace
+		p.namespaced = true
+		listNs = ns
+		tcList, err = p.cli.PingcapV1alpha1().TidbClusters(listNs).List(metav1.ListOptions{})
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	leaders := []pdLeader{}
+	for i := range tcList.Items {
+		tc := &tcList.Items[i]
+		nodeName := getPDLeaderNode(tc)
+		if nodeName == "" {
+			continue
+		}
+		leaders = append(leaders, pdLeader{
+			namespace:    tc.GetNamespace(),
+			instanceName: tc.GetLabels()[label.InstanceLabelKey],
+			nodeName:     nodeName,
+		})
+	}
+	p.leaders[listNs] = &listedPDLeaders{listed: time.Now(), leaders: leaders}
+	return leaders, nil
+}
+
+// getPDLeaderNode returns the node of the PD leader recorded in the TidbCluster status
+func getPDLeaderNode(tc *v1alpha1.TidbCluster) string {
+	// the TidbClusters sharing the PD of another TidbCluster have no PD leader of their own
+	if tc.HasClusterRef() || tc.Status.PD.Leader.Name == "" {
+		return ""
+	}
+	member, ok := tc.Status.PD.Members[tc.Status.PD.Leader.Name]
+	if !ok || member.NodeName == "" {
+		glog.V(4).Infof("the node of PD leader %s/%s is unknown", tc.GetNamespace(), tc.Status.PD.Leader.Name)
+		return ""
+	}
+	return member.NodeName
+}
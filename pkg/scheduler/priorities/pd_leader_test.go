@@ -0,0 +1,112 @@
+// Copyright 2019 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package priorities
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/pingcap/tidb-operator/pkg/apis/pingcap.com/v1alpha1"
+	"github.com/pingcap/tidb-operator/pkg/client/clientset/versioned/fake"
+	"github.com/pingcap/tidb-operator/pkg/label"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	core "k8s.io/client-go/testing"
+)
+
+func TestPDLeaderAvoidanceScore(t *testing.T) {
+	g := NewGomegaWithT(t)
+	type testcase struct {
+		name    string
+		leaders map[string]string
+		expect  map[string]int
+	}
+
+	testFn := func(test *testcase, t *testing.T) {
+		t.Log(test.name)
+
+		cli := fake.NewSimpleClientset()
+		for tcName, nodeName := range test.leaders {
+			cli.PingcapV1alpha1().TidbClusters(metav1.NamespaceDefault).Create(newTidbClusterWithPDLeader(tcName, nodeName))
+		}
+
+		p := NewPDLeaderAvoidance(cli)
+		result, err := p.Score("demo", newPod("demo-pd-1", label.PDLabelVal, ""), newNodes("node-1", "node-2", "node-3"))
+		g.Expect(err).NotTo(HaveOccurred())
+		g.Expect(scoresOf(result)).To(Equal(test.expect))
+	}
+
+	tests := []testcase{
+		{
+			name:    "no other clusters",
+			leaders: map[string]string{"demo": "node-1"},
+			expect:  map[string]int{"node-1": MaxPriority, "node-2": MaxPriority, "node-3": MaxPriority},
+		},
+		{
+			name:    "avoid the nodes running the PD leaders of other clusters",
+			leaders: map[string]string{"demo": "node-1", "other-1": "node-2", "other-2": "node-3"},
+			expect:  map[string]int{"node-1": MaxPriority, "node-2": 0, "node-3": 0},
+		},
+		{
+			name:    "the node of the PD leader of other cluster is unknown",
+			leaders: map[string]string{"other": ""},
+			expect:  map[string]int{"node-1": MaxPriority, "node-2": MaxPriority, "node-3": MaxPriority},
+		},
+	}
+
+	for i := range tests {
+		testFn(&tests[i], t)
+	}
+}
+
+func TestPDLeaderAvoidanceListOnce(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	cli := fake.NewSimpleClientset(newTidbClusterWithPDLeader("other", "node-2"))
+	listNamespaces := []string{}
+	cli.PrependReactor("list", "tidbclusters", func(action core.Action) (bool, runtime.Object, error) {
+		listNamespaces = append(listNamespaces, action.GetNamespace())
+		if action.GetNamespace() == metav1.NamespaceAll {
+			return true, nil, apierrors.NewForbidden(schema.GroupResource{Resource: "tidbclusters"}, "", nil)
+		}
+		return false, nil, nil
+	})
+
+	p := NewPDLeaderAvoidance(cli)
+	for _, podName := range []string{"demo-pd-0", "demo-pd-1", "demo-pd-2"} {
+		result, err := p.Score("demo", newPod(podName, label.PDLabelVal, ""), newNodes("node-1", "node-2"))
+		g.Expect(err).NotTo(HaveOccurred())
+		g.Expect(scoresOf(result)).To(Equal(map[string]int{"node-1": MaxPriority, "node-2": 0}))
+	}
+	// listing all the namespaces is forbidden, the TidbClusters in the namespace are listed once within the TTL
+	g.Expect(listNamespaces).To(Equal([]string{metav1.NamespaceAll, metav1.NamespaceDefault}))
+}
+
+func newTidbClusterWithPDLeader(tcName, nodeName string) *v1alpha1.TidbCluster {
+	tc := &v1alpha1.TidbCluster{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      tcName,
+			Namespace: metav1.NamespaceDefault,
+			Labels:    label.New().Instance(tcName).Labels(),
+		},
+	}
+	leaderName := tcName + "-pd-0"
+	tc.Status.PD.Leader.Name = leaderName
+	tc.Status.PD.Members = map[string]v1alpha1.PDMember{
+		leaderName: {Name: leaderName, Health: true, NodeName: nodeName},
+	}
+	return tc
+}
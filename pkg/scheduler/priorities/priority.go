@@ -0,0 +1,52 @@
+// Copyright 2019 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package priorities
+
+import (
+	apiv1 "k8s.io/api/core/v1"
+	schedulerapi "k8s.io/kubernetes/pkg/scheduler/api"
+	schedulerapiv1 "k8s.io/kubernetes/pkg/scheduler/api/v1"
+)
+
+// MaxPriority is the max score a priority function gives to a node
+const MaxPriority = schedulerapi.MaxPriority
+
+// Priority is an interface as extender-implemented priority functions
+type Priority interface {
+	// Name return the priority name
+	Name() string
+
+	// Score function receives a set of nodes and returns the score of each node, ranging from 0 to MaxPriority.
+	Score(string, *apiv1.Pod, []apiv1.Node) (schedulerapiv1.HostPriorityList, error)
+}
+
+// scoreByValues scores the nodes in proportion to their values, the node with the max value gets MaxPriority
+func scoreByValues(nodes []apiv1.Node, values map[string]int64) schedulerapiv1.HostPriorityList {
+	var max int64
+	for _, value := range values {
+		if value > max {
+			max = value
+		}
+	}
+
+	result := schedulerapiv1.HostPriorityList{}
+	for _, node := range nodes {
+		score := 0
+		if max > 0 {
+			score = int(values[node.GetName()] * MaxPriority / max)
+		}
+		result = append(result, schedulerapiv1.HostPriority{Host: node.GetName(), Score: score})
+	}
+	return result
+}
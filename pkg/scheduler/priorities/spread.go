@@ -0,0 +1,71 @@
+// Copyright 2019 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package priorities
+
+import (
+	"github.com/pingcap/tidb-operator/pkg/label"
+	apiv1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/kubernetes"
+	schedulerapiv1 "k8s.io/kubernetes/pkg/scheduler/api/v1"
+)
+
+type spread struct {
+	kubeCli kubernetes.Interface
+}
+
+// NewSpread returns a Priority which prefers the nodes running less pods of the same component in the cluster
+func NewSpread(kubeCli kubernetes.Interface) Priority {
+	return &spread{kubeCli: kubeCli}
+}
+
+func (p *spread) Name() string {
+	return "Spread"
+}
+
+func (p *spread) Score(instanceName string, pod *apiv1.Pod, nodes []apiv1.Node) (schedulerapiv1.HostPriorityList, error) {
+	component := pod.Labels[label.ComponentLabelKey]
+	selector := label.New().Instance(instanceName).Component(component).Labels()
+	podList, err := p.kubeCli.CoreV1().Pods(pod.GetNamespace()).List(metav1.ListOptions{
+		LabelSelector: labels.SelectorFromSet(selector).String(),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	podsCount := map[string]int64{}
+	var max int64
+	for _, item := range podList.Items {
+		nodeName := item.Spec.NodeName
+		if item.GetName() == pod.GetName() || nodeName == "" {
+			continue
+		}
+		podsCount[nodeName]++
+		if podsCount[nodeName] > max {
+			max = podsCount[nodeName]
+		}
+	}
+
+	freeSlots := map[string]int64{}
+	for _, node := range nodes {
+		freeSlots[node.GetName()] = max - podsCount[node.GetName()]
+	}
+	if max == 0 {
+		for nodeName := range freeSlots {
+			freeSlots[nodeName] = 1
+		}
+	}
+	return scoreByValues(nodes, freeSlots), nil
+}
@@ -0,0 +1,98 @@
+// Copyright 2019 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package priorities
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/pingcap/tidb-operator/pkg/label"
+	apiv1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+	schedulerapiv1 "k8s.io/kubernetes/pkg/scheduler/api/v1"
+)
+
+func TestSpreadScore(t *testing.T) {
+	g := NewGomegaWithT(t)
+	type testcase struct {
+		name     string
+		podNodes map[string]string
+		expect   map[string]int
+	}
+
+	testFn := func(test *testcase, t *testing.T) {
+		t.Log(test.name)
+
+		kubeCli := kubefake.NewSimpleClientset()
+		for podName, nodeName := range test.podNodes {
+			kubeCli.CoreV1().Pods(metav1.NamespaceDefault).Create(newPod(podName, label.TiDBLabelVal, nodeName))
+		}
+		p := NewSpread(kubeCli)
+		result, err := p.Score("demo", newPod("demo-tidb-3", label.TiDBLabelVal, ""), newNodes("node-1", "node-2", "node-3"))
+		g.Expect(err).NotTo(HaveOccurred())
+		g.Expect(scoresOf(result)).To(Equal(test.expect))
+	}
+
+	tests := []testcase{
+		{
+			name:     "no pods scheduled",
+			podNodes: map[string]string{},
+			expect:   map[string]int{"node-1": MaxPriority, "node-2": MaxPriority, "node-3": MaxPriority},
+		},
+		{
+			name:     "prefer the nodes running less pods",
+			podNodes: map[string]string{"demo-tidb-0": "node-1", "demo-tidb-1": "node-1", "demo-tidb-2": "node-2"},
+			expect:   map[string]int{"node-1": 0, "node-2": MaxPriority / 2, "node-3": MaxPriority},
+		},
+		{
+			name:     "ignore the pod itself",
+			podNodes: map[string]string{"demo-tidb-0": "node-1", "demo-tidb-3": "node-2"},
+			expect:   map[string]int{"node-1": 0, "node-2": MaxPriority, "node-3": MaxPriority},
+		},
+	}
+
+	for i := range tests {
+		testFn(&tests[i], t)
+	}
+}
+
+func newPod(podName, component, nodeName string) *apiv1.Pod {
+	return &apiv1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      podName,
+			Namespace: metav1.NamespaceDefault,
+			Labels:    label.New().Instance("demo").Component(component).Labels(),
+		},
+		Spec: apiv1.PodSpec{
+			NodeName: nodeName,
+		},
+	}
+}
+
+func newNodes(names ...string) []apiv1.Node {
+	nodes := []apiv1.Node{}
+	for _, name := range names {
+		nodes = append(nodes, apiv1.Node{ObjectMeta: metav1.ObjectMeta{Name: name}})
+	}
+	return nodes
+}
+
+func scoresOf(result schedulerapiv1.HostPriorityList) map[string]int {
+	scores := map[string]int{}
+	for _, hostPriority := range result {
+		scores[hostPriority.Host] = hostPriority.Score
+	}
+	return scores
+}
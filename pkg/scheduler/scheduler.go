@@ -19,6 +19,7 @@ import (
 	"github.com/pingcap/tidb-operator/pkg/features"
 	"github.com/pingcap/tidb-operator/pkg/label"
 	"github.com/pingcap/tidb-operator/pkg/scheduler/predicates"
+	"github.com/pingcap/tidb-operator/pkg/scheduler/priorities"
 	apiv1 "k8s.io/api/core/v1"
 	"k8s.io/client-go/kubernetes"
 	kubescheme "k8s.io/client-go/kubernetes/scheme"
@@ -44,15 +45,30 @@ type Scheduler interface {
 type scheduler struct {
 	// component => predicates
 	predicates map[string][]predicates.Predicate
+	// component => priorities
+	priorities map[string][]priorities.Priority
 }
 
 // NewScheduler returns a Scheduler
 func NewScheduler(kubeCli kubernetes.Interface, cli versioned.Interface) Scheduler {
+	recorder := NewEventRecorder(kubeCli)
+	return &scheduler{
+		predicates: NewPredicates(kubeCli, cli, recorder),
+		priorities: NewPriorities(kubeCli, cli),
+	}
+}
+
+// NewEventRecorder returns the EventRecorder of tidb-scheduler
+func NewEventRecorder(kubeCli kubernetes.Interface) record.EventRecorder {
 	eventBroadcaster := record.NewBroadcaster()
 	eventBroadcaster.StartLogging(glog.Infof)
 	eventBroadcaster.StartRecordingToSink(&eventv1.EventSinkImpl{
 		Interface: eventv1.New(kubeCli.CoreV1().RESTClient()).Events("")})
-	recorder := eventBroadcaster.NewRecorder(kubescheme.Scheme, apiv1.EventSource{Component: "tidb-scheduler"})
+	return eventBroadcaster.NewRecorder(kubescheme.Scheme, apiv1.EventSource{Component: "tidb-scheduler"})
+}
+
+// NewPredicates returns the predicates by component, they are shared by the extender and the kube-scheduler plugin
+func NewPredicates(kubeCli kubernetes.Interface, cli versioned.Interface, recorder record.EventRecorder) map[string][]predicates.Predicate {
 	predicatesByComponent := map[string][]predicates.Predicate{
 		label.PDLabelVal: {
 			predicates.NewHA(kubeCli, cli, recorder),
@@ -62,12 +78,27 @@ func NewScheduler(kubeCli kubernetes.Interface, cli versioned.Interface) Schedul
 		},
 	}
 	if features.DefaultFeatureGate.Enabled(features.StableScheduling) {
-		predicatesByComponent[label.TiDBLabelVal] = []predicates.Predicate{
-			predicates.NewStableScheduling(kubeCli, cli, recorder),
+		// run after the HA predicate, the previous node is preferred only if it keeps the cluster available
+		stableScheduling := predicates.NewStableScheduling(kubeCli, cli, recorder)
+		for _, component := range []string{label.PDLabelVal, label.TiKVLabelVal, label.TiDBLabelVal} {
+			predicatesByComponent[component] = append(predicatesByComponent[component], stableScheduling)
 		}
 	}
-	return &scheduler{
-		predicates: predicatesByComponent,
+	return predicatesByComponent
+}
+
+// NewPriorities returns the priorities by component, they are shared by the extender and the kube-scheduler plugin
+func NewPriorities(kubeCli kubernetes.Interface, cli versioned.Interface) map[string][]priorities.Priority {
+	return map[string][]priorities.Priority{
+		label.PDLabelVal: {
+			priorities.NewPDLeaderAvoidance(cli),
+		},
+		label.TiKVLabelVal: {
+			priorities.NewLocalPVCapacity(kubeCli),
+		},
+		label.TiDBLabelVal: {
+			priorities.NewSpread(kubeCli),
+		},
 	}
 }
 
@@ -118,19 +149,43 @@ func (s *scheduler) Filter(args *schedulerapiv1.ExtenderArgs) (*schedulerapiv1.E
 	}, nil
 }
 
-// We don't pass `prioritizeVerb` to kubernetes scheduler extender's config file, this method will not be called.
+// Priority sums up the scores of the priorities registered for the pod's component,
+// every node scores 0 if the pod is not a member of a tidb cluster.
+// A failed priority is skipped, it should not prevent the pod from being scheduled
 func (s *scheduler) Priority(args *schedulerapiv1.ExtenderArgs) (schedulerapiv1.HostPriorityList, error) {
 	result := schedulerapiv1.HostPriorityList{}
+	if args.Nodes == nil {
+		return result, nil
+	}
 
-	if args.Nodes != nil {
-		for _, node := range args.Nodes.Items {
-			result = append(result, schedulerapiv1.HostPriority{
-				Host:  node.Name,
-				Score: 0,
-			})
+	kubeNodes := args.Nodes.Items
+	scores := map[string]int{}
+	pod := args.Pod
+	if pod != nil {
+		instanceName, instanceExist := pod.Labels[label.InstanceLabelKey]
+		component, componentExist := pod.Labels[label.ComponentLabelKey]
+		if instanceExist && componentExist {
+			for _, priority := range s.priorities[component] {
+				hostPriorities, err := priority.Score(instanceName, pod, kubeNodes)
+				if err != nil {
+					glog.Warningf("priority: %s failed for pod: %s/%s, skipping: %v", priority.Name(), pod.GetNamespace(), pod.GetName(), err)
+					continue
+				}
+				glog.V(4).Infof("priority: %s, pod: %s/%s, scores: %v", priority.Name(), pod.GetNamespace(), pod.GetName(), hostPriorities)
+				for _, hostPriority := range hostPriorities {
+					scores[hostPriority.Host] += hostPriority.Score
+				}
+			}
 		}
 	}
 
+	for _, node := range kubeNodes {
+		result = append(result, schedulerapiv1.HostPriority{
+			Host:  node.Name,
+			Score: scores[node.Name],
+		})
+	}
+
 	return result, nil
 }
 
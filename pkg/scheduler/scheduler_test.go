@@ -21,6 +21,7 @@ import (
 	. "github.com/onsi/gomega"
 	"github.com/pingcap/tidb-operator/pkg/label"
 	"github.com/pingcap/tidb-operator/pkg/scheduler/predicates"
+	"github.com/pingcap/tidb-operator/pkg/scheduler/priorities"
 	apiv1 "k8s.io/api/core/v1"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -269,6 +270,107 @@ func TestSchedulerPriority(t *testing.T) {
 	}
 }
 
+func TestSchedulerPriorityWithPriorities(t *testing.T) {
+	g := NewGomegaWithT(t)
+	type testcase struct {
+		name       string
+		pod        *apiv1.Pod
+		priorities []priorities.Priority
+		expect     map[string]int
+	}
+
+	testFn := func(test *testcase, t *testing.T) {
+		t.Log(test.name)
+
+		s := scheduler{
+			priorities: map[string][]priorities.Priority{
+				label.TiKVLabelVal: test.priorities,
+			},
+		}
+		args := &schedulerapiv1.ExtenderArgs{
+			Pod: test.pod,
+			Nodes: &apiv1.NodeList{
+				Items: []apiv1.Node{
+					{ObjectMeta: metav1.ObjectMeta{Name: "node-1"}},
+					{ObjectMeta: metav1.ObjectMeta{Name: "node-2"}},
+				},
+			},
+		}
+		result, err := s.Priority(args)
+		g.Expect(err).NotTo(HaveOccurred())
+		g.Expect(len(result)).To(Equal(2))
+		for _, hostPriority := range result {
+			g.Expect(hostPriority.Score).To(Equal(test.expect[hostPriority.Host]))
+		}
+	}
+
+	tikvPod := &apiv1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "demo-tikv-0",
+			Namespace: corev1.NamespaceDefault,
+			Labels:    label.New().Instance("demo").TiKV().Labels(),
+		},
+	}
+	tests := []testcase{
+		{
+			name: "pod is not a tidb cluster member",
+			pod: &apiv1.Pod{
+				ObjectMeta: metav1.ObjectMeta{Name: "pod-1", Namespace: corev1.NamespaceDefault},
+			},
+			priorities: []priorities.Priority{
+				newFakePriority(map[string]int{"node-1": 10}, nil),
+			},
+			expect: map[string]int{},
+		},
+		{
+			name: "sum up the scores",
+			pod:  tikvPod,
+			priorities: []priorities.Priority{
+				newFakePriority(map[string]int{"node-1": 10, "node-2": 5}, nil),
+				newFakePriority(map[string]int{"node-2": 10}, nil),
+			},
+			expect: map[string]int{"node-1": 10, "node-2": 15},
+		},
+		{
+			name: "skip the failed priority",
+			pod:  tikvPod,
+			priorities: []priorities.Priority{
+				newFakePriority(map[string]int{"node-1": 10, "node-2": 10}, fmt.Errorf("failed")),
+				newFakePriority(map[string]int{"node-2": 10}, nil),
+			},
+			expect: map[string]int{"node-2": 10},
+		},
+	}
+
+	for i := range tests {
+		testFn(&tests[i], t)
+	}
+}
+
+type fakePriority struct {
+	scores map[string]int
+	err    error
+}
+
+func newFakePriority(scores map[string]int, err error) *fakePriority {
+	return &fakePriority{scores: scores, err: err}
+}
+
+func (fp *fakePriority) Name() string {
+	return "fakePriority"
+}
+
+func (fp *fakePriority) Score(_ string, _ *apiv1.Pod, nodes []apiv1.Node) (schedulerapiv1.HostPriorityList, error) {
+	if fp.err != nil {
+		return nil, fp.err
+	}
+	result := schedulerapiv1.HostPriorityList{}
+	for _, node := range nodes {
+		result = append(result, schedulerapiv1.HostPriority{Host: node.GetName(), Score: fp.scores[node.GetName()]})
+	}
+	return result, nil
+}
+
 type fakeErrPredicate struct {
 	err error
 }
@@ -17,13 +17,22 @@ import (
 	"flag"
 	"github.com/pingcap/tidb-operator/pkg/tkctl/cmd/version"
 	"io"
+	"os"
+	"path/filepath"
+	"strings"
 
 	"github.com/pingcap/tidb-operator/pkg/tkctl/cmd/completion"
 	"github.com/pingcap/tidb-operator/pkg/tkctl/cmd/ctop"
 	"github.com/pingcap/tidb-operator/pkg/tkctl/cmd/debug"
+	"github.com/pingcap/tidb-operator/pkg/tkctl/cmd/diagnose"
 	"github.com/pingcap/tidb-operator/pkg/tkctl/cmd/get"
 	"github.com/pingcap/tidb-operator/pkg/tkctl/cmd/info"
 	"github.com/pingcap/tidb-operator/pkg/tkctl/cmd/list"
+	"github.com/pingcap/tidb-operator/pkg/tkctl/cmd/logs"
+	"github.com/pingcap/tidb-operator/pkg/tkctl/cmd/pdctl"
+	"github.com/pingcap/tidb-operator/pkg/tkctl/cmd/scale"
+	"github.com/pingcap/tidb-operator/pkg/tkctl/cmd/sql"
+	"github.com/pingcap/tidb-operator/pkg/tkctl/cmd/upgrade"
 	"github.com/pingcap/tidb-operator/pkg/tkctl/cmd/upinfo"
 	"github.com/pingcap/tidb-operator/pkg/tkctl/cmd/use"
 	"github.com/pingcap/tidb-operator/pkg/tkctl/config"
@@ -35,7 +44,13 @@ import (
 const (
 	tkcLongDescription = `
 		"tkctl"(TiDB kubernetes control) is a command line interface for cloud tidb management and troubleshooting.
+
+		When installed as "kubectl-tidb" in PATH, it runs as the "kubectl tidb" plugin.
 `
+
+	tkcName = "tkctl"
+	// kubectlPluginName is the binary name for kubectl to discover tkctl as the "kubectl tidb" plugin
+	kubectlPluginName = "kubectl-tidb"
 )
 
 // NewTkcCommand creates the root `tkc` command and its nested children.
@@ -45,9 +60,9 @@ func NewTkcCommand(streams genericclioptions.IOStreams) *cobra.Command {
 
 	// Root command that all the subcommands are added to
 	rootCmd := &cobra.Command{
-		Use:   "tkctl",
+		Use:   rootCommandName(os.Args[0]),
 		Short: "TiDB kubernetes control.",
-		Long:  tkcLongDescription,
+		Long:  templates.LongDesc(tkcLongDescription),
 		Run:   runHelp,
 	}
 
@@ -71,6 +86,9 @@ func NewTkcCommand(streams genericclioptions.IOStreams) *cobra.Command {
 				use.NewCmdUse(tkcContext, streams),
 				version.NewCmdVersion(tkcContext, streams.Out),
 				upinfo.NewCmdUpInfo(tkcContext, streams),
+				sql.NewCmdSQL(tkcContext, streams),
+				scale.NewCmdScale(tkcContext, streams),
+				upgrade.NewCmdUpgrade(tkcContext, streams),
 			},
 		},
 		{
@@ -78,6 +96,9 @@ func NewTkcCommand(streams genericclioptions.IOStreams) *cobra.Command {
 			Commands: []*cobra.Command{
 				debug.NewCmdDebug(tkcContext, streams),
 				ctop.NewCmdCtop(tkcContext, streams),
+				logs.NewCmdLogs(tkcContext, streams),
+				pdctl.NewCmdPdctl(tkcContext, streams),
+				diagnose.NewCmdDiagnose(tkcContext, streams),
 			},
 		},
 		{
@@ -94,6 +115,14 @@ func NewTkcCommand(streams genericclioptions.IOStreams) *cobra.Command {
 	return rootCmd
 }
 
+// rootCommandName returns the name of the root command according to the name of the binary
+func rootCommandName(binary string) string {
+	if strings.TrimSuffix(filepath.Base(binary), ".exe") == kubectlPluginName {
+		return kubectlPluginName
+	}
+	return tkcName
+}
+
 func runHelp(cmd *cobra.Command, _ []string) {
 	cmd.Help()
 }
@@ -0,0 +1,30 @@
+// Copyright 2019. PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+)
+
+func TestRootCommandName(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	g.Expect(rootCommandName("/usr/local/bin/tkctl")).To(Equal("tkctl"))
+	g.Expect(rootCommandName("tkctl.exe")).To(Equal("tkctl"))
+	g.Expect(rootCommandName("/usr/local/bin/kubectl-tidb")).To(Equal("kubectl-tidb"))
+	g.Expect(rootCommandName(`C:\bin\kubectl-tidb.exe`)).To(Equal("tkctl"))
+	g.Expect(rootCommandName("kubectl-tidb.exe")).To(Equal("kubectl-tidb"))
+}
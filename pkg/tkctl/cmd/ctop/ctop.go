@@ -16,6 +16,7 @@ package ctop
 import (
 	"fmt"
 	"github.com/pingcap/tidb-operator/pkg/tkctl/config"
+	launcher "github.com/pingcap/tidb-operator/pkg/tkctl/debug"
 	"github.com/pingcap/tidb-operator/pkg/tkctl/executor"
 	"github.com/pingcap/tidb-operator/pkg/tkctl/util"
 	"github.com/spf13/cobra"
@@ -132,6 +133,13 @@ func (o *CtopOptions) Run() error {
 		if err != nil {
 			return err
 		}
+		// ctop talks to the docker socket of the node, which is absent on containerd and CRI-O nodes
+		for _, status := range pod.Status.ContainerStatuses {
+			runtime, _, err := launcher.ParseContainerID(status.ContainerID)
+			if err == nil && runtime != launcher.RuntimeDocker {
+				return fmt.Errorf("ctop only supports docker, pod %s runs on %s, try 'debug' instead", pod.Name, runtime)
+			}
+		}
 		nodeName = pod.Spec.NodeName
 		filter = pod.Name
 	case CtopNode:
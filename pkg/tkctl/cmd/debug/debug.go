@@ -16,6 +16,7 @@ package debug
 import (
 	"fmt"
 	"github.com/pingcap/tidb-operator/pkg/tkctl/config"
+	launcher "github.com/pingcap/tidb-operator/pkg/tkctl/debug"
 	"github.com/pingcap/tidb-operator/pkg/tkctl/executor"
 	"github.com/pingcap/tidb-operator/pkg/tkctl/util"
 	"github.com/spf13/cobra"
@@ -26,6 +27,7 @@ import (
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/rest"
 	cmdutil "k8s.io/kubernetes/pkg/kubectl/cmd/util"
+	"path"
 )
 
 const (
@@ -50,11 +52,17 @@ Under the hood, 'debug' create a agent in target node, the agent is responsible
 container which will join linux namespaces of the target container and proxying I/O connection. When debug session
 ends, the agent destroy and cleanup the debug container and exited with code 0, 'tkc' will clean the finished agent 
 in the defer manner if it is not interrupted by user.
+
+The container runtime is detected from the container id of the target container. For docker, the agent launches the
+debug container through the docker socket of the node. For containerd and CRI-O, the agent runs in the debug image,
+finds the target container through the CRI socket of the node and joins its namespaces.
 `
 	debugUsage    = "expected 'debug POD_NAME' for the debug command"
 	defaultImage  = "pingcap/tidb-debug:latest"
 	launcherImage = "pingcap/debug-launcher:latest"
 	launcherName  = "debug-launcher"
+	// launcherBinDir is where the launcher binary is copied to in the debug image for containerd and CRI-O
+	launcherBinDir = "/opt/tkctl"
 )
 
 var (
@@ -73,6 +81,7 @@ type DebugOptions struct {
 	ContainerName    string
 	Command          []string
 	HostDockerSocket string
+	HostCRISocket    string
 	LauncherImage    string
 	Privileged       bool
 
@@ -113,6 +122,8 @@ func NewCmdDebug(tkcContext *config.TkcContext, streams genericclioptions.IOStre
 		"Target container to debug, default to the first container in pod")
 	cmd.Flags().StringVar(&options.HostDockerSocket, "docker-socketl", options.HostDockerSocket,
 		"docker socket path of kubernetes node")
+	cmd.Flags().StringVar(&options.HostCRISocket, "cri-socket", options.HostCRISocket,
+		"CRI socket path of kubernetes node for containerd or CRI-O, default to the socket of the detected runtime")
 	cmd.Flags().StringVar(&options.LauncherImage, "launcher-image", options.LauncherImage,
 		"image for launcher pod which is responsible to launch the debug container")
 	cmd.Flags().BoolVar(&options.Privileged, "privileged", options.Privileged,
@@ -179,8 +190,17 @@ func (o *DebugOptions) Run() error {
 		return err
 	}
 
-	launcher := o.makeLauncherPod(nodeName, targetContainerID, o.Command)
-	podExecutor := executor.NewPodExecutor(o.KubeCli, launcher, o.RestConfig, o.IOStreams)
+	runtime, _, err := launcher.ParseContainerID(targetContainerID)
+	if err != nil {
+		return err
+	}
+	var launcherPod *v1.Pod
+	if launcher.IsCRIRuntime(runtime) {
+		launcherPod = o.makeCRILauncherPod(nodeName, runtime, targetContainerID, o.Command)
+	} else {
+		launcherPod = o.makeLauncherPod(nodeName, targetContainerID, o.Command)
+	}
+	podExecutor := executor.NewPodExecutor(o.KubeCli, launcherPod, o.RestConfig, o.IOStreams)
 	return podExecutor.Execute()
 }
 
@@ -225,6 +245,77 @@ func (o *DebugOptions) makeLauncherPod(nodeName, containerID string, command []s
 	}
 }
 
+// makeCRILauncherPod makes the launcher pod for the containers of containerd and CRI-O. CRI cannot run a container
+// outside of a pod sandbox, so the launcher pod runs the debug image in the host pid namespace, with the launcher
+// binary copied from the launcher image, and the launcher joins the namespaces of the target container.
+func (o *DebugOptions) makeCRILauncherPod(nodeName, runtime, containerID string, command []string) *v1.Pod {
+	hostCRISocket := o.HostCRISocket
+	if len(hostCRISocket) == 0 {
+		hostCRISocket = launcher.DefaultRuntimeSocket(runtime)
+	}
+	socketVolume, socketMount := util.MakeCRISocketMount(hostCRISocket)
+	binVolume := v1.Volume{
+		Name:         launcherName,
+		VolumeSource: v1.VolumeSource{EmptyDir: &v1.EmptyDirVolumeSource{}},
+	}
+	binMount := v1.VolumeMount{
+		Name:      launcherName,
+		MountPath: launcherBinDir,
+	}
+	launcherBin := path.Join(launcherBinDir, launcherName)
+
+	// the CRI socket is always mounted to the same path despite the host CRI socket path
+	launchArgs := []string{
+		"--target-container",
+		containerID,
+		"--cri-socket",
+		util.CRISocket,
+		"--",
+	}
+	launchArgs = append(launchArgs, command...)
+	securityContext := &v1.SecurityContext{
+		Capabilities: &v1.Capabilities{
+			Add: []v1.Capability{launcher.CAP_SYS_PTRACE, launcher.CAP_SYS_ADMIN},
+		},
+	}
+	if o.Privileged {
+		securityContext = &v1.SecurityContext{Privileged: &o.Privileged}
+	}
+	return &v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      fmt.Sprintf("%s-%s", launcherName, string(uuid.NewUUID())),
+			Namespace: o.Namespace,
+		},
+		Spec: v1.PodSpec{
+			InitContainers: []v1.Container{
+				{
+					Name:            "install-" + launcherName,
+					Image:           o.LauncherImage,
+					Command:         []string{"cp", "/" + launcherName, launcherBin},
+					VolumeMounts:    []v1.VolumeMount{binMount},
+					ImagePullPolicy: v1.PullAlways,
+				},
+			},
+			Containers: []v1.Container{
+				{
+					Name:            launcherName,
+					Image:           o.Image,
+					Command:         []string{launcherBin},
+					Args:            launchArgs,
+					Stdin:           true,
+					TTY:             true,
+					VolumeMounts:    []v1.VolumeMount{socketMount, binMount},
+					SecurityContext: securityContext,
+				},
+			},
+			Volumes:       []v1.Volume{socketVolume, binVolume},
+			NodeName:      nodeName,
+			HostPID:       true,
+			RestartPolicy: v1.RestartPolicyNever,
+		},
+	}
+}
+
 func (o *DebugOptions) getContainerIDByName(pod *v1.Pod, containerName string) (string, error) {
 	for _, containerStatus := range pod.Status.ContainerStatuses {
 		if containerStatus.Name != containerName {
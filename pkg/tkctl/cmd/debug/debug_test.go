@@ -0,0 +1,67 @@
+// Copyright 2019. PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package debug
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+	launcher "github.com/pingcap/tidb-operator/pkg/tkctl/debug"
+	"github.com/pingcap/tidb-operator/pkg/tkctl/util"
+	"k8s.io/api/core/v1"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+)
+
+func TestMakeCRILauncherPod(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	o := NewDebugOptions(genericclioptions.NewTestIOStreamsDiscard())
+	o.Namespace = "ns"
+	pod := o.makeCRILauncherPod("node-1", launcher.RuntimeContainerd, "containerd://abc", []string{"bash", "-l"})
+
+	g.Expect(pod.Namespace).To(Equal("ns"))
+	g.Expect(pod.Spec.NodeName).To(Equal("node-1"))
+	g.Expect(pod.Spec.HostPID).To(BeTrue())
+	g.Expect(pod.Spec.InitContainers).To(HaveLen(1))
+	g.Expect(pod.Spec.InitContainers[0].Image).To(Equal(launcherImage))
+	g.Expect(pod.Spec.InitContainers[0].Command).To(Equal([]string{"cp", "/debug-launcher", "/opt/tkctl/debug-launcher"}))
+
+	g.Expect(pod.Spec.Containers).To(HaveLen(1))
+	container := pod.Spec.Containers[0]
+	g.Expect(container.Image).To(Equal(defaultImage))
+	g.Expect(container.Command).To(Equal([]string{"/opt/tkctl/debug-launcher"}))
+	g.Expect(container.Args).To(Equal([]string{
+		"--target-container", "containerd://abc", "--cri-socket", util.CRISocket, "--", "bash", "-l",
+	}))
+	g.Expect(container.SecurityContext.Privileged).To(BeNil())
+	g.Expect(container.SecurityContext.Capabilities.Add).To(ConsistOf(
+		v1.Capability(launcher.CAP_SYS_PTRACE), v1.Capability(launcher.CAP_SYS_ADMIN)))
+
+	g.Expect(hostSocketOf(pod)).To(Equal(launcher.DefaultRuntimeSocket(launcher.RuntimeContainerd)))
+
+	o.HostCRISocket = "/run/k3s/containerd/containerd.sock"
+	o.Privileged = true
+	pod = o.makeCRILauncherPod("node-1", launcher.RuntimeContainerd, "containerd://abc", []string{"bash"})
+	g.Expect(*pod.Spec.Containers[0].SecurityContext.Privileged).To(BeTrue())
+	g.Expect(hostSocketOf(pod)).To(Equal(o.HostCRISocket))
+}
+
+func hostSocketOf(pod *v1.Pod) string {
+	for _, volume := range pod.Spec.Volumes {
+		if volume.HostPath != nil {
+			return volume.HostPath.Path
+		}
+	}
+	return ""
+}
@@ -0,0 +1,72 @@
+// Copyright 2019. PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package diagnose
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"io"
+	"path"
+	"time"
+)
+
+// bundle writes files into a gzipped tarball, all files are placed under a directory named after the prefix
+type bundle struct {
+	prefix  string
+	modTime time.Time
+
+	w   io.WriteCloser
+	gw  *gzip.Writer
+	tw  *tar.Writer
+	err error
+}
+
+func newBundle(w io.WriteCloser, prefix string) *bundle {
+	gw := gzip.NewWriter(w)
+	return &bundle{
+		prefix:  prefix,
+		modTime: time.Now(),
+		w:       w,
+		gw:      gw,
+		tw:      tar.NewWriter(gw),
+	}
+}
+
+// Add writes a file into the bundle, the first error is kept and returned by Close,
+// files added after an error are dropped
+func (b *bundle) Add(name string, data []byte) {
+	if b.err != nil {
+		return
+	}
+	header := &tar.Header{
+		Name:    path.Join(b.prefix, name),
+		Mode:    0644,
+		Size:    int64(len(data)),
+		ModTime: b.modTime,
+	}
+	if b.err = b.tw.WriteHeader(header); b.err != nil {
+		return
+	}
+	_, b.err = b.tw.Write(data)
+}
+
+// Close flushes the bundle and closes the underlying writer
+func (b *bundle) Close() error {
+	for _, closer := range []io.Closer{b.tw, b.gw, b.w} {
+		if err := closer.Close(); err != nil && b.err == nil {
+			b.err = err
+		}
+	}
+	return b.err
+}
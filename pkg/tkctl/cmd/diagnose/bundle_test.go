@@ -0,0 +1,60 @@
+// Copyright 2019. PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package diagnose
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"io"
+	"io/ioutil"
+	"testing"
+
+	. "github.com/onsi/gomega"
+)
+
+type nopCloser struct {
+	io.Writer
+}
+
+func (nopCloser) Close() error { return nil }
+
+func TestBundle(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	var buf bytes.Buffer
+	b := newBundle(nopCloser{&buf}, "diagnose-ns-demo")
+	b.Add("tidbcluster.yaml", []byte("name: demo\n"))
+	b.Add("logs/demo-pd-0/pd.log", []byte("started\n"))
+	g.Expect(b.Close()).To(Succeed())
+
+	gr, err := gzip.NewReader(&buf)
+	g.Expect(err).NotTo(HaveOccurred())
+	tr := tar.NewReader(gr)
+	files := map[string]string{}
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		g.Expect(err).NotTo(HaveOccurred())
+		data, err := ioutil.ReadAll(tr)
+		g.Expect(err).NotTo(HaveOccurred())
+		files[header.Name] = string(data)
+	}
+	g.Expect(files).To(Equal(map[string]string{
+		"diagnose-ns-demo/tidbcluster.yaml":      "name: demo\n",
+		"diagnose-ns-demo/logs/demo-pd-0/pd.log": "started\n",
+	}))
+}
@@ -0,0 +1,426 @@
+// Copyright 2019. PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package diagnose
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ghodss/yaml"
+	"github.com/pingcap/tidb-operator/pkg/apis/pingcap.com/v1alpha1"
+	"github.com/pingcap/tidb-operator/pkg/client/clientset/versioned"
+	"github.com/pingcap/tidb-operator/pkg/controller"
+	"github.com/pingcap/tidb-operator/pkg/label"
+	"github.com/pingcap/tidb-operator/pkg/tkctl/cmd/pdctl"
+	"github.com/pingcap/tidb-operator/pkg/tkctl/config"
+	"github.com/spf13/cobra"
+	"k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	cmdutil "k8s.io/kubernetes/pkg/kubectl/cmd/util"
+	"k8s.io/kubernetes/pkg/printers"
+	printersinternal "k8s.io/kubernetes/pkg/printers/internalversion"
+)
+
+const (
+	diagnoseLongDesc = `
+		Gather the diagnostic information of the tidb cluster into a tarball.
+
+		The tarball contains the TidbCluster object, the pods, statefulsets, services,
+		persistent volume claims, configmaps and events of the tidb cluster along with
+		their descriptions, the members, stores and config of PD, and the logs of
+		every container (including the previous ones of restarted containers).
+
+		Secrets are never collected. Environment values, passwords and tokens found
+		in the collected objects, configmaps and logs are redacted.
+`
+	diagnoseExample = `
+		# gather the diagnostic information of current tidb cluster
+		tkc diagnose
+
+		# only gather the logs of the last hour
+		tkc diagnose --since 1h
+
+		# gather the logs within a time window into the specified file
+		tkc diagnose --since-time 2019-03-01T08:00:00Z --until-time 2019-03-01T09:00:00Z -o demo.tar.gz
+`
+	diagnoseUsage = `expected 'diagnose -t CLUSTER_NAME' for the diagnose command or
+using 'tkc use' to set tidb cluster first.
+`
+
+	pdTimeout = 10 * time.Second
+)
+
+// DiagnoseOptions contains the input to the diagnose command.
+type DiagnoseOptions struct {
+	TidbClusterName string
+	Namespace       string
+
+	Output    string
+	Since     time.Duration
+	SinceTime string
+	UntilTime string
+
+	window logWindow
+
+	RestConfig *rest.Config
+	TcCli      *versioned.Clientset
+	KubeCli    *kubernetes.Clientset
+
+	genericclioptions.IOStreams
+}
+
+// NewDiagnoseOptions returns a DiagnoseOptions
+func NewDiagnoseOptions(streams genericclioptions.IOStreams) *DiagnoseOptions {
+	return &DiagnoseOptions{
+		IOStreams: streams,
+	}
+}
+
+// NewCmdDiagnose creates the diagnose command which gathers the diagnostic information of the tidb cluster
+func NewCmdDiagnose(tkcContext *config.TkcContext, streams genericclioptions.IOStreams) *cobra.Command {
+	o := NewDiagnoseOptions(streams)
+
+	cmd := &cobra.Command{
+		Use:     "diagnose",
+		Short:   "Gather the diagnostic information of the tidb cluster into a tarball.",
+		Long:    diagnoseLongDesc,
+		Example: diagnoseExample,
+		Run: func(cmd *cobra.Command, args []string) {
+			cmdutil.CheckErr(o.Complete(tkcContext, cmd, args))
+			cmdutil.CheckErr(o.Run())
+		},
+	}
+	cmd.Flags().StringVarP(&o.Output, "output", "o", o.Output,
+		"The path of the tarball, defaults to diagnose-NAMESPACE-CLUSTER-TIMESTAMP.tar.gz in current directory.")
+	cmd.Flags().DurationVar(&o.Since, "since", o.Since,
+		"Only gather the logs newer than a relative duration like 5s, 2m, or 3h.")
+	cmd.Flags().StringVar(&o.SinceTime, "since-time", o.SinceTime,
+		"Only gather the logs after the specified time (RFC3339).")
+	cmd.Flags().StringVar(&o.UntilTime, "until-time", o.UntilTime,
+		"Only gather the logs before the specified time (RFC3339).")
+
+	return cmd
+}
+
+func (o *DiagnoseOptions) Complete(tkcContext *config.TkcContext, cmd *cobra.Command, args []string) error {
+	window, err := newLogWindow(o.Since, o.SinceTime, o.UntilTime)
+	if err != nil {
+		return cmdutil.UsageErrorf(cmd, err.Error())
+	}
+	o.window = window
+
+	clientConfig, err := tkcContext.ToTkcClientConfig()
+	if err != nil {
+		return err
+	}
+
+	if tidbClusterName, ok := clientConfig.TidbClusterName(); ok {
+		o.TidbClusterName = tidbClusterName
+	} else {
+		return cmdutil.UsageErrorf(cmd, diagnoseUsage)
+	}
+
+	namespace, _, err := clientConfig.Namespace()
+	if err != nil {
+		return err
+	}
+	o.Namespace = namespace
+
+	restConfig, err := clientConfig.RestConfig()
+	if err != nil {
+		return err
+	}
+	o.RestConfig = restConfig
+	tcCli, err := versioned.NewForConfig(restConfig)
+	if err != nil {
+		return err
+	}
+	o.TcCli = tcCli
+	kubeCli, err := kubernetes.NewForConfig(restConfig)
+	if err != nil {
+		return err
+	}
+	o.KubeCli = kubeCli
+
+	if len(o.Output) == 0 {
+		o.Output = fmt.Sprintf("diagnose-%s-%s-%s.tar.gz",
+			o.Namespace, o.TidbClusterName, time.Now().Format("20060102150405"))
+	}
+	return nil
+}
+
+func (o *DiagnoseOptions) Run() error {
+	tc, err := o.TcCli.PingcapV1alpha1().
+		TidbClusters(o.Namespace).
+		Get(o.TidbClusterName, metav1.GetOptions{})
+	if err != nil {
+		return err
+	}
+
+	f, err := os.Create(o.Output)
+	if err != nil {
+		return err
+	}
+	b := newBundle(f, strings.TrimSuffix(path.Base(o.Output), ".tar.gz"))
+	c := &collector{
+		o:      o,
+		tc:     tc,
+		bundle: b,
+	}
+	c.collect()
+	if err := b.Close(); err != nil {
+		return err
+	}
+
+	if len(c.errs) > 0 {
+		fmt.Fprintf(o.ErrOut, "%d item(s) could not be gathered, see errors.txt in the tarball\n", len(c.errs))
+	}
+	fmt.Fprintf(o.Out, "diagnostic information of tidb cluster %s/%s is written to %s\n",
+		o.Namespace, o.TidbClusterName, o.Output)
+	return nil
+}
+
+// collector gathers the diagnostic information of a tidb cluster into a bundle, gathering is best-effort:
+// failures are recorded in errors.txt of the bundle instead of aborting the whole diagnosis
+type collector struct {
+	o      *DiagnoseOptions
+	tc     *v1alpha1.TidbCluster
+	bundle *bundle
+	errs   []string
+}
+
+func (c *collector) collect() {
+	tc := c.tc.DeepCopy()
+	redactTidbCluster(tc)
+	c.addObject("tidbcluster.yaml", stripObjectMeta(tc))
+
+	selector, err := label.New().Instance(c.tc.Name).Selector()
+	if err != nil {
+		c.fail("build label selector", err)
+		return
+	}
+	listOpts := metav1.ListOptions{LabelSelector: selector.String()}
+	coreCli := c.o.KubeCli.CoreV1()
+
+	pods, err := coreCli.Pods(c.o.Namespace).List(listOpts)
+	if err != nil {
+		c.fail("list pods", err)
+	} else {
+		sort.Slice(pods.Items, func(i, j int) bool { return pods.Items[i].Name < pods.Items[j].Name })
+		for i := range pods.Items {
+			redactPodSpec(&pods.Items[i].Spec)
+			stripObjectMeta(&pods.Items[i])
+		}
+		c.addObject("resources/pods.yaml", pods)
+	}
+
+	var configMapNames []string
+	sets, err := c.o.KubeCli.AppsV1beta1().StatefulSets(c.o.Namespace).List(listOpts)
+	if err != nil {
+		c.fail("list statefulsets", err)
+	} else {
+		for i := range sets.Items {
+			configMapNames = append(configMapNames, referencedConfigMaps(&sets.Items[i].Spec.Template.Spec)...)
+			redactStatefulSet(&sets.Items[i])
+		}
+		c.addObject("resources/statefulsets.yaml", sets)
+	}
+
+	if svcs, err := coreCli.Services(c.o.Namespace).List(listOpts); err != nil {
+		c.fail("list services", err)
+	} else {
+		for i := range svcs.Items {
+			stripObjectMeta(&svcs.Items[i])
+		}
+		c.addObject("resources/services.yaml", svcs)
+	}
+
+	if pvcs, err := coreCli.PersistentVolumeClaims(c.o.Namespace).List(listOpts); err != nil {
+		c.fail("list persistent volume claims", err)
+	} else {
+		for i := range pvcs.Items {
+			stripObjectMeta(&pvcs.Items[i])
+			c.describe(schema.GroupKind{Kind: "PersistentVolumeClaim"}, pvcs.Items[i].Name)
+		}
+		c.addObject("resources/pvcs.yaml", pvcs)
+	}
+
+	c.collectConfigMaps(configMapNames)
+	c.collectEvents()
+
+	if pods != nil {
+		for _, pod := range pods.Items {
+			c.describe(schema.GroupKind{Kind: "Pod"}, pod.Name)
+		}
+	}
+	if sets != nil {
+		for _, set := range sets.Items {
+			c.describe(schema.GroupKind{Group: "apps", Kind: "StatefulSet"}, set.Name)
+		}
+	}
+
+	c.collectPD()
+
+	if pods != nil {
+		for i := range pods.Items {
+			c.collectLogs(&pods.Items[i])
+		}
+	}
+
+	if len(c.errs) > 0 {
+		c.bundle.Add("errors.txt", []byte(strings.Join(c.errs, "\n")+"\n"))
+	}
+}
+
+func (c *collector) collectConfigMaps(names []string) {
+	cms := &v1.ConfigMapList{}
+	seen := map[string]bool{}
+	for _, name := range names {
+		if seen[name] {
+			continue
+		}
+		seen[name] = true
+		cm, err := c.o.KubeCli.CoreV1().ConfigMaps(c.o.Namespace).Get(name, metav1.GetOptions{})
+		if err != nil {
+			c.fail(fmt.Sprintf("get configmap %s", name), err)
+			continue
+		}
+		stripObjectMeta(cm)
+		for key, value := range cm.Data {
+			cm.Data[key] = string(redactText([]byte(value)))
+		}
+		cms.Items = append(cms.Items, *cm)
+	}
+	sort.Slice(cms.Items, func(i, j int) bool { return cms.Items[i].Name < cms.Items[j].Name })
+	c.addObject("resources/configmaps.yaml", cms)
+}
+
+func (c *collector) collectEvents() {
+	events, err := c.o.KubeCli.CoreV1().Events(c.o.Namespace).List(metav1.ListOptions{})
+	if err != nil {
+		c.fail("list events", err)
+		return
+	}
+	related := &v1.EventList{}
+	for _, event := range events.Items {
+		// the resources of a tidb cluster are all named after the tidb cluster
+		if name := event.InvolvedObject.Name; name == c.tc.Name || strings.HasPrefix(name, c.tc.Name+"-") {
+			related.Items = append(related.Items, event)
+		}
+	}
+	sort.Slice(related.Items, func(i, j int) bool {
+		return related.Items[i].LastTimestamp.Before(&related.Items[j].LastTimestamp)
+	})
+	c.addObject("resources/events.yaml", related)
+}
+
+func (c *collector) describe(kind schema.GroupKind, name string) {
+	describer, ok := printersinternal.DescriberFor(kind, c.o.RestConfig)
+	if !ok {
+		c.fail(fmt.Sprintf("describe %s %s", kind.Kind, name), fmt.Errorf("no describer for %s", kind))
+		return
+	}
+	out, err := describer.Describe(c.o.Namespace, name, printers.DescriberSettings{ShowEvents: true})
+	if err != nil {
+		c.fail(fmt.Sprintf("describe %s %s", kind.Kind, name), err)
+		return
+	}
+	c.bundle.Add(fmt.Sprintf("describe/%s-%s.txt", strings.ToLower(kind.Kind), name), redactText([]byte(out)))
+}
+
+func (c *collector) collectPD() {
+	pdClient, forwarder, err := pdctl.ForwardPD(c.o.RestConfig, c.o.KubeCli, c.tc, pdTimeout, ioutil.Discard)
+	if err != nil {
+		c.fail("connect to PD", err)
+		return
+	}
+	defer forwarder.Close()
+
+	items := []struct {
+		name string
+		get  func(pdClient controller.PDClient) (interface{}, error)
+	}{
+		{"health", func(cli controller.PDClient) (interface{}, error) { return cli.GetHealth() }},
+		{"members", func(cli controller.PDClient) (interface{}, error) { return cli.GetMembers() }},
+		{"stores", func(cli controller.PDClient) (interface{}, error) { return cli.GetStores() }},
+		{"tombstone-stores", func(cli controller.PDClient) (interface{}, error) { return cli.GetTombStoneStores() }},
+		{"config", func(cli controller.PDClient) (interface{}, error) { return cli.GetConfig() }},
+		{"cluster", func(cli controller.PDClient) (interface{}, error) { return cli.GetCluster() }},
+	}
+	for _, item := range items {
+		obj, err := item.get(pdClient)
+		if err != nil {
+			c.fail(fmt.Sprintf("get PD %s", item.name), err)
+			continue
+		}
+		data, err := json.MarshalIndent(obj, "", "  ")
+		if err != nil {
+			c.fail(fmt.Sprintf("encode PD %s", item.name), err)
+			continue
+		}
+		c.bundle.Add(fmt.Sprintf("pd/%s.json", item.name), redactText(data))
+	}
+}
+
+func (c *collector) collectLogs(pod *v1.Pod) {
+	restarted := map[string]bool{}
+	for _, status := range pod.Status.ContainerStatuses {
+		restarted[status.Name] = status.RestartCount > 0
+	}
+	for _, container := range pod.Spec.Containers {
+		c.collectLog(pod.Name, container.Name, false)
+		// a container without restarts has no previous log
+		if restarted[container.Name] {
+			c.collectLog(pod.Name, container.Name, true)
+		}
+	}
+}
+
+func (c *collector) collectLog(podName, containerName string, previous bool) {
+	name := fmt.Sprintf("logs/%s/%s.log", podName, containerName)
+	if previous {
+		name = fmt.Sprintf("logs/%s/%s.previous.log", podName, containerName)
+	}
+	data, err := c.o.KubeCli.CoreV1().Pods(c.o.Namespace).
+		GetLogs(podName, c.o.window.logOptions(containerName, previous)).
+		DoRaw()
+	if err != nil {
+		c.fail(fmt.Sprintf("get log of %s/%s", podName, containerName), err)
+		return
+	}
+	c.bundle.Add(name, redactText(c.o.window.filter(data)))
+}
+
+func (c *collector) addObject(name string, obj interface{}) {
+	data, err := yaml.Marshal(obj)
+	if err != nil {
+		c.fail(fmt.Sprintf("encode %s", name), err)
+		return
+	}
+	c.bundle.Add(name, data)
+}
+
+func (c *collector) fail(what string, err error) {
+	c.errs = append(c.errs, fmt.Sprintf("%s: %v", what, err))
+}
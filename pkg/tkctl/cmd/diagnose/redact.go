@@ -0,0 +1,155 @@
+// Copyright 2019. PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package diagnose
+
+import (
+	"regexp"
+
+	"github.com/pingcap/tidb-operator/pkg/apis/pingcap.com/v1alpha1"
+	apps "k8s.io/api/apps/v1beta1"
+	"k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	redacted = "<redacted>"
+
+	lastAppliedAnnotation = "kubectl.kubernetes.io/last-applied-configuration"
+	// operatorLastAppliedAnnotation is the member.LastAppliedConfigAnnotation set by tidb-operator on the statefulsets,
+	// their pod templates and the pods, it holds the whole spec including the literal env values
+	operatorLastAppliedAnnotation = "pingcap.com/last-applied-configuration"
+)
+
+var (
+	sensitiveKey = `[\w.-]*(?i:password|passwd|token|secret[_-]?key|access[_-]?key|private[_-]?key)[\w.-]*`
+
+	sensitiveKeyRegexp = regexp.MustCompile(`^` + sensitiveKey + `$`)
+
+	// matches "key: value", "key=value" and the JSON form "key": "value" of sensitive keys,
+	// the value is kept on the same line so an empty value never swallows the next line
+	sensitiveValueRegexp = regexp.MustCompile(
+		`(` + sensitiveKey + `["']?[ \t]*[:=][ \t]*)("[^"\n]*"|'[^'\n]*'|[^\s,;}\]]+)`)
+
+	// matches the passwords in SQL statements like "CREATE USER ... IDENTIFIED BY 'password'"
+	identifiedByRegexp = regexp.MustCompile(`(?i)(identified[ \t]+by[ \t]+(?:password[ \t]+)?)('[^'\n]*'|"[^"\n]*")`)
+)
+
+// redactText masks the values of sensitive keys and the passwords in SQL statements
+func redactText(data []byte) []byte {
+	data = sensitiveValueRegexp.ReplaceAllFunc(data, func(match []byte) []byte {
+		groups := sensitiveValueRegexp.FindSubmatch(match)
+		return append(append([]byte{}, groups[1]...), quoteLike(groups[2], redacted)...)
+	})
+	return identifiedByRegexp.ReplaceAllFunc(data, func(match []byte) []byte {
+		groups := identifiedByRegexp.FindSubmatch(match)
+		return append(append([]byte{}, groups[1]...), quoteLike(groups[2], redacted)...)
+	})
+}
+
+// quoteLike quotes s in the same way as the original value
+func quoteLike(original []byte, s string) []byte {
+	if len(original) > 0 && (original[0] == '"' || original[0] == '\'') {
+		return []byte(string(original[0]) + s + string(original[0]))
+	}
+	return []byte(s)
+}
+
+// redactPodSpec masks the literal values of the sensitive environment variables,
+// the values taken from secrets are references and kept as is
+func redactPodSpec(spec *v1.PodSpec) {
+	for _, containers := range [][]v1.Container{spec.InitContainers, spec.Containers} {
+		for i := range containers {
+			redactEnv(containers[i].Env)
+		}
+	}
+}
+
+// redactStatefulSet masks the sensitive environment variables of the pod template
+// and drops the last applied configurations of the statefulset and its pod template
+func redactStatefulSet(set *apps.StatefulSet) {
+	redactPodSpec(&set.Spec.Template.Spec)
+	stripObjectMeta(&set.Spec.Template)
+	stripObjectMeta(set)
+}
+
+// redactTidbCluster masks the literal values of the sensitive environment variables in the pod templates
+// of the components and the sensitive config items of the TiDB groups
+func redactTidbCluster(tc *v1alpha1.TidbCluster) {
+	for _, template := range []*v1alpha1.PodTemplateOverride{tc.Spec.PD.PodTemplate, tc.Spec.TiKV.PodTemplate, tc.Spec.TiDB.PodTemplate} {
+		if template == nil {
+			continue
+		}
+		redactEnv(template.Env)
+		for _, containers := range [][]v1.Container{template.InitContainers, template.Sidecars} {
+			for i := range containers {
+				redactEnv(containers[i].Env)
+			}
+		}
+	}
+	for _, group := range tc.Spec.TiDB.Groups {
+		for key := range group.Config {
+			if sensitiveKeyRegexp.MatchString(key) {
+				// the config values are TOML values
+				group.Config[key] = `"` + redacted + `"`
+			}
+		}
+	}
+}
+
+func redactEnv(envs []v1.EnvVar) {
+	for i := range envs {
+		env := &envs[i]
+		if len(env.Value) > 0 && sensitiveKeyRegexp.MatchString(env.Name) {
+			env.Value = redacted
+		}
+	}
+}
+
+// stripObjectMeta drops the last applied configurations of kubectl and tidb-operator, which duplicate
+// the object and may carry the values redacted elsewhere
+func stripObjectMeta(obj metav1.Object) metav1.Object {
+	annotations := obj.GetAnnotations()
+	for _, key := range []string{lastAppliedAnnotation, operatorLastAppliedAnnotation} {
+		if _, ok := annotations[key]; ok {
+			delete(annotations, key)
+			obj.SetAnnotations(annotations)
+		}
+	}
+	return obj
+}
+
+// referencedConfigMaps returns the names of the configmaps mounted or referenced by the pod spec
+func referencedConfigMaps(spec *v1.PodSpec) []string {
+	var names []string
+	for _, volume := range spec.Volumes {
+		if volume.ConfigMap != nil {
+			names = append(names, volume.ConfigMap.Name)
+		}
+	}
+	for _, containers := range [][]v1.Container{spec.InitContainers, spec.Containers} {
+		for _, container := range containers {
+			for _, env := range container.Env {
+				if env.ValueFrom != nil && env.ValueFrom.ConfigMapKeyRef != nil {
+					names = append(names, env.ValueFrom.ConfigMapKeyRef.Name)
+				}
+			}
+			for _, envFrom := range container.EnvFrom {
+				if envFrom.ConfigMapRef != nil {
+					names = append(names, envFrom.ConfigMapRef.Name)
+				}
+			}
+		}
+	}
+	return names
+}
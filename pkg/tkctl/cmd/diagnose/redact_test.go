@@ -0,0 +1,167 @@
+// Copyright 2019. PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package diagnose
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/pingcap/tidb-operator/pkg/apis/pingcap.com/v1alpha1"
+	apps "k8s.io/api/apps/v1beta1"
+	"k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestRedactText(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	tests := []struct {
+		in  string
+		out string
+	}{
+		{"password: abc", "password: <redacted>"},
+		{"  tidb_password = \"abc\"", "  tidb_password = \"<redacted>\""},
+		{`{"token": "abc", "name": "demo"}`, `{"token": "<redacted>", "name": "demo"}`},
+		{"--password=abc --user=root", "--password=<redacted> --user=root"},
+		{"      MYSQL_PASSWORD:  abc", "      MYSQL_PASSWORD:  <redacted>"},
+		{"aws_secret_key: 'abc'", "aws_secret_key: '<redacted>'"},
+		{"CREATE USER 'u'@'%' IDENTIFIED BY 'abc'", "CREATE USER 'u'@'%' IDENTIFIED BY '<redacted>'"},
+		{"set password for u = password('abc')", "set password for u = password('abc')"},
+		{"password:\nuser: root", "password:\nuser: root"},
+		{"secretName: tidb-secret", "secretName: tidb-secret"},
+	}
+	for _, test := range tests {
+		g.Expect(string(redactText([]byte(test.in)))).To(Equal(test.out), test.in)
+	}
+}
+
+func TestRedactPodSpec(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	spec := &v1.PodSpec{
+		InitContainers: []v1.Container{{
+			Env: []v1.EnvVar{{Name: "ACCESS_KEY", Value: "abc"}},
+		}},
+		Containers: []v1.Container{{
+			Env: []v1.EnvVar{
+				{Name: "TZ", Value: "UTC"},
+				{Name: "ROOT_PASSWORD", Value: "abc"},
+				{Name: "TOKEN", ValueFrom: &v1.EnvVarSource{
+					SecretKeyRef: &v1.SecretKeySelector{Key: "token"},
+				}},
+			},
+		}},
+	}
+	redactPodSpec(spec)
+	g.Expect(spec.InitContainers[0].Env[0].Value).To(Equal(redacted))
+	g.Expect(spec.Containers[0].Env[0].Value).To(Equal("UTC"))
+	g.Expect(spec.Containers[0].Env[1].Value).To(Equal(redacted))
+	g.Expect(spec.Containers[0].Env[2].Value).To(BeEmpty())
+	g.Expect(spec.Containers[0].Env[2].ValueFrom).NotTo(BeNil())
+}
+
+func TestRedactStatefulSet(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	lastApplied := `{"containers":[{"name":"tidb","env":[{"name":"MYSQL_PASSWORD","value":"abc"}]}]}`
+	set := &apps.StatefulSet{
+		ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{
+			operatorLastAppliedAnnotation: lastApplied,
+			"foo":                         "bar",
+		}},
+	}
+	set.Spec.Template.Annotations = map[string]string{operatorLastAppliedAnnotation: lastApplied}
+	set.Spec.Template.Spec.Containers = []v1.Container{{
+		Name: "tidb",
+		Env:  []v1.EnvVar{{Name: "MYSQL_PASSWORD", Value: "abc"}},
+	}}
+	redactStatefulSet(set)
+	g.Expect(set.Annotations).To(Equal(map[string]string{"foo": "bar"}))
+	g.Expect(set.Spec.Template.Annotations).To(BeEmpty())
+	g.Expect(set.Spec.Template.Spec.Containers[0].Env[0].Value).To(Equal(redacted))
+}
+
+func TestRedactTidbCluster(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	tc := &v1alpha1.TidbCluster{}
+	tc.Spec.TiDB.PodTemplate = &v1alpha1.PodTemplateOverride{
+		Env: []v1.EnvVar{
+			{Name: "TZ", Value: "UTC"},
+			{Name: "MYSQL_PASSWORD", Value: "abc"},
+		},
+		InitContainers: []v1.Container{{
+			Env: []v1.EnvVar{{Name: "SECRET_KEY", Value: "abc"}},
+		}},
+		Sidecars: []v1.Container{{
+			Env: []v1.EnvVar{{Name: "API_TOKEN", Value: "abc"}},
+		}},
+	}
+	tc.Spec.TiKV.PodTemplate = &v1alpha1.PodTemplateOverride{
+		Env: []v1.EnvVar{{Name: "S3_ACCESS_KEY", Value: "abc"}},
+	}
+	tc.Spec.TiDB.Groups = []v1alpha1.TiDBGroupSpec{{
+		Name: "olap",
+		Config: map[string]string{
+			"performance.max-procs": "8",
+			"plugin.audit.password": `"abc"`,
+		},
+	}}
+	redactTidbCluster(tc)
+	g.Expect(tc.Spec.TiDB.PodTemplate.Env[0].Value).To(Equal("UTC"))
+	g.Expect(tc.Spec.TiDB.PodTemplate.Env[1].Value).To(Equal(redacted))
+	g.Expect(tc.Spec.TiDB.PodTemplate.InitContainers[0].Env[0].Value).To(Equal(redacted))
+	g.Expect(tc.Spec.TiDB.PodTemplate.Sidecars[0].Env[0].Value).To(Equal(redacted))
+	g.Expect(tc.Spec.TiKV.PodTemplate.Env[0].Value).To(Equal(redacted))
+	g.Expect(tc.Spec.TiDB.Groups[0].Config).To(Equal(map[string]string{
+		"performance.max-procs": "8",
+		"plugin.audit.password": `"<redacted>"`,
+	}))
+}
+
+func TestStripObjectMeta(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	pod := &v1.Pod{ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{
+		lastAppliedAnnotation:         "{}",
+		operatorLastAppliedAnnotation: "{}",
+		"foo":                         "bar",
+	}}}
+	stripObjectMeta(pod)
+	g.Expect(pod.Annotations).To(Equal(map[string]string{"foo": "bar"}))
+}
+
+func TestReferencedConfigMaps(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	spec := &v1.PodSpec{
+		Volumes: []v1.Volume{
+			{Name: "config", VolumeSource: v1.VolumeSource{ConfigMap: &v1.ConfigMapVolumeSource{
+				LocalObjectReference: v1.LocalObjectReference{Name: "demo-tikv"},
+			}}},
+			{Name: "data", VolumeSource: v1.VolumeSource{EmptyDir: &v1.EmptyDirVolumeSource{}}},
+		},
+		Containers: []v1.Container{{
+			Env: []v1.EnvVar{{Name: "FOO", ValueFrom: &v1.EnvVarSource{
+				ConfigMapKeyRef: &v1.ConfigMapKeySelector{
+					LocalObjectReference: v1.LocalObjectReference{Name: "demo-env"},
+				},
+			}}},
+			EnvFrom: []v1.EnvFromSource{{ConfigMapRef: &v1.ConfigMapEnvSource{
+				LocalObjectReference: v1.LocalObjectReference{Name: "demo-env-from"},
+			}}},
+		}},
+	}
+	g.Expect(referencedConfigMaps(spec)).To(Equal([]string{"demo-tikv", "demo-env", "demo-env-from"}))
+}
@@ -0,0 +1,98 @@
+// Copyright 2019. PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package diagnose
+
+import (
+	"bytes"
+	"fmt"
+	"time"
+
+	"k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// logWindow is the time window of the gathered logs, the start of the window is handled by the
+// API server while the end is handled by filtering the timestamped log lines
+type logWindow struct {
+	sinceSeconds *int64
+	sinceTime    *metav1.Time
+	untilTime    *time.Time
+}
+
+func newLogWindow(since time.Duration, sinceTime, untilTime string) (logWindow, error) {
+	w := logWindow{}
+	if since != 0 && len(sinceTime) > 0 {
+		return w, fmt.Errorf("at most one of --since and --since-time may be specified")
+	}
+	if since < 0 {
+		return w, fmt.Errorf("--since must be positive, got %s", since)
+	}
+	if since > 0 {
+		seconds := int64((since + time.Second - 1) / time.Second)
+		w.sinceSeconds = &seconds
+	}
+	if len(sinceTime) > 0 {
+		t, err := time.Parse(time.RFC3339, sinceTime)
+		if err != nil {
+			return w, fmt.Errorf("invalid --since-time %q, expected RFC3339 format: %v", sinceTime, err)
+		}
+		w.sinceTime = &metav1.Time{Time: t}
+	}
+	if len(untilTime) > 0 {
+		t, err := time.Parse(time.RFC3339, untilTime)
+		if err != nil {
+			return w, fmt.Errorf("invalid --until-time %q, expected RFC3339 format: %v", untilTime, err)
+		}
+		if w.sinceTime != nil && !t.After(w.sinceTime.Time) {
+			return w, fmt.Errorf("--until-time must be after --since-time")
+		}
+		w.untilTime = &t
+	}
+	return w, nil
+}
+
+func (w logWindow) logOptions(container string, previous bool) *v1.PodLogOptions {
+	return &v1.PodLogOptions{
+		Container:    container,
+		Previous:     previous,
+		SinceSeconds: w.sinceSeconds,
+		SinceTime:    w.sinceTime,
+		// the timestamps are required to find the end of the window
+		Timestamps: w.untilTime != nil,
+	}
+}
+
+// filter drops the log lines after the end of the window and strips the timestamps added for filtering
+func (w logWindow) filter(data []byte) []byte {
+	if w.untilTime == nil {
+		return data
+	}
+	var buf bytes.Buffer
+	for _, line := range bytes.SplitAfter(data, []byte("\n")) {
+		if len(line) == 0 {
+			continue
+		}
+		fields := bytes.SplitN(line, []byte(" "), 2)
+		ts, err := time.Parse(time.RFC3339Nano, string(fields[0]))
+		if err != nil || len(fields) < 2 {
+			buf.Write(line)
+			continue
+		}
+		if ts.After(*w.untilTime) {
+			break
+		}
+		buf.Write(fields[1])
+	}
+	return buf.Bytes()
+}
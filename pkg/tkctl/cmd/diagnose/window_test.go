@@ -0,0 +1,68 @@
+// Copyright 2019. PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package diagnose
+
+import (
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+)
+
+func TestNewLogWindow(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	_, err := newLogWindow(time.Hour, "2019-03-01T08:00:00Z", "")
+	g.Expect(err).To(HaveOccurred())
+	_, err = newLogWindow(-time.Hour, "", "")
+	g.Expect(err).To(HaveOccurred())
+	_, err = newLogWindow(0, "yesterday", "")
+	g.Expect(err).To(HaveOccurred())
+	_, err = newLogWindow(0, "2019-03-01T08:00:00Z", "2019-03-01T07:00:00Z")
+	g.Expect(err).To(HaveOccurred())
+
+	w, err := newLogWindow(1500*time.Millisecond, "", "")
+	g.Expect(err).NotTo(HaveOccurred())
+	opts := w.logOptions("tikv", true)
+	g.Expect(*opts.SinceSeconds).To(Equal(int64(2)))
+	g.Expect(opts.SinceTime).To(BeNil())
+	g.Expect(opts.Container).To(Equal("tikv"))
+	g.Expect(opts.Previous).To(BeTrue())
+	g.Expect(opts.Timestamps).To(BeFalse())
+
+	w, err = newLogWindow(0, "2019-03-01T08:00:00Z", "2019-03-01T09:00:00Z")
+	g.Expect(err).NotTo(HaveOccurred())
+	opts = w.logOptions("pd", false)
+	g.Expect(opts.SinceSeconds).To(BeNil())
+	g.Expect(opts.SinceTime.Time).To(Equal(time.Date(2019, 3, 1, 8, 0, 0, 0, time.UTC)))
+	g.Expect(opts.Timestamps).To(BeTrue())
+}
+
+func TestLogWindowFilter(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	logs := []byte("2019-03-01T08:59:59.123456789Z first\n" +
+		"continued\n" +
+		"2019-03-01T09:00:00Z second\n" +
+		"2019-03-01T09:00:00.5Z third\n" +
+		"2019-03-01T09:10:00Z fourth\n")
+
+	w, err := newLogWindow(0, "", "")
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(w.filter(logs)).To(Equal(logs))
+
+	w, err = newLogWindow(0, "", "2019-03-01T09:00:00Z")
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(string(w.filter(logs))).To(Equal("first\ncontinued\nsecond\n"))
+}
@@ -23,8 +23,11 @@ import (
 	"github.com/spf13/cobra"
 	"k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
 	"k8s.io/cli-runtime/pkg/genericclioptions"
 	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
 	cmdutil "k8s.io/kubernetes/pkg/kubectl/cmd/util"
 	kubeprinters "k8s.io/kubernetes/pkg/printers"
 	"strings"
@@ -34,8 +37,15 @@ const (
 	getLongDesc = `
 		Get tidb component detail.
 
-		Available components include: all, pd, tidb, tikv, volume
+		Available components include: all, pd, tidb, tikv, volume, regions, hotspots
 		You can omit --tidbcluster=<name> option by running 'tkc use <name>',
+
+		The regions and hotspots are fetched from PD through a port-forward, they
+		are not included in all. The regions show the region count, leader count
+		and size of every TiKV store, the hotspots show the hot read and write
+		regions of every TiKV store and the regions with the most read and written
+		bytes. For machine-readable output, the TiKV pods are printed with the
+		distribution attached to their computed store.
 `
 	getExample = `
 		# get PD details 
@@ -49,6 +59,15 @@ const (
 
 		# get all components
 		tkc get all
+
+		# get tikv of all tidb clusters in all namespaces
+		tkc get tikv --all-namespaces
+
+		# get the region and leader distribution of the TiKV stores
+		tkc get regions
+
+		# get the hot regions of the TiKV stores and the top 20 regions by flow
+		tkc get hotspots --top 20
 `
 	getUsage = "expect 'get -t=CLUSTER_NAME kind | get -A kind' for get command or set tidb cluster by 'use' first"
 )
@@ -59,23 +78,32 @@ const (
 	kindTiDB   = "tidb"
 	kindVolume = "volume"
 	kindAll    = "all"
+
+	kindRegions  = "regions"
+	kindHotspots = "hotspots"
+
+	defaultTop = 10
 )
 
 // GetOptions contains the input to the list command.
 type GetOptions struct {
 	LabelSelector   string
-	AllClusters     bool
+	AllNamespaces   bool
 	Namespace       string
 	TidbClusterName string
 	GetPD           bool
 	GetTiKV         bool
 	GetTiDB         bool
 	GetVolume       bool
+	GetRegions      bool
+	GetHotspots     bool
+	Top             int
 
 	PrintFlags *readable.PrintFlags
 
-	tcCli   *versioned.Clientset
-	kubeCli *kubernetes.Clientset
+	restConfig *rest.Config
+	tcCli      *versioned.Clientset
+	kubeCli    *kubernetes.Clientset
 
 	genericclioptions.IOStreams
 }
@@ -86,7 +114,7 @@ func NewCmdGet(tkcContext *config.TkcContext, streams genericclioptions.IOStream
 
 	cmd := &cobra.Command{
 		Use:     "get",
-		Short:   "get pd|tikv|tidb|volume|all",
+		Short:   "get pd|tikv|tidb|volume|all|regions|hotspots",
 		Long:    getLongDesc,
 		Example: getExample,
 		Run: func(cmd *cobra.Command, args []string) {
@@ -97,8 +125,13 @@ func NewCmdGet(tkcContext *config.TkcContext, streams genericclioptions.IOStream
 	}
 
 	options.PrintFlags.AddFlags(cmd)
-	cmd.Flags().BoolVarP(&options.AllClusters, "all-clusters", "A", false,
+	cmd.Flags().BoolVarP(&options.AllNamespaces, "all-namespaces", "A", false,
+		"whether list components of all tidb clusters in all namespaces")
+	cmd.Flags().BoolVar(&options.AllNamespaces, "all-clusters", false,
 		"whether list components of all tidb clusters")
+	cmd.Flags().MarkDeprecated("all-clusters", "use --all-namespaces instead")
+	cmd.Flags().IntVar(&options.Top, "top", options.Top,
+		"The number of the regions with the most read and written bytes to show for hotspots, 0 to hide them.")
 
 	return cmd
 }
@@ -107,13 +140,16 @@ func NewCmdGet(tkcContext *config.TkcContext, streams genericclioptions.IOStream
 func NewGetOptions(streams genericclioptions.IOStreams) *GetOptions {
 	return &GetOptions{
 		PrintFlags: readable.NewPrintFlags(),
+		Top:        defaultTop,
 
 		IOStreams: streams,
 	}
 }
 
 func (o *GetOptions) Complete(tkcContext *config.TkcContext, cmd *cobra.Command, args []string) error {
-
+	if err := o.PrintFlags.Validate(); err != nil {
+		return err
+	}
 	clientConfig, err := tkcContext.ToTkcClientConfig()
 	if err != nil {
 		return err
@@ -125,17 +161,21 @@ func (o *GetOptions) Complete(tkcContext *config.TkcContext, cmd *cobra.Command,
 	o.Namespace = namespace
 	if tcName, ok := clientConfig.TidbClusterName(); ok {
 		o.TidbClusterName = tcName
-	} else if !o.AllClusters {
+	} else if !o.AllNamespaces {
 		return cmdutil.UsageErrorf(cmd, getUsage)
 	}
 	if len(args) < 1 {
 		return cmdutil.UsageErrorf(cmd, getUsage)
 	}
+	if o.Top < 0 {
+		return cmdutil.UsageErrorf(cmd, "--top must not be negative, got %d", o.Top)
+	}
 
 	restConfig, err := clientConfig.RestConfig()
 	if err != nil {
 		return err
 	}
+	o.restConfig = restConfig
 	tcClient, err := versioned.NewForConfig(restConfig)
 	if err != nil {
 		return err
@@ -164,6 +204,10 @@ func (o *GetOptions) Complete(tkcContext *config.TkcContext, cmd *cobra.Command,
 			o.GetTiDB = true
 		case kindVolume:
 			o.GetVolume = true
+		case kindRegions:
+			o.GetRegions = true
+		case kindHotspots:
+			o.GetHotspots = true
 		}
 	}
 	return nil
@@ -172,7 +216,7 @@ func (o *GetOptions) Complete(tkcContext *config.TkcContext, cmd *cobra.Command,
 func (o *GetOptions) Run(tkcContext *config.TkcContext, cmd *cobra.Command, args []string) error {
 
 	var tcs []v1alpha1.TidbCluster
-	if o.AllClusters {
+	if o.AllNamespaces {
 		tcList, err := o.tcCli.PingcapV1alpha1().
 			TidbClusters(v1.NamespaceAll).
 			List(metav1.ListOptions{})
@@ -190,16 +234,18 @@ func (o *GetOptions) Run(tkcContext *config.TkcContext, cmd *cobra.Command, args
 		tcs = []v1alpha1.TidbCluster{*tc}
 	}
 
-	printer, err := o.PrintFlags.ToPrinter(false, o.AllClusters)
+	printer, err := o.PrintFlags.ToPrinter(false, o.AllNamespaces)
 	if err != nil {
 		return err
 	}
+	human := o.PrintFlags.IsHumanReadable()
+	var objs []*unstructured.Unstructured
 	w := kubeprinters.GetNewTabWriter(o.Out)
 	printTidbInfo := len(tcs) > 1
 	var errs []error
 	for i := range tcs {
 		tc := tcs[i]
-		if printTidbInfo {
+		if printTidbInfo && human {
 			w.Write([]byte(fmt.Sprintf("Cluster: %s/%s\n", tc.Namespace, tc.Name)))
 			w.Flush()
 		}
@@ -209,6 +255,19 @@ func (o *GetOptions) Run(tkcContext *config.TkcContext, cmd *cobra.Command, args
 			})
 			if err != nil {
 				errs = append(errs, err)
+				return
+			}
+			if !human {
+				for j := range podList.Items {
+					pod := &podList.Items[j]
+					obj, err := readable.NewObject(pod, readable.PodKind, readable.ComputePod(&tc, pod))
+					if err != nil {
+						errs = append(errs, err)
+						continue
+					}
+					objs = append(objs, obj)
+				}
+				return
 			}
 			printer.PrintObj(podList, w)
 			w.Flush()
@@ -231,9 +290,33 @@ func (o *GetOptions) Run(tkcContext *config.TkcContext, cmd *cobra.Command, args
 			if err != nil {
 				return err
 			}
-			printer.PrintObj(volumeList, w)
-			w.Flush()
+			if !human {
+				for j := range volumeList.Items {
+					obj, err := readable.NewObject(&volumeList.Items[j], readable.PersistentVolumeKind, nil)
+					if err != nil {
+						return err
+					}
+					objs = append(objs, obj)
+				}
+			} else {
+				printer.PrintObj(volumeList, w)
+				w.Flush()
+			}
+		}
+		if o.GetRegions || o.GetHotspots {
+			distObjs, err := o.getDistribution(&tc, human)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("failed to get the data distribution of tidb cluster %s/%s: %v",
+					tc.Namespace, tc.Name, err))
+				continue
+			}
+			objs = append(objs, distObjs...)
 		}
 	}
-	return nil
+	if !human {
+		if err := printer.PrintObj(readable.NewList(objs), o.Out); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return utilerrors.NewAggregate(errs)
 }
@@ -0,0 +1,275 @@
+// Copyright 2019. PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package get
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/pingcap/pd/server/core"
+	"github.com/pingcap/tidb-operator/pkg/apis/pingcap.com/v1alpha1"
+	"github.com/pingcap/tidb-operator/pkg/controller"
+	"github.com/pingcap/tidb-operator/pkg/label"
+	"github.com/pingcap/tidb-operator/pkg/tkctl/cmd/pdctl"
+	"github.com/pingcap/tidb-operator/pkg/tkctl/readable"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+const (
+	pdTimeout = 10 * time.Second
+	unset     = "<none>"
+)
+
+// distribution is the data distribution of a tidb cluster fetched from PD
+type distribution struct {
+	stats    *core.RegionStats
+	hotRead  *core.StoreHotRegionInfos
+	hotWrite *core.StoreHotRegionInfos
+	topRead  []*controller.RegionInfo
+	topWrite []*controller.RegionInfo
+}
+
+// storeRow is a TiKV store in the status of the tidb cluster, correlated with its pod and node
+type storeRow struct {
+	id    uint64
+	pod   string
+	node  string
+	state string
+}
+
+// getDistribution fetches the data distribution through a PD of the tidb cluster and prints it,
+// for machine-readable output the TiKV pods with the distribution attached to their stores are returned instead
+func (o *GetOptions) getDistribution(tc *v1alpha1.TidbCluster, human bool) ([]*unstructured.Unstructured, error) {
+	pdClient, forwarder, err := pdctl.ForwardPD(o.restConfig, o.kubeCli, tc, pdTimeout, o.ErrOut)
+	if err != nil {
+		return nil, err
+	}
+	defer forwarder.Close()
+
+	top := 0
+	if human {
+		top = o.Top
+	}
+	dist, err := fetchDistribution(pdClient, o.GetRegions, o.GetHotspots, top)
+	if err != nil {
+		return nil, err
+	}
+
+	if human {
+		msg, err := readable.TabbedString(func(out io.Writer) error {
+			renderDistribution(out, tc, dist)
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+		fmt.Fprint(o.Out, msg)
+		return nil, nil
+	}
+
+	podList, err := o.kubeCli.CoreV1().Pods(tc.Namespace).List(metav1.ListOptions{
+		LabelSelector: label.New().Instance(tc.Name).TiKV().String(),
+	})
+	if err != nil {
+		return nil, err
+	}
+	var objs []*unstructured.Unstructured
+	for i := range podList.Items {
+		pod := &podList.Items[i]
+		computed := readable.ComputePod(tc, pod)
+		dist.attach(computed.Store)
+		obj, err := readable.NewObject(pod, readable.PodKind, computed)
+		if err != nil {
+			return nil, err
+		}
+		objs = append(objs, obj)
+	}
+	return objs, nil
+}
+
+// fetchDistribution fetches the region statistics and the hot regions from PD, top is the number
+// of the regions with the most read and written bytes to fetch
+func fetchDistribution(pdClient controller.PDClient, regions, hotspots bool, top int) (*distribution, error) {
+	dist := &distribution{}
+	var err error
+	if regions {
+		if dist.stats, err = pdClient.GetRegionStats(); err != nil {
+			return nil, fmt.Errorf("failed to get the region statistics from PD: %v", err)
+		}
+	}
+	if hotspots {
+		if dist.hotRead, err = pdClient.GetHotReadRegions(); err != nil {
+			return nil, fmt.Errorf("failed to get the hot read regions from PD: %v", err)
+		}
+		if dist.hotWrite, err = pdClient.GetHotWriteRegions(); err != nil {
+			return nil, fmt.Errorf("failed to get the hot write regions from PD: %v", err)
+		}
+		if top > 0 {
+			topRead, err := pdClient.GetTopReadFlowRegions(top)
+			if err != nil {
+				return nil, fmt.Errorf("failed to get the top read regions from PD: %v", err)
+			}
+			dist.topRead = topRead.Regions
+			topWrite, err := pdClient.GetTopWriteFlowRegions(top)
+			if err != nil {
+				return nil, fmt.Errorf("failed to get the top write regions from PD: %v", err)
+			}
+			dist.topWrite = topWrite.Regions
+		}
+	}
+	return dist, nil
+}
+
+// attach attaches the distribution of the store to the computed fields of its pod
+func (d *distribution) attach(store *readable.StoreComputed) {
+	if store == nil {
+		return
+	}
+	id, err := strconv.ParseUint(store.ID, 10, 64)
+	if err != nil {
+		return
+	}
+	if d.stats != nil {
+		store.Regions = readable.ComputeStoreRegions(d.stats, id)
+	}
+	if d.hotRead != nil || d.hotWrite != nil {
+		store.Hotspots = readable.ComputeStoreHotspots(d.hotRead, d.hotWrite, id)
+	}
+}
+
+// storeRows returns the TiKV stores of the tidb cluster sorted by pod name
+func storeRows(tc *v1alpha1.TidbCluster) []storeRow {
+	rows := make([]storeRow, 0, len(tc.Status.TiKV.Stores))
+	for _, store := range tc.Status.TiKV.Stores {
+		id, err := strconv.ParseUint(store.ID, 10, 64)
+		if err != nil {
+			continue
+		}
+		rows = append(rows, storeRow{
+			id:    id,
+			pod:   store.PodName,
+			node:  orUnset(store.NodeName),
+			state: store.State,
+		})
+	}
+	sort.Slice(rows, func(i, j int) bool {
+		return rows[i].pod < rows[j].pod
+	})
+	return rows
+}
+
+func renderDistribution(out io.Writer, tc *v1alpha1.TidbCluster, dist *distribution) {
+	rows := storeRows(tc)
+	podOf := map[uint64]string{}
+	for _, row := range rows {
+		podOf[row.id] = row.pod
+	}
+
+	w := readable.NewPrefixWriter(out)
+	if dist.stats != nil {
+		w.WriteLine(readable.LEVEL_0, "Regions:\t%d (%d empty), %d MiB, %d keys",
+			dist.stats.Count, dist.stats.EmptyCount, dist.stats.StorageSize, dist.stats.StorageRows)
+		w.WriteLine(readable.LEVEL_0, "STORE\tPOD\tNODE\tSTATE\tREGIONS\tLEADERS\tLEADER-RATIO\tREGION-SIZE(MiB)\tLEADER-SIZE(MiB)")
+		for _, row := range rows {
+			regions := readable.ComputeStoreRegions(dist.stats, row.id)
+			w.WriteLine(readable.LEVEL_0, "%d\t%s\t%s\t%s\t%d\t%d\t%.1f%%\t%d\t%d",
+				row.id, row.pod, row.node, row.state, regions.RegionCount, regions.LeaderCount,
+				regions.LeaderRatio, regions.RegionSize, regions.LeaderSize)
+		}
+	}
+	if dist.hotRead != nil || dist.hotWrite != nil {
+		if dist.stats != nil {
+			w.WriteLine(readable.LEVEL_0, "")
+		}
+		w.WriteLine(readable.LEVEL_0, "Hotspots:")
+		w.WriteLine(readable.LEVEL_0, "STORE\tPOD\tNODE\tHOT-WRITE-LEADERS\tWRITE-FLOW\tHOT-WRITE-PEERS\tHOT-READ-LEADERS\tREAD-FLOW\tHOTTEST-REGIONS")
+		for _, row := range rows {
+			hot := readable.ComputeStoreHotspots(dist.hotRead, dist.hotWrite, row.id)
+			w.WriteLine(readable.LEVEL_0, "%d\t%s\t%s\t%d\t%s\t%d\t%d\t%s\t%s",
+				row.id, row.pod, row.node,
+				hot.Write.LeaderCount, formatFlow(hot.Write.LeaderFlowBytes), hot.Write.PeerCount,
+				hot.Read.LeaderCount, formatFlow(hot.Read.LeaderFlowBytes),
+				hottestRegions(hot.Write.Regions, hot.Read.Regions))
+		}
+		renderTopRegions(w, "Top write regions:", dist.topWrite, podOf)
+		renderTopRegions(w, "Top read regions:", dist.topRead, podOf)
+	}
+}
+
+func renderTopRegions(w readable.PrefixWriter, title string, regions []*controller.RegionInfo, podOf map[uint64]string) {
+	if len(regions) == 0 {
+		return
+	}
+	w.WriteLine(readable.LEVEL_0, "")
+	w.WriteLine(readable.LEVEL_0, title)
+	w.WriteLine(readable.LEVEL_0, "REGION\tLEADER-STORE\tLEADER-POD\tWRITTEN\tREAD\tSIZE(MiB)\tKEYS")
+	for _, region := range regions {
+		store, pod := unset, unset
+		if region.Leader != nil {
+			store = strconv.FormatUint(region.Leader.StoreId, 10)
+			pod = orUnset(podOf[region.Leader.StoreId])
+		}
+		w.WriteLine(readable.LEVEL_0, "%d\t%s\t%s\t%s\t%s\t%d\t%d",
+			region.ID, store, pod, formatBytes(region.WrittenBytes), formatBytes(region.ReadBytes),
+			region.ApproximateSize, region.ApproximateKeys)
+	}
+}
+
+// hottestRegions renders the hottest write and read regions led by a store, at most 3 of each
+func hottestRegions(write, read []uint64) string {
+	const max = 3
+	format := func(prefix string, ids []uint64) string {
+		s := ""
+		for i, id := range ids {
+			if i == max {
+				s += ",..."
+				break
+			}
+			if i > 0 {
+				s += ","
+			}
+			s += strconv.FormatUint(id, 10)
+		}
+		return prefix + s
+	}
+	switch {
+	case len(write) > 0 && len(read) > 0:
+		return format("w:", write) + " " + format("r:", read)
+	case len(write) > 0:
+		return format("w:", write)
+	case len(read) > 0:
+		return format("r:", read)
+	}
+	return unset
+}
+
+func formatBytes(b uint64) string {
+	return resource.NewQuantity(int64(b), resource.BinarySI).String()
+}
+
+func formatFlow(b uint64) string {
+	return formatBytes(b) + "/s"
+}
+
+func orUnset(s string) string {
+	if len(s) == 0 {
+		return unset
+	}
+	return s
+}
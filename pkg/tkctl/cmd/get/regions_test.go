@@ -0,0 +1,161 @@
+// Copyright 2019. PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package get
+
+import (
+	"io"
+	"strings"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/pingcap/kvproto/pkg/metapb"
+	"github.com/pingcap/pd/server/core"
+	"github.com/pingcap/tidb-operator/pkg/apis/pingcap.com/v1alpha1"
+	"github.com/pingcap/tidb-operator/pkg/controller"
+	"github.com/pingcap/tidb-operator/pkg/tkctl/readable"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func newTidbCluster() *v1alpha1.TidbCluster {
+	tc := &v1alpha1.TidbCluster{ObjectMeta: metav1.ObjectMeta{Name: "demo", Namespace: "ns"}}
+	tc.Status.TiKV.Stores = map[string]v1alpha1.TiKVStore{
+		"5": {ID: "5", PodName: "demo-tikv-1", State: "Up", NodeName: "node-2"},
+		"4": {ID: "4", PodName: "demo-tikv-0", State: "Up", NodeName: "node-1"},
+	}
+	return tc
+}
+
+func newFakePDClient() *controller.FakePDClient {
+	pdClient := controller.NewFakePDClient()
+	pdClient.AddReaction(controller.GetRegionStatsActionType, func(action *controller.Action) (interface{}, error) {
+		return &core.RegionStats{
+			Count:            4,
+			StorageSize:      400,
+			StorageRows:      4000,
+			StoreLeaderCount: map[uint64]int{4: 3, 5: 1},
+			StorePeerCount:   map[uint64]int{4: 4, 5: 4},
+			StoreLeaderSize:  map[uint64]int64{4: 300, 5: 100},
+			StorePeerSize:    map[uint64]int64{4: 400, 5: 400},
+		}, nil
+	})
+	pdClient.AddReaction(controller.GetHotReadRegionsActionType, func(action *controller.Action) (interface{}, error) {
+		return &core.StoreHotRegionInfos{}, nil
+	})
+	pdClient.AddReaction(controller.GetHotWriteRegionsActionType, func(action *controller.Action) (interface{}, error) {
+		return &core.StoreHotRegionInfos{
+			AsLeader: core.StoreHotRegionsStat{
+				5: &core.HotRegionsStat{TotalFlowBytes: 2048, RegionsCount: 1, RegionsStat: core.RegionsStat{{RegionID: 7, FlowBytes: 2048}}},
+			},
+			AsPeer: core.StoreHotRegionsStat{
+				4: &core.HotRegionsStat{TotalFlowBytes: 2048, RegionsCount: 1},
+				5: &core.HotRegionsStat{TotalFlowBytes: 2048, RegionsCount: 1},
+			},
+		}, nil
+	})
+	topRegions := func(action *controller.Action) (interface{}, error) {
+		return &controller.RegionsInfo{Count: 1, Regions: []*controller.RegionInfo{
+			{ID: 7, Leader: &metapb.Peer{Id: 8, StoreId: 5}, WrittenBytes: 4096, ApproximateSize: 96, ApproximateKeys: 1000},
+		}}, nil
+	}
+	pdClient.AddReaction(controller.GetTopReadFlowRegionsActionType, topRegions)
+	pdClient.AddReaction(controller.GetTopWriteFlowRegionsActionType, topRegions)
+	return pdClient
+}
+
+func TestFetchDistribution(t *testing.T) {
+	g := NewGomegaWithT(t)
+	pdClient := newFakePDClient()
+
+	dist, err := fetchDistribution(pdClient, true, false, 10)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(dist.stats.Count).To(Equal(4))
+	g.Expect(dist.hotWrite).To(BeNil())
+	g.Expect(dist.topWrite).To(BeNil())
+
+	dist, err = fetchDistribution(pdClient, false, true, 0)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(dist.stats).To(BeNil())
+	g.Expect(dist.hotWrite).NotTo(BeNil())
+	g.Expect(dist.topWrite).To(BeNil())
+
+	dist, err = fetchDistribution(pdClient, false, true, 10)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(dist.topWrite).To(HaveLen(1))
+	g.Expect(dist.topRead).To(HaveLen(1))
+
+	_, err = fetchDistribution(controller.NewFakePDClient(), true, false, 0)
+	g.Expect(err).To(HaveOccurred())
+}
+
+func TestDistributionAttach(t *testing.T) {
+	g := NewGomegaWithT(t)
+	dist, err := fetchDistribution(newFakePDClient(), true, true, 0)
+	g.Expect(err).NotTo(HaveOccurred())
+
+	store := &readable.StoreComputed{ID: "5"}
+	dist.attach(store)
+	g.Expect(store.Regions).To(Equal(&readable.StoreRegionsComputed{
+		RegionCount: 4, LeaderCount: 1, RegionSize: 400, LeaderSize: 100, LeaderRatio: 25,
+	}))
+	g.Expect(store.Hotspots.Write.Regions).To(Equal([]uint64{7}))
+
+	// pods without a store are left untouched
+	dist.attach(nil)
+}
+
+func TestRenderDistribution(t *testing.T) {
+	g := NewGomegaWithT(t)
+	dist, err := fetchDistribution(newFakePDClient(), true, true, 10)
+	g.Expect(err).NotTo(HaveOccurred())
+
+	msg, err := readable.TabbedString(func(out io.Writer) error {
+		renderDistribution(out, newTidbCluster(), dist)
+		return nil
+	})
+	g.Expect(err).NotTo(HaveOccurred())
+
+	lines := strings.Split(msg, "\n")
+	g.Expect(lines[0]).To(HavePrefix("Regions:"))
+	g.Expect(lines[0]).To(ContainSubstring("4 (0 empty), 400 MiB, 4000 keys"))
+	g.Expect(strings.Fields(lines[1])).To(Equal([]string{"STORE", "POD", "NODE", "STATE", "REGIONS", "LEADERS",
+		"LEADER-RATIO", "REGION-SIZE(MiB)", "LEADER-SIZE(MiB)"}))
+	// the stores are sorted by pod name
+	g.Expect(strings.Fields(lines[2])).To(Equal([]string{"4", "demo-tikv-0", "node-1", "Up", "4", "3", "75.0%", "400", "300"}))
+	g.Expect(strings.Fields(lines[3])).To(Equal([]string{"5", "demo-tikv-1", "node-2", "Up", "4", "1", "25.0%", "400", "100"}))
+
+	g.Expect(msg).To(ContainSubstring("Hotspots:"))
+	var hotRow, topRow string
+	for _, line := range lines {
+		if strings.HasPrefix(line, "5 ") {
+			hotRow = line
+		}
+		if strings.HasPrefix(line, "7 ") {
+			topRow = line
+		}
+	}
+	g.Expect(strings.Fields(hotRow)).To(Equal([]string{"5", "demo-tikv-1", "node-2", "1", "2Ki/s", "1", "0", "0/s", "w:7"}))
+	// the leader of the top region is correlated with its pod
+	g.Expect(strings.Fields(topRow)).To(Equal([]string{"7", "5", "demo-tikv-1", "4Ki", "0", "96", "1000"}))
+	g.Expect(msg).To(ContainSubstring("Top write regions:"))
+	g.Expect(msg).To(ContainSubstring("Top read regions:"))
+}
+
+func TestHottestRegions(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	g.Expect(hottestRegions(nil, nil)).To(Equal(unset))
+	g.Expect(hottestRegions([]uint64{1, 2}, nil)).To(Equal("w:1,2"))
+	g.Expect(hottestRegions(nil, []uint64{3})).To(Equal("r:3"))
+	g.Expect(hottestRegions([]uint64{1, 2, 3, 4}, []uint64{5})).To(Equal("w:1,2,3,... r:5"))
+}
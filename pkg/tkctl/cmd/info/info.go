@@ -21,8 +21,10 @@ import (
 	"github.com/pingcap/tidb-operator/pkg/tkctl/config"
 	"github.com/pingcap/tidb-operator/pkg/tkctl/readable"
 	"github.com/pingcap/tidb-operator/pkg/tkctl/util"
+	"github.com/pingcap/tidb-operator/pkg/tkctl/watch"
 	"github.com/spf13/cobra"
 	"io"
+	apps "k8s.io/api/apps/v1beta1"
 	"k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/cli-runtime/pkg/genericclioptions"
@@ -42,6 +44,9 @@ const (
 
 		# get specified tidb cluster info
 		tkc info -t another-cluster
+
+		# watch the tidb cluster info until the ongoing upgrade or scaling is completed
+		tkc info --watch
 `
 	infoUsage = `expected 'info -t CLUSTER_NAME' for the info command or 
 using 'tkc use' to set tidb cluster first.
@@ -52,6 +57,9 @@ using 'tkc use' to set tidb cluster first.
 type InfoOptions struct {
 	TidbClusterName string
 	Namespace       string
+	Watch           bool
+
+	PrintFlags *readable.PrintFlags
 
 	TcCli   *versioned.Clientset
 	KubeCli *kubernetes.Clientset
@@ -62,6 +70,8 @@ type InfoOptions struct {
 // NewInfoOptions returns a InfoOptions
 func NewInfoOptions(streams genericclioptions.IOStreams) *InfoOptions {
 	return &InfoOptions{
+		PrintFlags: readable.NewPrintFlags(),
+
 		IOStreams: streams,
 	}
 }
@@ -81,11 +91,20 @@ func NewCmdInfo(tkcContext *config.TkcContext, streams genericclioptions.IOStrea
 		},
 		SuggestFor: []string{"inspect", "explain"},
 	}
+	cmd.Flags().BoolVarP(&o.Watch, "watch", "w", o.Watch,
+		"Refresh the info on changes until the ongoing upgrade or scaling is completed.")
+	o.PrintFlags.AddFlags(cmd)
 
 	return cmd
 }
 
 func (o *InfoOptions) Complete(tkcContext *config.TkcContext, cmd *cobra.Command, args []string) error {
+	if err := o.PrintFlags.Validate(); err != nil {
+		return err
+	}
+	if o.Watch && !o.PrintFlags.IsHumanReadable() {
+		return cmdutil.UsageErrorf(cmd, "--watch only supports the default output format")
+	}
 
 	clientConfig, err := tkcContext.ToTkcClientConfig()
 	if err != nil {
@@ -123,6 +142,10 @@ func (o *InfoOptions) Complete(tkcContext *config.TkcContext, cmd *cobra.Command
 }
 
 func (o *InfoOptions) Run() error {
+	if o.Watch {
+		return watch.Watch(o.TcCli, o.KubeCli, o.Namespace, o.TidbClusterName,
+			renderFromListers, o.Out, watch.SetupSignalHandler())
+	}
 
 	tc, err := o.TcCli.PingcapV1alpha1().
 		TidbClusters(o.Namespace).
@@ -141,6 +164,17 @@ func (o *InfoOptions) Run() error {
 	if err != nil {
 		return err
 	}
+	if !o.PrintFlags.IsHumanReadable() {
+		printer, err := o.PrintFlags.ToPrinter(false, false)
+		if err != nil {
+			return err
+		}
+		obj, err := readable.NewObject(tc, readable.TidbClusterKind, computeClusterInfo(tc, svc, podList))
+		if err != nil {
+			return err
+		}
+		return printer.PrintObj(obj, o.Out)
+	}
 	msg, err := renderTidbCluster(tc, svc, podList)
 	if err != nil {
 		return err
@@ -149,9 +183,100 @@ func (o *InfoOptions) Run() error {
 	return nil
 }
 
+func renderFromListers(tc *v1alpha1.TidbCluster, listers *watch.Listers) (string, error) {
+	svc, err := listers.Services.Services(tc.Namespace).Get(util.GetTidbServiceName(tc.Name))
+	if err != nil {
+		return "", err
+	}
+	selector, err := label.New().Instance(tc.Name).TiDB().Selector()
+	if err != nil {
+		return "", err
+	}
+	podList, err := listers.PodList(tc.Namespace, selector)
+	if err != nil {
+		return "", err
+	}
+	return renderTidbCluster(tc, svc, podList)
+}
+
+// clusterInfo is the info of a tidb cluster computed from the TidbCluster and the TiDB service and pods
+type clusterInfo struct {
+	PD        componentInfo `json:"pd"`
+	TiKV      componentInfo `json:"tikv"`
+	TiDB      componentInfo `json:"tidb"`
+	Endpoints endpoints     `json:"endpoints"`
+}
+
+type componentInfo struct {
+	Phase   v1alpha1.MemberPhase `json:"phase"`
+	Ready   int32                `json:"ready"`
+	Desired int32                `json:"desired"`
+	CPU     string               `json:"cpu"`
+	Memory  string               `json:"memory"`
+	Storage string               `json:"storage"`
+	Image   string               `json:"image"`
+}
+
+type endpoints struct {
+	Type      v1.ServiceType `json:"type"`
+	ClusterIP string         `json:"clusterIP,omitempty"`
+	NodePort  int32          `json:"nodePort,omitempty"`
+	// Addresses are the node addresses of the running TiDB pods if the service is exposed by node port
+	Addresses []string `json:"addresses,omitempty"`
+}
+
+func computeClusterInfo(tc *v1alpha1.TidbCluster, svc *v1.Service, podList *v1.PodList) *clusterInfo {
+	info := &clusterInfo{
+		PD:   computeComponentInfo(tc.Status.PD.Phase, tc.Status.PD.StatefulSet, tc.Spec.PD.ContainerSpec),
+		TiKV: computeComponentInfo(tc.Status.TiKV.Phase, tc.Status.TiKV.StatefulSet, tc.Spec.TiKV.ContainerSpec),
+		TiDB: computeComponentInfo(tc.Status.TiDB.Phase, tc.Status.TiDB.StatefulSet, tc.Spec.TiDB.ContainerSpec),
+		Endpoints: endpoints{
+			Type: svc.Spec.Type,
+		},
+	}
+	if svc.Spec.Type != v1.ServiceTypeNodePort {
+		info.Endpoints.ClusterIP = svc.Spec.ClusterIP
+		return info
+	}
+	for _, port := range svc.Spec.Ports {
+		// FIXME: magic name
+		if port.Name == "mysql-client" {
+			info.Endpoints.NodePort = port.NodePort
+			break
+		}
+	}
+	if info.Endpoints.NodePort > 0 {
+		for _, pod := range podList.Items {
+			if pod.Status.Phase == v1.PodRunning {
+				info.Endpoints.Addresses = append(info.Endpoints.Addresses,
+					fmt.Sprintf("%s:%d", pod.Status.HostIP, info.Endpoints.NodePort))
+			}
+		}
+	}
+	return info
+}
+
+func computeComponentInfo(phase v1alpha1.MemberPhase, status *apps.StatefulSetStatus, spec v1alpha1.ContainerSpec) componentInfo {
+	info := componentInfo{
+		Phase: phase,
+		Image: spec.Image,
+	}
+	if status != nil {
+		info.Ready = status.ReadyReplicas
+		info.Desired = status.Replicas
+	}
+	if spec.Requests != nil {
+		info.CPU = spec.Requests.CPU
+		info.Memory = spec.Requests.Memory
+		info.Storage = spec.Requests.Storage
+	}
+	return info
+}
+
 // go template is lacking type checking and hard to maintain, in this
 // case we just render manually
 func renderTidbCluster(tc *v1alpha1.TidbCluster, svc *v1.Service, podList *v1.PodList) (string, error) {
+	info := computeClusterInfo(tc, svc, podList)
 	return readable.TabbedString(func(out io.Writer) error {
 		w := readable.NewPrefixWriter(out)
 		w.WriteLine(readable.LEVEL_0, "Name:\t%s", tc.Name)
@@ -161,58 +286,35 @@ func renderTidbCluster(tc *v1alpha1.TidbCluster, svc *v1.Service, podList *v1.Po
 		{
 			w.WriteLine(readable.LEVEL_1, "\tPhase\tReady\tDesired\tCPU\tMemory\tStorage\tVersion")
 			w.WriteLine(readable.LEVEL_1, "\t-----\t-----\t-------\t---\t------\t-------\t-------")
-			w.Write(readable.LEVEL_1, "PD:\t")
-			{
-				w.Write(readable.LEVEL_0, "%s\t", tc.Status.PD.Phase)
-				w.Write(readable.LEVEL_0, "%d\t", tc.Status.PD.StatefulSet.ReadyReplicas)
-				w.Write(readable.LEVEL_0, "%d\t", tc.Status.PD.StatefulSet.Replicas)
-				w.Write(readable.LEVEL_0, "%s\t", tc.Spec.PD.Requests.CPU)
-				w.Write(readable.LEVEL_0, "%s\t", tc.Spec.PD.Requests.Memory)
-				w.Write(readable.LEVEL_0, "%s\t", tc.Spec.PD.Requests.Storage)
-				w.Write(readable.LEVEL_0, "%s\t\n", tc.Spec.PD.Image)
-			}
-			w.Write(readable.LEVEL_1, "TiKV:\t")
-			{
-				w.Write(readable.LEVEL_0, "%s\t", tc.Status.TiKV.Phase)
-				w.Write(readable.LEVEL_0, "%d\t", tc.Status.TiKV.StatefulSet.ReadyReplicas)
-				w.Write(readable.LEVEL_0, "%d\t", tc.Status.TiKV.StatefulSet.Replicas)
-				w.Write(readable.LEVEL_0, "%s\t", tc.Spec.TiKV.Requests.CPU)
-				w.Write(readable.LEVEL_0, "%s\t", tc.Spec.TiKV.Requests.Memory)
-				w.Write(readable.LEVEL_0, "%s\t", tc.Spec.TiKV.Requests.Storage)
-				w.Write(readable.LEVEL_0, "%s\t\n", tc.Spec.TiKV.Image)
-			}
-			w.Write(readable.LEVEL_1, "TiDB\t")
-			{
-				w.Write(readable.LEVEL_0, "%s\t", tc.Status.TiDB.Phase)
-				w.Write(readable.LEVEL_0, "%d\t", tc.Status.TiDB.StatefulSet.ReadyReplicas)
-				w.Write(readable.LEVEL_0, "%d\t", tc.Status.TiDB.StatefulSet.Replicas)
-				w.Write(readable.LEVEL_0, "%s\t", tc.Spec.TiDB.Requests.CPU)
-				w.Write(readable.LEVEL_0, "%s\t", tc.Spec.TiDB.Requests.Memory)
-				w.Write(readable.LEVEL_0, "%s\t", tc.Spec.TiDB.Requests.Storage)
-				w.Write(readable.LEVEL_0, "%s\t\n", tc.Spec.TiDB.Image)
+			for _, component := range []struct {
+				name string
+				info componentInfo
+			}{
+				{"PD:", info.PD},
+				{"TiKV:", info.TiKV},
+				{"TiDB", info.TiDB},
+			} {
+				w.Write(readable.LEVEL_1, "%s\t", component.name)
+				w.Write(readable.LEVEL_0, "%s\t", component.info.Phase)
+				w.Write(readable.LEVEL_0, "%d\t", component.info.Ready)
+				w.Write(readable.LEVEL_0, "%d\t", component.info.Desired)
+				w.Write(readable.LEVEL_0, "%s\t", component.info.CPU)
+				w.Write(readable.LEVEL_0, "%s\t", component.info.Memory)
+				w.Write(readable.LEVEL_0, "%s\t", component.info.Storage)
+				w.Write(readable.LEVEL_0, "%s\t\n", component.info.Image)
 			}
 		}
-		w.WriteLine(readable.LEVEL_0, "Endpoints(%s):", svc.Spec.Type)
-		if svc.Spec.Type == v1.ServiceTypeNodePort {
-			var nodePort int32
-			for _, port := range svc.Spec.Ports {
-				// FIXME: magic name
-				if port.Name == "mysql-client" {
-					nodePort = port.NodePort
-					break
-				}
-			}
-			if nodePort > 0 {
-				for _, pod := range podList.Items {
-					if pod.Status.Phase == v1.PodRunning {
-						w.WriteLine(readable.LEVEL_1, "- %s:%d", pod.Status.HostIP, nodePort)
-					}
+		w.WriteLine(readable.LEVEL_0, "Endpoints(%s):", info.Endpoints.Type)
+		if info.Endpoints.Type == v1.ServiceTypeNodePort {
+			if info.Endpoints.NodePort > 0 {
+				for _, address := range info.Endpoints.Addresses {
+					w.WriteLine(readable.LEVEL_1, "- %s", address)
 				}
 			} else {
 				w.WriteLine(readable.LEVEL_1, "no suitable port")
 			}
 		} else {
-			w.WriteLine(readable.LEVEL_1, "Cluster IP:\t%s", svc.Spec.ClusterIP)
+			w.WriteLine(readable.LEVEL_1, "Cluster IP:\t%s", info.Endpoints.ClusterIP)
 		}
 		return nil
 	})
@@ -0,0 +1,60 @@
+// Copyright 2019. PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package info
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/pingcap/tidb-operator/pkg/apis/pingcap.com/v1alpha1"
+	apps "k8s.io/api/apps/v1beta1"
+	"k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestComputeClusterInfo(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	tc := &v1alpha1.TidbCluster{ObjectMeta: metav1.ObjectMeta{Name: "demo", Namespace: "ns"}}
+	tc.Spec.PD.Image = "pingcap/pd:v2.1.0"
+	tc.Spec.PD.Requests = &v1alpha1.ResourceRequirement{CPU: "1", Memory: "2Gi", Storage: "10Gi"}
+	tc.Status.PD.Phase = v1alpha1.NormalPhase
+	tc.Status.PD.StatefulSet = &apps.StatefulSetStatus{Replicas: 3, ReadyReplicas: 2}
+	svc := &v1.Service{Spec: v1.ServiceSpec{Type: v1.ServiceTypeClusterIP, ClusterIP: "10.0.0.1"}}
+
+	info := computeClusterInfo(tc, svc, &v1.PodList{})
+	g.Expect(info.PD).To(Equal(componentInfo{
+		Phase: v1alpha1.NormalPhase, Ready: 2, Desired: 3,
+		CPU: "1", Memory: "2Gi", Storage: "10Gi", Image: "pingcap/pd:v2.1.0",
+	}))
+	// components without status or requests are computed as zero values
+	g.Expect(info.TiKV).To(Equal(componentInfo{}))
+	g.Expect(info.Endpoints).To(Equal(endpoints{Type: v1.ServiceTypeClusterIP, ClusterIP: "10.0.0.1"}))
+
+	svc.Spec.Type = v1.ServiceTypeNodePort
+	svc.Spec.Ports = []v1.ServicePort{{Name: "mysql-client", Port: 4000, NodePort: 30000}}
+	podList := &v1.PodList{Items: []v1.Pod{
+		{Status: v1.PodStatus{Phase: v1.PodRunning, HostIP: "172.16.0.1"}},
+		{Status: v1.PodStatus{Phase: v1.PodPending, HostIP: "172.16.0.2"}},
+	}}
+	info = computeClusterInfo(tc, svc, podList)
+	g.Expect(info.Endpoints).To(Equal(endpoints{
+		Type: v1.ServiceTypeNodePort, NodePort: 30000, Addresses: []string{"172.16.0.1:30000"},
+	}))
+
+	msg, err := renderTidbCluster(tc, svc, podList)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(msg).To(ContainSubstring("- 172.16.0.1:30000"))
+	g.Expect(msg).NotTo(ContainSubstring("172.16.0.2"))
+}
@@ -14,11 +14,15 @@
 package list
 
 import (
+	"fmt"
+
 	"github.com/golang/glog"
 	"github.com/pingcap/tidb-operator/pkg/apis/pingcap.com/v1alpha1"
 	"github.com/pingcap/tidb-operator/pkg/tkctl/config"
 	"github.com/pingcap/tidb-operator/pkg/tkctl/readable"
 	"github.com/spf13/cobra"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/cli-runtime/pkg/genericclioptions"
 	cmdutil "k8s.io/kubernetes/pkg/kubectl/cmd/util"
 	kubeprinters "k8s.io/kubernetes/pkg/printers"
@@ -88,6 +92,9 @@ func NewCmdList(tkcContext *config.TkcContext, streams genericclioptions.IOStrea
 }
 
 func (o *ListOptions) Complete(tkcContext *config.TkcContext, cmd *cobra.Command, args []string) error {
+	if err := o.PrintFlags.Validate(); err != nil {
+		return err
+	}
 	clientConfig, err := tkcContext.ToTkcClientConfig()
 	if err != nil {
 		return err
@@ -125,6 +132,18 @@ func (o *ListOptions) Run(tkcContext *config.TkcContext, cmd *cobra.Command, arg
 		return err
 	}
 
+	if !o.PrintFlags.IsHumanReadable() {
+		objs := make([]*unstructured.Unstructured, 0, len(infos))
+		for _, info := range infos {
+			obj, err := toObject(info.Object)
+			if err != nil {
+				return err
+			}
+			objs = append(objs, obj)
+		}
+		return printer.PrintObj(readable.NewList(objs), o.Out)
+	}
+
 	w := kubeprinters.GetNewTabWriter(o.Out)
 	for _, info := range infos {
 		internalObj, err := v1alpha1.Scheme.ConvertToVersion(info.Object, v1alpha1.SchemeGroupVersion)
@@ -139,3 +158,16 @@ func (o *ListOptions) Run(tkcContext *config.TkcContext, cmd *cobra.Command, arg
 
 	return nil
 }
+
+// toObject attaches the computed ready status to the tidb cluster for machine-readable output
+func toObject(obj runtime.Object) (*unstructured.Unstructured, error) {
+	tc := &v1alpha1.TidbCluster{}
+	u, ok := obj.(*unstructured.Unstructured)
+	if !ok {
+		return nil, fmt.Errorf("unexpected object %T", obj)
+	}
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(u.Object, tc); err != nil {
+		return nil, err
+	}
+	return readable.NewObject(tc, readable.TidbClusterKind, readable.ComputeCluster(tc))
+}
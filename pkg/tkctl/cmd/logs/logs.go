@@ -0,0 +1,431 @@
+// Copyright 2019. PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package logs
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pingcap/tidb-operator/pkg/apis/pingcap.com/v1alpha1"
+	"github.com/pingcap/tidb-operator/pkg/client/clientset/versioned"
+	"github.com/pingcap/tidb-operator/pkg/label"
+	"github.com/pingcap/tidb-operator/pkg/tkctl/config"
+	"github.com/spf13/cobra"
+	"k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+	"k8s.io/client-go/kubernetes"
+	cmdutil "k8s.io/kubernetes/pkg/kubectl/cmd/util"
+)
+
+const (
+	logsLongDesc = `
+		Print the logs of every member of a component in the tidb cluster.
+
+		The component is one of pd, tikv, tidb and slowlog, every line is prefixed
+		with the name of the pod it comes from. The slowlog component reads the
+		slow log tailer containers of tidb, which requires 'separateSlowLog' to be
+		enabled in the TidbCluster, the --grep filter is applied to whole slow log
+		entries instead of single lines.
+
+		Pods created after the command started are not followed.
+`
+	logsExample = `
+		# print the logs of all tikv pods of current tidb cluster
+		tkc logs tikv
+
+		# follow the logs of all pd pods of the last 10 minutes
+		tkc logs pd -f --since 10m
+
+		# print the tidb logs which contain "error" or "warn", case insensitively
+		tkc logs tidb --grep '(?i)error|warn'
+
+		# follow the slow queries of all tidb pods
+		tkc logs slowlog -f
+`
+	logsUsage = `expected 'logs -t CLUSTER_NAME COMPONENT' for the logs command or
+using 'tkc use' to set tidb cluster first.
+`
+
+	// slowLogEntryStart is the first line of every slow log entry of tidb
+	slowLogEntryStart = "# Time: "
+)
+
+// pendingEntryTimeout is how long a multi-line entry waits for more lines before it's written,
+// so that the last entry shows up under --follow without waiting for the next one
+var pendingEntryTimeout = time.Second
+
+var components = []string{
+	v1alpha1.PDMemberType.String(),
+	v1alpha1.TiKVMemberType.String(),
+	v1alpha1.TiDBMemberType.String(),
+	v1alpha1.SlowLogTailerMemberType.String(),
+}
+
+// LogsOptions contains the input to the logs command.
+type LogsOptions struct {
+	TidbClusterName string
+	Namespace       string
+
+	Component  string
+	Follow     bool
+	Since      time.Duration
+	Tail       int64
+	Grep       string
+	Timestamps bool
+
+	grep *regexp.Regexp
+
+	TcCli   *versioned.Clientset
+	KubeCli *kubernetes.Clientset
+
+	genericclioptions.IOStreams
+}
+
+// NewLogsOptions returns a LogsOptions
+func NewLogsOptions(streams genericclioptions.IOStreams) *LogsOptions {
+	return &LogsOptions{
+		Tail:      -1,
+		IOStreams: streams,
+	}
+}
+
+// NewCmdLogs creates the logs command which prints the logs of all members of a component
+func NewCmdLogs(tkcContext *config.TkcContext, streams genericclioptions.IOStreams) *cobra.Command {
+	o := NewLogsOptions(streams)
+
+	cmd := &cobra.Command{
+		Use:       "logs (pd|tikv|tidb|slowlog)",
+		Short:     "Print the logs of all members of a component in the tidb cluster.",
+		Long:      logsLongDesc,
+		Example:   logsExample,
+		ValidArgs: components,
+		Run: func(cmd *cobra.Command, args []string) {
+			cmdutil.CheckErr(o.Complete(tkcContext, cmd, args))
+			cmdutil.CheckErr(o.Run())
+		},
+	}
+	cmd.Flags().BoolVarP(&o.Follow, "follow", "f", o.Follow,
+		"Specify if the logs should be streamed.")
+	cmd.Flags().DurationVar(&o.Since, "since", o.Since,
+		"Only return logs newer than a relative duration like 5s, 2m, or 3h.")
+	cmd.Flags().Int64Var(&o.Tail, "tail", o.Tail,
+		"Lines of recent log of each pod to display, defaults to -1 showing all log lines.")
+	cmd.Flags().StringVar(&o.Grep, "grep", o.Grep,
+		"Only print the log lines matching the regular expression.")
+	cmd.Flags().BoolVar(&o.Timestamps, "timestamps", o.Timestamps,
+		"Include timestamps on each line in the log output.")
+
+	return cmd
+}
+
+func (o *LogsOptions) Complete(tkcContext *config.TkcContext, cmd *cobra.Command, args []string) error {
+	if len(args) != 1 {
+		return cmdutil.UsageErrorf(cmd, "expected exactly one component, one of %s", strings.Join(components, ", "))
+	}
+	if !isComponent(args[0]) {
+		return cmdutil.UsageErrorf(cmd, "unknown component %q, expected one of %s", args[0], strings.Join(components, ", "))
+	}
+	o.Component = args[0]
+
+	if o.Since < 0 {
+		return cmdutil.UsageErrorf(cmd, "--since must be positive, got %s", o.Since)
+	}
+	if len(o.Grep) > 0 {
+		grep, err := regexp.Compile(o.Grep)
+		if err != nil {
+			return cmdutil.UsageErrorf(cmd, "invalid --grep %q: %v", o.Grep, err)
+		}
+		o.grep = grep
+	}
+
+	clientConfig, err := tkcContext.ToTkcClientConfig()
+	if err != nil {
+		return err
+	}
+
+	if tidbClusterName, ok := clientConfig.TidbClusterName(); ok {
+		o.TidbClusterName = tidbClusterName
+	} else {
+		return cmdutil.UsageErrorf(cmd, logsUsage)
+	}
+
+	namespace, _, err := clientConfig.Namespace()
+	if err != nil {
+		return err
+	}
+	o.Namespace = namespace
+
+	restConfig, err := clientConfig.RestConfig()
+	if err != nil {
+		return err
+	}
+	tcCli, err := versioned.NewForConfig(restConfig)
+	if err != nil {
+		return err
+	}
+	o.TcCli = tcCli
+	kubeCli, err := kubernetes.NewForConfig(restConfig)
+	if err != nil {
+		return err
+	}
+	o.KubeCli = kubeCli
+
+	return nil
+}
+
+func (o *LogsOptions) Run() error {
+	tc, err := o.TcCli.PingcapV1alpha1().
+		TidbClusters(o.Namespace).
+		Get(o.TidbClusterName, metav1.GetOptions{})
+	if err != nil {
+		return err
+	}
+	if o.Component == v1alpha1.SlowLogTailerMemberType.String() && !tc.Spec.TiDB.SeparateSlowLog {
+		return fmt.Errorf("the slow log of tidb cluster %s/%s is not separated, enable 'separateSlowLog' or use 'tkc logs tidb' instead",
+			o.Namespace, o.TidbClusterName)
+	}
+
+	pods, err := o.KubeCli.CoreV1().Pods(o.Namespace).List(metav1.ListOptions{
+		LabelSelector: componentLabel(tc.Name, o.Component).String(),
+	})
+	if err != nil {
+		return err
+	}
+	targets := logTargets(pods.Items, o.Component)
+	if len(targets) == 0 {
+		return fmt.Errorf("no %s pod found in tidb cluster %s/%s", o.Component, o.Namespace, o.TidbClusterName)
+	}
+
+	open := func(t logTarget) (io.ReadCloser, error) {
+		return o.KubeCli.CoreV1().Pods(o.Namespace).GetLogs(t.pod, o.logOptions(t.container)).Stream()
+	}
+	entryStart := ""
+	if o.Component == v1alpha1.SlowLogTailerMemberType.String() {
+		entryStart = slowLogEntryStart
+	}
+	return streamLogs(targets, open, o.grep, entryStart, o.Out)
+}
+
+func (o *LogsOptions) logOptions(container string) *v1.PodLogOptions {
+	opts := &v1.PodLogOptions{
+		Container:  container,
+		Follow:     o.Follow,
+		Timestamps: o.Timestamps,
+	}
+	if o.Since > 0 {
+		seconds := int64((o.Since + time.Second - 1) / time.Second)
+		opts.SinceSeconds = &seconds
+	}
+	if o.Tail >= 0 {
+		tail := o.Tail
+		opts.TailLines = &tail
+	}
+	return opts
+}
+
+func isComponent(component string) bool {
+	for _, c := range components {
+		if c == component {
+			return true
+		}
+	}
+	return false
+}
+
+// componentLabel returns the label of the pods running the component, the slow log
+// tailer is a sidecar of the tidb pods
+func componentLabel(tcName, component string) label.Label {
+	l := label.New().Instance(tcName)
+	switch component {
+	case v1alpha1.PDMemberType.String():
+		return l.PD()
+	case v1alpha1.TiKVMemberType.String():
+		return l.TiKV()
+	default:
+		return l.TiDB()
+	}
+}
+
+// logTarget is a container whose logs are streamed
+type logTarget struct {
+	pod       string
+	container string
+}
+
+// logTargets returns the containers of the component in the pods, sorted by pod name
+func logTargets(pods []v1.Pod, component string) []logTarget {
+	var targets []logTarget
+	for _, pod := range pods {
+		for _, c := range pod.Spec.Containers {
+			if c.Name == component {
+				targets = append(targets, logTarget{pod: pod.Name, container: c.Name})
+				break
+			}
+		}
+	}
+	sort.Slice(targets, func(i, j int) bool {
+		return targets[i].pod < targets[j].pod
+	})
+	return targets
+}
+
+// streamLogs streams the logs of the targets concurrently, the lines are written to out
+// with the pod names as prefixes and never interleaved
+func streamLogs(targets []logTarget, open func(logTarget) (io.ReadCloser, error), grep *regexp.Regexp, entryStart string, out io.Writer) error {
+	width := 0
+	for _, t := range targets {
+		if len(t.pod) > width {
+			width = len(t.pod)
+		}
+	}
+
+	w := &entryWriter{out: out}
+	errs := make([]error, len(targets))
+	var wg sync.WaitGroup
+	for i, t := range targets {
+		wg.Add(1)
+		go func(i int, t logTarget) {
+			defer wg.Done()
+			rc, err := open(t)
+			if err != nil {
+				errs[i] = fmt.Errorf("failed to get logs of %s: %v", t.pod, err)
+				return
+			}
+			defer rc.Close()
+			prefix := fmt.Sprintf("%-*s | ", width, t.pod)
+			if err := copyLines(rc, w, prefix, grep, entryStart); err != nil {
+				errs[i] = fmt.Errorf("failed to read logs of %s: %v", t.pod, err)
+			}
+		}(i, t)
+	}
+	wg.Wait()
+	return utilerrors.NewAggregate(errs)
+}
+
+// copyLines writes the entries of r matching grep to w with the prefix on every line.
+// An entry is a single line unless entryStart is set, in which case an entry spans from
+// a line starting with entryStart to the next one, so that a multi-line slow log entry
+// is matched and printed as a whole. The timestamp added by --timestamps is skipped when
+// looking for entryStart, and a pending entry is written after pendingEntryTimeout without new lines.
+func copyLines(r io.Reader, w *entryWriter, prefix string, grep *regexp.Regexp, entryStart string) error {
+	var entry []string
+	flush := func() error {
+		defer func() { entry = entry[:0] }()
+		if len(entry) == 0 || (grep != nil && !grep.MatchString(strings.Join(entry, ""))) {
+			return nil
+		}
+		var buf strings.Builder
+		for _, line := range entry {
+			buf.WriteString(prefix)
+			buf.WriteString(line)
+		}
+		return w.writeEntry(buf.String())
+	}
+
+	done := make(chan struct{})
+	defer close(done)
+	lines := make(chan string)
+	var readErr error
+	go func() {
+		defer close(lines)
+		reader := bufio.NewReader(r)
+		for {
+			line, err := reader.ReadString('\n')
+			if len(line) > 0 {
+				select {
+				case lines <- line:
+				case <-done:
+					return
+				}
+			}
+			if err != nil {
+				readErr = err
+				return
+			}
+		}
+	}()
+
+	idle := time.NewTimer(pendingEntryTimeout)
+	defer idle.Stop()
+	for {
+		select {
+		case line, ok := <-lines:
+			if !ok {
+				if readErr == io.EOF {
+					return flush()
+				}
+				return readErr
+			}
+			if !strings.HasSuffix(line, "\n") {
+				line += "\n"
+			}
+			if len(entryStart) > 0 && strings.HasPrefix(trimTimestamp(line), entryStart) {
+				if err := flush(); err != nil {
+					return err
+				}
+			}
+			entry = append(entry, line)
+			if len(entryStart) == 0 {
+				if err := flush(); err != nil {
+					return err
+				}
+				continue
+			}
+			if !idle.Stop() {
+				select {
+				case <-idle.C:
+				default:
+				}
+			}
+			idle.Reset(pendingEntryTimeout)
+		case <-idle.C:
+			if err := flush(); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// trimTimestamp removes the RFC3339 timestamp prefixed to the line by --timestamps
+func trimTimestamp(line string) string {
+	i := strings.IndexByte(line, ' ')
+	if i <= 0 {
+		return line
+	}
+	if _, err := time.Parse(time.RFC3339Nano, line[:i]); err != nil {
+		return line
+	}
+	return line[i+1:]
+}
+
+// entryWriter serializes the entries written by the concurrent streams
+type entryWriter struct {
+	mu  sync.Mutex
+	out io.Writer
+}
+
+func (w *entryWriter) writeEntry(entry string) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	_, err := io.WriteString(w.out, entry)
+	return err
+}
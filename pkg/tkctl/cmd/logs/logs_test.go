@@ -0,0 +1,196 @@
+// Copyright 2019. PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package logs
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	"github.com/pingcap/tidb-operator/pkg/label"
+	"k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestComponentLabel(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	g.Expect(componentLabel("demo", "pd")).To(Equal(label.New().Instance("demo").PD()))
+	g.Expect(componentLabel("demo", "tikv")).To(Equal(label.New().Instance("demo").TiKV()))
+	g.Expect(componentLabel("demo", "tidb")).To(Equal(label.New().Instance("demo").TiDB()))
+	// the slow log tailer runs in the tidb pods
+	g.Expect(componentLabel("demo", "slowlog")).To(Equal(label.New().Instance("demo").TiDB()))
+}
+
+func TestLogTargets(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	newPod := func(name string, containers ...string) v1.Pod {
+		pod := v1.Pod{ObjectMeta: metav1.ObjectMeta{Name: name}}
+		for _, c := range containers {
+			pod.Spec.Containers = append(pod.Spec.Containers, v1.Container{Name: c})
+		}
+		return pod
+	}
+	pods := []v1.Pod{
+		newPod("demo-tidb-1", "tidb", "slowlog"),
+		newPod("demo-tidb-0", "tidb", "slowlog"),
+		newPod("demo-tidb-2", "tidb"),
+	}
+
+	g.Expect(logTargets(pods, "tidb")).To(Equal([]logTarget{
+		{pod: "demo-tidb-0", container: "tidb"},
+		{pod: "demo-tidb-1", container: "tidb"},
+		{pod: "demo-tidb-2", container: "tidb"},
+	}))
+	g.Expect(logTargets(pods, "slowlog")).To(Equal([]logTarget{
+		{pod: "demo-tidb-0", container: "slowlog"},
+		{pod: "demo-tidb-1", container: "slowlog"},
+	}))
+	g.Expect(logTargets(pods, "tikv")).To(BeEmpty())
+}
+
+func TestCopyLines(t *testing.T) {
+	g := NewGomegaWithT(t)
+	type testcase struct {
+		name       string
+		input      string
+		grep       string
+		entryStart string
+		expected   string
+	}
+	slowLog := "# Time: 2019-03-01T08:00:00Z\n# Query_time: 1.5\nselect 1;\n# Time: 2019-03-01T08:00:01Z\n# Query_time: 2.5\nselect * from t;\n"
+	tests := []testcase{
+		{
+			name:     "prefix every line",
+			input:    "a\nb\n",
+			expected: "p | a\np | b\n",
+		},
+		{
+			name:     "unterminated last line",
+			input:    "a\nb",
+			expected: "p | a\np | b\n",
+		},
+		{
+			name:     "grep lines",
+			input:    "[INFO] a\n[WARN] b\n[ERROR] c\n",
+			grep:     "WARN|ERROR",
+			expected: "p | [WARN] b\np | [ERROR] c\n",
+		},
+		{
+			name:       "grep slow log entries",
+			input:      slowLog,
+			grep:       "from t",
+			entryStart: slowLogEntryStart,
+			expected:   "p | # Time: 2019-03-01T08:00:01Z\np | # Query_time: 2.5\np | select * from t;\n",
+		},
+		{
+			name:       "grep slow log entries with timestamps",
+			input:      "2019-03-01T08:00:00.123456789Z " + strings.Replace(slowLog, "\n# Time:", "\n2019-03-01T08:00:01.123456789Z # Time:", 1),
+			grep:       "select 1",
+			entryStart: slowLogEntryStart,
+			expected:   "p | 2019-03-01T08:00:00.123456789Z # Time: 2019-03-01T08:00:00Z\np | # Query_time: 1.5\np | select 1;\n",
+		},
+		{
+			name:       "slow log without grep",
+			input:      slowLog,
+			entryStart: slowLogEntryStart,
+			expected: "p | # Time: 2019-03-01T08:00:00Z\np | # Query_time: 1.5\np | select 1;\n" +
+				"p | # Time: 2019-03-01T08:00:01Z\np | # Query_time: 2.5\np | select * from t;\n",
+		},
+	}
+	for _, test := range tests {
+		t.Log(test.name)
+		var grep *regexp.Regexp
+		if len(test.grep) > 0 {
+			grep = regexp.MustCompile(test.grep)
+		}
+		var out bytes.Buffer
+		err := copyLines(strings.NewReader(test.input), &entryWriter{out: &out}, "p | ", grep, test.entryStart)
+		g.Expect(err).NotTo(HaveOccurred())
+		g.Expect(out.String()).To(Equal(test.expected))
+	}
+}
+
+func TestCopyLinesFlushPendingEntry(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	timeout := pendingEntryTimeout
+	pendingEntryTimeout = 10 * time.Millisecond
+	defer func() { pendingEntryTimeout = timeout }()
+
+	r, w := io.Pipe()
+	out := &syncBuffer{}
+	errc := make(chan error, 1)
+	go func() {
+		errc <- copyLines(r, &entryWriter{out: out}, "p | ", nil, slowLogEntryStart)
+	}()
+	_, err := io.WriteString(w, "# Time: 2019-03-01T08:00:00Z\nselect 1;\n")
+	g.Expect(err).NotTo(HaveOccurred())
+	// the entry is written before the stream goes on
+	g.Eventually(out.String).Should(Equal("p | # Time: 2019-03-01T08:00:00Z\np | select 1;\n"))
+	g.Expect(w.Close()).To(Succeed())
+	g.Expect(<-errc).NotTo(HaveOccurred())
+}
+
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+func TestStreamLogs(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	targets := []logTarget{
+		{pod: "demo-tikv-0", container: "tikv"},
+		{pod: "demo-tikv-10", container: "tikv"},
+		{pod: "demo-tikv-2", container: "tikv"},
+	}
+	open := func(t logTarget) (io.ReadCloser, error) {
+		if t.pod == "demo-tikv-2" {
+			return nil, fmt.Errorf("container is not running")
+		}
+		return ioutil.NopCloser(strings.NewReader("line of " + t.pod + "\n")), nil
+	}
+
+	var out bytes.Buffer
+	err := streamLogs(targets, open, nil, "", &out)
+	g.Expect(err).To(HaveOccurred())
+	g.Expect(err.Error()).To(ContainSubstring("demo-tikv-2"))
+
+	lines := strings.Split(strings.TrimSuffix(out.String(), "\n"), "\n")
+	g.Expect(lines).To(ConsistOf(
+		"demo-tikv-0  | line of demo-tikv-0",
+		"demo-tikv-10 | line of demo-tikv-10",
+	))
+}
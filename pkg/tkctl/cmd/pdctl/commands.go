@@ -0,0 +1,188 @@
+// Copyright 2019. PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pdctl
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/pingcap/tidb-operator/pkg/controller"
+	"github.com/pingcap/tidb-operator/pkg/tkctl/config"
+	"github.com/spf13/cobra"
+	cmdutil "k8s.io/kubernetes/pkg/kubectl/cmd/util"
+)
+
+func newCmdMembers(tkcContext *config.TkcContext, o *PdctlOptions) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "members",
+		Short: "List the PD members.",
+	}
+	return newPdctlSubCommand(tkcContext, o, cmd, func(cmd *cobra.Command, args []string, pdClient controller.PDClient) error {
+		members, err := pdClient.GetMembers()
+		if err != nil {
+			return err
+		}
+		return o.print(members, renderMembers(members))
+	})
+}
+
+func newCmdStores(tkcContext *config.TkcContext, o *PdctlOptions) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "stores [STORE_ID]",
+		Short: "List the TiKV stores, or show the specified store.",
+	}
+	return newPdctlSubCommand(tkcContext, o, cmd, func(cmd *cobra.Command, args []string, pdClient controller.PDClient) error {
+		if len(args) > 0 {
+			storeID, err := parseID(cmd, args[0])
+			if err != nil {
+				return err
+			}
+			store, err := pdClient.GetStore(storeID)
+			if err != nil {
+				return err
+			}
+			return o.print(store, renderStores([]*controller.StoreInfo{store}))
+		}
+		stores, err := pdClient.GetStores()
+		if err != nil {
+			return err
+		}
+		return o.print(stores, renderStores(stores.Stores))
+	})
+}
+
+func newCmdRegions(tkcContext *config.TkcContext, o *PdctlOptions) *cobra.Command {
+	var storeID uint64
+	cmd := &cobra.Command{
+		Use:   "regions [REGION_ID]",
+		Short: "List the regions, or show the specified region.",
+	}
+	cmd.Flags().Uint64Var(&storeID, "store", 0, "Only list the regions which have a peer on the specified store.")
+	return newPdctlSubCommand(tkcContext, o, cmd, func(cmd *cobra.Command, args []string, pdClient controller.PDClient) error {
+		if len(args) > 0 {
+			regionID, err := parseID(cmd, args[0])
+			if err != nil {
+				return err
+			}
+			region, err := pdClient.GetRegion(regionID)
+			if err != nil {
+				return err
+			}
+			return o.print(region, renderRegions([]*controller.RegionInfo{region}))
+		}
+		var regions *controller.RegionsInfo
+		var err error
+		if storeID > 0 {
+			regions, err = pdClient.GetStoreRegions(storeID)
+		} else {
+			regions, err = pdClient.GetRegions()
+		}
+		if err != nil {
+			return err
+		}
+		return o.print(regions, renderRegions(regions.Regions))
+	})
+}
+
+func newCmdSchedulers(tkcContext *config.TkcContext, o *PdctlOptions) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "schedulers",
+		Short: "List, add or remove the PD schedulers.",
+	}
+	cmd = newPdctlSubCommand(tkcContext, o, cmd, func(cmd *cobra.Command, args []string, pdClient controller.PDClient) error {
+		schedulers, err := pdClient.GetSchedulers()
+		if err != nil {
+			return err
+		}
+		return o.print(schedulers, renderSchedulers(schedulers))
+	})
+
+	add := &cobra.Command{
+		Use:   "add NAME [STORE_ID]",
+		Short: "Add a scheduler, STORE_ID is required by store schedulers, e.g. evict-leader-scheduler.",
+	}
+	cmd.AddCommand(newPdctlSubCommand(tkcContext, o, add, func(cmd *cobra.Command, args []string, pdClient controller.PDClient) error {
+		if len(args) < 1 || len(args) > 2 {
+			return cmdutil.UsageErrorf(cmd, "expected 'schedulers add NAME [STORE_ID]'")
+		}
+		var storeID uint64
+		if len(args) == 2 {
+			id, err := parseID(cmd, args[1])
+			if err != nil {
+				return err
+			}
+			storeID = id
+		}
+		if err := pdClient.AddScheduler(args[0], storeID); err != nil {
+			return err
+		}
+		fmt.Fprintf(o.Out, "scheduler %s added\n", args[0])
+		return nil
+	}))
+
+	remove := &cobra.Command{
+		Use:   "remove NAME",
+		Short: "Remove a scheduler, store schedulers are named like evict-leader-scheduler-1.",
+	}
+	cmd.AddCommand(newPdctlSubCommand(tkcContext, o, remove, func(cmd *cobra.Command, args []string, pdClient controller.PDClient) error {
+		if len(args) != 1 {
+			return cmdutil.UsageErrorf(cmd, "expected 'schedulers remove NAME'")
+		}
+		if err := pdClient.RemoveScheduler(args[0]); err != nil {
+			return err
+		}
+		fmt.Fprintf(o.Out, "scheduler %s removed\n", args[0])
+		return nil
+	}))
+	return cmd
+}
+
+func newCmdConfig(tkcContext *config.TkcContext, o *PdctlOptions) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "config",
+		Short: "Show the replication and schedule config of PD.",
+	}
+	return newPdctlSubCommand(tkcContext, o, cmd, func(cmd *cobra.Command, args []string, pdClient controller.PDClient) error {
+		cfg, err := pdClient.GetConfig()
+		if err != nil {
+			return err
+		}
+		return o.print(cfg, renderConfig(cfg))
+	})
+}
+
+func newCmdTransferLeader(tkcContext *config.TkcContext, o *PdctlOptions) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "transfer-leader MEMBER_NAME",
+		Short: "Transfer the PD leader to the specified member.",
+	}
+	return newPdctlSubCommand(tkcContext, o, cmd, func(cmd *cobra.Command, args []string, pdClient controller.PDClient) error {
+		if len(args) != 1 {
+			return cmdutil.UsageErrorf(cmd, "expected 'transfer-leader MEMBER_NAME'")
+		}
+		if err := pdClient.TransferPDLeader(args[0]); err != nil {
+			return err
+		}
+		fmt.Fprintf(o.Out, "PD leader transferred to %s\n", args[0])
+		return nil
+	})
+}
+
+func parseID(cmd *cobra.Command, arg string) (uint64, error) {
+	id, err := strconv.ParseUint(arg, 10, 64)
+	if err != nil {
+		return 0, cmdutil.UsageErrorf(cmd, "invalid id %q: %v", arg, err)
+	}
+	return id, nil
+}
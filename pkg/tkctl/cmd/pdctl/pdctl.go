@@ -15,20 +15,200 @@ package pdctl
 
 import (
 	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/pingcap/tidb-operator/pkg/apis/pingcap.com/v1alpha1"
+	"github.com/pingcap/tidb-operator/pkg/client/clientset/versioned"
+	"github.com/pingcap/tidb-operator/pkg/controller"
+	"github.com/pingcap/tidb-operator/pkg/label"
 	"github.com/pingcap/tidb-operator/pkg/tkctl/config"
+	"github.com/pingcap/tidb-operator/pkg/tkctl/util"
 	"github.com/spf13/cobra"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/cli-runtime/pkg/genericclioptions"
-	"os"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	cmdutil "k8s.io/kubernetes/pkg/kubectl/cmd/util"
+)
+
+const (
+	pdctlLongDesc = `
+		Inspect and operate the PD cluster of current tidb cluster.
+
+		The PD API is reached by port-forwarding to a running PD pod of
+		the tidb cluster, the PD leader is preferred.
+`
+	pdctlExample = `
+		# list the PD members of current tidb cluster
+		tkc pdctl members
+
+		# show the stores in json
+		tkc pdctl stores -o json
+
+		# show the regions of store 4
+		tkc pdctl regions --store 4
+
+		# add a scheduler that evicts the leaders of store 4
+		tkc pdctl schedulers add evict-leader-scheduler 4
+
+		# transfer the PD leader to the member demo-pd-1
+		tkc pdctl transfer-leader demo-pd-1
+`
+	pdctlUsage = `expected 'pdctl -t CLUSTER_NAME' for the pdctl command or
+using 'tkc use' to set tidb cluster first.
+`
+
+	pdPort         = 2379
+	defaultTimeout = 10 * time.Second
+
+	outputTable = "table"
+	outputJSON  = "json"
 )
 
-// TODO: implementation
+// PdctlOptions contains the input to the pdctl command and its subcommands.
+type PdctlOptions struct {
+	TidbClusterName string
+	Namespace       string
+	Output          string
+	Timeout         time.Duration
+
+	RestConfig *rest.Config
+	TcCli      *versioned.Clientset
+	KubeCli    *kubernetes.Clientset
+
+	genericclioptions.IOStreams
+}
+
+// NewPdctlOptions returns a PdctlOptions
+func NewPdctlOptions(streams genericclioptions.IOStreams) *PdctlOptions {
+	return &PdctlOptions{
+		Output:  outputTable,
+		Timeout: defaultTimeout,
+
+		IOStreams: streams,
+	}
+}
+
 // NewCmdPdctl creates the pdctl subcommand
 func NewCmdPdctl(tkcContext *config.TkcContext, streams genericclioptions.IOStreams) *cobra.Command {
-	return &cobra.Command{
-		Use:   "pdctl",
-		Short: "Not implemented",
-		Run: func(_ *cobra.Command, args []string) {
-			fmt.Fprint(os.Stdout, "not implemented")
-		},
+	o := NewPdctlOptions(streams)
+
+	cmd := &cobra.Command{
+		Use:     "pdctl",
+		Short:   "Inspect and operate the PD cluster of the tidb cluster.",
+		Long:    pdctlLongDesc,
+		Example: pdctlExample,
+		Run:     cmdutil.DefaultSubCommandRun(streams.ErrOut),
+	}
+	cmd.PersistentFlags().StringVarP(&o.Output, "output", "o", o.Output,
+		"Output format. table|json.")
+	cmd.PersistentFlags().DurationVar(&o.Timeout, "pd-timeout", o.Timeout,
+		"Timeout of each request to PD.")
+
+	cmd.AddCommand(newCmdMembers(tkcContext, o))
+	cmd.AddCommand(newCmdStores(tkcContext, o))
+	cmd.AddCommand(newCmdRegions(tkcContext, o))
+	cmd.AddCommand(newCmdSchedulers(tkcContext, o))
+	cmd.AddCommand(newCmdConfig(tkcContext, o))
+	cmd.AddCommand(newCmdTransferLeader(tkcContext, o))
+	return cmd
+}
+
+func (o *PdctlOptions) Complete(tkcContext *config.TkcContext, cmd *cobra.Command) error {
+	output := strings.ToLower(o.Output)
+	if output != outputTable && output != outputJSON {
+		return cmdutil.UsageErrorf(cmd, "unsupported output format %q, expected table or json", o.Output)
+	}
+	o.Output = output
+
+	clientConfig, err := tkcContext.ToTkcClientConfig()
+	if err != nil {
+		return err
+	}
+
+	if tidbClusterName, ok := clientConfig.TidbClusterName(); ok {
+		o.TidbClusterName = tidbClusterName
+	} else {
+		return cmdutil.UsageErrorf(cmd, pdctlUsage)
+	}
+
+	namespace, _, err := clientConfig.Namespace()
+	if err != nil {
+		return err
+	}
+	o.Namespace = namespace
+
+	restConfig, err := clientConfig.RestConfig()
+	if err != nil {
+		return err
+	}
+	o.RestConfig = restConfig
+	tcCli, err := versioned.NewForConfig(restConfig)
+	if err != nil {
+		return err
+	}
+	o.TcCli = tcCli
+	kubeCli, err := kubernetes.NewForConfig(restConfig)
+	if err != nil {
+		return err
+	}
+	o.KubeCli = kubeCli
+
+	return nil
+}
+
+// Run port-forwards to a PD pod of the tidb cluster and calls fn with a PD client
+// talking to the forwarded port, the forwarding is stopped once fn returns
+func (o *PdctlOptions) Run(fn func(pdClient controller.PDClient) error) error {
+	tc, err := o.TcCli.PingcapV1alpha1().
+		TidbClusters(o.Namespace).
+		Get(o.TidbClusterName, metav1.GetOptions{})
+	if err != nil {
+		return err
+	}
+	pdClient, forwarder, err := ForwardPD(o.RestConfig, o.KubeCli, tc, o.Timeout, o.ErrOut)
+	if err != nil {
+		return err
+	}
+	defer forwarder.Close()
+	return fn(pdClient)
+}
+
+// ForwardPD port-forwards to a running PD pod of the tidb cluster, the PD leader is preferred, and returns
+// a PD client talking to the forwarded port, callers should close the forwarder once the client is no longer used
+func ForwardPD(restConfig *rest.Config, kubeCli kubernetes.Interface, tc *v1alpha1.TidbCluster,
+	timeout time.Duration, errOut io.Writer) (controller.PDClient, *util.PortForwarder, error) {
+	selector, err := label.New().Instance(tc.Name).PD().Selector()
+	if err != nil {
+		return nil, nil, err
+	}
+	podList, err := kubeCli.CoreV1().Pods(tc.Namespace).List(metav1.ListOptions{
+		LabelSelector: selector.String(),
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	pod, err := util.SelectRunningPod(podList.Items, tc.Status.PD.Leader.Name)
+	if err != nil {
+		return nil, nil, fmt.Errorf("cannot find a PD pod of tidb cluster %s/%s: %v", tc.Namespace, tc.Name, err)
+	}
+	forwarder, err := util.PortForwardPod(restConfig, kubeCli, tc.Namespace, pod.Name, pdPort, errOut)
+	if err != nil {
+		return nil, nil, err
+	}
+	return controller.NewPDClient(fmt.Sprintf("http://127.0.0.1:%d", forwarder.LocalPort), timeout), forwarder, nil
+}
+
+// newPdctlSubCommand creates a subcommand which completes the options and runs fn through a forwarded PD client
+func newPdctlSubCommand(tkcContext *config.TkcContext, o *PdctlOptions, cmd *cobra.Command,
+	fn func(cmd *cobra.Command, args []string, pdClient controller.PDClient) error) *cobra.Command {
+	cmd.Run = func(cmd *cobra.Command, args []string) {
+		cmdutil.CheckErr(o.Complete(tkcContext, cmd))
+		cmdutil.CheckErr(o.Run(func(pdClient controller.PDClient) error {
+			return fn(cmd, args, pdClient)
+		}))
 	}
+	return cmd
 }
@@ -0,0 +1,173 @@
+// Copyright 2019. PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pdctl
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/pingcap/pd/server"
+	"github.com/pingcap/tidb-operator/pkg/controller"
+	"github.com/pingcap/tidb-operator/pkg/tkctl/readable"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+const (
+	unset = "<none>"
+)
+
+type renderFunc func(out io.Writer) error
+
+// print writes obj as indented json or renders it as table according to the output format
+func (o *PdctlOptions) print(obj interface{}, render renderFunc) error {
+	if o.Output == outputJSON {
+		data, err := json.MarshalIndent(obj, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(o.Out, string(data))
+		return nil
+	}
+	msg, err := readable.TabbedString(render)
+	if err != nil {
+		return err
+	}
+	fmt.Fprint(o.Out, msg)
+	return nil
+}
+
+func renderMembers(members *controller.MembersInfo) renderFunc {
+	return func(out io.Writer) error {
+		w := readable.NewPrefixWriter(out)
+		w.WriteLine(readable.LEVEL_0, "NAME\tID\tCLIENT-URLS\tLEADER")
+		for _, member := range members.Members {
+			isLeader := members.Leader != nil && members.Leader.MemberId == member.MemberId
+			w.WriteLine(readable.LEVEL_0, "%s\t%d\t%s\t%t",
+				member.Name, member.MemberId, joinOrUnset(member.ClientUrls), isLeader)
+		}
+		return nil
+	}
+}
+
+func renderStores(stores []*controller.StoreInfo) renderFunc {
+	return func(out io.Writer) error {
+		w := readable.NewPrefixWriter(out)
+		w.WriteLine(readable.LEVEL_0, "ID\tADDRESS\tSTATE\tCAPACITY\tAVAILABLE\tLEADERS\tREGIONS\tLABELS")
+		for _, store := range stores {
+			if store.Store == nil || store.Store.Store == nil {
+				continue
+			}
+			labels := make([]string, 0, len(store.Store.Labels))
+			for _, l := range store.Store.Labels {
+				labels = append(labels, fmt.Sprintf("%s=%s", l.Key, l.Value))
+			}
+			capacity, available := unset, unset
+			var leaderCount, regionCount int
+			if store.Status != nil {
+				capacity = formatBytes(uint64(store.Status.Capacity))
+				available = formatBytes(uint64(store.Status.Available))
+				leaderCount = store.Status.LeaderCount
+				regionCount = store.Status.RegionCount
+			}
+			w.WriteLine(readable.LEVEL_0, "%d\t%s\t%s\t%s\t%s\t%d\t%d\t%s",
+				store.Store.Id, store.Store.Address, store.Store.StateName,
+				capacity, available, leaderCount, regionCount, joinOrUnset(labels))
+		}
+		return nil
+	}
+}
+
+func renderRegions(regions []*controller.RegionInfo) renderFunc {
+	return func(out io.Writer) error {
+		w := readable.NewPrefixWriter(out)
+		w.WriteLine(readable.LEVEL_0, "ID\tSTART-KEY\tEND-KEY\tLEADER-STORE\tPEER-STORES\tSIZE(MiB)\tKEYS")
+		for _, region := range regions {
+			leader := unset
+			if region.Leader != nil {
+				leader = fmt.Sprintf("%d", region.Leader.StoreId)
+			}
+			peers := make([]string, 0, len(region.Peers))
+			for _, peer := range region.Peers {
+				peers = append(peers, fmt.Sprintf("%d", peer.StoreId))
+			}
+			w.WriteLine(readable.LEVEL_0, "%d\t%s\t%s\t%s\t%s\t%d\t%d",
+				region.ID, keyOrUnset(region.StartKey), keyOrUnset(region.EndKey),
+				leader, joinOrUnset(peers), region.ApproximateSize, region.ApproximateKeys)
+		}
+		return nil
+	}
+}
+
+func renderSchedulers(schedulers []string) renderFunc {
+	return func(out io.Writer) error {
+		sorted := append([]string(nil), schedulers...)
+		sort.Strings(sorted)
+		w := readable.NewPrefixWriter(out)
+		w.WriteLine(readable.LEVEL_0, "NAME")
+		for _, name := range sorted {
+			w.WriteLine(readable.LEVEL_0, "%s", name)
+		}
+		return nil
+	}
+}
+
+func renderConfig(cfg *server.Config) renderFunc {
+	return func(out io.Writer) error {
+		w := readable.NewPrefixWriter(out)
+		w.WriteLine(readable.LEVEL_0, "Replication:")
+		{
+			w.WriteLine(readable.LEVEL_1, "max-replicas:\t%d", cfg.Replication.MaxReplicas)
+			w.WriteLine(readable.LEVEL_1, "location-labels:\t%s", joinOrUnset(cfg.Replication.LocationLabels))
+		}
+		w.WriteLine(readable.LEVEL_0, "Schedule:")
+		{
+			schedule := cfg.Schedule
+			w.WriteLine(readable.LEVEL_1, "max-snapshot-count:\t%d", schedule.MaxSnapshotCount)
+			w.WriteLine(readable.LEVEL_1, "max-pending-peer-count:\t%d", schedule.MaxPendingPeerCount)
+			w.WriteLine(readable.LEVEL_1, "max-merge-region-size:\t%d", schedule.MaxMergeRegionSize)
+			w.WriteLine(readable.LEVEL_1, "max-merge-region-rows:\t%d", schedule.MaxMergeRegionRows)
+			w.WriteLine(readable.LEVEL_1, "max-store-down-time:\t%s", schedule.MaxStoreDownTime.Duration)
+			w.WriteLine(readable.LEVEL_1, "leader-schedule-limit:\t%d", schedule.LeaderScheduleLimit)
+			w.WriteLine(readable.LEVEL_1, "region-schedule-limit:\t%d", schedule.RegionScheduleLimit)
+			w.WriteLine(readable.LEVEL_1, "replica-schedule-limit:\t%d", schedule.ReplicaScheduleLimit)
+			w.WriteLine(readable.LEVEL_1, "merge-schedule-limit:\t%d", schedule.MergeScheduleLimit)
+			w.WriteLine(readable.LEVEL_1, "tolerant-size-ratio:\t%g", schedule.TolerantSizeRatio)
+			w.WriteLine(readable.LEVEL_1, "low-space-ratio:\t%g", schedule.LowSpaceRatio)
+			w.WriteLine(readable.LEVEL_1, "high-space-ratio:\t%g", schedule.HighSpaceRatio)
+		}
+		return nil
+	}
+}
+
+func formatBytes(b uint64) string {
+	return resource.NewQuantity(int64(b), resource.BinarySI).String()
+}
+
+func joinOrUnset(values []string) string {
+	if len(values) == 0 {
+		return unset
+	}
+	return strings.Join(values, ",")
+}
+
+// keyOrUnset renders the empty start or end key, which means the key range is unbounded
+func keyOrUnset(key string) string {
+	if len(key) == 0 {
+		return unset
+	}
+	return key
+}
@@ -0,0 +1,104 @@
+// Copyright 2019. PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pdctl
+
+import (
+	"strings"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/pingcap/kvproto/pkg/metapb"
+	"github.com/pingcap/kvproto/pkg/pdpb"
+	"github.com/pingcap/tidb-operator/pkg/controller"
+	"github.com/pingcap/tidb-operator/pkg/tkctl/readable"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+)
+
+func TestRenderMembers(t *testing.T) {
+	g := NewGomegaWithT(t)
+	leader := &pdpb.Member{Name: "demo-pd-0", MemberId: 1, ClientUrls: []string{"http://demo-pd-0:2379"}}
+	members := &controller.MembersInfo{
+		Members: []*pdpb.Member{leader, {Name: "demo-pd-1", MemberId: 2}},
+		Leader:  leader,
+	}
+	msg, err := readable.TabbedString(renderMembers(members))
+	g.Expect(err).NotTo(HaveOccurred())
+	lines := strings.Split(strings.TrimSpace(msg), "\n")
+	g.Expect(lines).To(HaveLen(3))
+	g.Expect(strings.Fields(lines[1])).To(Equal([]string{"demo-pd-0", "1", "http://demo-pd-0:2379", "true"}))
+	g.Expect(strings.Fields(lines[2])).To(Equal([]string{"demo-pd-1", "2", unset, "false"}))
+}
+
+func TestRenderStores(t *testing.T) {
+	g := NewGomegaWithT(t)
+	stores := []*controller.StoreInfo{
+		{
+			Store: &controller.MetaStore{
+				Store: &metapb.Store{
+					Id:      4,
+					Address: "demo-tikv-0:20160",
+					Labels:  []*metapb.StoreLabel{{Key: "zone", Value: "a"}},
+				},
+				StateName: "Up",
+			},
+			Status: &controller.StoreStatus{Capacity: 1 << 30, Available: 1 << 29, LeaderCount: 3, RegionCount: 10},
+		},
+		{
+			Store: &controller.MetaStore{
+				Store:     &metapb.Store{Id: 5, Address: "demo-tikv-1:20160"},
+				StateName: "Offline",
+			},
+		},
+	}
+	msg, err := readable.TabbedString(renderStores(stores))
+	g.Expect(err).NotTo(HaveOccurred())
+	lines := strings.Split(strings.TrimSpace(msg), "\n")
+	g.Expect(lines).To(HaveLen(3))
+	g.Expect(strings.Fields(lines[1])).To(Equal([]string{"4", "demo-tikv-0:20160", "Up", "1Gi", "512Mi", "3", "10", "zone=a"}))
+	g.Expect(strings.Fields(lines[2])).To(Equal([]string{"5", "demo-tikv-1:20160", "Offline", unset, unset, "0", "0", unset}))
+}
+
+func TestRenderRegions(t *testing.T) {
+	g := NewGomegaWithT(t)
+	regions := []*controller.RegionInfo{
+		{
+			ID:              2,
+			EndKey:          "7480000000000000FF",
+			Peers:           []*metapb.Peer{{Id: 3, StoreId: 1}, {Id: 4, StoreId: 4}},
+			Leader:          &metapb.Peer{Id: 3, StoreId: 1},
+			ApproximateSize: 96,
+			ApproximateKeys: 1000,
+		},
+	}
+	msg, err := readable.TabbedString(renderRegions(regions))
+	g.Expect(err).NotTo(HaveOccurred())
+	lines := strings.Split(strings.TrimSpace(msg), "\n")
+	g.Expect(lines).To(HaveLen(2))
+	g.Expect(strings.Fields(lines[1])).To(Equal([]string{"2", unset, "7480000000000000FF", "1", "1,4", "96", "1000"}))
+}
+
+func TestPrint(t *testing.T) {
+	g := NewGomegaWithT(t)
+	schedulers := []string{"label-scheduler", "balance-leader-scheduler"}
+
+	streams, _, out, _ := genericclioptions.NewTestIOStreams()
+	o := NewPdctlOptions(streams)
+	g.Expect(o.print(schedulers, renderSchedulers(schedulers))).To(Succeed())
+	g.Expect(strings.Fields(out.String())).To(Equal([]string{"NAME", "balance-leader-scheduler", "label-scheduler"}))
+
+	out.Reset()
+	o.Output = outputJSON
+	g.Expect(o.print(schedulers, renderSchedulers(schedulers))).To(Succeed())
+	g.Expect(out.String()).To(Equal("[\n  \"label-scheduler\",\n  \"balance-leader-scheduler\"\n]\n"))
+}
@@ -0,0 +1,220 @@
+// Copyright 2019. PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scale
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/pingcap/tidb-operator/pkg/apis/pingcap.com/v1alpha1"
+	"github.com/pingcap/tidb-operator/pkg/client/clientset/versioned"
+	"github.com/pingcap/tidb-operator/pkg/tkctl/cmd/upinfo"
+	"github.com/pingcap/tidb-operator/pkg/tkctl/config"
+	"github.com/pingcap/tidb-operator/pkg/tkctl/rollout"
+	"github.com/spf13/cobra"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+	"k8s.io/client-go/kubernetes"
+	cmdutil "k8s.io/kubernetes/pkg/kubectl/cmd/util"
+)
+
+const (
+	scaleLongDesc = `
+		Scale the components of the tidb cluster.
+
+		The change is validated against the current tidb cluster and the plan
+		is printed before the TidbCluster is updated.
+`
+	scaleExample = `
+		# scale tikv of current tidb cluster to 5 replicas
+		tkc scale --tikv=5
+
+		# scale pd and tidb, and wait until the new replicas are ready
+		tkc scale --pd=5 --tidb=3 --wait
+`
+	scaleUsage = `expected 'scale -t CLUSTER_NAME' for the scale command or
+using 'tkc use' to set tidb cluster first.
+`
+
+	unchanged          = -1
+	defaultWaitTimeout = 30 * time.Minute
+	waitInterval       = 5 * time.Second
+)
+
+// ScaleOptions contains the input to the scale command.
+type ScaleOptions struct {
+	TidbClusterName string
+	Namespace       string
+
+	PDReplicas   int32
+	TiKVReplicas int32
+	TiDBReplicas int32
+	Wait         bool
+	Timeout      time.Duration
+
+	TcCli   *versioned.Clientset
+	KubeCli *kubernetes.Clientset
+
+	genericclioptions.IOStreams
+}
+
+// NewScaleOptions returns a ScaleOptions
+func NewScaleOptions(streams genericclioptions.IOStreams) *ScaleOptions {
+	return &ScaleOptions{
+		PDReplicas:   unchanged,
+		TiKVReplicas: unchanged,
+		TiDBReplicas: unchanged,
+		Timeout:      defaultWaitTimeout,
+
+		IOStreams: streams,
+	}
+}
+
+// NewCmdScale creates the scale command which changes the replicas of the tidb cluster components
+func NewCmdScale(tkcContext *config.TkcContext, streams genericclioptions.IOStreams) *cobra.Command {
+	o := NewScaleOptions(streams)
+
+	cmd := &cobra.Command{
+		Use:     "scale",
+		Short:   "Scale the components of the tidb cluster.",
+		Long:    scaleLongDesc,
+		Example: scaleExample,
+		Run: func(cmd *cobra.Command, args []string) {
+			cmdutil.CheckErr(o.Complete(tkcContext, cmd, args))
+			cmdutil.CheckErr(o.Run())
+		},
+	}
+	cmd.Flags().Int32Var(&o.PDReplicas, "pd", o.PDReplicas, "The desired replicas of PD, must be odd.")
+	cmd.Flags().Int32Var(&o.TiKVReplicas, "tikv", o.TiKVReplicas, "The desired replicas of TiKV.")
+	cmd.Flags().Int32Var(&o.TiDBReplicas, "tidb", o.TiDBReplicas, "The desired replicas of TiDB.")
+	cmd.Flags().BoolVar(&o.Wait, "wait", o.Wait, "Wait until the scaling is done and show the progress.")
+	cmd.Flags().DurationVar(&o.Timeout, "timeout", o.Timeout, "The time to wait for the scaling, only used with --wait.")
+
+	return cmd
+}
+
+func (o *ScaleOptions) Complete(tkcContext *config.TkcContext, cmd *cobra.Command, args []string) error {
+	if o.PDReplicas == unchanged && o.TiKVReplicas == unchanged && o.TiDBReplicas == unchanged {
+		return cmdutil.UsageErrorf(cmd, "at least one of --pd, --tikv and --tidb is required")
+	}
+
+	clientConfig, err := tkcContext.ToTkcClientConfig()
+	if err != nil {
+		return err
+	}
+
+	if tidbClusterName, ok := clientConfig.TidbClusterName(); ok {
+		o.TidbClusterName = tidbClusterName
+	} else {
+		return cmdutil.UsageErrorf(cmd, scaleUsage)
+	}
+
+	namespace, _, err := clientConfig.Namespace()
+	if err != nil {
+		return err
+	}
+	o.Namespace = namespace
+
+	restConfig, err := clientConfig.RestConfig()
+	if err != nil {
+		return err
+	}
+	tcCli, err := versioned.NewForConfig(restConfig)
+	if err != nil {
+		return err
+	}
+	o.TcCli = tcCli
+	kubeCli, err := kubernetes.NewForConfig(restConfig)
+	if err != nil {
+		return err
+	}
+	o.KubeCli = kubeCli
+
+	return nil
+}
+
+func (o *ScaleOptions) Run() error {
+	target := o.target()
+	tc, err := o.TcCli.PingcapV1alpha1().
+		TidbClusters(o.Namespace).
+		Get(o.TidbClusterName, metav1.GetOptions{})
+	if err != nil {
+		return err
+	}
+	if err := validateScale(tc, target); err != nil {
+		return err
+	}
+	plan, err := rollout.RenderPlan(tc, target.Changes(tc))
+	if err != nil {
+		return err
+	}
+	fmt.Fprint(o.Out, plan)
+
+	// validate again against the latest tidb cluster in case it is changed meanwhile
+	if _, err := rollout.Update(o.TcCli, o.Namespace, o.TidbClusterName, target, func(tc *v1alpha1.TidbCluster) error {
+		return validateScale(tc, target)
+	}); err != nil {
+		return err
+	}
+	fmt.Fprintf(o.Out, "tidbcluster %s/%s scaled\n", o.Namespace, o.TidbClusterName)
+	if !o.Wait {
+		return nil
+	}
+	return rollout.Wait(o.TcCli, o.KubeCli, o.Namespace, o.TidbClusterName, target, waitInterval, o.Timeout,
+		func() (string, error) {
+			return upinfo.RenderUpgradeInfo(o.TcCli, o.KubeCli, o.Namespace, o.TidbClusterName)
+		}, o.Out)
+}
+
+func (o *ScaleOptions) target() *rollout.Target {
+	target := rollout.NewTarget()
+	if o.PDReplicas != unchanged {
+		target.Replicas[v1alpha1.PDMemberType] = o.PDReplicas
+	}
+	if o.TiKVReplicas != unchanged {
+		target.Replicas[v1alpha1.TiKVMemberType] = o.TiKVReplicas
+	}
+	if o.TiDBReplicas != unchanged {
+		target.Replicas[v1alpha1.TiDBMemberType] = o.TiDBReplicas
+	}
+	return target
+}
+
+// validateScale checks the target replicas, PD and TiKV can not be scaled while they are
+// upgrading, which is deferred by the operator until the upgrade is completed
+func validateScale(tc *v1alpha1.TidbCluster, target *rollout.Target) error {
+	if len(target.Changes(tc)) == 0 {
+		return fmt.Errorf("tidb cluster %s/%s already has the desired replicas", tc.Namespace, tc.Name)
+	}
+	if replicas, ok := target.Replicas[v1alpha1.PDMemberType]; ok && replicas != tc.Spec.PD.Replicas {
+		if replicas < 1 || replicas%2 == 0 {
+			return fmt.Errorf("the replicas of pd must be a positive odd number to keep the raft quorum, got %d", replicas)
+		}
+		if tc.PDUpgrading() {
+			return fmt.Errorf("pd of tidb cluster %s/%s is upgrading, scale it after the upgrade is completed", tc.Namespace, tc.Name)
+		}
+	}
+	if replicas, ok := target.Replicas[v1alpha1.TiKVMemberType]; ok && replicas != tc.Spec.TiKV.Replicas {
+		if replicas < 1 {
+			return fmt.Errorf("the replicas of tikv must be positive, got %d", replicas)
+		}
+		if tc.TiKVUpgrading() {
+			return fmt.Errorf("tikv of tidb cluster %s/%s is upgrading, scale it after the upgrade is completed", tc.Namespace, tc.Name)
+		}
+	}
+	if replicas, ok := target.Replicas[v1alpha1.TiDBMemberType]; ok && replicas < 0 {
+		return fmt.Errorf("the replicas of tidb must not be negative, got %d", replicas)
+	}
+	return nil
+}
@@ -0,0 +1,107 @@
+// Copyright 2019. PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scale
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/pingcap/tidb-operator/pkg/apis/pingcap.com/v1alpha1"
+	"github.com/pingcap/tidb-operator/pkg/tkctl/rollout"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestValidateScale(t *testing.T) {
+	g := NewGomegaWithT(t)
+	type testcase struct {
+		name        string
+		replicas    map[v1alpha1.MemberType]int32
+		update      func(tc *v1alpha1.TidbCluster)
+		errExpected bool
+	}
+	tests := []testcase{
+		{
+			name:        "scale out tikv",
+			replicas:    map[v1alpha1.MemberType]int32{v1alpha1.TiKVMemberType: 5},
+			errExpected: false,
+		},
+		{
+			name:        "nothing changed",
+			replicas:    map[v1alpha1.MemberType]int32{v1alpha1.TiKVMemberType: 3},
+			errExpected: true,
+		},
+		{
+			name:        "even pd replicas",
+			replicas:    map[v1alpha1.MemberType]int32{v1alpha1.PDMemberType: 4},
+			errExpected: true,
+		},
+		{
+			name:        "odd pd replicas",
+			replicas:    map[v1alpha1.MemberType]int32{v1alpha1.PDMemberType: 5},
+			errExpected: false,
+		},
+		{
+			name:        "zero tikv replicas",
+			replicas:    map[v1alpha1.MemberType]int32{v1alpha1.TiKVMemberType: 0},
+			errExpected: true,
+		},
+		{
+			name:     "scale in tikv during upgrade",
+			replicas: map[v1alpha1.MemberType]int32{v1alpha1.TiKVMemberType: 2},
+			update: func(tc *v1alpha1.TidbCluster) {
+				tc.Status.TiKV.Phase = v1alpha1.UpgradePhase
+			},
+			errExpected: true,
+		},
+		{
+			name:     "scale pd during upgrade",
+			replicas: map[v1alpha1.MemberType]int32{v1alpha1.PDMemberType: 5},
+			update: func(tc *v1alpha1.TidbCluster) {
+				tc.Status.PD.Phase = v1alpha1.UpgradePhase
+			},
+			errExpected: true,
+		},
+		{
+			name:     "scale tidb during tikv upgrade",
+			replicas: map[v1alpha1.MemberType]int32{v1alpha1.TiDBMemberType: 0},
+			update: func(tc *v1alpha1.TidbCluster) {
+				tc.Status.TiKV.Phase = v1alpha1.UpgradePhase
+			},
+			errExpected: false,
+		},
+		{
+			name:        "negative tidb replicas",
+			replicas:    map[v1alpha1.MemberType]int32{v1alpha1.TiDBMemberType: -2},
+			errExpected: true,
+		},
+	}
+	for _, test := range tests {
+		t.Log(test.name)
+		tc := &v1alpha1.TidbCluster{ObjectMeta: metav1.ObjectMeta{Name: "demo", Namespace: metav1.NamespaceDefault}}
+		tc.Spec.PD.Replicas = 3
+		tc.Spec.TiKV.Replicas = 3
+		tc.Spec.TiDB.Replicas = 2
+		if test.update != nil {
+			test.update(tc)
+		}
+		target := rollout.NewTarget()
+		target.Replicas = test.replicas
+		err := validateScale(tc, target)
+		if test.errExpected {
+			g.Expect(err).To(HaveOccurred())
+		} else {
+			g.Expect(err).NotTo(HaveOccurred())
+		}
+	}
+}
@@ -0,0 +1,190 @@
+// Copyright 2019. PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sql
+
+import (
+	"bufio"
+	dbsql "database/sql"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/pingcap/tidb-operator/pkg/tkctl/readable"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+)
+
+const (
+	prompt             = "mysql> "
+	continuationPrompt = "    -> "
+	nullValue          = "NULL"
+	maxStatementSize   = 16 * 1024 * 1024
+)
+
+// shell executes statements against the session and renders the results in tables
+type shell struct {
+	db *dbsql.DB
+
+	genericclioptions.IOStreams
+}
+
+// execute runs the semicolon separated statements and stops at the first error
+func (s *shell) execute(input string) error {
+	stmts, rest := splitStatements(input)
+	if len(strings.TrimSpace(rest)) > 0 {
+		stmts = append(stmts, rest)
+	}
+	for _, stmt := range stmts {
+		if _, err := s.run(stmt); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// interact reads statements from the input until EOF or quit, errors of
+// statements are printed and do not end the session
+func (s *shell) interact() error {
+	scanner := bufio.NewScanner(s.In)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxStatementSize)
+	var buffer string
+	fmt.Fprint(s.Out, prompt)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if len(strings.TrimSpace(buffer)) == 0 && isQuit(line) {
+			return nil
+		}
+		stmts, rest := splitStatements(buffer + line + "\n")
+		for _, stmt := range stmts {
+			start := time.Now()
+			summary, err := s.run(stmt)
+			if err != nil {
+				fmt.Fprintf(s.ErrOut, "ERROR: %v\n", err)
+				continue
+			}
+			fmt.Fprintf(s.Out, "%s (%.2f sec)\n\n", summary, time.Since(start).Seconds())
+		}
+		buffer = rest
+		if len(strings.TrimSpace(buffer)) == 0 {
+			buffer = ""
+			fmt.Fprint(s.Out, prompt)
+		} else {
+			fmt.Fprint(s.Out, continuationPrompt)
+		}
+	}
+	fmt.Fprintln(s.Out)
+	return scanner.Err()
+}
+
+// run executes a single statement, prints the result set if there is one
+// and returns a summary of the result
+func (s *shell) run(stmt string) (string, error) {
+	rows, err := s.db.Query(stmt)
+	if err != nil {
+		return "", err
+	}
+	defer rows.Close()
+	columns, err := rows.Columns()
+	if err != nil {
+		return "", err
+	}
+	if len(columns) == 0 {
+		return "Query OK", rows.Err()
+	}
+
+	var records [][]string
+	values := make([]dbsql.RawBytes, len(columns))
+	dest := make([]interface{}, len(columns))
+	for i := range values {
+		dest[i] = &values[i]
+	}
+	for rows.Next() {
+		if err := rows.Scan(dest...); err != nil {
+			return "", err
+		}
+		record := make([]string, len(values))
+		for i, value := range values {
+			if value == nil {
+				record[i] = nullValue
+			} else {
+				record[i] = string(value)
+			}
+		}
+		records = append(records, record)
+	}
+	if err := rows.Err(); err != nil {
+		return "", err
+	}
+	msg, err := renderRows(columns, records)
+	if err != nil {
+		return "", err
+	}
+	fmt.Fprint(s.Out, msg)
+	if len(records) == 1 {
+		return "1 row in set", nil
+	}
+	return fmt.Sprintf("%d rows in set", len(records)), nil
+}
+
+func renderRows(columns []string, records [][]string) (string, error) {
+	return readable.TabbedString(func(out io.Writer) error {
+		w := readable.NewPrefixWriter(out)
+		w.WriteLine(readable.LEVEL_0, "%s", strings.Join(columns, "\t"))
+		for _, record := range records {
+			w.WriteLine(readable.LEVEL_0, "%s", strings.Join(record, "\t"))
+		}
+		return nil
+	})
+}
+
+// splitStatements splits the input into statements terminated by semicolons
+// outside of quotes and comments, the unterminated remainder is returned as rest
+func splitStatements(input string) (stmts []string, rest string) {
+	var quote byte
+	lineComment := false
+	start := 0
+	for i := 0; i < len(input); i++ {
+		c := input[i]
+		switch {
+		case lineComment:
+			if c == '\n' {
+				lineComment = false
+			}
+		case quote != 0:
+			if c == '\\' && quote != '`' {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+		case c == '\'' || c == '"' || c == '`':
+			quote = c
+		case c == '#' || (c == '-' && strings.HasPrefix(input[i:], "-- ")):
+			lineComment = true
+		case c == ';':
+			if stmt := strings.TrimSpace(input[start:i]); len(stmt) > 0 {
+				stmts = append(stmts, stmt)
+			}
+			start = i + 1
+		}
+	}
+	return stmts, input[start:]
+}
+
+func isQuit(line string) bool {
+	switch strings.TrimRight(strings.TrimSpace(line), ";") {
+	case "exit", "quit", "\\q":
+		return true
+	}
+	return false
+}
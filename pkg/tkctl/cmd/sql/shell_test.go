@@ -0,0 +1,93 @@
+// Copyright 2019. PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sql
+
+import (
+	"strings"
+	"testing"
+
+	. "github.com/onsi/gomega"
+)
+
+func TestSplitStatements(t *testing.T) {
+	g := NewGomegaWithT(t)
+	type testcase struct {
+		name  string
+		input string
+		stmts []string
+		rest  string
+	}
+	tests := []testcase{
+		{
+			name:  "single statement",
+			input: "select 1;",
+			stmts: []string{"select 1"},
+			rest:  "",
+		},
+		{
+			name:  "multiple statements and remainder",
+			input: "use test; select * from t;\nselect",
+			stmts: []string{"use test", "select * from t"},
+			rest:  "\nselect",
+		},
+		{
+			name:  "semicolons in quotes",
+			input: "select ';', \"a;b\", `c;d`; select 'it\\'s;';",
+			stmts: []string{"select ';', \"a;b\", `c;d`", "select 'it\\'s;'"},
+			rest:  "",
+		},
+		{
+			name:  "semicolons in comments",
+			input: "select 1 -- comment;\n; # another;\nselect 2;",
+			stmts: []string{"select 1 -- comment;", "# another;\nselect 2"},
+			rest:  "",
+		},
+		{
+			name:  "unterminated quote",
+			input: "select 'a;\n",
+			stmts: nil,
+			rest:  "select 'a;\n",
+		},
+		{
+			name:  "empty statements",
+			input: " ; ;",
+			stmts: nil,
+			rest:  "",
+		},
+	}
+	for _, test := range tests {
+		t.Log(test.name)
+		stmts, rest := splitStatements(test.input)
+		g.Expect(stmts).To(Equal(test.stmts))
+		g.Expect(rest).To(Equal(test.rest))
+	}
+}
+
+func TestIsQuit(t *testing.T) {
+	g := NewGomegaWithT(t)
+	for _, line := range []string{"exit", "quit;", " \\q "} {
+		g.Expect(isQuit(line)).To(BeTrue())
+	}
+	g.Expect(isQuit("select 1;")).To(BeFalse())
+}
+
+func TestRenderRows(t *testing.T) {
+	g := NewGomegaWithT(t)
+	msg, err := renderRows([]string{"id", "name"}, [][]string{{"1", "a"}, {"2", nullValue}})
+	g.Expect(err).NotTo(HaveOccurred())
+	lines := strings.Split(strings.TrimSpace(msg), "\n")
+	g.Expect(lines).To(HaveLen(3))
+	g.Expect(strings.Fields(lines[0])).To(Equal([]string{"id", "name"}))
+	g.Expect(strings.Fields(lines[2])).To(Equal([]string{"2", "NULL"}))
+}
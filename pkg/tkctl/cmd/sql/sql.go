@@ -0,0 +1,244 @@
+// Copyright 2019. PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sql
+
+import (
+	dbsql "database/sql"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/pingcap/tidb-operator/pkg/apis/pingcap.com/v1alpha1"
+	"github.com/pingcap/tidb-operator/pkg/client/clientset/versioned"
+	"github.com/pingcap/tidb-operator/pkg/tkctl/config"
+	"github.com/pingcap/tidb-operator/pkg/tkctl/util"
+	"github.com/spf13/cobra"
+	"k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	cmdutil "k8s.io/kubernetes/pkg/kubectl/cmd/util"
+)
+
+const (
+	sqlLongDesc = `
+		Open a MySQL session to the tidb cluster.
+
+		The session is established through a port-forward to a healthy TiDB pod,
+		the password is read from the secret specified by --secret, which is
+		the secret created for 'tidb.passwordSecretName' of the tidb-cluster chart
+		(a key named after the user) or a secret with 'user' and 'password' keys.
+`
+	sqlExample = `
+		# open an interactive session to current tidb cluster as root
+		tkc sql --secret tidb-secret
+
+		# execute a single statement
+		tkc sql --secret tidb-secret -e "show databases"
+
+		# execute statements in the specified database
+		tkc sql -D test -e "select count(*) from t"
+`
+	sqlUsage = `expected 'sql -t CLUSTER_NAME' for the sql command or
+using 'tkc use' to set tidb cluster first.
+`
+
+	tidbPort           = 4000
+	defaultUser        = "root"
+	defaultDialTimeout = 10 * time.Second
+
+	secretUserKey     = "user"
+	secretPasswordKey = "password"
+)
+
+// SQLOptions contains the input to the sql command.
+type SQLOptions struct {
+	TidbClusterName string
+	Namespace       string
+
+	User        string
+	SecretName  string
+	Database    string
+	Execute     string
+	DialTimeout time.Duration
+
+	RestConfig *rest.Config
+	TcCli      *versioned.Clientset
+	KubeCli    *kubernetes.Clientset
+
+	genericclioptions.IOStreams
+}
+
+// NewSQLOptions returns a SQLOptions
+func NewSQLOptions(streams genericclioptions.IOStreams) *SQLOptions {
+	return &SQLOptions{
+		User:        defaultUser,
+		DialTimeout: defaultDialTimeout,
+
+		IOStreams: streams,
+	}
+}
+
+// NewCmdSQL creates the sql command which opens a MySQL session to the tidb cluster
+func NewCmdSQL(tkcContext *config.TkcContext, streams genericclioptions.IOStreams) *cobra.Command {
+	o := NewSQLOptions(streams)
+
+	cmd := &cobra.Command{
+		Use:     "sql",
+		Short:   "Open a MySQL session to the tidb cluster.",
+		Long:    sqlLongDesc,
+		Example: sqlExample,
+		Run: func(cmd *cobra.Command, args []string) {
+			cmdutil.CheckErr(o.Complete(tkcContext, cmd, args))
+			cmdutil.CheckErr(o.Run())
+		},
+		SuggestFor: []string{"mysql"},
+	}
+	cmd.Flags().StringVarP(&o.User, "user", "u", o.User, "The user to connect as.")
+	cmd.Flags().StringVar(&o.SecretName, "secret", o.SecretName,
+		"The secret which stores the password, the password is empty if unset.")
+	cmd.Flags().StringVarP(&o.Database, "database", "D", o.Database, "The database to use.")
+	cmd.Flags().StringVarP(&o.Execute, "execute", "e", o.Execute,
+		"Execute the statements and quit instead of opening an interactive shell.")
+	cmd.Flags().DurationVar(&o.DialTimeout, "dial-timeout", o.DialTimeout, "Timeout of connecting to TiDB.")
+
+	return cmd
+}
+
+func (o *SQLOptions) Complete(tkcContext *config.TkcContext, cmd *cobra.Command, args []string) error {
+	clientConfig, err := tkcContext.ToTkcClientConfig()
+	if err != nil {
+		return err
+	}
+
+	if tidbClusterName, ok := clientConfig.TidbClusterName(); ok {
+		o.TidbClusterName = tidbClusterName
+	} else {
+		return cmdutil.UsageErrorf(cmd, sqlUsage)
+	}
+
+	namespace, _, err := clientConfig.Namespace()
+	if err != nil {
+		return err
+	}
+	o.Namespace = namespace
+
+	restConfig, err := clientConfig.RestConfig()
+	if err != nil {
+		return err
+	}
+	o.RestConfig = restConfig
+	tcCli, err := versioned.NewForConfig(restConfig)
+	if err != nil {
+		return err
+	}
+	o.TcCli = tcCli
+	kubeCli, err := kubernetes.NewForConfig(restConfig)
+	if err != nil {
+		return err
+	}
+	o.KubeCli = kubeCli
+
+	return nil
+}
+
+func (o *SQLOptions) Run() error {
+	tc, err := o.TcCli.PingcapV1alpha1().
+		TidbClusters(o.Namespace).
+		Get(o.TidbClusterName, metav1.GetOptions{})
+	if err != nil {
+		return err
+	}
+	podName, err := healthyTiDBMember(tc)
+	if err != nil {
+		return err
+	}
+
+	user, password := o.User, ""
+	if len(o.SecretName) > 0 {
+		secret, err := o.KubeCli.CoreV1().Secrets(o.Namespace).Get(o.SecretName, metav1.GetOptions{})
+		if err != nil {
+			return err
+		}
+		user, password, err = credentialsFromSecret(secret, o.User)
+		if err != nil {
+			return err
+		}
+	}
+
+	forwarder, err := util.PortForwardPod(o.RestConfig, o.KubeCli, o.Namespace, podName, tidbPort, o.ErrOut)
+	if err != nil {
+		return err
+	}
+	defer forwarder.Close()
+
+	dsnConfig := mysql.NewConfig()
+	dsnConfig.User = user
+	dsnConfig.Passwd = password
+	dsnConfig.Net = "tcp"
+	dsnConfig.Addr = fmt.Sprintf("127.0.0.1:%d", forwarder.LocalPort)
+	dsnConfig.DBName = o.Database
+	dsnConfig.Timeout = o.DialTimeout
+	db, err := dbsql.Open("mysql", dsnConfig.FormatDSN())
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+	// statements like 'use' and 'set' only take effect in the session,
+	// so a single connection is kept for the whole shell
+	db.SetMaxOpenConns(1)
+	if err := db.Ping(); err != nil {
+		return fmt.Errorf("cannot connect to tidb cluster %s/%s via pod %s: %v", o.Namespace, tc.Name, podName, err)
+	}
+
+	s := &shell{db: db, IOStreams: o.IOStreams}
+	if len(o.Execute) > 0 {
+		return s.execute(o.Execute)
+	}
+	fmt.Fprintf(o.Out, "Connected to tidb cluster %s/%s via pod %s as %s.\n", o.Namespace, tc.Name, podName, user)
+	return s.interact()
+}
+
+// healthyTiDBMember returns the first healthy TiDB member in name order
+func healthyTiDBMember(tc *v1alpha1.TidbCluster) (string, error) {
+	names := make([]string, 0, len(tc.Status.TiDB.Members))
+	for name, member := range tc.Status.TiDB.Members {
+		if member.Health {
+			names = append(names, name)
+		}
+	}
+	if len(names) == 0 {
+		return "", fmt.Errorf("tidb cluster %s/%s has no healthy TiDB member", tc.Namespace, tc.Name)
+	}
+	sort.Strings(names)
+	return names[0], nil
+}
+
+// credentialsFromSecret reads the password of user from the secret, either stored
+// under a key named after the user, or under the 'password' key together with an optional 'user' key
+func credentialsFromSecret(secret *v1.Secret, user string) (string, string, error) {
+	if password, ok := secret.Data[user]; ok {
+		return user, string(password), nil
+	}
+	password, ok := secret.Data[secretPasswordKey]
+	if !ok {
+		return "", "", fmt.Errorf("secret %s/%s has neither key %q nor key %q", secret.Namespace, secret.Name, user, secretPasswordKey)
+	}
+	if secretUser, ok := secret.Data[secretUserKey]; ok {
+		user = string(secretUser)
+	}
+	return user, string(password), nil
+}
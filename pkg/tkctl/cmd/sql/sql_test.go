@@ -0,0 +1,99 @@
+// Copyright 2019. PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sql
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/pingcap/tidb-operator/pkg/apis/pingcap.com/v1alpha1"
+	"k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestHealthyTiDBMember(t *testing.T) {
+	g := NewGomegaWithT(t)
+	tc := &v1alpha1.TidbCluster{ObjectMeta: metav1.ObjectMeta{Name: "demo", Namespace: "ns"}}
+
+	_, err := healthyTiDBMember(tc)
+	g.Expect(err).To(HaveOccurred())
+
+	tc.Status.TiDB.Members = map[string]v1alpha1.TiDBMember{
+		"demo-tidb-0": {Name: "demo-tidb-0", Health: false},
+		"demo-tidb-2": {Name: "demo-tidb-2", Health: true},
+		"demo-tidb-1": {Name: "demo-tidb-1", Health: true},
+	}
+	name, err := healthyTiDBMember(tc)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(name).To(Equal("demo-tidb-1"))
+}
+
+func TestCredentialsFromSecret(t *testing.T) {
+	g := NewGomegaWithT(t)
+	type testcase struct {
+		name     string
+		data     map[string][]byte
+		user     string
+		expectFn func(*GomegaWithT, string, string, error)
+	}
+	tests := []testcase{
+		{
+			name: "key named after the user",
+			data: map[string][]byte{"root": []byte("secret")},
+			user: "root",
+			expectFn: func(g *GomegaWithT, user, password string, err error) {
+				g.Expect(err).NotTo(HaveOccurred())
+				g.Expect(user).To(Equal("root"))
+				g.Expect(password).To(Equal("secret"))
+			},
+		},
+		{
+			name: "user and password keys",
+			data: map[string][]byte{"user": []byte("backup"), "password": []byte("secret")},
+			user: "root",
+			expectFn: func(g *GomegaWithT, user, password string, err error) {
+				g.Expect(err).NotTo(HaveOccurred())
+				g.Expect(user).To(Equal("backup"))
+				g.Expect(password).To(Equal("secret"))
+			},
+		},
+		{
+			name: "password key only",
+			data: map[string][]byte{"password": []byte("secret")},
+			user: "admin",
+			expectFn: func(g *GomegaWithT, user, password string, err error) {
+				g.Expect(err).NotTo(HaveOccurred())
+				g.Expect(user).To(Equal("admin"))
+				g.Expect(password).To(Equal("secret"))
+			},
+		},
+		{
+			name: "no password",
+			data: map[string][]byte{"other": []byte("secret")},
+			user: "root",
+			expectFn: func(g *GomegaWithT, user, password string, err error) {
+				g.Expect(err).To(HaveOccurred())
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Log(test.name)
+		secret := &v1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "tidb-secret", Namespace: "ns"},
+			Data:       test.data,
+		}
+		user, password, err := credentialsFromSecret(secret, test.user)
+		test.expectFn(g, user, password, err)
+	}
+}
@@ -0,0 +1,255 @@
+// Copyright 2019. PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package upgrade
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/pingcap/tidb-operator/pkg/apis/pingcap.com/v1alpha1"
+	"github.com/pingcap/tidb-operator/pkg/client/clientset/versioned"
+	"github.com/pingcap/tidb-operator/pkg/tkctl/cmd/upinfo"
+	"github.com/pingcap/tidb-operator/pkg/tkctl/config"
+	"github.com/pingcap/tidb-operator/pkg/tkctl/rollout"
+	"github.com/spf13/cobra"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+	"k8s.io/client-go/kubernetes"
+	cmdutil "k8s.io/kubernetes/pkg/kubectl/cmd/util"
+)
+
+const (
+	upgradeLongDesc = `
+		Upgrade the components of the tidb cluster to the specified version.
+
+		The image tags of the components are replaced with the version, the change
+		is validated against the current tidb cluster and the plan is printed
+		before the TidbCluster is updated.
+`
+	upgradeExample = `
+		# upgrade current tidb cluster to v3.0.1
+		tkc upgrade --version=v3.0.1
+
+		# upgrade tidb only, and wait until the upgrade is completed
+		tkc upgrade --version=v3.0.1 --components=tidb --wait
+`
+	upgradeUsage = `expected 'upgrade -t CLUSTER_NAME' for the upgrade command or
+using 'tkc use' to set tidb cluster first.
+`
+
+	defaultWaitTimeout = time.Hour
+	waitInterval       = 5 * time.Second
+)
+
+// UpgradeOptions contains the input to the upgrade command.
+type UpgradeOptions struct {
+	TidbClusterName string
+	Namespace       string
+
+	Version    string
+	Components []string
+	Wait       bool
+	Timeout    time.Duration
+
+	TcCli   *versioned.Clientset
+	KubeCli *kubernetes.Clientset
+
+	genericclioptions.IOStreams
+}
+
+// NewUpgradeOptions returns a UpgradeOptions
+func NewUpgradeOptions(streams genericclioptions.IOStreams) *UpgradeOptions {
+	components := make([]string, 0, len(rollout.Components))
+	for _, component := range rollout.Components {
+		components = append(components, component.String())
+	}
+	return &UpgradeOptions{
+		Components: components,
+		Timeout:    defaultWaitTimeout,
+
+		IOStreams: streams,
+	}
+}
+
+// NewCmdUpgrade creates the upgrade command which changes the versions of the tidb cluster components
+func NewCmdUpgrade(tkcContext *config.TkcContext, streams genericclioptions.IOStreams) *cobra.Command {
+	o := NewUpgradeOptions(streams)
+
+	cmd := &cobra.Command{
+		Use:     "upgrade",
+		Short:   "Upgrade the tidb cluster to the specified version.",
+		Long:    upgradeLongDesc,
+		Example: upgradeExample,
+		Run: func(cmd *cobra.Command, args []string) {
+			cmdutil.CheckErr(o.Complete(tkcContext, cmd, args))
+			cmdutil.CheckErr(o.Run())
+		},
+	}
+	cmd.Flags().StringVar(&o.Version, "version", o.Version, "The version to upgrade to, e.g. v3.0.1.")
+	cmd.Flags().StringSliceVar(&o.Components, "components", o.Components, "The components to upgrade.")
+	cmd.Flags().BoolVar(&o.Wait, "wait", o.Wait, "Wait until the upgrade is completed and show the progress.")
+	cmd.Flags().DurationVar(&o.Timeout, "timeout", o.Timeout, "The time to wait for the upgrade, only used with --wait.")
+
+	return cmd
+}
+
+func (o *UpgradeOptions) Complete(tkcContext *config.TkcContext, cmd *cobra.Command, args []string) error {
+	if len(o.Version) == 0 {
+		return cmdutil.UsageErrorf(cmd, "--version is required")
+	}
+	if strings.ContainsAny(o.Version, ":@/") {
+		return cmdutil.UsageErrorf(cmd, "invalid version %q, expected an image tag like v3.0.1", o.Version)
+	}
+	for _, component := range o.Components {
+		if !isComponent(component) {
+			return cmdutil.UsageErrorf(cmd, "unknown component %q, expected pd, tikv or tidb", component)
+		}
+	}
+
+	clientConfig, err := tkcContext.ToTkcClientConfig()
+	if err != nil {
+		return err
+	}
+
+	if tidbClusterName, ok := clientConfig.TidbClusterName(); ok {
+		o.TidbClusterName = tidbClusterName
+	} else {
+		return cmdutil.UsageErrorf(cmd, upgradeUsage)
+	}
+
+	namespace, _, err := clientConfig.Namespace()
+	if err != nil {
+		return err
+	}
+	o.Namespace = namespace
+
+	restConfig, err := clientConfig.RestConfig()
+	if err != nil {
+		return err
+	}
+	tcCli, err := versioned.NewForConfig(restConfig)
+	if err != nil {
+		return err
+	}
+	o.TcCli = tcCli
+	kubeCli, err := kubernetes.NewForConfig(restConfig)
+	if err != nil {
+		return err
+	}
+	o.KubeCli = kubeCli
+
+	return nil
+}
+
+func (o *UpgradeOptions) Run() error {
+	tc, err := o.TcCli.PingcapV1alpha1().
+		TidbClusters(o.Namespace).
+		Get(o.TidbClusterName, metav1.GetOptions{})
+	if err != nil {
+		return err
+	}
+	// the images are computed once, so that a concurrent change of
+	// the images is refused by the validation instead of being overridden
+	target := upgradeTarget(tc, o.Components, o.Version)
+	if err := validateUpgrade(tc, target); err != nil {
+		return err
+	}
+	plan, err := rollout.RenderPlan(tc, target.Changes(tc))
+	if err != nil {
+		return err
+	}
+	fmt.Fprint(o.Out, plan)
+
+	if _, err := rollout.Update(o.TcCli, o.Namespace, o.TidbClusterName, target, func(latest *v1alpha1.TidbCluster) error {
+		if err := validateUpgrade(latest, target); err != nil {
+			return err
+		}
+		if !equalImages(tc, latest) {
+			return fmt.Errorf("the images of tidb cluster %s/%s are changed during the upgrade, please retry", o.Namespace, o.TidbClusterName)
+		}
+		return nil
+	}); err != nil {
+		return err
+	}
+	fmt.Fprintf(o.Out, "tidbcluster %s/%s upgraded\n", o.Namespace, o.TidbClusterName)
+	if !o.Wait {
+		return nil
+	}
+	return rollout.Wait(o.TcCli, o.KubeCli, o.Namespace, o.TidbClusterName, target, waitInterval, o.Timeout,
+		func() (string, error) {
+			return upinfo.RenderUpgradeInfo(o.TcCli, o.KubeCli, o.Namespace, o.TidbClusterName)
+		}, o.Out)
+}
+
+// upgradeTarget replaces the image tags of the components with the version
+func upgradeTarget(tc *v1alpha1.TidbCluster, components []string, version string) *rollout.Target {
+	target := rollout.NewTarget()
+	images := map[v1alpha1.MemberType]string{
+		v1alpha1.PDMemberType:   tc.Spec.PD.Image,
+		v1alpha1.TiKVMemberType: tc.Spec.TiKV.Image,
+		v1alpha1.TiDBMemberType: tc.Spec.TiDB.Image,
+	}
+	for _, component := range components {
+		memberType := v1alpha1.MemberType(component)
+		target.Images[memberType] = imageWithVersion(images[memberType], version)
+	}
+	return target
+}
+
+// validateUpgrade refuses to start an upgrade while a component is still upgrading,
+// the rolling update of the new version would be mixed up with the ongoing one
+func validateUpgrade(tc *v1alpha1.TidbCluster, target *rollout.Target) error {
+	if len(target.Changes(tc)) == 0 {
+		return fmt.Errorf("tidb cluster %s/%s is already at the desired version", tc.Namespace, tc.Name)
+	}
+	upgrading := map[v1alpha1.MemberType]bool{
+		v1alpha1.PDMemberType:   tc.PDUpgrading(),
+		v1alpha1.TiKVMemberType: tc.TiKVUpgrading(),
+		v1alpha1.TiDBMemberType: tc.TiDBUpgrading(),
+	}
+	for _, component := range rollout.Components {
+		if _, ok := target.Images[component]; ok && upgrading[component] {
+			return fmt.Errorf("%s of tidb cluster %s/%s is upgrading, upgrade it after the ongoing upgrade is completed",
+				component, tc.Namespace, tc.Name)
+		}
+	}
+	return nil
+}
+
+// imageWithVersion replaces the tag or digest of the image with the version
+func imageWithVersion(image, version string) string {
+	if i := strings.Index(image, "@"); i >= 0 {
+		image = image[:i]
+	}
+	if i := strings.LastIndex(image, ":"); i > strings.LastIndex(image, "/") {
+		image = image[:i]
+	}
+	return image + ":" + version
+}
+
+func equalImages(a, b *v1alpha1.TidbCluster) bool {
+	return a.Spec.PD.Image == b.Spec.PD.Image &&
+		a.Spec.TiKV.Image == b.Spec.TiKV.Image &&
+		a.Spec.TiDB.Image == b.Spec.TiDB.Image
+}
+
+func isComponent(name string) bool {
+	for _, component := range rollout.Components {
+		if component.String() == name {
+			return true
+		}
+	}
+	return false
+}
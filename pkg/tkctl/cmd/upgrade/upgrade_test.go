@@ -0,0 +1,90 @@
+// Copyright 2019. PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package upgrade
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/pingcap/tidb-operator/pkg/apis/pingcap.com/v1alpha1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestImageWithVersion(t *testing.T) {
+	g := NewGomegaWithT(t)
+	g.Expect(imageWithVersion("pingcap/tidb:v2.1.8", "v3.0.1")).To(Equal("pingcap/tidb:v3.0.1"))
+	g.Expect(imageWithVersion("pingcap/tidb", "v3.0.1")).To(Equal("pingcap/tidb:v3.0.1"))
+	g.Expect(imageWithVersion("localhost:5000/pingcap/tidb", "v3.0.1")).To(Equal("localhost:5000/pingcap/tidb:v3.0.1"))
+	g.Expect(imageWithVersion("localhost:5000/pingcap/tidb:v2.1.8", "v3.0.1")).To(Equal("localhost:5000/pingcap/tidb:v3.0.1"))
+	g.Expect(imageWithVersion("pingcap/tidb@sha256:abcd", "v3.0.1")).To(Equal("pingcap/tidb:v3.0.1"))
+}
+
+func TestValidateUpgrade(t *testing.T) {
+	g := NewGomegaWithT(t)
+	type testcase struct {
+		name        string
+		components  []string
+		update      func(tc *v1alpha1.TidbCluster)
+		errExpected bool
+	}
+	tests := []testcase{
+		{
+			name:        "upgrade all",
+			components:  []string{"pd", "tikv", "tidb"},
+			errExpected: false,
+		},
+		{
+			name:       "already at the version",
+			components: []string{"tidb"},
+			update: func(tc *v1alpha1.TidbCluster) {
+				tc.Spec.TiDB.Image = "pingcap/tidb:v3.0.1"
+			},
+			errExpected: true,
+		},
+		{
+			name:       "tikv is upgrading",
+			components: []string{"pd", "tikv", "tidb"},
+			update: func(tc *v1alpha1.TidbCluster) {
+				tc.Status.TiKV.Phase = v1alpha1.UpgradePhase
+			},
+			errExpected: true,
+		},
+		{
+			name:       "upgrade tidb while tikv is upgrading",
+			components: []string{"tidb"},
+			update: func(tc *v1alpha1.TidbCluster) {
+				tc.Status.TiKV.Phase = v1alpha1.UpgradePhase
+			},
+			errExpected: false,
+		},
+	}
+	for _, test := range tests {
+		t.Log(test.name)
+		tc := &v1alpha1.TidbCluster{ObjectMeta: metav1.ObjectMeta{Name: "demo", Namespace: metav1.NamespaceDefault}}
+		tc.Spec.PD.Image = "pingcap/pd:v2.1.8"
+		tc.Spec.TiKV.Image = "pingcap/tikv:v2.1.8"
+		tc.Spec.TiDB.Image = "pingcap/tidb:v2.1.8"
+		if test.update != nil {
+			test.update(tc)
+		}
+		target := upgradeTarget(tc, test.components, "v3.0.1")
+		g.Expect(target.Images).To(HaveLen(len(test.components)))
+		err := validateUpgrade(tc, target)
+		if test.errExpected {
+			g.Expect(err).To(HaveOccurred())
+		} else {
+			g.Expect(err).NotTo(HaveOccurred())
+		}
+	}
+}
@@ -24,6 +24,7 @@ import (
 	"github.com/pingcap/tidb-operator/pkg/tkctl/config"
 	"github.com/pingcap/tidb-operator/pkg/tkctl/readable"
 	tkctlUtil "github.com/pingcap/tidb-operator/pkg/tkctl/util"
+	"github.com/pingcap/tidb-operator/pkg/tkctl/watch"
 	"github.com/pingcap/tidb-operator/pkg/util"
 	"github.com/spf13/cobra"
 	apps "k8s.io/api/apps/v1beta1"
@@ -46,6 +47,9 @@ const (
 
 		# get specified tidb cluster component upgrade info
 		tkc upinfo -t another-cluster
+
+		# watch the upgrade info until the upgrade is completed
+		tkc upinfo --watch
 `
 	infoUsage = `expected 'upinfo -t CLUSTER_NAME' for the upinfo command or 
 using 'tkc use' to set tidb cluster first.
@@ -59,6 +63,9 @@ using 'tkc use' to set tidb cluster first.
 type UpInfoOptions struct {
 	TidbClusterName string
 	Namespace       string
+	Watch           bool
+
+	PrintFlags *readable.PrintFlags
 
 	TcCli   *versioned.Clientset
 	KubeCli *kubernetes.Clientset
@@ -69,6 +76,8 @@ type UpInfoOptions struct {
 // NewUpInfoOptions returns a UpInfoOptions
 func NewUpInfoOptions(streams genericclioptions.IOStreams) *UpInfoOptions {
 	return &UpInfoOptions{
+		PrintFlags: readable.NewPrintFlags(),
+
 		IOStreams: streams,
 	}
 }
@@ -88,11 +97,20 @@ func NewCmdUpInfo(tkcContext *config.TkcContext, streams genericclioptions.IOStr
 		},
 		SuggestFor: []string{"updateinfo", "upgradeinfo"},
 	}
+	cmd.Flags().BoolVarP(&o.Watch, "watch", "w", o.Watch,
+		"Refresh the upgrade info on changes until the ongoing upgrade or scaling is completed.")
+	o.PrintFlags.AddFlags(cmd)
 
 	return cmd
 }
 
 func (o *UpInfoOptions) Complete(tkcContext *config.TkcContext, cmd *cobra.Command, args []string) error {
+	if err := o.PrintFlags.Validate(); err != nil {
+		return err
+	}
+	if o.Watch && !o.PrintFlags.IsHumanReadable() {
+		return cmdutil.UsageErrorf(cmd, "--watch only supports the default output format")
+	}
 
 	clientConfig, err := tkcContext.ToTkcClientConfig()
 	if err != nil {
@@ -130,35 +148,94 @@ func (o *UpInfoOptions) Complete(tkcContext *config.TkcContext, cmd *cobra.Comma
 }
 
 func (o *UpInfoOptions) Run() error {
-
-	tc, err := o.TcCli.PingcapV1alpha1().
-		TidbClusters(o.Namespace).
-		Get(o.TidbClusterName, metav1.GetOptions{})
+	if o.Watch {
+		return watch.Watch(o.TcCli, o.KubeCli, o.Namespace, o.TidbClusterName,
+			renderFromListers, o.Out, watch.SetupSignalHandler())
+	}
+	if !o.PrintFlags.IsHumanReadable() {
+		printer, err := o.PrintFlags.ToPrinter(false, false)
+		if err != nil {
+			return err
+		}
+		tc, info, err := getUpgradeInfo(o.TcCli, o.KubeCli, o.Namespace, o.TidbClusterName)
+		if err != nil {
+			return err
+		}
+		obj, err := readable.NewObject(tc, readable.TidbClusterKind, &upgradeComputed{Upgrade: info})
+		if err != nil {
+			return err
+		}
+		return printer.PrintObj(obj, o.Out)
+	}
+	msg, err := RenderUpgradeInfo(o.TcCli, o.KubeCli, o.Namespace, o.TidbClusterName)
 	if err != nil {
 		return err
 	}
+	fmt.Fprint(o.Out, msg)
+	return nil
+}
+
+// RenderUpgradeInfo fetches and renders the upgrade info of the tidb cluster
+func RenderUpgradeInfo(tcCli versioned.Interface, kubeCli kubernetes.Interface, namespace, tcName string) (string, error) {
+	tc, info, err := getUpgradeInfo(tcCli, kubeCli, namespace, tcName)
+	if err != nil {
+		return "", err
+	}
+	return renderUpgradeInfo(tc, info)
+}
+
+func getUpgradeInfo(tcCli versioned.Interface, kubeCli kubernetes.Interface, namespace, tcName string) (*v1alpha1.TidbCluster, *upgradeInfo, error) {
+	tc, err := tcCli.PingcapV1alpha1().
+		TidbClusters(namespace).
+		Get(tcName, metav1.GetOptions{})
+	if err != nil {
+		return nil, nil, err
+	}
 	setName := controller.TiDBMemberName(tc.Name)
-	set, err := o.KubeCli.AppsV1beta1().StatefulSets(o.Namespace).Get(setName, metav1.GetOptions{})
+	set, err := kubeCli.AppsV1beta1().StatefulSets(namespace).Get(setName, metav1.GetOptions{})
 	if err != nil {
-		return err
+		return nil, nil, err
 	}
-	podList, err := o.KubeCli.CoreV1().Pods(o.Namespace).List(metav1.ListOptions{
+	podList, err := kubeCli.CoreV1().Pods(namespace).List(metav1.ListOptions{
 		LabelSelector: label.New().Instance(tc.Name).TiDB().String(),
 	})
 	if err != nil {
-		return err
+		return nil, nil, err
 	}
 	svcName := tkctlUtil.GetTidbServiceName(tc.Name)
-	svc, err := o.KubeCli.CoreV1().Services(o.Namespace).Get(svcName, metav1.GetOptions{})
+	svc, err := kubeCli.CoreV1().Services(namespace).Get(svcName, metav1.GetOptions{})
 	if err != nil {
-		return err
+		return nil, nil, err
 	}
-	msg, err := renderTCUpgradeInfo(tc, set, podList, svc)
+	info, err := computeUpgradeInfo(tc, set, podList, svc)
 	if err != nil {
-		return err
+		return nil, nil, err
 	}
-	fmt.Fprint(o.Out, msg)
-	return nil
+	return tc, info, nil
+}
+
+func renderFromListers(tc *v1alpha1.TidbCluster, listers *watch.Listers) (string, error) {
+	set, err := listers.StatefulSets.StatefulSets(tc.Namespace).Get(controller.TiDBMemberName(tc.Name))
+	if err != nil {
+		return "", err
+	}
+	selector, err := label.New().Instance(tc.Name).TiDB().Selector()
+	if err != nil {
+		return "", err
+	}
+	podList, err := listers.PodList(tc.Namespace, selector)
+	if err != nil {
+		return "", err
+	}
+	svc, err := listers.Services.Services(tc.Namespace).Get(tkctlUtil.GetTidbServiceName(tc.Name))
+	if err != nil {
+		return "", err
+	}
+	info, err := computeUpgradeInfo(tc, set, podList, svc)
+	if err != nil {
+		return "", err
+	}
+	return renderUpgradeInfo(tc, info)
 }
 
 func getState(updateReplicas int32, ordinal int32, tc *v1alpha1.TidbCluster, pod *v1.Pod) string {
@@ -196,46 +273,85 @@ func getTiDBServerPort(svc *v1.Service) string {
 	return "<none>"
 }
 
-func renderTCUpgradeInfo(tc *v1alpha1.TidbCluster, set *apps.StatefulSet, podList *v1.PodList, svc *v1.Service) (string, error) {
+// upgradeInfo is the upgrade progress of the TiDB pods
+type upgradeInfo struct {
+	Status  v1alpha1.MemberPhase `json:"status"`
+	Image   *imageChange         `json:"image,omitempty"`
+	Members []upgradeMember      `json:"members"`
+}
+
+// upgradeComputed holds the upgrade info in the computed field of machine-readable output
+type upgradeComputed struct {
+	Upgrade *upgradeInfo `json:"upgrade"`
+}
+
+type imageChange struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+type upgradeMember struct {
+	Name   string `json:"name"`
+	State  string `json:"state"`
+	NodeIP string `json:"nodeIP"`
+	PodIP  string `json:"podIP"`
+	Port   string `json:"port"`
+}
+
+func computeUpgradeInfo(tc *v1alpha1.TidbCluster, set *apps.StatefulSet, podList *v1.PodList, svc *v1.Service) (*upgradeInfo, error) {
+	dbPhase := tc.Status.TiDB.Phase
+	info := &upgradeInfo{
+		Status:  dbPhase,
+		Members: []upgradeMember{},
+	}
+	if dbPhase == v1alpha1.UpgradePhase && len(podList.Items) != 0 {
+		info.Image = &imageChange{
+			From: podList.Items[0].Spec.Containers[0].Image,
+			To:   tc.Spec.TiDB.Image,
+		}
+	}
+	updateReplicas := set.Spec.UpdateStrategy.RollingUpdate.Partition
+	for _, pod := range podList.Items {
+		var state string
+		ordinal, err := util.GetOrdinalFromPodName(pod.Name)
+		if err != nil {
+			return nil, err
+		}
+		if dbPhase == v1alpha1.UpgradePhase {
+			state = getState(*updateReplicas, ordinal, tc, &pod)
+		} else {
+			state = UPDATED
+		}
+		info.Members = append(info.Members, upgradeMember{
+			Name:   pod.Name,
+			State:  state,
+			NodeIP: pod.Status.HostIP,
+			PodIP:  pod.Status.PodIP,
+			Port:   getTiDBServerPort(svc),
+		})
+	}
+	return info, nil
+}
+
+func renderUpgradeInfo(tc *v1alpha1.TidbCluster, info *upgradeInfo) (string, error) {
 	return readable.TabbedString(func(out io.Writer) error {
 		w := readable.NewPrefixWriter(out)
-		dbPhase := tc.Status.TiDB.Phase
 		w.WriteLine(readable.LEVEL_0, "Name:\t%s", tc.Name)
 		w.WriteLine(readable.LEVEL_0, "Namespace:\t%s", tc.Namespace)
 		w.WriteLine(readable.LEVEL_0, "CreationTimestamp:\t%s", tc.CreationTimestamp)
-		w.WriteLine(readable.LEVEL_0, "Status:\t%s", dbPhase)
-		if dbPhase == v1alpha1.UpgradePhase {
-			if len(podList.Items) != 0 {
-				pod := podList.Items[0]
-				w.WriteLine(readable.LEVEL_0, "Image:\t%s ---> %s", pod.Spec.Containers[0].Image, tc.Spec.TiDB.Image)
-			}
+		w.WriteLine(readable.LEVEL_0, "Status:\t%s", info.Status)
+		if info.Image != nil {
+			w.WriteLine(readable.LEVEL_0, "Image:\t%s ---> %s", info.Image.From, info.Image.To)
 		}
 		{
 			w.WriteLine(readable.LEVEL_1, "Name\tState\tNodeIP\tPodIP\tPort\t")
 			w.WriteLine(readable.LEVEL_1, "----\t-----\t------\t-----\t----\t")
-			{
-				updateReplicas := set.Spec.UpdateStrategy.RollingUpdate.Partition
-
-				if len(podList.Items) != 0 {
-					for _, pod := range podList.Items {
-						var state string
-						ordinal, err := util.GetOrdinalFromPodName(pod.Name)
-						if err != nil {
-							return err
-						}
-						if dbPhase == v1alpha1.UpgradePhase {
-							state = getState(*updateReplicas, ordinal, tc, &pod)
-						} else {
-							state = UPDATED
-						}
-
-						port := getTiDBServerPort(svc)
-
-						w.WriteLine(readable.LEVEL_1, "%s\t%s\t%s\t%s\t%s\t", pod.Name, state, pod.Status.HostIP, pod.Status.PodIP, port)
-					}
-				} else {
-					w.WriteLine(readable.LEVEL_1, "no resource found")
+			if len(info.Members) != 0 {
+				for _, member := range info.Members {
+					w.WriteLine(readable.LEVEL_1, "%s\t%s\t%s\t%s\t%s\t", member.Name, member.State, member.NodeIP, member.PodIP, member.Port)
 				}
+			} else {
+				w.WriteLine(readable.LEVEL_1, "no resource found")
 			}
 		}
 		return nil
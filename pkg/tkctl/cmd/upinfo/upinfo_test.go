@@ -0,0 +1,74 @@
+// Copyright 2019. PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package upinfo
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/pingcap/tidb-operator/pkg/apis/pingcap.com/v1alpha1"
+	apps "k8s.io/api/apps/v1beta1"
+	"k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestComputeUpgradeInfo(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	tc := &v1alpha1.TidbCluster{ObjectMeta: metav1.ObjectMeta{Name: "demo", Namespace: "ns"}}
+	tc.Spec.TiDB.Image = "pingcap/tidb:v2.1.1"
+	tc.Status.TiDB.Phase = v1alpha1.UpgradePhase
+	tc.Status.TiDB.StatefulSet = &apps.StatefulSetStatus{UpdateRevision: "new"}
+	tc.Status.TiDB.Members = map[string]v1alpha1.TiDBMember{
+		"demo-tidb-1": {Name: "demo-tidb-1", Health: true},
+	}
+	partition := int32(1)
+	set := &apps.StatefulSet{}
+	set.Spec.UpdateStrategy.RollingUpdate = &apps.RollingUpdateStatefulSetStrategy{Partition: &partition}
+	newPod := func(name, revision string) v1.Pod {
+		return v1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:   name,
+				Labels: map[string]string{apps.ControllerRevisionHashLabelKey: revision},
+			},
+			Spec:   v1.PodSpec{Containers: []v1.Container{{Image: "pingcap/tidb:v2.1.0"}}},
+			Status: v1.PodStatus{HostIP: "172.16.0.1", PodIP: "10.1.0.1"},
+		}
+	}
+	podList := &v1.PodList{Items: []v1.Pod{
+		newPod("demo-tidb-0", "old"),
+		newPod("demo-tidb-1", "new"),
+		newPod("demo-tidb-2", "new"),
+	}}
+	svc := &v1.Service{Spec: v1.ServiceSpec{Ports: []v1.ServicePort{
+		{Name: "mysql-client", Port: 4000, Protocol: v1.ProtocolTCP},
+	}}}
+
+	info, err := computeUpgradeInfo(tc, set, podList, svc)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(info.Status).To(Equal(v1alpha1.UpgradePhase))
+	g.Expect(info.Image).To(Equal(&imageChange{From: "pingcap/tidb:v2.1.0", To: "pingcap/tidb:v2.1.1"}))
+	var states []string
+	for _, member := range info.Members {
+		states = append(states, member.State)
+		g.Expect(member.Port).To(Equal("4000/TCP"))
+	}
+	g.Expect(states).To(Equal([]string{WAITING, UPDATED, UPDATED}))
+
+	tc.Status.TiDB.Phase = v1alpha1.NormalPhase
+	info, err = computeUpgradeInfo(tc, set, podList, svc)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(info.Image).To(BeNil())
+	g.Expect(info.Members[0].State).To(Equal(UPDATED))
+}
@@ -29,6 +29,11 @@ const (
 		
 		By using a certain cluster, you may omit --tidbcluster option
 		in many control commands.
+
+		The selected tidb cluster is kept for each kubernetes context, switching
+		the kubernetes context (e.g. by 'kubectl config use-context') switches to
+		the tidb cluster selected in that context. The selected tidb cluster is
+		not used if --namespace is set to another namespace.
 `
 	useExample = `
 		# specify a tidb cluster to use
@@ -14,14 +14,12 @@
 package config
 
 import (
-	"io/ioutil"
 	"os"
 	"os/user"
 	"path/filepath"
 	"sync"
 
 	"github.com/golang/glog"
-	"gopkg.in/yaml.v2"
 	"k8s.io/cli-runtime/pkg/genericclioptions"
 	"k8s.io/cli-runtime/pkg/genericclioptions/resource"
 	"k8s.io/client-go/rest"
@@ -81,7 +79,8 @@ func NewTkcContext(kubeFlags *genericclioptions.ConfigFlags, tkcOptions *TkcOpti
 }
 
 // ToTkcConfigLoader create the tkc client config for tidb cluster which overrides
-// the tidb cluster context and namespace to the raw kubectl config
+// the tidb cluster namespace to the raw kubectl config, the kubernetes context of
+// kubectl is respected and the tidb cluster selected in that context is used
 func (c *TkcContext) ToTkcClientConfig() (*TkcClientConfig, error) {
 	if c.clientConfig != nil {
 		return c.clientConfig, nil
@@ -95,25 +94,6 @@ func (c *TkcContext) ToTkcClientConfig() (*TkcClientConfig, error) {
 		return c.clientConfig, nil
 	}
 
-	// try loading tidb cluster config
-	tcConfigFile, err := tcConfigLocation()
-	if err != nil {
-		glog.V(4).Info("Error getting tidb cluster config file location")
-	} else {
-		tcConfig, err := LoadFile(tcConfigFile)
-		if err != nil {
-			glog.V(4).Info("Error reading tidb cluster config file")
-			c.TidbClusterConfig = &TidbClusterConfig{}
-		} else {
-			c.TidbClusterConfig = tcConfig
-		}
-	}
-
-	// override tidb cluster name from command line
-	if len(c.TkcOptions.TidbClusterName) > 0 {
-		c.TidbClusterConfig.ClusterName = c.TkcOptions.TidbClusterName
-	}
-
 	loadingRules := clientcmd.NewDefaultClientConfigLoadingRules()
 	loadingRules.DefaultClientConfig = &clientcmd.DefaultClientConfig
 	if c.KubeConfig != nil {
@@ -124,6 +104,17 @@ func (c *TkcContext) ToTkcClientConfig() (*TkcClientConfig, error) {
 		return nil, err
 	}
 
+	// the kubernetes context is always decided by kubectl flags and config
+	kubeContext := mergedConfig.CurrentContext
+	if c.Context != nil && len(*c.Context) > 0 {
+		kubeContext = *c.Context
+	}
+	namespace := ""
+	if c.Namespace != nil {
+		namespace = *c.Namespace
+	}
+	c.TidbClusterConfig = selectTidbCluster(loadTkcConfig(), kubeContext, namespace, c.TkcOptions.TidbClusterName)
+
 	overrides := c.collectOverrides()
 
 	var kubeConfig clientcmd.ClientConfig
@@ -168,8 +159,9 @@ func (c *TkcContext) ToKubectlRestConfig() (*rest.Config, error) {
 	return c.ConfigFlags.ToRESTConfig()
 }
 
-// SwitchTidbCluster store current tidb cluster configuration to local file,
-// this action will not affect the configured context and namespace.
+// SwitchTidbCluster store current tidb cluster configuration of the kubernetes context to local file,
+// the tidb clusters selected in other contexts are kept, and the configured context and namespace
+// of kubectl are not affected.
 func (c *TkcContext) SwitchTidbCluster(context, namespace, clusterName string) error {
 	tcConfigFile, err := tcConfigLocation()
 	if err != nil {
@@ -181,16 +173,47 @@ func (c *TkcContext) SwitchTidbCluster(context, namespace, clusterName string) e
 			return err
 		}
 	}
-	tcConfig := &TidbClusterConfig{
-		KubeContext: context,
-		Namespace:   namespace,
-		ClusterName: clusterName,
+	tkcConfig, err := LoadFile(tcConfigFile)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			glog.V(4).Infof("Error reading tidb cluster config file, overwriting it: %v", err)
+		}
+		tkcConfig = &TkcConfig{}
+	}
+	tkcConfig.SetTidbCluster(context, namespace, clusterName)
+	return WriteFile(tkcConfig, tcConfigFile)
+}
+
+// loadTkcConfig loads the local config file, an empty config is returned if the file can't be read
+func loadTkcConfig() *TkcConfig {
+	tcConfigFile, err := tcConfigLocation()
+	if err != nil {
+		glog.V(4).Info("Error getting tidb cluster config file location")
+		return &TkcConfig{}
 	}
-	content, err := yaml.Marshal(tcConfig)
+	tkcConfig, err := LoadFile(tcConfigFile)
 	if err != nil {
-		return err
+		glog.V(4).Info("Error reading tidb cluster config file")
+		return &TkcConfig{}
+	}
+	return tkcConfig
+}
+
+// selectTidbCluster decides the tidb cluster to use in the kubernetes context. The tidb cluster
+// selected by 'tkc use' in the context is used unless the namespace is overridden to another one
+// by the namespace flag, and the tidb cluster name flag has the highest priority.
+func selectTidbCluster(tkcConfig *TkcConfig, kubeContext, namespace, clusterName string) *TidbClusterConfig {
+	tcConfig := &TidbClusterConfig{KubeContext: kubeContext}
+	if selected := tkcConfig.TidbCluster(kubeContext); selected != nil {
+		if len(namespace) == 0 || namespace == selected.Namespace {
+			tcConfig.Namespace = selected.Namespace
+			tcConfig.ClusterName = selected.ClusterName
+		}
+	}
+	if len(clusterName) > 0 {
+		tcConfig.ClusterName = clusterName
 	}
-	return ioutil.WriteFile(tcConfigFile, content, 0644)
+	return tcConfig
 }
 
 func (c *TkcContext) collectOverrides() *clientcmd.ConfigOverrides {
@@ -198,11 +221,10 @@ func (c *TkcContext) collectOverrides() *clientcmd.ConfigOverrides {
 	// calculate flag and config overrides
 	overrides := &clientcmd.ConfigOverrides{ClusterDefaults: clientcmd.ClusterDefaults}
 
-	// bind tidb cluster config and flags, command line flags has higher priority
+	// bind tidb cluster config and flags, command line flags has higher priority,
+	// the context is never overridden by the tidb cluster config
 	if c.Context != nil {
 		overrides.CurrentContext = *c.Context
-	} else if c.TidbClusterConfig != nil && len(c.TidbClusterConfig.KubeContext) > 0 {
-		overrides.CurrentContext = c.TidbClusterConfig.KubeContext
 	}
 	if c.Namespace != nil && len(*c.Namespace) > 0 {
 		overrides.Context.Namespace = *c.Namespace
@@ -0,0 +1,162 @@
+// Copyright 2019. PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "github.com/onsi/gomega"
+)
+
+func TestLoad(t *testing.T) {
+	g := NewGomegaWithT(t)
+	type testcase struct {
+		name     string
+		content  string
+		expected *TkcConfig
+	}
+	tests := []testcase{
+		{
+			name:     "empty",
+			content:  "",
+			expected: &TkcConfig{},
+		},
+		{
+			name: "per kubernetes context",
+			content: `contexts:
+  ctx-a:
+    kubecontext: ctx-a
+    namespace: foo
+    clustername: demo
+  ctx-b:
+    kubecontext: ctx-b
+    namespace: bar
+    clustername: demo2
+`,
+			expected: &TkcConfig{Contexts: map[string]*TidbClusterConfig{
+				"ctx-a": {KubeContext: "ctx-a", Namespace: "foo", ClusterName: "demo"},
+				"ctx-b": {KubeContext: "ctx-b", Namespace: "bar", ClusterName: "demo2"},
+			}},
+		},
+		{
+			name: "legacy single tidb cluster",
+			content: `kubecontext: ctx-a
+namespace: foo
+clustername: demo
+`,
+			expected: &TkcConfig{Contexts: map[string]*TidbClusterConfig{
+				"ctx-a": {KubeContext: "ctx-a", Namespace: "foo", ClusterName: "demo"},
+			}},
+		},
+		{
+			name: "legacy tidb cluster selected again in its context",
+			content: `kubecontext: ctx-a
+namespace: foo
+clustername: demo
+contexts:
+  ctx-a:
+    kubecontext: ctx-a
+    namespace: bar
+    clustername: demo2
+`,
+			expected: &TkcConfig{Contexts: map[string]*TidbClusterConfig{
+				"ctx-a": {KubeContext: "ctx-a", Namespace: "bar", ClusterName: "demo2"},
+			}},
+		},
+	}
+	for _, test := range tests {
+		t.Log(test.name)
+		cfg, err := Load(test.content)
+		g.Expect(err).NotTo(HaveOccurred())
+		g.Expect(cfg).To(Equal(test.expected))
+	}
+}
+
+func TestWriteFile(t *testing.T) {
+	g := NewGomegaWithT(t)
+	dir, err := ioutil.TempDir("", "tkctl-config")
+	g.Expect(err).NotTo(HaveOccurred())
+	defer os.RemoveAll(dir)
+	filename := filepath.Join(dir, "tidbcluster-config")
+
+	cfg := &TkcConfig{}
+	cfg.SetTidbCluster("ctx-a", "foo", "demo")
+	cfg.SetTidbCluster("ctx-b", "bar", "demo2")
+	// selecting again replaces the tidb cluster of the context only
+	cfg.SetTidbCluster("ctx-a", "foo", "demo3")
+	g.Expect(WriteFile(cfg, filename)).To(Succeed())
+
+	loaded, err := LoadFile(filename)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(loaded).To(Equal(cfg))
+	g.Expect(loaded.TidbCluster("ctx-a")).To(Equal(&TidbClusterConfig{KubeContext: "ctx-a", Namespace: "foo", ClusterName: "demo3"}))
+	g.Expect(loaded.TidbCluster("ctx-c")).To(BeNil())
+}
+
+func TestSelectTidbCluster(t *testing.T) {
+	g := NewGomegaWithT(t)
+	cfg := &TkcConfig{}
+	cfg.SetTidbCluster("ctx-a", "foo", "demo")
+	type testcase struct {
+		name        string
+		kubeContext string
+		namespace   string
+		clusterName string
+		expected    *TidbClusterConfig
+	}
+	tests := []testcase{
+		{
+			name:        "selected in context",
+			kubeContext: "ctx-a",
+			expected:    &TidbClusterConfig{KubeContext: "ctx-a", Namespace: "foo", ClusterName: "demo"},
+		},
+		{
+			name:        "not selected in context",
+			kubeContext: "ctx-b",
+			expected:    &TidbClusterConfig{KubeContext: "ctx-b"},
+		},
+		{
+			name:        "same namespace flag",
+			kubeContext: "ctx-a",
+			namespace:   "foo",
+			expected:    &TidbClusterConfig{KubeContext: "ctx-a", Namespace: "foo", ClusterName: "demo"},
+		},
+		{
+			name:        "another namespace flag",
+			kubeContext: "ctx-a",
+			namespace:   "bar",
+			expected:    &TidbClusterConfig{KubeContext: "ctx-a"},
+		},
+		{
+			name:        "tidb cluster flag",
+			kubeContext: "ctx-a",
+			clusterName: "demo2",
+			expected:    &TidbClusterConfig{KubeContext: "ctx-a", Namespace: "foo", ClusterName: "demo2"},
+		},
+		{
+			name:        "tidb cluster and namespace flags",
+			kubeContext: "ctx-b",
+			namespace:   "bar",
+			clusterName: "demo2",
+			expected:    &TidbClusterConfig{KubeContext: "ctx-b", ClusterName: "demo2"},
+		},
+	}
+	for _, test := range tests {
+		t.Log(test.name)
+		g.Expect(selectTidbCluster(cfg, test.kubeContext, test.namespace, test.clusterName)).To(Equal(test.expected))
+	}
+}
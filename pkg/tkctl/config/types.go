@@ -14,31 +14,85 @@
 package config
 
 import (
-	"gopkg.in/yaml.v2"
 	"io/ioutil"
+
+	"gopkg.in/yaml.v2"
 )
 
 // TidbClusterConfig indicates which tidb cluster to use.
 type TidbClusterConfig struct {
-	KubeContext string `json:"context,omitempty"`
-	Namespace   string `json:"namespace,omitempty"`
-	ClusterName string `json:"clusterName,omitempty"`
+	KubeContext string `json:"context,omitempty" yaml:"kubecontext,omitempty"`
+	Namespace   string `json:"namespace,omitempty" yaml:"namespace,omitempty"`
+	ClusterName string `json:"clusterName,omitempty" yaml:"clustername,omitempty"`
+}
+
+// TkcConfig is the local config of tkctl, it keeps the tidb cluster selected by 'tkc use'
+// in every kubernetes context.
+type TkcConfig struct {
+	// Contexts maps the name of a kubernetes context to the tidb cluster selected in it
+	Contexts map[string]*TidbClusterConfig `json:"contexts,omitempty" yaml:"contexts,omitempty"`
+}
+
+// legacyTkcConfig is the config file written by the older tkctl, which keeps a single
+// tidb cluster for all the kubernetes contexts
+type legacyTkcConfig struct {
+	TkcConfig         `yaml:",inline"`
+	TidbClusterConfig `yaml:",inline"`
+}
+
+// TidbCluster returns the tidb cluster selected in the kubernetes context, nil if not selected
+func (c *TkcConfig) TidbCluster(kubeContext string) *TidbClusterConfig {
+	if c.Contexts == nil {
+		return nil
+	}
+	tc, ok := c.Contexts[kubeContext]
+	if !ok || tc == nil || len(tc.ClusterName) == 0 {
+		return nil
+	}
+	return tc
+}
+
+// SetTidbCluster selects the tidb cluster in the kubernetes context
+func (c *TkcConfig) SetTidbCluster(kubeContext, namespace, clusterName string) {
+	if c.Contexts == nil {
+		c.Contexts = map[string]*TidbClusterConfig{}
+	}
+	c.Contexts[kubeContext] = &TidbClusterConfig{
+		KubeContext: kubeContext,
+		Namespace:   namespace,
+		ClusterName: clusterName,
+	}
 }
 
-func Load(s string) (*TidbClusterConfig, error) {
-	cfg := &TidbClusterConfig{}
+func Load(s string) (*TkcConfig, error) {
+	cfg := &legacyTkcConfig{}
 
 	err := yaml.Unmarshal([]byte(s), cfg)
 	if err != nil {
 		return nil, err
 	}
-	return cfg, nil
+	// the tidb cluster selected by the older tkctl is migrated to its kubernetes context,
+	// unless another tidb cluster has been selected in that context
+	legacy := cfg.TidbClusterConfig
+	if len(legacy.ClusterName) > 0 && cfg.TidbCluster(legacy.KubeContext) == nil {
+		cfg.SetTidbCluster(legacy.KubeContext, legacy.Namespace, legacy.ClusterName)
+	}
+	return &cfg.TkcConfig, nil
 }
 
-func LoadFile(filename string) (*TidbClusterConfig, error) {
+func LoadFile(filename string) (*TkcConfig, error) {
 	c, err := ioutil.ReadFile(filename)
 	if err != nil {
 		return nil, err
 	}
 	return Load(string(c))
 }
+
+// WriteFile writes the config to the file, the directory of the file must exist
+func WriteFile(cfg *TkcConfig, filename string) error {
+	content, err := yaml.Marshal(cfg)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(filename, content, 0644)
+}
@@ -0,0 +1,114 @@
+// Copyright 2019. PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package debug
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// the ContainerStatus method of the CRI runtime service, runtimes serve either or both of the versions
+var containerStatusMethods = []string{
+	"/runtime.v1.RuntimeService/ContainerStatus",
+	"/runtime.v1alpha2.RuntimeService/ContainerStatus",
+}
+
+// containerStatusRequest is the ContainerStatusRequest message of the CRI api
+type containerStatusRequest struct {
+	ContainerID string `protobuf:"bytes,1,opt,name=container_id,json=containerId,proto3"`
+	Verbose     bool   `protobuf:"varint,2,opt,name=verbose,proto3"`
+}
+
+func (m *containerStatusRequest) Reset()         { *m = containerStatusRequest{} }
+func (m *containerStatusRequest) String() string { return fmt.Sprintf("%+v", *m) }
+func (*containerStatusRequest) ProtoMessage()    {}
+
+// containerStatusResponse is the ContainerStatusResponse message of the CRI api, only the verbose info is decoded
+type containerStatusResponse struct {
+	Info map[string]string `protobuf:"bytes,2,rep,name=info,proto3" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"bytes,2,opt,name=value,proto3"`
+}
+
+func (m *containerStatusResponse) Reset()         { *m = containerStatusResponse{} }
+func (m *containerStatusResponse) String() string { return fmt.Sprintf("%+v", *m) }
+func (*containerStatusResponse) ProtoMessage()    {}
+
+// criClient resolves the containers through the CRI endpoint of containerd or CRI-O
+type criClient struct {
+	conn *grpc.ClientConn
+}
+
+func newCRIClient(ctx context.Context, socket string, timeout time.Duration) (*criClient, error) {
+	socket = strings.TrimPrefix(socket, "unix://")
+	dialCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	conn, err := grpc.DialContext(dialCtx, socket,
+		grpc.WithInsecure(),
+		grpc.WithBlock(),
+		grpc.WithDialer(func(addr string, timeout time.Duration) (net.Conn, error) {
+			return net.DialTimeout("unix", addr, timeout)
+		}))
+	if err != nil {
+		return nil, fmt.Errorf("cannot connect to the CRI socket %s: %v", socket, err)
+	}
+	return &criClient{conn: conn}, nil
+}
+
+// containerPID returns the pid of the container's init process on the node
+func (c *criClient) containerPID(ctx context.Context, containerID string) (int, error) {
+	req := &containerStatusRequest{ContainerID: containerID, Verbose: true}
+	var err error
+	for _, method := range containerStatusMethods {
+		resp := &containerStatusResponse{}
+		err = c.conn.Invoke(ctx, method, req, resp)
+		if status.Code(err) == codes.Unimplemented {
+			continue
+		}
+		if err != nil {
+			return 0, fmt.Errorf("cannot get the status of container %s: %v", containerID, err)
+		}
+		return pidFromVerboseInfo(resp.Info)
+	}
+	return 0, fmt.Errorf("cannot get the status of container %s: %v", containerID, err)
+}
+
+func (c *criClient) Close() error {
+	return c.conn.Close()
+}
+
+// pidFromVerboseInfo extracts the pid from the verbose info of the container status,
+// both containerd and CRI-O report it in the json under the "info" key
+func pidFromVerboseInfo(info map[string]string) (int, error) {
+	raw, ok := info["info"]
+	if !ok {
+		return 0, fmt.Errorf("the runtime does not report the verbose info of the container")
+	}
+	var verbose struct {
+		Pid int `json:"pid"`
+	}
+	if err := json.Unmarshal([]byte(raw), &verbose); err != nil {
+		return 0, fmt.Errorf("cannot decode the verbose info of the container: %v", err)
+	}
+	if verbose.Pid <= 0 {
+		return 0, fmt.Errorf("the container is not running")
+	}
+	return verbose.Pid, nil
+}
@@ -0,0 +1,92 @@
+// Copyright 2019. PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package debug
+
+import (
+	"context"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// serveCRI serves the ContainerStatus method of the CRI runtime service in the given version on a unix socket
+func serveCRI(g *GomegaWithT, version string, infos map[string]map[string]string) (string, func()) {
+	dir, err := ioutil.TempDir("", "cri")
+	g.Expect(err).NotTo(HaveOccurred())
+	socket := filepath.Join(dir, "cri.sock")
+	lis, err := net.Listen("unix", socket)
+	g.Expect(err).NotTo(HaveOccurred())
+
+	server := grpc.NewServer()
+	server.RegisterService(&grpc.ServiceDesc{
+		ServiceName: "runtime." + version + ".RuntimeService",
+		HandlerType: (*interface{})(nil),
+		Methods: []grpc.MethodDesc{{
+			MethodName: "ContainerStatus",
+			Handler: func(srv interface{}, ctx context.Context, dec func(interface{}) error, _ grpc.UnaryServerInterceptor) (interface{}, error) {
+				req := &containerStatusRequest{}
+				if err := dec(req); err != nil {
+					return nil, err
+				}
+				info, ok := infos[req.ContainerID]
+				if !ok {
+					return nil, status.Errorf(codes.NotFound, "container %s not found", req.ContainerID)
+				}
+				if !req.Verbose {
+					info = nil
+				}
+				return &containerStatusResponse{Info: info}, nil
+			},
+		}},
+	}, struct{}{})
+	go server.Serve(lis)
+	return "unix://" + socket, func() {
+		server.Stop()
+		os.RemoveAll(dir)
+	}
+}
+
+func TestCRIClientContainerPID(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	infos := map[string]map[string]string{
+		"running": {"info": `{"sandboxID":"sandbox","pid":1234}`},
+		"exited":  {"info": `{"sandboxID":"sandbox","pid":0}`},
+		"unknown": {},
+	}
+	for _, version := range []string{"v1", "v1alpha2"} {
+		socket, stop := serveCRI(g, version, infos)
+		client, err := newCRIClient(context.Background(), socket, 5*time.Second)
+		g.Expect(err).NotTo(HaveOccurred())
+
+		pid, err := client.containerPID(context.Background(), "running")
+		g.Expect(err).NotTo(HaveOccurred(), version)
+		g.Expect(pid).To(Equal(1234))
+		for _, id := range []string{"exited", "unknown", "absent"} {
+			_, err = client.containerPID(context.Background(), id)
+			g.Expect(err).To(HaveOccurred(), id)
+		}
+
+		client.Close()
+		stop()
+	}
+}
@@ -23,12 +23,12 @@ import (
 	"github.com/docker/docker/pkg/jsonmessage"
 	"github.com/spf13/cobra"
 	"io"
-	"strings"
+	"time"
 )
 
 const (
-	defaultDockerSocket   = "unix:///var/run/docker.sock"
-	dockerContainerPrefix = "docker://"
+	defaultDockerSocket = "unix:///var/run/docker.sock"
+	criTimeout          = 10 * time.Second
 
 	CAP_SYS_PTRACE = "SYS_PTRACE"
 	CAP_SYS_ADMIN  = "SYS_ADMIN"
@@ -47,6 +47,7 @@ type Launcher struct {
 	targetContainerID string
 	image             string
 	dockerSocket      string
+	criSocket         string
 	ctx               context.Context
 
 	privileged bool
@@ -79,15 +80,29 @@ func NewLauncherCmd(streams IOStreams) *cobra.Command {
 		"debug container image")
 	cmd.Flags().StringVar(&launcher.dockerSocket, "docker-socket", launcher.dockerSocket,
 		"docker socket to bind")
+	cmd.Flags().StringVar(&launcher.criSocket, "cri-socket", launcher.criSocket,
+		"CRI socket of containerd or CRI-O, default to the socket of the runtime of the target container")
 	cmd.Flags().BoolVar(&launcher.privileged, "privileged", launcher.privileged,
 		"whether launch container in privileged mode (full container capabilities)")
 	return cmd
 }
 
-// Run launches the debug container and attach it.
+// Run launches the debug container through the runtime of the target container.
+func (l *Launcher) Run(args []string) error {
+	runtime, containerID, err := ParseContainerID(l.targetContainerID)
+	if err != nil {
+		return err
+	}
+	if IsCRIRuntime(runtime) {
+		return l.runWithCRI(runtime, containerID, args)
+	}
+	return l.runWithDocker(containerID, args)
+}
+
+// runWithDocker launches the debug container and attach it.
 // We could alternatively just run docker exec in command line, but this brings shell and docker client to the
 // image, which is unwanted.
-func (l *Launcher) Run(args []string) error {
+func (l *Launcher) runWithDocker(targetContainerID string, args []string) error {
 	client, err := dockerclient.NewClient(l.dockerSocket, "", nil, nil)
 	if err != nil {
 		return err
@@ -97,7 +112,7 @@ func (l *Launcher) Run(args []string) error {
 	if err != nil {
 		return err
 	}
-	resp, err := l.createContainer(args)
+	resp, err := l.createContainer(targetContainerID, args)
 	if err != nil {
 		return err
 	}
@@ -115,6 +130,30 @@ func (l *Launcher) Run(args []string) error {
 	return nil
 }
 
+// runWithCRI resolves the target container through the CRI socket of containerd or CRI-O and runs the command
+// in its namespaces. Unlike docker, CRI has no way to run a container outside of a pod sandbox, so the launcher
+// itself runs in the debug image on the node of the target container, see the debug command of tkctl.
+func (l *Launcher) runWithCRI(runtime, containerID string, command []string) error {
+	socket := l.criSocket
+	if len(socket) == 0 {
+		socket = DefaultRuntimeSocket(runtime)
+	}
+	client, err := newCRIClient(l.ctx, socket, criTimeout)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+	ctx, cancel := context.WithTimeout(l.ctx, criTimeout)
+	defer cancel()
+	pid, err := client.containerPID(ctx, containerID)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(l.Out, "joining the namespaces of %s container %s, the root filesystem of the container is /proc/%d/root\n",
+		runtime, containerID, pid)
+	return runInNamespaces(pid, command, l.IOStreams)
+}
+
 func (l *Launcher) pullImage() error {
 	out, err := l.client.ImagePull(l.ctx, l.image, types.ImagePullOptions{})
 	if err != nil {
@@ -126,11 +165,7 @@ func (l *Launcher) pullImage() error {
 	return nil
 }
 
-func (l *Launcher) createContainer(command []string) (*container.ContainerCreateCreatedBody, error) {
-	if !strings.HasPrefix(l.targetContainerID, dockerContainerPrefix) {
-		return nil, fmt.Errorf("Only docker containers are supported now")
-	}
-	dockerContainerID := l.targetContainerID[len(dockerContainerPrefix):]
+func (l *Launcher) createContainer(dockerContainerID string, command []string) (*container.ContainerCreateCreatedBody, error) {
 	config := &container.Config{
 		Entrypoint: strslice.StrSlice(command),
 		Image:      l.image,
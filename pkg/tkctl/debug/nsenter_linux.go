@@ -0,0 +1,61 @@
+// Copyright 2019. PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package debug
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+
+	"golang.org/x/sys/unix"
+)
+
+// the namespaces of the target container joined by the debug process, the mount namespace is
+// kept so the debug process runs with the tools of the debug image
+var targetNamespaces = []struct {
+	name string
+	flag int
+}{
+	{"ipc", unix.CLONE_NEWIPC},
+	{"uts", unix.CLONE_NEWUTS},
+	{"net", unix.CLONE_NEWNET},
+	{"pid", unix.CLONE_NEWPID},
+}
+
+// runInNamespaces runs the command in the namespaces of the process on the node and waits for it to exit
+func runInNamespaces(pid int, command []string, streams IOStreams) error {
+	if len(command) == 0 {
+		return fmt.Errorf("no command to run")
+	}
+	// the namespaces are per thread and inherited by the forked process, the thread is never
+	// unlocked so it is terminated rather than reused by other goroutines
+	runtime.LockOSThread()
+	for _, ns := range targetNamespaces {
+		f, err := os.Open(fmt.Sprintf("/proc/%d/ns/%s", pid, ns.name))
+		if err != nil {
+			return err
+		}
+		err = unix.Setns(int(f.Fd()), ns.flag)
+		f.Close()
+		if err != nil {
+			return fmt.Errorf("cannot join the %s namespace of process %d: %v", ns.name, pid, err)
+		}
+	}
+	cmd := exec.Command(command[0], command[1:]...)
+	cmd.Stdin = streams.In
+	cmd.Stdout = streams.Out
+	cmd.Stderr = streams.ErrOut
+	return cmd.Run()
+}
@@ -0,0 +1,26 @@
+// Copyright 2019. PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build !linux
+// +build !linux
+
+package debug
+
+import (
+	"fmt"
+	"runtime"
+)
+
+func runInNamespaces(pid int, command []string, streams IOStreams) error {
+	return fmt.Errorf("joining the namespaces of a container is not supported on %s", runtime.GOOS)
+}
@@ -0,0 +1,59 @@
+// Copyright 2019. PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package debug
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	// RuntimeDocker is the runtime of the containers whose id is prefixed by docker://
+	RuntimeDocker = "docker"
+	// RuntimeContainerd is the runtime of the containers whose id is prefixed by containerd://
+	RuntimeContainerd = "containerd"
+	// RuntimeCRIO is the runtime of the containers whose id is prefixed by cri-o://
+	RuntimeCRIO = "cri-o"
+)
+
+var defaultRuntimeSockets = map[string]string{
+	RuntimeDocker:     "/var/run/docker.sock",
+	RuntimeContainerd: "/run/containerd/containerd.sock",
+	RuntimeCRIO:       "/var/run/crio/crio.sock",
+}
+
+// ParseContainerID splits the container id reported in the pod status, e.g. containerd://ID,
+// into the container runtime and the id known by the runtime
+func ParseContainerID(containerID string) (runtime string, id string, err error) {
+	parts := strings.SplitN(containerID, "://", 2)
+	if len(parts) != 2 || len(parts[1]) == 0 {
+		return "", "", fmt.Errorf("invalid container id %q", containerID)
+	}
+	runtime, id = parts[0], parts[1]
+	if _, ok := defaultRuntimeSockets[runtime]; !ok {
+		return "", "", fmt.Errorf("unsupported container runtime %q of container %s, only docker, containerd and cri-o are supported",
+			runtime, id)
+	}
+	return runtime, id, nil
+}
+
+// DefaultRuntimeSocket returns the default socket path of the container runtime on kubernetes nodes
+func DefaultRuntimeSocket(runtime string) string {
+	return defaultRuntimeSockets[runtime]
+}
+
+// IsCRIRuntime returns whether the debug container is launched through CRI for the container runtime
+func IsCRIRuntime(runtime string) bool {
+	return runtime == RuntimeContainerd || runtime == RuntimeCRIO
+}
@@ -0,0 +1,48 @@
+// Copyright 2019. PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package debug
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+)
+
+func TestParseContainerID(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	tests := []struct {
+		containerID string
+		runtime     string
+		id          string
+		cri         bool
+	}{
+		{"docker://abc", RuntimeDocker, "abc", false},
+		{"containerd://abc", RuntimeContainerd, "abc", true},
+		{"cri-o://abc", RuntimeCRIO, "abc", true},
+	}
+	for _, test := range tests {
+		runtime, id, err := ParseContainerID(test.containerID)
+		g.Expect(err).NotTo(HaveOccurred())
+		g.Expect(runtime).To(Equal(test.runtime))
+		g.Expect(id).To(Equal(test.id))
+		g.Expect(IsCRIRuntime(runtime)).To(Equal(test.cri))
+		g.Expect(DefaultRuntimeSocket(runtime)).NotTo(BeEmpty())
+	}
+
+	for _, containerID := range []string{"", "abc", "docker://", "rkt://abc"} {
+		_, _, err := ParseContainerID(containerID)
+		g.Expect(err).To(HaveOccurred(), containerID)
+	}
+}
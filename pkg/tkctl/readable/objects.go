@@ -0,0 +1,237 @@
+// Copyright 2019. PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package readable
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/pingcap/pd/server/core"
+	"github.com/pingcap/tidb-operator/pkg/apis/pingcap.com/v1alpha1"
+	"github.com/pingcap/tidb-operator/pkg/label"
+	apps "k8s.io/api/apps/v1beta1"
+	"k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// ComputedField is the field attached to the objects of machine-readable output, it holds the
+// fields computed by tkctl, e.g. the store state of a TiKV pod or the upgrade state of a TiDB pod
+const ComputedField = "computed"
+
+var (
+	PodKind              = v1.SchemeGroupVersion.WithKind("Pod")
+	PersistentVolumeKind = v1.SchemeGroupVersion.WithKind("PersistentVolume")
+	TidbClusterKind      = v1alpha1.SchemeGroupVersion.WithKind("TidbCluster")
+)
+
+// ClusterComputed holds the computed fields of a tidb cluster
+type ClusterComputed struct {
+	PD   string `json:"pd"`
+	TiKV string `json:"tikv"`
+	TiDB string `json:"tidb"`
+}
+
+// PodComputed holds the computed fields of a pod of the tidb cluster
+type PodComputed struct {
+	Component string `json:"component"`
+	Ready     string `json:"ready"`
+	Status    string `json:"status"`
+	Restarts  int64  `json:"restarts"`
+	Memory    string `json:"memory"`
+	CPU       string `json:"cpu"`
+	// Member is the PD or TiDB member of the pod
+	Member *MemberComputed `json:"member,omitempty"`
+	// Store is the TiKV store of the pod
+	Store *StoreComputed `json:"store,omitempty"`
+}
+
+// MemberComputed is the PD or TiDB member of a pod
+type MemberComputed struct {
+	ID     string `json:"id,omitempty"`
+	Health bool   `json:"health"`
+	Leader bool   `json:"leader,omitempty"`
+}
+
+// StoreComputed is the TiKV store of a pod
+type StoreComputed struct {
+	ID          string `json:"id"`
+	State       string `json:"state"`
+	LeaderCount int32  `json:"leaderCount"`
+	RegionCount int32  `json:"regionCount"`
+	// Regions is the region distribution of the store reported by PD, only computed by 'get regions'
+	Regions *StoreRegionsComputed `json:"regions,omitempty"`
+	// Hotspots is the hot regions of the store reported by PD, only computed by 'get hotspots'
+	Hotspots *StoreHotspotsComputed `json:"hotspots,omitempty"`
+}
+
+// StoreRegionsComputed is the region distribution of a TiKV store, the sizes are in MiB
+type StoreRegionsComputed struct {
+	RegionCount int   `json:"regionCount"`
+	LeaderCount int   `json:"leaderCount"`
+	RegionSize  int64 `json:"regionSize"`
+	LeaderSize  int64 `json:"leaderSize"`
+	// LeaderRatio is the percentage of the leaders of all the regions held by the store
+	LeaderRatio float64 `json:"leaderRatio"`
+}
+
+// StoreHotspotsComputed is the hot read and write regions of a TiKV store
+type StoreHotspotsComputed struct {
+	Read  HotRegionsComputed `json:"read"`
+	Write HotRegionsComputed `json:"write"`
+}
+
+// HotRegionsComputed is the hot regions of a TiKV store in one direction, the flows are in bytes per second
+type HotRegionsComputed struct {
+	LeaderCount     int    `json:"leaderCount"`
+	LeaderFlowBytes uint64 `json:"leaderFlowBytes"`
+	PeerCount       int    `json:"peerCount"`
+	PeerFlowBytes   uint64 `json:"peerFlowBytes"`
+	// Regions is the ids of the hot regions led by the store, the hottest first
+	Regions []uint64 `json:"regions,omitempty"`
+}
+
+// ComputeCluster computes the ready status of the components, the same as the columns of the tidb cluster table
+func ComputeCluster(tc *v1alpha1.TidbCluster) *ClusterComputed {
+	return &ClusterComputed{
+		PD:   readyReplicas(tc.Status.PD.StatefulSet),
+		TiKV: readyReplicas(tc.Status.TiKV.StatefulSet),
+		TiDB: readyReplicas(tc.Status.TiDB.StatefulSet),
+	}
+}
+
+// ComputePod computes the status of the pod and correlates the pod with its member or store of the tidb cluster
+func ComputePod(tc *v1alpha1.TidbCluster, pod *v1.Pod) *PodComputed {
+	columns := basicPodColumns(pod)
+	computed := &PodComputed{
+		Component: pod.Labels[label.ComponentLabelKey],
+		Ready:     columns.Ready,
+		Status:    columns.Reason,
+		Restarts:  columns.Restarts,
+		Memory:    columns.MemInfo,
+		CPU:       columns.CPUInfo,
+	}
+	switch computed.Component {
+	case label.PDLabelVal:
+		if member, ok := tc.Status.PD.Members[pod.Name]; ok {
+			computed.Member = &MemberComputed{
+				ID:     member.ID,
+				Health: member.Health,
+				Leader: tc.Status.PD.Leader.Name == pod.Name,
+			}
+		}
+	case label.TiDBLabelVal:
+		if member, ok := tc.Status.TiDB.Members[pod.Name]; ok {
+			computed.Member = &MemberComputed{Health: member.Health}
+		}
+	case label.TiKVLabelVal:
+		for _, store := range tc.Status.TiKV.Stores {
+			if store.PodName == pod.Name {
+				computed.Store = &StoreComputed{
+					ID:          store.ID,
+					State:       store.State,
+					LeaderCount: store.LeaderCount,
+					RegionCount: store.RegionCount,
+				}
+				break
+			}
+		}
+	}
+	return computed
+}
+
+// ComputeStoreRegions computes the region distribution of the store from the region statistics of PD
+func ComputeStoreRegions(stats *core.RegionStats, storeID uint64) *StoreRegionsComputed {
+	computed := &StoreRegionsComputed{
+		RegionCount: stats.StorePeerCount[storeID],
+		LeaderCount: stats.StoreLeaderCount[storeID],
+		RegionSize:  stats.StorePeerSize[storeID],
+		LeaderSize:  stats.StoreLeaderSize[storeID],
+	}
+	if stats.Count > 0 {
+		computed.LeaderRatio = float64(computed.LeaderCount) * 100 / float64(stats.Count)
+	}
+	return computed
+}
+
+// ComputeStoreHotspots computes the hot regions of the store from the hot read and write regions of PD
+func ComputeStoreHotspots(read, write *core.StoreHotRegionInfos, storeID uint64) *StoreHotspotsComputed {
+	return &StoreHotspotsComputed{
+		Read:  computeHotRegions(read, storeID),
+		Write: computeHotRegions(write, storeID),
+	}
+}
+
+func computeHotRegions(infos *core.StoreHotRegionInfos, storeID uint64) HotRegionsComputed {
+	computed := HotRegionsComputed{}
+	if infos == nil {
+		return computed
+	}
+	if stat, ok := infos.AsLeader[storeID]; ok && stat != nil {
+		computed.LeaderCount = stat.RegionsCount
+		computed.LeaderFlowBytes = stat.TotalFlowBytes
+		regions := make(core.RegionsStat, len(stat.RegionsStat))
+		copy(regions, stat.RegionsStat)
+		sort.SliceStable(regions, func(i, j int) bool {
+			return regions[i].FlowBytes > regions[j].FlowBytes
+		})
+		for _, r := range regions {
+			computed.Regions = append(computed.Regions, r.RegionID)
+		}
+	}
+	if stat, ok := infos.AsPeer[storeID]; ok && stat != nil {
+		computed.PeerCount = stat.RegionsCount
+		computed.PeerFlowBytes = stat.TotalFlowBytes
+	}
+	return computed
+}
+
+// NewObject converts the object to an unstructured object of the given kind for machine-readable output,
+// the computed fields, if any, are attached as the ComputedField
+func NewObject(obj runtime.Object, kind schema.GroupVersionKind, computed interface{}) (*unstructured.Unstructured, error) {
+	content, err := runtime.DefaultUnstructuredConverter.ToUnstructured(obj)
+	if err != nil {
+		return nil, err
+	}
+	u := &unstructured.Unstructured{Object: content}
+	u.SetGroupVersionKind(kind)
+	if computed != nil {
+		computedContent, err := runtime.DefaultUnstructuredConverter.ToUnstructured(computed)
+		if err != nil {
+			return nil, err
+		}
+		u.Object[ComputedField] = computedContent
+	}
+	return u, nil
+}
+
+// NewList wraps the objects into a v1 List, which is how kubectl prints multiple objects
+func NewList(objs []*unstructured.Unstructured) *unstructured.UnstructuredList {
+	list := &unstructured.UnstructuredList{Object: map[string]interface{}{}}
+	list.SetAPIVersion("v1")
+	list.SetKind("List")
+	list.Items = []unstructured.Unstructured{}
+	for _, obj := range objs {
+		list.Items = append(list.Items, *obj)
+	}
+	return list
+}
+
+func readyReplicas(status *apps.StatefulSetStatus) string {
+	if status == nil {
+		return unset
+	}
+	return fmt.Sprintf("%d/%d", status.ReadyReplicas, status.Replicas)
+}
@@ -0,0 +1,218 @@
+// Copyright 2019. PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package readable
+
+import (
+	"bytes"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/pingcap/pd/server/core"
+	"github.com/pingcap/tidb-operator/pkg/apis/pingcap.com/v1alpha1"
+	"github.com/pingcap/tidb-operator/pkg/label"
+	apps "k8s.io/api/apps/v1beta1"
+	"k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func newTidbCluster() *v1alpha1.TidbCluster {
+	tc := &v1alpha1.TidbCluster{ObjectMeta: metav1.ObjectMeta{Name: "demo", Namespace: "ns"}}
+	tc.Status.PD.StatefulSet = &apps.StatefulSetStatus{Replicas: 3, ReadyReplicas: 2}
+	tc.Status.PD.Members = map[string]v1alpha1.PDMember{
+		"demo-pd-0": {Name: "demo-pd-0", ID: "1", Health: true},
+		"demo-pd-1": {Name: "demo-pd-1", ID: "2", Health: false},
+	}
+	tc.Status.PD.Leader = tc.Status.PD.Members["demo-pd-0"]
+	tc.Status.TiKV.Stores = map[string]v1alpha1.TiKVStore{
+		"4": {ID: "4", PodName: "demo-tikv-0", State: "Up", LeaderCount: 10, RegionCount: 30},
+		"5": {ID: "5", PodName: "demo-tikv-1", State: "Offline", LeaderCount: 0, RegionCount: 12},
+	}
+	tc.Status.TiDB.Members = map[string]v1alpha1.TiDBMember{
+		"demo-tidb-0": {Name: "demo-tidb-0", Health: true},
+	}
+	return tc
+}
+
+func newPod(name, component string) *v1.Pod {
+	return &v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: "ns",
+			Labels:    map[string]string{label.ComponentLabelKey: component},
+		},
+		Spec: v1.PodSpec{Containers: []v1.Container{{Name: component}}},
+		Status: v1.PodStatus{
+			Phase: v1.PodRunning,
+			ContainerStatuses: []v1.ContainerStatus{{
+				Name:         component,
+				Ready:        true,
+				RestartCount: 2,
+				State:        v1.ContainerState{Running: &v1.ContainerStateRunning{}},
+			}},
+		},
+	}
+}
+
+func TestComputeCluster(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	g.Expect(ComputeCluster(newTidbCluster())).To(Equal(&ClusterComputed{PD: "2/3", TiKV: unset, TiDB: unset}))
+}
+
+func TestComputePod(t *testing.T) {
+	g := NewGomegaWithT(t)
+	tc := newTidbCluster()
+
+	computed := ComputePod(tc, newPod("demo-pd-0", "pd"))
+	g.Expect(computed.Component).To(Equal("pd"))
+	g.Expect(computed.Ready).To(Equal("1/1"))
+	g.Expect(computed.Status).To(Equal("Running"))
+	g.Expect(computed.Restarts).To(Equal(int64(2)))
+	g.Expect(computed.Member).To(Equal(&MemberComputed{ID: "1", Health: true, Leader: true}))
+	g.Expect(ComputePod(tc, newPod("demo-pd-1", "pd")).Member).To(Equal(&MemberComputed{ID: "2"}))
+	g.Expect(ComputePod(tc, newPod("demo-pd-2", "pd")).Member).To(BeNil())
+
+	g.Expect(ComputePod(tc, newPod("demo-tikv-1", "tikv")).Store).To(Equal(&StoreComputed{
+		ID: "5", State: "Offline", LeaderCount: 0, RegionCount: 12,
+	}))
+	g.Expect(ComputePod(tc, newPod("demo-tikv-2", "tikv")).Store).To(BeNil())
+
+	computed = ComputePod(tc, newPod("demo-tidb-0", "tidb"))
+	g.Expect(computed.Member).To(Equal(&MemberComputed{Health: true}))
+	g.Expect(computed.Store).To(BeNil())
+}
+
+func TestComputeStoreRegions(t *testing.T) {
+	g := NewGomegaWithT(t)
+	stats := &core.RegionStats{
+		Count:            8,
+		StoreLeaderCount: map[uint64]int{4: 6, 5: 2},
+		StorePeerCount:   map[uint64]int{4: 8, 5: 8},
+		StoreLeaderSize:  map[uint64]int64{4: 600, 5: 200},
+		StorePeerSize:    map[uint64]int64{4: 800, 5: 800},
+	}
+
+	g.Expect(ComputeStoreRegions(stats, 4)).To(Equal(&StoreRegionsComputed{
+		RegionCount: 8, LeaderCount: 6, RegionSize: 800, LeaderSize: 600, LeaderRatio: 75,
+	}))
+	g.Expect(ComputeStoreRegions(stats, 6)).To(Equal(&StoreRegionsComputed{}))
+	g.Expect(ComputeStoreRegions(&core.RegionStats{}, 4)).To(Equal(&StoreRegionsComputed{}))
+}
+
+func TestComputeStoreHotspots(t *testing.T) {
+	g := NewGomegaWithT(t)
+	write := &core.StoreHotRegionInfos{
+		AsLeader: core.StoreHotRegionsStat{
+			4: &core.HotRegionsStat{TotalFlowBytes: 3072, RegionsCount: 2, RegionsStat: core.RegionsStat{
+				{RegionID: 10, FlowBytes: 1024},
+				{RegionID: 11, FlowBytes: 2048},
+			}},
+		},
+		AsPeer: core.StoreHotRegionsStat{
+			4: &core.HotRegionsStat{TotalFlowBytes: 3072, RegionsCount: 2},
+			5: &core.HotRegionsStat{TotalFlowBytes: 1024, RegionsCount: 1},
+		},
+	}
+
+	g.Expect(ComputeStoreHotspots(nil, write, 4)).To(Equal(&StoreHotspotsComputed{
+		Write: HotRegionsComputed{LeaderCount: 2, LeaderFlowBytes: 3072, PeerCount: 2, PeerFlowBytes: 3072, Regions: []uint64{11, 10}},
+	}))
+	g.Expect(ComputeStoreHotspots(nil, write, 5)).To(Equal(&StoreHotspotsComputed{
+		Write: HotRegionsComputed{PeerCount: 1, PeerFlowBytes: 1024},
+	}))
+	// the hot regions reported by PD are not reordered
+	g.Expect(write.AsLeader[4].RegionsStat[0].RegionID).To(Equal(uint64(10)))
+
+	pod := newPod("demo-tikv-0", "tikv")
+	computed := ComputePod(newTidbCluster(), pod)
+	computed.Store.Hotspots = ComputeStoreHotspots(nil, write, 4)
+	obj, err := NewObject(pod, PodKind, computed)
+	g.Expect(err).NotTo(HaveOccurred())
+	regions, _, _ := unstructured.NestedSlice(obj.Object, ComputedField, "store", "hotspots", "write", "regions")
+	g.Expect(regions).To(HaveLen(2))
+}
+
+func TestNewObject(t *testing.T) {
+	g := NewGomegaWithT(t)
+	tc := newTidbCluster()
+
+	obj, err := NewObject(newPod("demo-tikv-0", "tikv"), PodKind, ComputePod(tc, newPod("demo-tikv-0", "tikv")))
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(obj.GetKind()).To(Equal("Pod"))
+	g.Expect(obj.GetAPIVersion()).To(Equal("v1"))
+	g.Expect(obj.GetName()).To(Equal("demo-tikv-0"))
+	state, _, _ := unstructured.NestedString(obj.Object, ComputedField, "store", "state")
+	g.Expect(state).To(Equal("Up"))
+	leaders, _, _ := unstructured.NestedInt64(obj.Object, ComputedField, "store", "leaderCount")
+	g.Expect(leaders).To(Equal(int64(10)))
+
+	obj, err = NewObject(tc, TidbClusterKind, nil)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(obj.GetKind()).To(Equal("TidbCluster"))
+	g.Expect(obj.Object).NotTo(HaveKey(ComputedField))
+}
+
+func TestPrintFlags(t *testing.T) {
+	g := NewGomegaWithT(t)
+	tc := newTidbCluster()
+
+	var objs []*unstructured.Unstructured
+	for _, name := range []string{"demo-tikv-0", "demo-tikv-1"} {
+		pod := newPod(name, "tikv")
+		obj, err := NewObject(pod, PodKind, ComputePod(tc, pod))
+		g.Expect(err).NotTo(HaveOccurred())
+		objs = append(objs, obj)
+	}
+	list := NewList(objs)
+
+	tests := []struct {
+		output   string
+		expected string
+	}{
+		{
+			output:   "jsonpath={range .items[*]}{.metadata.name}={.computed.store.state}{\"\\n\"}{end}",
+			expected: "demo-tikv-0=Up\ndemo-tikv-1=Offline\n",
+		},
+		{
+			output:   "custom-columns=NAME:.metadata.name,STORE:.computed.store.id,LEADERS:.computed.store.leaderCount",
+			expected: "NAME          STORE   LEADERS\ndemo-tikv-0   4       10\ndemo-tikv-1   5       0\n",
+		},
+	}
+	for _, test := range tests {
+		p := NewPrintFlags()
+		p.OutputFormat = test.output
+		g.Expect(p.IsHumanReadable()).To(BeFalse())
+		printer, err := p.ToPrinter(false, false)
+		g.Expect(err).NotTo(HaveOccurred())
+		var buf bytes.Buffer
+		g.Expect(printer.PrintObj(list, &buf)).To(Succeed())
+		g.Expect(buf.String()).To(Equal(test.expected))
+	}
+
+	p := NewPrintFlags()
+	p.OutputFormat = "json"
+	printer, err := p.ToPrinter(false, false)
+	g.Expect(err).NotTo(HaveOccurred())
+	var buf bytes.Buffer
+	g.Expect(printer.PrintObj(list, &buf)).To(Succeed())
+	g.Expect(buf.String()).To(ContainSubstring(`"kind": "List"`))
+	g.Expect(buf.String()).To(ContainSubstring(`"state": "Offline"`))
+
+	p.OutputFormat = ""
+	g.Expect(p.IsHumanReadable()).To(BeTrue())
+	g.Expect(p.Validate()).To(Succeed())
+	p.OutputFormat = "wide"
+	g.Expect(p.Validate()).To(HaveOccurred())
+}
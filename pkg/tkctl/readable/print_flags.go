@@ -24,30 +24,47 @@ import (
 )
 
 type PrintFlags struct {
-	JSONYamlPrintFlags *genericclioptions.JSONYamlPrintFlags
-	OutputFormat       string
+	JSONYamlPrintFlags      *genericclioptions.JSONYamlPrintFlags
+	TemplatePrintFlags      *genericclioptions.KubeTemplatePrintFlags
+	CustomColumnsPrintFlags *kubeprinters.CustomColumnsPrintFlags
+	OutputFormat            string
 }
 
 func NewPrintFlags() *PrintFlags {
 	return &PrintFlags{
-		JSONYamlPrintFlags: genericclioptions.NewJSONYamlPrintFlags(),
+		JSONYamlPrintFlags:      genericclioptions.NewJSONYamlPrintFlags(),
+		TemplatePrintFlags:      genericclioptions.NewKubeTemplatePrintFlags(),
+		CustomColumnsPrintFlags: kubeprinters.NewCustomColumnsPrintFlags(),
 	}
 }
 
 func (p *PrintFlags) AddFlags(cmd *cobra.Command) {
 	cmd.Flags().StringVarP(&p.OutputFormat, "output", "o", p.OutputFormat,
-		"Output format. json|yaml|default.")
+		"Output format. One of: default|json|yaml|jsonpath=...|jsonpath-file=...|go-template=...|"+
+			"go-template-file=...|custom-columns=...|custom-columns-file=...")
 	p.JSONYamlPrintFlags.AddFlags(cmd)
+	p.TemplatePrintFlags.AddFlags(cmd)
+	p.CustomColumnsPrintFlags.AddFlags(cmd)
+	cmd.Flags().BoolVar(&p.CustomColumnsPrintFlags.NoHeaders, "no-headers", p.CustomColumnsPrintFlags.NoHeaders,
+		"When using the custom-columns output format, don't print headers.")
 }
 
-func (p *PrintFlags) ToPrinter(withKind, withNamespace bool) (printers.ResourcePrinter, error) {
+// IsHumanReadable returns whether the output is the default human readable format,
+// otherwise the output is machine-readable and the objects should be built by NewObject
+func (p *PrintFlags) IsHumanReadable() bool {
 	output := strings.ToLower(p.OutputFormat)
-	if output == "json" || output == "yaml" {
-		printer, err := p.JSONYamlPrintFlags.ToPrinter(output)
-		if err != nil {
-			return nil, err
-		}
-		return printer, nil
+	return output == "" || output == "default"
+}
+
+// Validate returns an error if the output format is not supported
+func (p *PrintFlags) Validate() error {
+	_, err := p.ToPrinter(false, false)
+	return err
+}
+
+func (p *PrintFlags) ToPrinter(withKind, withNamespace bool) (printers.ResourcePrinter, error) {
+	if !p.IsHumanReadable() {
+		return p.toMachinePrinter()
 	}
 	// Reuse kubectl HumanReadablePrinter
 	printer := kubeprinters.NewHumanReadablePrinter(scheme.Codecs.UniversalDecoder(),
@@ -59,3 +76,20 @@ func (p *PrintFlags) ToPrinter(withKind, withNamespace bool) (printers.ResourceP
 	AddHandlers(printer)
 	return printer, nil
 }
+
+// toMachinePrinter reuses the json, yaml, template and custom-columns printers of kubectl
+func (p *PrintFlags) toMachinePrinter() (printers.ResourcePrinter, error) {
+	if printer, err := p.JSONYamlPrintFlags.ToPrinter(p.OutputFormat); !genericclioptions.IsNoCompatiblePrinterError(err) {
+		return printer, err
+	}
+	if printer, err := p.TemplatePrintFlags.ToPrinter(p.OutputFormat); !genericclioptions.IsNoCompatiblePrinterError(err) {
+		return printer, err
+	}
+	if printer, err := p.CustomColumnsPrintFlags.ToPrinter(p.OutputFormat); !genericclioptions.IsNoCompatiblePrinterError(err) {
+		return printer, err
+	}
+	allowedFormats := append([]string{"default"}, p.JSONYamlPrintFlags.AllowedFormats()...)
+	allowedFormats = append(allowedFormats, p.TemplatePrintFlags.AllowedFormats()...)
+	allowedFormats = append(allowedFormats, p.CustomColumnsPrintFlags.AllowedFormats()...)
+	return nil, genericclioptions.NoCompatiblePrinterError{OutputFormat: &p.OutputFormat, AllowedFormats: allowedFormats}
+}
@@ -99,21 +99,10 @@ func printTidbCluster(tc *v1alpha1.TidbCluster, options printers.PrintOptions) (
 	row := metav1beta1.TableRow{
 		Object: runtime.RawExtension{Object: tc},
 	}
-	pdReady := unset
-	if tc.Status.PD.StatefulSet != nil {
-		pdReady = fmt.Sprintf("%d/%d", tc.Status.PD.StatefulSet.ReadyReplicas, tc.Status.PD.StatefulSet.Replicas)
-	}
-	tikvReady := unset
-	if tc.Status.TiKV.StatefulSet != nil {
-		tikvReady = fmt.Sprintf("%d/%d", tc.Status.TiKV.StatefulSet.ReadyReplicas, tc.Status.TiKV.StatefulSet.Replicas)
-	}
-	tidbReady := unset
-	if tc.Status.TiDB.StatefulSet != nil {
-		tidbReady = fmt.Sprintf("%d/%d", tc.Status.TiDB.StatefulSet.ReadyReplicas, tc.Status.TiDB.StatefulSet.Replicas)
-	}
+	ready := ComputeCluster(tc)
 	age := translateTimestampSince(tc.CreationTimestamp)
 
-	row.Cells = append(row.Cells, tc.Name, pdReady, tikvReady, tidbReady, age)
+	row.Cells = append(row.Cells, tc.Name, ready.PD, ready.TiKV, ready.TiDB, age)
 
 	return []metav1beta1.TableRow{row}, nil
 }
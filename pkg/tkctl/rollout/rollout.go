@@ -0,0 +1,262 @@
+// Copyright 2019. PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rollout
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/pingcap/tidb-operator/pkg/apis/pingcap.com/v1alpha1"
+	"github.com/pingcap/tidb-operator/pkg/client/clientset/versioned"
+	"github.com/pingcap/tidb-operator/pkg/controller"
+	"github.com/pingcap/tidb-operator/pkg/tkctl/readable"
+	apps "k8s.io/api/apps/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/util/retry"
+)
+
+// Components are the components a Target may change, in the order the changes are planned
+var Components = []v1alpha1.MemberType{
+	v1alpha1.PDMemberType,
+	v1alpha1.TiKVMemberType,
+	v1alpha1.TiDBMemberType,
+}
+
+// Target is the desired replicas and images of the components,
+// components absent from the maps are left unchanged
+type Target struct {
+	Replicas map[v1alpha1.MemberType]int32
+	Images   map[v1alpha1.MemberType]string
+}
+
+// NewTarget returns an empty Target
+func NewTarget() *Target {
+	return &Target{
+		Replicas: map[v1alpha1.MemberType]int32{},
+		Images:   map[v1alpha1.MemberType]string{},
+	}
+}
+
+// Change is a planned change of a component field
+type Change struct {
+	Component v1alpha1.MemberType
+	Field     string
+	From      string
+	To        string
+}
+
+func (c Change) String() string {
+	return fmt.Sprintf("%s %s: %s -> %s", c.Component, c.Field, c.From, c.To)
+}
+
+// Changes returns the changes the target makes to the tidb cluster
+func (t *Target) Changes(tc *v1alpha1.TidbCluster) []Change {
+	var changes []Change
+	for _, component := range Components {
+		spec := componentSpec(tc, component)
+		if replicas, ok := t.Replicas[component]; ok && replicas != *spec.replicas {
+			changes = append(changes, Change{
+				Component: component,
+				Field:     "replicas",
+				From:      fmt.Sprintf("%d", *spec.replicas),
+				To:        fmt.Sprintf("%d", replicas),
+			})
+		}
+		if image, ok := t.Images[component]; ok && image != *spec.image {
+			changes = append(changes, Change{
+				Component: component,
+				Field:     "image",
+				From:      *spec.image,
+				To:        image,
+			})
+		}
+	}
+	return changes
+}
+
+// ApplyTo sets the target to the spec of the tidb cluster
+func (t *Target) ApplyTo(tc *v1alpha1.TidbCluster) {
+	for _, component := range Components {
+		spec := componentSpec(tc, component)
+		if replicas, ok := t.Replicas[component]; ok {
+			*spec.replicas = replicas
+		}
+		if image, ok := t.Images[component]; ok {
+			*spec.image = image
+		}
+	}
+}
+
+// RenderPlan renders the changes to be made to the tidb cluster
+func RenderPlan(tc *v1alpha1.TidbCluster, changes []Change) (string, error) {
+	return readable.TabbedString(func(out io.Writer) error {
+		w := readable.NewPrefixWriter(out)
+		w.WriteLine(readable.LEVEL_0, "Plan for tidb cluster %s/%s:", tc.Namespace, tc.Name)
+		w.WriteLine(readable.LEVEL_1, "Component\tField\tCurrent\tDesired")
+		w.WriteLine(readable.LEVEL_1, "---------\t-----\t-------\t-------")
+		for _, change := range changes {
+			w.WriteLine(readable.LEVEL_1, "%s\t%s\t%s\t%s", change.Component, change.Field, change.From, change.To)
+		}
+		return nil
+	})
+}
+
+// Update applies the target to the tidb cluster, validate is called against
+// the latest tidb cluster before each attempt, it retries on conflicts
+func Update(tcCli versioned.Interface, namespace, name string, target *Target,
+	validate func(tc *v1alpha1.TidbCluster) error) (*v1alpha1.TidbCluster, error) {
+	var updated *v1alpha1.TidbCluster
+	err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		tc, err := tcCli.PingcapV1alpha1().TidbClusters(namespace).Get(name, metav1.GetOptions{})
+		if err != nil {
+			return err
+		}
+		if err := validate(tc); err != nil {
+			return err
+		}
+		target.ApplyTo(tc)
+		updated, err = tcCli.PingcapV1alpha1().TidbClusters(namespace).Update(tc)
+		return err
+	})
+	return updated, err
+}
+
+// Progress returns whether the components changed by the target have been rolled out,
+// along with a progress line for each of them
+func Progress(tc *v1alpha1.TidbCluster, sets map[v1alpha1.MemberType]*apps.StatefulSet, target *Target) (bool, []string) {
+	done := true
+	var lines []string
+	for _, component := range Components {
+		_, replicasChanged := target.Replicas[component]
+		image, imageChanged := target.Images[component]
+		if !replicasChanged && !imageChanged {
+			continue
+		}
+		phase, desired := componentStatus(tc, component)
+		set := sets[component]
+		if set == nil {
+			done = false
+			lines = append(lines, fmt.Sprintf("%s: statefulset not found, phase %s", component, phase))
+			continue
+		}
+
+		componentDone := phase == v1alpha1.NormalPhase &&
+			set.Status.ObservedGeneration != nil && *set.Status.ObservedGeneration >= set.Generation &&
+			set.Spec.Replicas != nil && *set.Spec.Replicas == desired &&
+			set.Status.Replicas == desired &&
+			set.Status.ReadyReplicas == desired
+		if imageChanged {
+			componentDone = componentDone &&
+				containerImage(set, component) == image &&
+				set.Status.UpdatedReplicas == desired &&
+				set.Status.CurrentRevision == set.Status.UpdateRevision
+		}
+		done = done && componentDone
+		lines = append(lines, fmt.Sprintf("%s: phase %s, %d/%d ready, %d/%d updated",
+			component, phase, set.Status.ReadyReplicas, desired, set.Status.UpdatedReplicas, desired))
+	}
+	return done, lines
+}
+
+// Wait polls the tidb cluster until the target is rolled out or the timeout is reached, the progress
+// and the output of render are written to out whenever they change, render may be nil
+func Wait(tcCli versioned.Interface, kubeCli kubernetes.Interface, namespace, name string, target *Target,
+	interval, timeout time.Duration, render func() (string, error), out io.Writer) error {
+	var lastProgress, lastRendered string
+	err := wait.PollImmediate(interval, timeout, func() (bool, error) {
+		tc, err := tcCli.PingcapV1alpha1().TidbClusters(namespace).Get(name, metav1.GetOptions{})
+		if err != nil {
+			return false, err
+		}
+		sets := map[v1alpha1.MemberType]*apps.StatefulSet{}
+		for _, component := range Components {
+			set, err := kubeCli.AppsV1beta1().StatefulSets(namespace).Get(statefulSetName(tc.Name, component), metav1.GetOptions{})
+			if err == nil {
+				sets[component] = set
+			}
+		}
+		done, lines := Progress(tc, sets, target)
+		if progress := strings.Join(lines, "\n"); progress != lastProgress {
+			fmt.Fprintf(out, "[%s]\n%s\n", time.Now().Format("15:04:05"), progress)
+			lastProgress = progress
+		}
+		if render != nil {
+			rendered, err := render()
+			if err != nil {
+				return false, err
+			}
+			if rendered != lastRendered {
+				fmt.Fprint(out, rendered)
+				lastRendered = rendered
+			}
+		}
+		return done, nil
+	})
+	if err == wait.ErrWaitTimeout {
+		return fmt.Errorf("timed out waiting for tidb cluster %s/%s to be rolled out", namespace, name)
+	}
+	return err
+}
+
+type specFields struct {
+	replicas *int32
+	image    *string
+}
+
+func componentSpec(tc *v1alpha1.TidbCluster, component v1alpha1.MemberType) specFields {
+	switch component {
+	case v1alpha1.PDMemberType:
+		return specFields{replicas: &tc.Spec.PD.Replicas, image: &tc.Spec.PD.Image}
+	case v1alpha1.TiKVMemberType:
+		return specFields{replicas: &tc.Spec.TiKV.Replicas, image: &tc.Spec.TiKV.Image}
+	default:
+		return specFields{replicas: &tc.Spec.TiDB.Replicas, image: &tc.Spec.TiDB.Image}
+	}
+}
+
+// componentStatus returns the phase and the desired statefulset replicas of the component
+func componentStatus(tc *v1alpha1.TidbCluster, component v1alpha1.MemberType) (v1alpha1.MemberPhase, int32) {
+	switch component {
+	case v1alpha1.PDMemberType:
+		return tc.Status.PD.Phase, tc.PDRealReplicas()
+	case v1alpha1.TiKVMemberType:
+		return tc.Status.TiKV.Phase, tc.TiKVRealReplicas()
+	default:
+		return tc.Status.TiDB.Phase, tc.TiDBRealReplicas()
+	}
+}
+
+func statefulSetName(tcName string, component v1alpha1.MemberType) string {
+	switch component {
+	case v1alpha1.PDMemberType:
+		return controller.PDMemberName(tcName)
+	case v1alpha1.TiKVMemberType:
+		return controller.TiKVMemberName(tcName)
+	default:
+		return controller.TiDBMemberName(tcName)
+	}
+}
+
+func containerImage(set *apps.StatefulSet, component v1alpha1.MemberType) string {
+	for _, container := range set.Spec.Template.Spec.Containers {
+		if container.Name == component.String() {
+			return container.Image
+		}
+	}
+	return ""
+}
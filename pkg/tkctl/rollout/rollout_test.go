@@ -0,0 +1,214 @@
+// Copyright 2019. PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rollout
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	"github.com/pingcap/tidb-operator/pkg/apis/pingcap.com/v1alpha1"
+	"github.com/pingcap/tidb-operator/pkg/client/clientset/versioned/fake"
+	apps "k8s.io/api/apps/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+)
+
+func TestTargetChanges(t *testing.T) {
+	g := NewGomegaWithT(t)
+	tc := newTidbCluster()
+	target := NewTarget()
+	target.Replicas[v1alpha1.TiKVMemberType] = 5
+	target.Replicas[v1alpha1.PDMemberType] = 3
+	target.Images[v1alpha1.TiDBMemberType] = "pingcap/tidb:v3.0.1"
+
+	changes := target.Changes(tc)
+	g.Expect(changes).To(Equal([]Change{
+		{Component: v1alpha1.TiKVMemberType, Field: "replicas", From: "3", To: "5"},
+		{Component: v1alpha1.TiDBMemberType, Field: "image", From: "pingcap/tidb:v2.1.8", To: "pingcap/tidb:v3.0.1"},
+	}))
+	g.Expect(changes[0].String()).To(Equal("tikv replicas: 3 -> 5"))
+
+	target.ApplyTo(tc)
+	g.Expect(tc.Spec.TiKV.Replicas).To(Equal(int32(5)))
+	g.Expect(tc.Spec.PD.Replicas).To(Equal(int32(3)))
+	g.Expect(tc.Spec.TiDB.Image).To(Equal("pingcap/tidb:v3.0.1"))
+	g.Expect(target.Changes(tc)).To(BeEmpty())
+
+	plan, err := RenderPlan(tc, changes)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(plan).To(ContainSubstring("Plan for tidb cluster default/demo:"))
+	g.Expect(plan).To(MatchRegexp(`tikv\s+replicas\s+3\s+5`))
+}
+
+func TestUpdate(t *testing.T) {
+	g := NewGomegaWithT(t)
+	tc := newTidbCluster()
+	tcCli := fake.NewSimpleClientset(tc)
+	target := NewTarget()
+	target.Replicas[v1alpha1.TiKVMemberType] = 5
+
+	_, err := Update(tcCli, tc.Namespace, tc.Name, target, func(tc *v1alpha1.TidbCluster) error {
+		return fmt.Errorf("refused")
+	})
+	g.Expect(err).To(MatchError("refused"))
+
+	updated, err := Update(tcCli, tc.Namespace, tc.Name, target, func(tc *v1alpha1.TidbCluster) error {
+		return nil
+	})
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(updated.Spec.TiKV.Replicas).To(Equal(int32(5)))
+	latest, err := tcCli.PingcapV1alpha1().TidbClusters(tc.Namespace).Get(tc.Name, metav1.GetOptions{})
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(latest.Spec.TiKV.Replicas).To(Equal(int32(5)))
+}
+
+func TestProgress(t *testing.T) {
+	g := NewGomegaWithT(t)
+	type testcase struct {
+		name     string
+		update   func(tc *v1alpha1.TidbCluster, set *apps.StatefulSet)
+		expected bool
+	}
+	tests := []testcase{
+		{
+			name:     "rolled out",
+			update:   func(tc *v1alpha1.TidbCluster, set *apps.StatefulSet) {},
+			expected: true,
+		},
+		{
+			name: "statefulset not observed",
+			update: func(tc *v1alpha1.TidbCluster, set *apps.StatefulSet) {
+				set.Generation = 2
+			},
+			expected: false,
+		},
+		{
+			name: "not ready",
+			update: func(tc *v1alpha1.TidbCluster, set *apps.StatefulSet) {
+				set.Status.ReadyReplicas = 4
+			},
+			expected: false,
+		},
+		{
+			name: "upgrading",
+			update: func(tc *v1alpha1.TidbCluster, set *apps.StatefulSet) {
+				tc.Status.TiKV.Phase = v1alpha1.UpgradePhase
+			},
+			expected: false,
+		},
+		{
+			name: "old image",
+			update: func(tc *v1alpha1.TidbCluster, set *apps.StatefulSet) {
+				set.Spec.Template.Spec.Containers[0].Image = "pingcap/tikv:v2.1.8"
+			},
+			expected: false,
+		},
+		{
+			name: "revision not updated",
+			update: func(tc *v1alpha1.TidbCluster, set *apps.StatefulSet) {
+				set.Status.UpdateRevision = "demo-tikv-2"
+			},
+			expected: false,
+		},
+	}
+	for _, test := range tests {
+		t.Log(test.name)
+		tc := newTidbCluster()
+		tc.Spec.TiKV.Replicas = 5
+		tc.Spec.TiKV.Image = "pingcap/tikv:v3.0.1"
+		set := newStatefulSet(5, "pingcap/tikv:v3.0.1")
+		test.update(tc, set)
+
+		target := NewTarget()
+		target.Replicas[v1alpha1.TiKVMemberType] = 5
+		target.Images[v1alpha1.TiKVMemberType] = "pingcap/tikv:v3.0.1"
+		done, lines := Progress(tc, map[v1alpha1.MemberType]*apps.StatefulSet{v1alpha1.TiKVMemberType: set}, target)
+		g.Expect(done).To(Equal(test.expected))
+		g.Expect(lines).To(HaveLen(1))
+		g.Expect(lines[0]).To(HavePrefix("tikv: phase"))
+	}
+
+	done, lines := Progress(newTidbCluster(), nil, &Target{Replicas: map[v1alpha1.MemberType]int32{v1alpha1.PDMemberType: 3}})
+	g.Expect(done).To(BeFalse())
+	g.Expect(lines).To(Equal([]string{"pd: statefulset not found, phase Normal"}))
+}
+
+func TestWait(t *testing.T) {
+	g := NewGomegaWithT(t)
+	tc := newTidbCluster()
+	tc.Spec.TiKV.Replicas = 5
+	tcCli := fake.NewSimpleClientset(tc)
+	set := newStatefulSet(5, "pingcap/tikv:v2.1.8")
+	kubeCli := kubefake.NewSimpleClientset(set)
+	target := NewTarget()
+	target.Replicas[v1alpha1.TiKVMemberType] = 5
+
+	out := &bytes.Buffer{}
+	err := Wait(tcCli, kubeCli, tc.Namespace, tc.Name, target, time.Millisecond, time.Second,
+		func() (string, error) { return "upinfo\n", nil }, out)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(out.String()).To(ContainSubstring("tikv: phase Normal, 5/5 ready, 5/5 updated"))
+	g.Expect(strings.Count(out.String(), "upinfo")).To(Equal(1))
+
+	target.Replicas[v1alpha1.TiKVMemberType] = 7
+	tc.Spec.TiKV.Replicas = 7
+	_, err = tcCli.PingcapV1alpha1().TidbClusters(tc.Namespace).Update(tc)
+	g.Expect(err).NotTo(HaveOccurred())
+	err = Wait(tcCli, kubeCli, tc.Namespace, tc.Name, target, time.Millisecond, 10*time.Millisecond, nil, &bytes.Buffer{})
+	g.Expect(err).To(MatchError("timed out waiting for tidb cluster default/demo to be rolled out"))
+}
+
+func newTidbCluster() *v1alpha1.TidbCluster {
+	tc := &v1alpha1.TidbCluster{
+		ObjectMeta: metav1.ObjectMeta{Name: "demo", Namespace: metav1.NamespaceDefault},
+	}
+	tc.Spec.PD.Replicas = 3
+	tc.Spec.PD.Image = "pingcap/pd:v2.1.8"
+	tc.Spec.TiKV.Replicas = 3
+	tc.Spec.TiKV.Image = "pingcap/tikv:v2.1.8"
+	tc.Spec.TiDB.Replicas = 2
+	tc.Spec.TiDB.Image = "pingcap/tidb:v2.1.8"
+	tc.Status.PD.Phase = v1alpha1.NormalPhase
+	tc.Status.TiKV.Phase = v1alpha1.NormalPhase
+	tc.Status.TiDB.Phase = v1alpha1.NormalPhase
+	return tc
+}
+
+func newStatefulSet(replicas int32, image string) *apps.StatefulSet {
+	observed := int64(1)
+	return &apps.StatefulSet{
+		ObjectMeta: metav1.ObjectMeta{Name: "demo-tikv", Namespace: metav1.NamespaceDefault, Generation: 1},
+		Spec: apps.StatefulSetSpec{
+			Replicas: &replicas,
+			Template: corev1.PodTemplateSpec{
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{{Name: "tikv", Image: image}},
+				},
+			},
+		},
+		Status: apps.StatefulSetStatus{
+			ObservedGeneration: &observed,
+			Replicas:           replicas,
+			ReadyReplicas:      replicas,
+			UpdatedReplicas:    replicas,
+			CurrentRevision:    "demo-tikv-1",
+			UpdateRevision:     "demo-tikv-1",
+		},
+	}
+}
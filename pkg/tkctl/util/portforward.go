@@ -0,0 +1,111 @@
+// Copyright 2019. PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package util
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+
+	"k8s.io/api/core/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/portforward"
+	"k8s.io/client-go/transport/spdy"
+)
+
+// PortForwarder forwards a local port to a port of a pod, callers
+// should Close it once the forwarded port is no longer used
+type PortForwarder struct {
+	LocalPort int
+
+	stopChan chan struct{}
+}
+
+// PortForwardPod forwards a random local port to the remotePort of the specified pod
+// and blocks until the forwarding is ready
+func PortForwardPod(restConfig *rest.Config, kubeCli kubernetes.Interface, namespace, podName string, remotePort int, errOut io.Writer) (*PortForwarder, error) {
+	localPort, err := getFreePort()
+	if err != nil {
+		return nil, err
+	}
+	req := kubeCli.CoreV1().RESTClient().Post().
+		Resource("pods").
+		Namespace(namespace).
+		Name(podName).
+		SubResource("portforward")
+	transport, upgrader, err := spdy.RoundTripperFor(restConfig)
+	if err != nil {
+		return nil, err
+	}
+	dialer := spdy.NewDialer(upgrader, &http.Client{Transport: transport}, http.MethodPost, req.URL())
+
+	stopChan := make(chan struct{})
+	readyChan := make(chan struct{})
+	ports := []string{fmt.Sprintf("%d:%d", localPort, remotePort)}
+	fw, err := portforward.New(dialer, ports, stopChan, readyChan, ioutil.Discard, errOut)
+	if err != nil {
+		return nil, err
+	}
+	errChan := make(chan error, 1)
+	go func() {
+		errChan <- fw.ForwardPorts()
+	}()
+	select {
+	case <-readyChan:
+		return &PortForwarder{LocalPort: localPort, stopChan: stopChan}, nil
+	case err = <-errChan:
+		if err == nil {
+			err = fmt.Errorf("port forwarding to pod %s/%s stopped unexpectedly", namespace, podName)
+		}
+		return nil, err
+	}
+}
+
+// Close stops the port forwarding
+func (f *PortForwarder) Close() {
+	close(f.stopChan)
+}
+
+// SelectRunningPod returns the preferred pod if it is running, otherwise the first running pod
+func SelectRunningPod(pods []v1.Pod, preferred string) (*v1.Pod, error) {
+	var selected *v1.Pod
+	for i := range pods {
+		pod := &pods[i]
+		if pod.Status.Phase != v1.PodRunning || pod.DeletionTimestamp != nil {
+			continue
+		}
+		if pod.Name == preferred {
+			return pod, nil
+		}
+		if selected == nil {
+			selected = pod
+		}
+	}
+	if selected == nil {
+		return nil, fmt.Errorf("no running pod found")
+	}
+	return selected, nil
+}
+
+func getFreePort() (int, error) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return 0, err
+	}
+	defer listener.Close()
+	return listener.Addr().(*net.TCPAddr).Port, nil
+}
@@ -17,6 +17,8 @@ import "k8s.io/api/core/v1"
 
 const (
 	DockerSocket = "/var/run/docker.sock"
+	// CRISocket is where the CRI socket of containerd or CRI-O is mounted in the debug launcher
+	CRISocket = "/var/run/cri.sock"
 )
 
 // MakeDockerSocketMount create the volume and corresponding mount for docker socket
@@ -37,6 +39,23 @@ func MakeDockerSocketMount(hostDockerSocket string, readOnly bool) (volume v1.Vo
 	return
 }
 
+// MakeCRISocketMount create the volume and corresponding mount for the CRI socket of containerd or CRI-O
+func MakeCRISocketMount(hostCRISocket string) (volume v1.Volume, mount v1.VolumeMount) {
+	mount = v1.VolumeMount{
+		Name:      "cri",
+		MountPath: CRISocket,
+	}
+	volume = v1.Volume{
+		Name: "cri",
+		VolumeSource: v1.VolumeSource{
+			HostPath: &v1.HostPathVolumeSource{
+				Path: hostCRISocket,
+			},
+		},
+	}
+	return
+}
+
 // TODO: fix unsafe name infer after resources being managed by CRD
 // GetTidbServiceName infers tidb service name from tidb cluster name
 func GetTidbServiceName(tc string) string {
@@ -15,6 +15,8 @@ package util
 
 import (
 	. "github.com/onsi/gomega"
+	"k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"testing"
 )
 
@@ -50,7 +52,42 @@ func TestMakeDockerSocketMount(t *testing.T) {
 	}
 }
 
+func TestMakeCRISocketMount(t *testing.T) {
+	g := NewGomegaWithT(t)
+	volume, mount := MakeCRISocketMount("/run/containerd/containerd.sock")
+	g.Expect(volume.HostPath).NotTo(BeNil())
+	g.Expect(volume.HostPath.Path).To(Equal("/run/containerd/containerd.sock"))
+	g.Expect(mount.Name).To(Equal(volume.Name))
+	g.Expect(mount.MountPath).To(Equal(CRISocket))
+}
+
 func TestGetTidbServiceName(t *testing.T) {
 	g := NewGomegaWithT(t)
 	g.Expect(GetTidbServiceName("demo")).To(Equal("demo-tidb"))
 }
+
+func TestSelectRunningPod(t *testing.T) {
+	g := NewGomegaWithT(t)
+	newPod := func(name string, phase v1.PodPhase) v1.Pod {
+		return v1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: name},
+			Status:     v1.PodStatus{Phase: phase},
+		}
+	}
+	pods := []v1.Pod{
+		newPod("demo-pd-0", v1.PodPending),
+		newPod("demo-pd-1", v1.PodRunning),
+		newPod("demo-pd-2", v1.PodRunning),
+	}
+
+	pod, err := SelectRunningPod(pods, "demo-pd-2")
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(pod.Name).To(Equal("demo-pd-2"))
+
+	pod, err = SelectRunningPod(pods, "demo-pd-0")
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(pod.Name).To(Equal("demo-pd-1"))
+
+	_, err = SelectRunningPod(pods[:1], "")
+	g.Expect(err).To(HaveOccurred())
+}
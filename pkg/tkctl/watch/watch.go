@@ -0,0 +1,251 @@
+// Copyright 2019. PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package watch
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"sort"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/pingcap/tidb-operator/pkg/apis/pingcap.com/v1alpha1"
+	"github.com/pingcap/tidb-operator/pkg/client/clientset/versioned"
+	informers "github.com/pingcap/tidb-operator/pkg/client/informers/externalversions"
+	listers "github.com/pingcap/tidb-operator/pkg/client/listers/pingcap.com/v1alpha1"
+	"github.com/pingcap/tidb-operator/pkg/controller"
+	"github.com/pingcap/tidb-operator/pkg/label"
+	apps "k8s.io/api/apps/v1beta1"
+	"k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/labels"
+	kubeinformers "k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes"
+	appslisters "k8s.io/client-go/listers/apps/v1beta1"
+	corelisters "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/kubernetes/pkg/kubectl/util/term"
+)
+
+const (
+	// refreshInterval coalesces the bursts of events into a single refresh
+	refreshInterval = 200 * time.Millisecond
+
+	clearScreen     = "\x1b[H\x1b[2J"
+	highlightPrefix = "\x1b[1m"
+	highlightSuffix = "\x1b[0m"
+)
+
+// Listers are the informer caches of the watched tidb cluster,
+// pods, services and statefulsets are limited to the tidb cluster
+type Listers struct {
+	TidbClusters listers.TidbClusterLister
+	Pods         corelisters.PodLister
+	Services     corelisters.ServiceLister
+	StatefulSets appslisters.StatefulSetLister
+}
+
+// PodList returns the pods matching the selector in name order, as a PodList returned by the API server
+func (l *Listers) PodList(namespace string, selector labels.Selector) (*v1.PodList, error) {
+	pods, err := l.Pods.Pods(namespace).List(selector)
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(pods, func(i, j int) bool { return pods[i].Name < pods[j].Name })
+	podList := &v1.PodList{}
+	for _, pod := range pods {
+		podList.Items = append(podList.Items, *pod)
+	}
+	return podList, nil
+}
+
+// RenderFunc renders the tidb cluster from the caches
+type RenderFunc func(tc *v1alpha1.TidbCluster, listers *Listers) (string, error)
+
+// Watch re-renders the tidb cluster whenever the TidbCluster, its pods, services or statefulsets change,
+// the lines changed since the last refresh are highlighted on terminals. It returns once an observed
+// upgrade or scaling is completed, or stopCh is closed.
+func Watch(tcCli versioned.Interface, kubeCli kubernetes.Interface, namespace, tcName string,
+	render RenderFunc, out io.Writer, stopCh <-chan struct{}) error {
+	tcInformerFactory := informers.NewSharedInformerFactoryWithOptions(tcCli, 0,
+		informers.WithNamespace(namespace),
+		informers.WithTweakListOptions(func(options *metav1.ListOptions) {
+			options.FieldSelector = fields.OneTermEqualSelector("metadata.name", tcName).String()
+		}))
+	kubeInformerFactory := kubeinformers.NewSharedInformerFactoryWithOptions(kubeCli, 0,
+		kubeinformers.WithNamespace(namespace),
+		kubeinformers.WithTweakListOptions(func(options *metav1.ListOptions) {
+			options.LabelSelector = label.New().Instance(tcName).String()
+		}))
+
+	trigger := make(chan struct{}, 1)
+	handler := cache.ResourceEventHandlerFuncs{
+		AddFunc:    func(obj interface{}) { notify(trigger) },
+		UpdateFunc: func(old, cur interface{}) { notify(trigger) },
+		DeleteFunc: func(obj interface{}) { notify(trigger) },
+	}
+	tcInformer := tcInformerFactory.Pingcap().V1alpha1().TidbClusters()
+	podInformer := kubeInformerFactory.Core().V1().Pods()
+	svcInformer := kubeInformerFactory.Core().V1().Services()
+	setInformer := kubeInformerFactory.Apps().V1beta1().StatefulSets()
+	tcInformer.Informer().AddEventHandler(handler)
+	podInformer.Informer().AddEventHandler(handler)
+	svcInformer.Informer().AddEventHandler(handler)
+	setInformer.Informer().AddEventHandler(handler)
+	l := &Listers{
+		TidbClusters: tcInformer.Lister(),
+		Pods:         podInformer.Lister(),
+		Services:     svcInformer.Lister(),
+		StatefulSets: setInformer.Lister(),
+	}
+
+	tcInformerFactory.Start(stopCh)
+	kubeInformerFactory.Start(stopCh)
+	if !cache.WaitForCacheSync(stopCh,
+		tcInformer.Informer().HasSynced,
+		podInformer.Informer().HasSynced,
+		svcInformer.Informer().HasSynced,
+		setInformer.Informer().HasSynced) {
+		return nil
+	}
+
+	terminal := term.IsTerminal(out)
+	var last string
+	observedRollout := false
+	for {
+		select {
+		case <-stopCh:
+			return nil
+		case <-trigger:
+		}
+		time.Sleep(refreshInterval)
+
+		tc, err := l.TidbClusters.TidbClusters(namespace).Get(tcName)
+		if errors.IsNotFound(err) {
+			return fmt.Errorf("tidb cluster %s/%s is deleted", namespace, tcName)
+		}
+		if err != nil {
+			return err
+		}
+		msg, err := render(tc, l)
+		if err != nil {
+			// the objects may be created or deleted in any order, keep watching
+			msg = fmt.Sprintf("Error: %v\n", err)
+		}
+		if msg != last {
+			now := time.Now().Format("15:04:05")
+			if terminal {
+				fmt.Fprintf(out, "%sWatching tidb cluster %s/%s, updated at %s (Ctrl-C to exit)\n\n%s",
+					clearScreen, namespace, tcName, now, HighlightChanges(last, msg))
+			} else {
+				fmt.Fprintf(out, "--- %s ---\n%s", now, msg)
+			}
+			last = msg
+		}
+
+		if RolloutInProgress(tc, statefulSets(l.StatefulSets, tc)) {
+			observedRollout = true
+		} else if observedRollout {
+			fmt.Fprintf(out, "tidb cluster %s/%s is rolled out\n", namespace, tcName)
+			return nil
+		}
+	}
+}
+
+// HighlightChanges highlights the lines of cur that differ from the same lines of prev
+func HighlightChanges(prev, cur string) string {
+	if len(prev) == 0 {
+		return cur
+	}
+	prevLines := strings.Split(prev, "\n")
+	lines := strings.Split(cur, "\n")
+	for i, line := range lines {
+		if len(line) == 0 {
+			continue
+		}
+		if i >= len(prevLines) || prevLines[i] != line {
+			lines[i] = highlightPrefix + line + highlightSuffix
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// RolloutInProgress returns whether any component of the tidb cluster is upgrading or scaling,
+// sets holds the statefulsets of the components, a missing statefulset is being created
+func RolloutInProgress(tc *v1alpha1.TidbCluster, sets map[v1alpha1.MemberType]*apps.StatefulSet) bool {
+	if tc.PDUpgrading() || tc.TiKVUpgrading() || tc.TiDBUpgrading() {
+		return true
+	}
+	desired := map[v1alpha1.MemberType]int32{
+		v1alpha1.PDMemberType:   tc.PDRealReplicas(),
+		v1alpha1.TiKVMemberType: tc.TiKVRealReplicas(),
+		v1alpha1.TiDBMemberType: tc.TiDBRealReplicas(),
+	}
+	for component, replicas := range desired {
+		set := sets[component]
+		if set == nil {
+			return true
+		}
+		if set.Status.ObservedGeneration == nil || *set.Status.ObservedGeneration < set.Generation {
+			return true
+		}
+		if set.Spec.Replicas == nil || *set.Spec.Replicas != replicas ||
+			set.Status.Replicas != replicas || set.Status.ReadyReplicas != replicas {
+			return true
+		}
+		if len(set.Status.UpdateRevision) > 0 && set.Status.CurrentRevision != set.Status.UpdateRevision {
+			return true
+		}
+	}
+	return false
+}
+
+// SetupSignalHandler returns a channel which is closed on SIGINT or SIGTERM
+func SetupSignalHandler() <-chan struct{} {
+	stopCh := make(chan struct{})
+	sigs := make(chan os.Signal, 1)
+	signal.Notify(sigs, syscall.SIGINT, syscall.SIGTERM)
+	go func() {
+		<-sigs
+		close(stopCh)
+	}()
+	return stopCh
+}
+
+func statefulSets(setLister appslisters.StatefulSetLister, tc *v1alpha1.TidbCluster) map[v1alpha1.MemberType]*apps.StatefulSet {
+	names := map[v1alpha1.MemberType]string{
+		v1alpha1.PDMemberType:   controller.PDMemberName(tc.Name),
+		v1alpha1.TiKVMemberType: controller.TiKVMemberName(tc.Name),
+		v1alpha1.TiDBMemberType: controller.TiDBMemberName(tc.Name),
+	}
+	sets := map[v1alpha1.MemberType]*apps.StatefulSet{}
+	for component, name := range names {
+		if set, err := setLister.StatefulSets(tc.Namespace).Get(name); err == nil {
+			sets[component] = set
+		}
+	}
+	return sets
+}
+
+func notify(trigger chan<- struct{}) {
+	select {
+	case trigger <- struct{}{}:
+	default:
+	}
+}
@@ -0,0 +1,175 @@
+// Copyright 2019. PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package watch
+
+import (
+	"bytes"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	"github.com/pingcap/tidb-operator/pkg/apis/pingcap.com/v1alpha1"
+	"github.com/pingcap/tidb-operator/pkg/client/clientset/versioned/fake"
+	"github.com/pingcap/tidb-operator/pkg/controller"
+	"github.com/pingcap/tidb-operator/pkg/label"
+	apps "k8s.io/api/apps/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+)
+
+func TestHighlightChanges(t *testing.T) {
+	g := NewGomegaWithT(t)
+	g.Expect(HighlightChanges("", "a\nb\n")).To(Equal("a\nb\n"))
+	g.Expect(HighlightChanges("a\nb\n", "a\nc\nd\n")).To(Equal(
+		"a\n" + highlightPrefix + "c" + highlightSuffix + "\n" + highlightPrefix + "d" + highlightSuffix + "\n"))
+}
+
+func TestRolloutInProgress(t *testing.T) {
+	g := NewGomegaWithT(t)
+	type testcase struct {
+		name     string
+		update   func(tc *v1alpha1.TidbCluster, sets map[v1alpha1.MemberType]*apps.StatefulSet)
+		expected bool
+	}
+	tests := []testcase{
+		{
+			name:     "stable",
+			update:   func(tc *v1alpha1.TidbCluster, sets map[v1alpha1.MemberType]*apps.StatefulSet) {},
+			expected: false,
+		},
+		{
+			name: "upgrading",
+			update: func(tc *v1alpha1.TidbCluster, sets map[v1alpha1.MemberType]*apps.StatefulSet) {
+				tc.Status.TiDB.Phase = v1alpha1.UpgradePhase
+			},
+			expected: true,
+		},
+		{
+			name: "statefulset missing",
+			update: func(tc *v1alpha1.TidbCluster, sets map[v1alpha1.MemberType]*apps.StatefulSet) {
+				delete(sets, v1alpha1.PDMemberType)
+			},
+			expected: true,
+		},
+		{
+			name: "tikv scaling in",
+			update: func(tc *v1alpha1.TidbCluster, sets map[v1alpha1.MemberType]*apps.StatefulSet) {
+				tc.Spec.TiKV.Replicas = 2
+			},
+			expected: true,
+		},
+		{
+			name: "pod not ready",
+			update: func(tc *v1alpha1.TidbCluster, sets map[v1alpha1.MemberType]*apps.StatefulSet) {
+				sets[v1alpha1.TiDBMemberType].Status.ReadyReplicas = 1
+			},
+			expected: true,
+		},
+		{
+			name: "revision not updated",
+			update: func(tc *v1alpha1.TidbCluster, sets map[v1alpha1.MemberType]*apps.StatefulSet) {
+				sets[v1alpha1.TiDBMemberType].Status.UpdateRevision = "demo-tidb-2"
+			},
+			expected: true,
+		},
+	}
+	for _, test := range tests {
+		t.Log(test.name)
+		tc := newTidbCluster()
+		sets := map[v1alpha1.MemberType]*apps.StatefulSet{}
+		for _, set := range newStatefulSets(tc) {
+			sets[v1alpha1.MemberType(set.Labels[label.ComponentLabelKey])] = set
+		}
+		test.update(tc, sets)
+		g.Expect(RolloutInProgress(tc, sets)).To(Equal(test.expected))
+	}
+}
+
+func TestWatch(t *testing.T) {
+	g := NewGomegaWithT(t)
+	tc := newTidbCluster()
+	tc.Status.TiDB.Phase = v1alpha1.UpgradePhase
+	tcCli := fake.NewSimpleClientset(tc)
+	sets := newStatefulSets(tc)
+	kubeCli := kubefake.NewSimpleClientset(sets[0], sets[1], sets[2])
+
+	var lock sync.Mutex
+	rendered := 0
+	render := func(tc *v1alpha1.TidbCluster, listers *Listers) (string, error) {
+		lock.Lock()
+		defer lock.Unlock()
+		rendered++
+		return fmt.Sprintf("tidb phase: %s\n", tc.Status.TiDB.Phase), nil
+	}
+
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+	out := &bytes.Buffer{}
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- Watch(tcCli, kubeCli, tc.Namespace, tc.Name, render, out, stopCh)
+	}()
+
+	// wait for the upgrade being observed before completing it
+	g.Eventually(func() int {
+		lock.Lock()
+		defer lock.Unlock()
+		return rendered
+	}, 5*time.Second, 10*time.Millisecond).Should(BeNumerically(">", 0))
+	tc.Status.TiDB.Phase = v1alpha1.NormalPhase
+	_, err := tcCli.PingcapV1alpha1().TidbClusters(tc.Namespace).Update(tc)
+	g.Expect(err).NotTo(HaveOccurred())
+
+	g.Eventually(errCh, 5*time.Second).Should(Receive(BeNil()))
+	g.Expect(out.String()).To(ContainSubstring("tidb phase: Upgrade"))
+	g.Expect(out.String()).To(ContainSubstring("tidb phase: Normal"))
+	g.Expect(out.String()).To(ContainSubstring("tidb cluster default/demo is rolled out"))
+}
+
+func newTidbCluster() *v1alpha1.TidbCluster {
+	tc := &v1alpha1.TidbCluster{
+		ObjectMeta: metav1.ObjectMeta{Name: "demo", Namespace: metav1.NamespaceDefault},
+	}
+	tc.Spec.PD.Replicas = 3
+	tc.Spec.TiKV.Replicas = 3
+	tc.Spec.TiDB.Replicas = 2
+	tc.Status.PD.Phase = v1alpha1.NormalPhase
+	tc.Status.TiKV.Phase = v1alpha1.NormalPhase
+	tc.Status.TiDB.Phase = v1alpha1.NormalPhase
+	return tc
+}
+
+func newStatefulSets(tc *v1alpha1.TidbCluster) []*apps.StatefulSet {
+	newSet := func(name string, l label.Label, replicas int32) *apps.StatefulSet {
+		observed := int64(1)
+		return &apps.StatefulSet{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: tc.Namespace, Generation: 1, Labels: l.Labels()},
+			Spec:       apps.StatefulSetSpec{Replicas: &replicas},
+			Status: apps.StatefulSetStatus{
+				ObservedGeneration: &observed,
+				Replicas:           replicas,
+				ReadyReplicas:      replicas,
+				CurrentRevision:    name + "-1",
+				UpdateRevision:     name + "-1",
+			},
+		}
+	}
+	return []*apps.StatefulSet{
+		newSet(controller.PDMemberName(tc.Name), label.New().Instance(tc.Name).PD(), tc.Spec.PD.Replicas),
+		newSet(controller.TiKVMemberName(tc.Name), label.New().Instance(tc.Name).TiKV(), tc.Spec.TiKV.Replicas),
+		newSet(controller.TiDBMemberName(tc.Name), label.New().Instance(tc.Name).TiDB(), tc.Spec.TiDB.Replicas),
+	}
+}